@@ -0,0 +1,142 @@
+// Command dispatchsim replays a snapshot of agents, parcels and jobs through
+// the real matcher and cascade dispatch logic on a virtual clock, so changes
+// to scoring weights or round settings can be evaluated before production.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terrascore/api/internal/job/sim"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func main() {
+	var (
+		fixture = flag.String("fixture", "", "Path to a JSON snapshot fixture")
+		dbURL   = flag.String("db", "", "Database URL to load a snapshot from (default DB_URL env var)")
+		since   = flag.Duration("since", 7*24*time.Hour, "With -db: replay jobs created in this window before -until")
+		until   = flag.String("until", "", "With -db: end of the replay window, RFC3339 (default now)")
+
+		mode          = flag.String("mode", "sequential", "Dispatch mode: sequential or broadcast")
+		broadcastSize = flag.Int("broadcast-size", 5, "Offers sent at once in broadcast mode")
+		rounds        = flag.Int("rounds", 3, "Cascade rounds before a job is marked unassigned")
+		offerTimeout  = flag.Duration("offer-timeout", 30*time.Minute, "How long an offer stays open")
+		strategy      = flag.String("strategy", "default", "Scoring strategy: default, weighted or performance")
+		weights       = flag.String("weights", "", `Weights for the weighted strategy as JSON, e.g. {"distance":0.5,"rating":0.5}`)
+		radii         = flag.String("radii", "25,50,100", "Comma-separated expansion radii in km")
+		surveyTime    = flag.Duration("survey-duration", time.Hour, "Time an agent spends on site per job")
+
+		seed    = flag.Int64("seed", 1, "Random seed for agent responses")
+		runs    = flag.Int("runs", 1, "Number of runs with consecutive seeds")
+		jsonOut = flag.Bool("json", false, "Print reports as JSON")
+		verbose = flag.Bool("v", false, "Log matcher decisions")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	snap, err := loadSnapshot(ctx, *fixture, *dbURL, *since, *until)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := sim.Config{
+		Dispatch: platform.DispatchConfig{
+			Mode:          *mode,
+			BroadcastSize: *broadcastSize,
+			OfferTimeout:  *offerTimeout,
+			MaxRounds:     *rounds,
+		},
+		Matcher: platform.MatcherConfig{
+			Strategy: *strategy,
+		},
+		SurveyDuration: *surveyTime,
+	}
+	if *weights != "" {
+		if err := json.Unmarshal([]byte(*weights), &cfg.Matcher.Weights); err != nil {
+			log.Fatalf("invalid -weights: %v", err)
+		}
+	}
+	for _, part := range strings.Split(*radii, ",") {
+		km, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || km <= 0 {
+			log.Fatalf("invalid -radii entry %q", part)
+		}
+		cfg.Matcher.ExpansionRadiiKm = append(cfg.Matcher.ExpansionRadiiKm, km)
+	}
+
+	level := slog.LevelError
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	fmt.Fprintf(os.Stderr, "snapshot: %d agents, %d parcels, %d jobs, %d agents with offer history\n",
+		len(snap.Agents), len(snap.Parcels), len(snap.Jobs), len(snap.OfferStats))
+
+	for i := 0; i < *runs; i++ {
+		cfg.Seed = *seed + int64(i)
+		s, err := sim.New(snap, cfg, logger)
+		if err != nil {
+			log.Fatalf("setting up simulation: %v", err)
+		}
+		report, err := s.Run(ctx)
+		if err != nil {
+			log.Fatalf("simulation failed: %v", err)
+		}
+
+		if *jsonOut {
+			out, _ := json.Marshal(report)
+			fmt.Println(string(out))
+			continue
+		}
+		printReport(cfg.Seed, report)
+	}
+}
+
+// loadSnapshot reads the snapshot from a fixture or database.
+func loadSnapshot(ctx context.Context, fixture, dbURL string, since time.Duration, until string) (sim.Snapshot, error) {
+	if fixture != "" {
+		return loadFixture(fixture)
+	}
+
+	if dbURL == "" {
+		dbURL = os.Getenv("DB_URL")
+	}
+	if dbURL == "" {
+		return sim.Snapshot{}, fmt.Errorf("snapshot source required: use -fixture, -db or DB_URL env var")
+	}
+
+	to := time.Now()
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return sim.Snapshot{}, fmt.Errorf("invalid -until: %w", err)
+		}
+		to = t
+	}
+	return loadFromDB(ctx, dbURL, to.Add(-since), to)
+}
+
+func printReport(seed int64, r *sim.Report) {
+	fmt.Printf("run (seed %d)\n", seed)
+	fmt.Printf("  jobs:              %d\n", r.Jobs)
+	fmt.Printf("  assigned:          %d\n", r.Assigned)
+	fmt.Printf("  unassigned rate:   %.1f%%\n", r.UnassignedRate*100)
+	fmt.Printf("  time to assign:    mean %s, p50 %s, p90 %s\n",
+		r.TimeToAssignMean.Round(time.Second), r.TimeToAssignP50.Round(time.Second), r.TimeToAssignP90.Round(time.Second))
+	fmt.Printf("  avg distance:      %.1f km\n", r.AvgDistanceKm)
+	fmt.Printf("  avg rounds:        %.2f\n", r.AvgRounds)
+	fmt.Printf("  offers sent:       %d to %d agents (%d idle)\n", r.OffersSent, r.AgentsOffered, r.AgentsIdle)
+	fmt.Printf("  offer fairness:    gini %.3f, top agent share %.1f%%\n", r.OfferGini, r.TopAgentShare*100)
+	fmt.Printf("  assignment gini:   %.3f\n", r.AssignmentsGini)
+}
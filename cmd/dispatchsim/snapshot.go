@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/internal/job/sim"
+)
+
+// loadFixture reads a snapshot from a JSON file.
+func loadFixture(path string) (sim.Snapshot, error) {
+	var snap sim.Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, fmt.Errorf("reading fixture: %w", err)
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("parsing fixture: %w", err)
+	}
+	return snap, nil
+}
+
+// loadFromDB builds a snapshot from a database (typically a restored
+// production dump): active agents, jobs created in [from, to) with their
+// parcels, and 90 days of offer history before from.
+func loadFromDB(ctx context.Context, dbURL string, from, to time.Time) (sim.Snapshot, error) {
+	var snap sim.Snapshot
+
+	db, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		return snap, fmt.Errorf("connecting to database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(ctx, `
+		SELECT a.id,
+			ST_X(COALESCE(a.last_known_location, a.home_location)),
+			ST_Y(COALESCE(a.last_known_location, a.home_location)),
+			COALESCE(a.tier, 'basic'), COALESCE(a.vehicle_type, ''),
+			COALESCE(a.avg_rating, 0)::float8, COALESCE(a.completion_rate, 1)::float8,
+			COALESCE(a.qa_pass_rate, 1)::float8,
+			COALESCE(a.available_days, '{}'),
+			COALESCE(to_char(a.available_start, 'HH24:MI'), ''),
+			COALESCE(to_char(a.available_end, 'HH24:MI'), ''),
+			a.preferred_radius_km, a.last_job_completed_at
+		FROM agents a
+		WHERE a.status = 'active'
+			AND COALESCE(a.last_known_location, a.home_location) IS NOT NULL`)
+	if err != nil {
+		return snap, fmt.Errorf("loading agents: %w", err)
+	}
+	for rows.Next() {
+		var a sim.Agent
+		if err := rows.Scan(&a.ID, &a.Lng, &a.Lat, &a.Tier, &a.VehicleType,
+			&a.AvgRating, &a.CompletionRate, &a.QAPassRate, &a.AvailableDays,
+			&a.AvailableStart, &a.AvailableEnd, &a.PreferredRadiusKm, &a.LastJobCompletedAt); err != nil {
+			rows.Close()
+			return snap, fmt.Errorf("scanning agent: %w", err)
+		}
+		snap.Agents = append(snap.Agents, a)
+	}
+	rows.Close()
+
+	rows, err = db.Query(ctx, `
+		SELECT j.id, j.parcel_id, j.survey_type, COALESCE(j.priority, 'normal'), j.created_at, j.deadline,
+			ST_X(p.centroid), ST_Y(p.centroid), p.state_code, p.district
+		FROM survey_jobs j
+		JOIN parcels p ON p.id = j.parcel_id
+		WHERE j.created_at >= $1 AND j.created_at < $2 AND p.centroid IS NOT NULL
+		ORDER BY j.created_at`, from, to)
+	if err != nil {
+		return snap, fmt.Errorf("loading jobs: %w", err)
+	}
+	seen := map[string]bool{}
+	for rows.Next() {
+		var j sim.Job
+		var p sim.Parcel
+		if err := rows.Scan(&j.ID, &j.ParcelID, &j.SurveyType, &j.Priority, &j.CreatedAt, &j.Deadline,
+			&p.Lng, &p.Lat, &p.StateCode, &p.District); err != nil {
+			rows.Close()
+			return snap, fmt.Errorf("scanning job: %w", err)
+		}
+		snap.Jobs = append(snap.Jobs, j)
+		if p.ID = j.ParcelID; !seen[p.ID.String()] {
+			seen[p.ID.String()] = true
+			snap.Parcels = append(snap.Parcels, p)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query(ctx, `
+		SELECT agent_id,
+			count(*) FILTER (WHERE status = 'accepted'),
+			count(*) FILTER (WHERE status = 'declined'),
+			count(*) FILTER (WHERE status = 'expired'),
+			COALESCE(avg(EXTRACT(EPOCH FROM responded_at - sent_at) / 60)
+				FILTER (WHERE status IN ('accepted', 'declined')), 0)::float8
+		FROM job_offers
+		WHERE sent_at >= $1::timestamptz - INTERVAL '90 days' AND sent_at < $1
+		GROUP BY agent_id`, from)
+	if err != nil {
+		return snap, fmt.Errorf("loading offer history: %w", err)
+	}
+	for rows.Next() {
+		var s sim.OfferStats
+		if err := rows.Scan(&s.AgentID, &s.Accepted, &s.Declined, &s.Expired, &s.AvgResponseMinutes); err != nil {
+			rows.Close()
+			return snap, fmt.Errorf("scanning offer history: %w", err)
+		}
+		snap.OfferStats = append(snap.OfferStats, s)
+	}
+	rows.Close()
+
+	return snap, rows.Err()
+}
@@ -15,13 +15,18 @@ import (
 	"github.com/terrascore/api/db/sqlc"
 	"github.com/terrascore/api/internal/agent"
 	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/billing"
 	"github.com/terrascore/api/internal/job"
 	"github.com/terrascore/api/internal/land"
+	"github.com/terrascore/api/internal/ledger"
 	"github.com/terrascore/api/internal/notification"
 	"github.com/terrascore/api/internal/platform"
 	"github.com/terrascore/api/internal/qa"
 	"github.com/terrascore/api/internal/report"
+	"github.com/terrascore/api/internal/risk"
 	"github.com/terrascore/api/internal/survey"
+	"github.com/terrascore/api/internal/tasks"
+	"github.com/terrascore/api/internal/webhook"
 	"github.com/terrascore/api/internal/ws"
 )
 
@@ -63,11 +68,19 @@ func run(ctx context.Context) error {
 	logger.Info("connected to redis")
 
 	// Event bus
-	eventBus := platform.NewEventBus(logger, 1000)
-	go eventBus.Start(ctx)
+	var eventBus *platform.EventBus
+	switch cfg.EventBus.Backend {
+	case "redis":
+		eventBus = platform.NewEventBusWithBackend(platform.NewRedisStreamsBackend(rdb, cfg.EventBus, logger), logger)
+	case "postgres":
+		eventBus = platform.NewEventBusWithBackend(platform.NewPostgresOutboxBackend(db, cfg.EventBus, logger), logger)
+	default:
+		eventBus = platform.NewEventBus(logger, cfg.EventBus.BufferSize)
+	}
+	logger.Info("initialized event bus", "backend", cfg.EventBus.Backend)
 
 	// Task queue
-	taskQueue := platform.NewTaskQueue(db, logger)
+	taskQueue := platform.NewTaskQueue(db, cfg.TaskQueue, logger)
 
 	// S3 client
 	s3Client, err := platform.NewS3Client(cfg.AWS)
@@ -85,7 +98,7 @@ func run(ctx context.Context) error {
 
 	// Land module
 	landRepo := land.NewRepository(db)
-	landService := land.NewService(landRepo, authRepo, eventBus, logger)
+	landService := land.NewService(landRepo, authRepo, logger)
 	landHandler := land.NewHandler(landService)
 
 	// Agent module
@@ -101,14 +114,25 @@ func run(ctx context.Context) error {
 	// Job module
 	jobRepo := job.NewRepository(db)
 	agentQueries := sqlc.New(db)
-	matcher := job.NewMatcher(agentQueries, jobRepo, logger)
-	dispatcher := job.NewDispatcher(matcher, jobRepo, rdb, eventBus, logger)
-	jobScheduler := job.NewScheduler(jobRepo, landRepo, eventBus, logger)
-	jobHandler := job.NewHandler(jobRepo, agentRepo, surveyRepo, s3Client, rdb, eventBus, logger)
+	strategies, err := job.NewStrategies(cfg.Matcher)
+	if err != nil {
+		return fmt.Errorf("configuring matcher: %w", err)
+	}
+	pricer := job.NewPricer(cfg.Pricing)
+	matcher := job.NewMatcher(agentQueries, jobRepo, strategies, cfg.Matcher.ExpansionRadiiKm, cfg.Dispatch.OfferTimeout, logger)
+	dispatcher := job.NewDispatcher(cfg.Dispatch, matcher, pricer, jobRepo, rdb, eventBus, logger)
+	jobScheduler := job.NewScheduler(jobRepo, landRepo, pricer, logger)
+	jobHandler := job.NewHandler(jobRepo, agentRepo, surveyRepo, s3Client, rdb, logger)
+	opsHandler := job.NewOpsHandler(cfg.Dispatch, jobRepo, agentRepo, rdb, logger)
+	visitHandler := job.NewVisitHandler(jobRepo, pricer, authRepo, logger)
+
+	// Ledger module
+	ledgerRepo := ledger.NewRepository(db)
+	ledgerHandler := ledger.NewHandler(ledgerRepo, agentRepo, logger)
 
 	// QA module
 	qaRepo := qa.NewRepository(db)
-	qaService := qa.NewService(qaRepo, surveyRepo, taskQueue, logger)
+	qaService := qa.NewService(qaRepo, surveyRepo, jobRepo, ledgerRepo, cfg.Payout.FraudPenalty, taskQueue, logger)
 
 	// Notification module
 	notifRepo := notification.NewRepository(db)
@@ -120,42 +144,111 @@ func run(ctx context.Context) error {
 
 	// Report module
 	reportRepo := report.NewRepository(db)
-	reportService := report.NewService(reportRepo, jobRepo, surveyRepo, authRepo, s3Client, taskQueue, logger)
+	reportService := report.NewService(reportRepo, jobRepo, surveyRepo, authRepo, s3Client, taskQueue, eventBus, logger)
 	reportHandler := report.NewHandler(reportRepo, reportService)
 
+	// Billing module
+	billingRepo := billing.NewRepository(db)
+	var payoutProvider billing.PayoutProvider
+	if cfg.Payout.Provider == "razorpayx" {
+		payoutProvider = billing.NewRazorpayXClient(cfg.Payout)
+	} else {
+		payoutProvider = billing.NewMockPayoutProvider(logger)
+	}
+	settlement := billing.NewSettlement(cfg.Payout, billingRepo, agentRepo, payoutProvider, taskQueue, logger)
+	billingHandler := billing.NewHandler(billingRepo, agentRepo, payoutProvider, logger)
+	var paymentGateway billing.PaymentGateway
+	if cfg.Payment.Provider == "razorpay" {
+		paymentGateway = billing.NewRazorpayClient(cfg.Payment)
+	} else {
+		paymentGateway = billing.NewMockPaymentGateway(logger)
+	}
+	subscriptions := billing.NewSubscriptions(billingRepo, paymentGateway, taskQueue, logger)
+	subscriptionHandler := billing.NewSubscriptionHandler(billingRepo, paymentGateway, authRepo, logger)
+	paymentEvents := billing.NewPaymentEvents(billingRepo, paymentGateway, taskQueue, logger)
+	paymentEventHandler := billing.NewPaymentEventHandler(paymentEvents, billingRepo, logger)
+	invoicer := billing.NewInvoicer(cfg.Invoice, billingRepo, s3Client, logger)
+	invoiceHandler := billing.NewInvoiceHandler(billingRepo, invoicer, authRepo, logger)
+
+	// Risk module
+	riskRules, err := risk.LoadRules(cfg.Risk.RulesFile)
+	if err != nil {
+		return fmt.Errorf("loading risk rules: %w", err)
+	}
+	riskRepo := risk.NewRepository(db)
+	riskService := risk.NewService(riskRepo, surveyRepo, riskRules, taskQueue, logger)
+	riskHandler := risk.NewHandler(riskRepo, authRepo)
+
+	// Webhook module
+	webhookRepo := webhook.NewRepository(db)
+	webhookService := webhook.NewService(webhookRepo, taskQueue, cfg.Webhook, logger)
+	webhookHandler := webhook.NewHandler(webhookRepo, webhookService, authRepo, logger)
+
 	// Register task handlers
 	taskQueue.Register("qa.score_survey", qaService.HandleTask)
 	taskQueue.Register("report.generate", reportService.HandleTask)
 	taskQueue.Register("notification.send", notifService.HandleTask)
+	taskQueue.Register(billing.TaskSettlePayouts, settlement.HandleTask)
+	taskQueue.Register(billing.TaskRenewSubscription, subscriptions.HandleTask)
+	taskQueue.Register(billing.TaskProcessPaymentEvent, paymentEvents.HandleTask)
+	taskQueue.Register(risk.TaskType, riskService.HandleTask)
+	taskQueue.Register(webhook.TaskType, webhookService.HandleTask)
 
-	// Start task queue
-	go taskQueue.Start(ctx)
+	taskHandler := tasks.NewHandler(tasks.NewRepository(db), logger)
+
+	// Start task queue; shutdown waits for in-flight tasks
+	taskQueueDone := make(chan struct{})
+	go func() {
+		taskQueue.Start(ctx)
+		close(taskQueueDone)
+	}()
 
 	// WebSocket handler
 	wsHandler := ws.NewHandler(rdb, keycloakClient, agentRepo, logger)
 
 	// Subscribe dispatcher to job.created events
-	eventBus.Subscribe("job.created", dispatcher.HandleJobCreated)
+	platform.Subscribe(eventBus, dispatcher.HandleJobCreated)
+	platform.Subscribe(eventBus, dispatcher.HandleJobRedispatched)
+
+	// Start dispatcher (resumes in-flight cascades, expires stale offers)
+	go dispatcher.Start(ctx)
+
+	// Start batch assigner (optional global first-round planning)
+	if cfg.Dispatch.BatchEnabled {
+		batchAssigner := job.NewBatchAssigner(cfg.Dispatch, dispatcher, matcher, jobRepo, logger)
+		go batchAssigner.Start(ctx)
+	}
 
 	// Subscribe to survey.submitted — enqueues QA scoring task
-	eventBus.Subscribe("survey.submitted", func(ctx context.Context, event platform.Event) {
-		payload, ok := event.Payload.(map[string]string)
-		if !ok {
-			logger.Error("invalid survey.submitted payload")
-			return
-		}
-		if err := taskQueue.Enqueue(ctx, "qa.score_survey", qa.SurveyQAPayload{
-			JobID:    payload["job_id"],
-			ParcelID: payload["parcel_id"],
-			UserID:   payload["user_id"],
-		}); err != nil {
-			logger.Error("failed to enqueue QA task", "error", err)
-		}
-	})
+	platform.Subscribe(eventBus, qaService.HandleSurveySubmitted)
+
+	// Subscribe to qa.completed — enqueues risk scoring of passed surveys
+	platform.Subscribe(eventBus, riskService.HandleQACompleted)
+
+	// Subscribe to job.unassigned — alerts ops that a job needs a hand
+	platform.Subscribe(eventBus, notifService.HandleJobUnassigned)
+
+	// Fan events out to webhook subscriptions
+	webhookService.Subscribe(eventBus)
+
+	// Start the event bus once every handler has subscribed
+	go eventBus.Start(ctx)
+
+	// Relay events recorded in the outbox by committed transactions
+	go platform.NewOutboxRelay(db, eventBus, logger).Start(ctx)
 
 	// Start job scheduler
 	go jobScheduler.Start(ctx)
 
+	// Start weekly payout settlement
+	go settlement.Start(ctx)
+
+	// Start subscription renewals
+	go subscriptions.Start(ctx)
+
+	// Start invoicing of paid charges
+	go invoicer.Start(ctx)
+
 	// Router
 	r := chi.NewRouter()
 
@@ -174,6 +267,10 @@ func run(ctx context.Context) error {
 	// WebSocket endpoint (outside /v1 prefix, no JWT middleware — auth via query param)
 	r.Get("/ws", wsHandler.ServeWS)
 
+	// Payout and payment gateway webhooks (no JWT — verified by body signature)
+	r.Post("/webhooks/payouts", billingHandler.PayoutWebhook)
+	r.Post("/webhooks/payments", paymentEventHandler.Webhook)
+
 	// API v1 routes
 	r.Route("/v1", func(r chi.Router) {
 		r.Mount("/auth", authHandler.Routes())
@@ -185,20 +282,47 @@ func run(ctx context.Context) error {
 			r.Mount("/agents", agentHandler.Routes())
 			r.Mount("/jobs", jobHandler.Routes())
 			r.Mount("/alerts", notifHandler.Routes())
+			r.Mount("/admin/jobs", opsHandler.Routes())
+			r.Mount("/admin/ledger", ledgerHandler.Routes())
+			r.Mount("/admin/billing/payment-events", paymentEventHandler.Routes())
+			r.Mount("/admin/tasks", taskHandler.Routes())
+			r.Mount("/webhooks", webhookHandler.Routes())
 
 			// Report routes
 			r.Get("/parcels/{parcelId}/reports", reportHandler.ListByParcel)
+			r.With(auth.RequireRole("landowner")).Post("/parcels/{parcelId}/visits", visitHandler.RequestVisit)
 			r.Get("/reports/{id}/download", reportHandler.Download)
 
+			// Landowner subscriptions and payments
+			r.Get("/billing/plans", subscriptionHandler.ListPlans)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole("landowner"))
+				r.Get("/billing/transactions", subscriptionHandler.ListTransactions)
+				r.Get("/billing/invoices", invoiceHandler.List)
+				r.Get("/billing/invoices/{id}/download", invoiceHandler.Download)
+				r.Post("/billing/payments/verify", subscriptionHandler.VerifyPayment)
+				r.Post("/parcels/{parcelId}/subscription", subscriptionHandler.CreateSubscription)
+				r.Get("/parcels/{parcelId}/subscription", subscriptionHandler.GetSubscription)
+				r.Post("/parcels/{parcelId}/subscription/upgrade", subscriptionHandler.Upgrade)
+				r.Post("/parcels/{parcelId}/subscription/downgrade", subscriptionHandler.Downgrade)
+				r.Post("/parcels/{parcelId}/subscription/pause", subscriptionHandler.Pause)
+				r.Post("/parcels/{parcelId}/subscription/resume", subscriptionHandler.Resume)
+				r.Post("/parcels/{parcelId}/subscription/cancel", subscriptionHandler.Cancel)
+				r.Get("/parcels/{parcelId}/risk", riskHandler.Get)
+				r.Get("/parcels/{parcelId}/risk/history", riskHandler.History)
+			})
+
 			// Agent-specific job/offer routes (explicit to avoid mount conflicts)
 			r.With(auth.RequireRole("agent")).Get("/agents/me/jobs", jobHandler.ListAgentJobs)
 			r.With(auth.RequireRole("agent")).Get("/agents/me/offers", jobHandler.ListAgentOffers)
+			r.With(auth.RequireRole("agent")).Get("/agents/me/payouts", billingHandler.ListMyPayouts)
+			r.With(auth.RequireRole("agent")).Get("/agents/me/earnings", billingHandler.Earnings)
+			r.With(auth.RequireRole("agent")).Get("/agents/me/statement", ledgerHandler.Statement)
 		})
 	})
 
-	_ = dispatcher    // Subscribed via eventBus, kept alive by event loop
-	_ = agentService  // Used by agentHandler, kept alive by router
-	_ = landService   // Used by landHandler, kept alive by router
+	_ = agentService // Used by agentHandler, kept alive by router
+	_ = landService  // Used by landHandler, kept alive by router
 
 	// Start server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -224,5 +348,6 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("server error: %w", err)
 	}
 
+	<-taskQueueDone
 	return nil
 }
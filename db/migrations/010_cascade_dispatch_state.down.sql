@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_offers_expiry;
+DROP INDEX IF EXISTS idx_offers_job_status;
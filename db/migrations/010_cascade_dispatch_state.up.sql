@@ -0,0 +1,6 @@
+-- 010: Persist cascade dispatch state so in-flight cascades survive restarts.
+-- Each round's ranked candidates are stored up front as 'queued' offers and
+-- promoted to 'sent' one at a time by the dispatcher.
+
+CREATE INDEX idx_offers_job_status ON job_offers(job_id, status);
+CREATE INDEX idx_offers_expiry ON job_offers(expires_at) WHERE status = 'sent';
@@ -0,0 +1,3 @@
+ALTER TABLE job_offers
+    DROP COLUMN IF EXISTS scoring_weights,
+    DROP COLUMN IF EXISTS scoring_strategy;
@@ -0,0 +1,6 @@
+-- 011: Record which scoring strategy and weights ranked each offer so
+-- strategies can be compared after the fact.
+
+ALTER TABLE job_offers
+    ADD COLUMN scoring_strategy VARCHAR(50),
+    ADD COLUMN scoring_weights  JSONB;
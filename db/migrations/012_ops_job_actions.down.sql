@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS job_admin_actions;
+
+ALTER TABLE survey_jobs
+    DROP COLUMN IF EXISTS dispatch_round_limit,
+    DROP COLUMN IF EXISTS dispatch_radius_km;
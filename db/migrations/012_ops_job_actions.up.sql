@@ -0,0 +1,19 @@
+-- 012: Ops console. Re-dispatch overrides on survey_jobs and an audit log of
+-- every manual action taken on a job.
+
+ALTER TABLE survey_jobs
+    ADD COLUMN dispatch_radius_km   REAL,
+    ADD COLUMN dispatch_round_limit INTEGER;
+
+CREATE TABLE job_admin_actions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id          UUID NOT NULL REFERENCES survey_jobs(id),
+    actor_id        VARCHAR(255) NOT NULL,      -- Keycloak subject
+    actor_name      VARCHAR(200),
+    action          VARCHAR(30) NOT NULL,       -- assign | redispatch | update | cancel
+    reason          TEXT,
+    details         JSONB NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_job_admin_actions_job ON job_admin_actions(job_id, created_at DESC);
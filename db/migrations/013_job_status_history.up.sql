@@ -0,0 +1,14 @@
+-- 013: Every job status transition, with who made it and why.
+
+CREATE TABLE job_status_history (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id          UUID NOT NULL REFERENCES survey_jobs(id),
+    from_status     VARCHAR(25) NOT NULL,
+    to_status       VARCHAR(25) NOT NULL,
+    actor_role      VARCHAR(20) NOT NULL,       -- system | agent | ops | admin
+    actor_id        VARCHAR(255),               -- agent ID, Keycloak subject or system component
+    reason          TEXT,
+    created_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_job_status_history_job ON job_status_history(job_id, created_at);
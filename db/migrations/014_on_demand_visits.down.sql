@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_job;
+ALTER TABLE transactions DROP COLUMN IF EXISTS job_id;
+DROP INDEX IF EXISTS idx_jobs_idempotency;
+ALTER TABLE survey_jobs DROP COLUMN IF EXISTS idempotency_key;
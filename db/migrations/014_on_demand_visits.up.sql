@@ -0,0 +1,12 @@
+-- 014: Landowner-requested visits. The idempotency key lets clients retry a
+-- request without creating a second job; the charge for a visit not covered
+-- by the subscription's quota is linked to its job.
+
+ALTER TABLE survey_jobs ADD COLUMN idempotency_key VARCHAR(100);
+
+CREATE UNIQUE INDEX idx_jobs_idempotency ON survey_jobs(parcel_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+ALTER TABLE transactions ADD COLUMN job_id UUID REFERENCES survey_jobs(id);
+
+CREATE INDEX idx_transactions_job ON transactions(job_id) WHERE job_id IS NOT NULL;
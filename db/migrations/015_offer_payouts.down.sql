@@ -0,0 +1,2 @@
+ALTER TABLE survey_jobs DROP COLUMN IF EXISTS surge_bonus;
+ALTER TABLE job_offers DROP COLUMN IF EXISTS payout_breakdown;
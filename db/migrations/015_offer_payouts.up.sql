@@ -0,0 +1,7 @@
+-- 015: Per-offer payout pricing. Each offer carries the payout breakdown the
+-- agent was shown; the accepted offer's amounts are copied onto the job.
+
+ALTER TABLE job_offers ADD COLUMN payout_breakdown JSONB;
+
+-- Supply/demand and cascade-round bonuses
+ALTER TABLE survey_jobs ADD COLUMN surge_bonus NUMERIC(8,2) DEFAULT 0;
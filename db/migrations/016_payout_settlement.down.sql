@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_jobs_unsettled;
+ALTER TABLE survey_jobs DROP COLUMN IF EXISTS payout_id;
+DROP INDEX IF EXISTS idx_payouts_provider;
+DROP INDEX IF EXISTS idx_payouts_agent_period;
+ALTER TABLE agent_payouts DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE agent_payouts DROP COLUMN IF EXISTS paid_at;
+DROP TABLE IF EXISTS payout_periods;
@@ -0,0 +1,22 @@
+-- 016: Weekly agent payout settlement. Each settled week is recorded once so
+-- the settlement task is enqueued exactly once per period; each payout row
+-- links the jobs it pays for.
+
+CREATE TABLE payout_periods (
+    period_start    DATE PRIMARY KEY,
+    period_end      DATE NOT NULL,
+    created_at      TIMESTAMPTZ DEFAULT NOW(),
+    settled_at      TIMESTAMPTZ
+);
+
+ALTER TABLE agent_payouts ADD COLUMN paid_at TIMESTAMPTZ;
+ALTER TABLE agent_payouts ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW();
+
+CREATE UNIQUE INDEX idx_payouts_agent_period ON agent_payouts(agent_id, period_start);
+CREATE UNIQUE INDEX idx_payouts_provider ON agent_payouts(razorpay_payout_id)
+    WHERE razorpay_payout_id IS NOT NULL;
+
+ALTER TABLE survey_jobs ADD COLUMN payout_id UUID REFERENCES agent_payouts(id);
+
+CREATE INDEX idx_jobs_unsettled ON survey_jobs(assigned_agent_id)
+    WHERE status = 'completed' AND payout_id IS NULL;
@@ -0,0 +1,110 @@
+-- 017: Double-entry ledger for agent wallets. Every movement of money is a
+-- ledger transaction whose debit and credit entries balance; each agent has
+-- a wallet account and agents.wallet_balance is kept as a projection of it.
+
+CREATE TABLE ledger_accounts (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code        VARCHAR(60) NOT NULL UNIQUE,
+    type        VARCHAR(20) NOT NULL,          -- asset, liability, revenue, expense
+    agent_id    UUID UNIQUE REFERENCES agents(id),
+    created_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO ledger_accounts (code, type) VALUES
+    ('survey_expense', 'expense'),
+    ('commission_revenue', 'revenue'),
+    ('penalty_revenue', 'revenue'),
+    ('tds_payable', 'liability'),
+    ('payouts_in_transit', 'liability'),
+    ('bank', 'asset'),
+    ('adjustments', 'expense');
+
+CREATE TABLE ledger_transactions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind         VARCHAR(30) NOT NULL,
+    reference_id UUID NOT NULL,                -- job, payout or adjustment it records
+    description  TEXT NOT NULL,
+    created_by   VARCHAR(100) NOT NULL,
+    created_at   TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (kind, reference_id)
+);
+
+-- Entries use a serial key so statements order them as they were posted.
+CREATE TABLE ledger_entries (
+    id             BIGSERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions(id),
+    account_id     UUID NOT NULL REFERENCES ledger_accounts(id),
+    direction      VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount         NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    created_at     TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_entries_account ON ledger_entries(account_id, id);
+CREATE INDEX idx_ledger_entries_transaction ON ledger_entries(transaction_id);
+
+-- Reject any transaction whose debits and credits differ at commit.
+CREATE FUNCTION check_ledger_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT COALESCE(SUM(CASE direction WHEN 'debit' THEN amount ELSE -amount END), 0)
+        FROM ledger_entries WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % is unbalanced', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_ledger_transaction_balanced();
+
+-- Open a wallet account for every agent, carrying over existing balances and
+-- crediting earnings for completed jobs not yet settled, so the next
+-- settlement debits what was earned.
+INSERT INTO ledger_accounts (code, type, agent_id)
+SELECT 'agent:' || id, 'liability', id FROM agents;
+
+WITH opening AS (
+    SELECT id AS agent_id, wallet_balance AS amount FROM agents
+    WHERE wallet_balance IS NOT NULL AND wallet_balance <> 0
+), txn AS (
+    INSERT INTO ledger_transactions (kind, reference_id, description, created_by)
+    SELECT 'opening_balance', agent_id, 'Opening wallet balance', 'migration' FROM opening
+    RETURNING id, reference_id
+)
+INSERT INTO ledger_entries (transaction_id, account_id, direction, amount)
+SELECT txn.id, a.id, CASE WHEN o.amount > 0 THEN 'credit' ELSE 'debit' END, ABS(o.amount)
+FROM txn
+JOIN opening o ON o.agent_id = txn.reference_id
+JOIN ledger_accounts a ON a.agent_id = o.agent_id
+UNION ALL
+SELECT txn.id, (SELECT id FROM ledger_accounts WHERE code = 'adjustments'),
+    CASE WHEN o.amount > 0 THEN 'debit' ELSE 'credit' END, ABS(o.amount)
+FROM txn
+JOIN opening o ON o.agent_id = txn.reference_id;
+
+WITH earned AS (
+    SELECT id AS job_id, assigned_agent_id AS agent_id, total_payout AS amount FROM survey_jobs
+    WHERE status = 'completed' AND payout_id IS NULL
+      AND assigned_agent_id IS NOT NULL AND total_payout > 0
+), txn AS (
+    INSERT INTO ledger_transactions (kind, reference_id, description, created_by)
+    SELECT 'job_earning', job_id, 'Survey job earnings', 'migration' FROM earned
+    RETURNING id, reference_id
+)
+INSERT INTO ledger_entries (transaction_id, account_id, direction, amount)
+SELECT txn.id, a.id, 'credit', e.amount
+FROM txn
+JOIN earned e ON e.job_id = txn.reference_id
+JOIN ledger_accounts a ON a.agent_id = e.agent_id
+UNION ALL
+SELECT txn.id, (SELECT id FROM ledger_accounts WHERE code = 'survey_expense'), 'debit', e.amount
+FROM txn
+JOIN earned e ON e.job_id = txn.reference_id;
+
+UPDATE agents ag SET wallet_balance = COALESCE((
+    SELECT SUM(CASE e.direction WHEN 'credit' THEN e.amount ELSE -e.amount END)
+    FROM ledger_entries e
+    JOIN ledger_accounts a ON a.id = e.account_id
+    WHERE a.agent_id = ag.id
+), 0);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_order;
+DROP INDEX IF EXISTS idx_subs_renewal_due;
+DROP INDEX IF EXISTS idx_subs_parcel_live;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS renewal_enqueued_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at_period_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS credit_balance;
@@ -0,0 +1,17 @@
+-- 018: Subscription lifecycle. A parcel has at most one live subscription;
+-- plan changes are prorated, with downgrade credit carried to the next
+-- renewal, and renewals are claimed so each is enqueued once.
+
+ALTER TABLE subscriptions ADD COLUMN credit_balance NUMERIC(10,2) DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN paused_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN cancel_at_period_end BOOLEAN DEFAULT FALSE;
+ALTER TABLE subscriptions ADD COLUMN cancelled_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN renewal_enqueued_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_subs_parcel_live ON subscriptions(parcel_id)
+    WHERE status IN ('pending', 'active', 'paused');
+CREATE INDEX idx_subs_renewal_due ON subscriptions(current_period_end)
+    WHERE status = 'active';
+
+CREATE UNIQUE INDEX idx_transactions_order ON transactions(razorpay_order_id)
+    WHERE razorpay_order_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- 019: Payment gateway webhook events. Each event is stored once, keyed by
+-- the gateway's event ID, and processed asynchronously; failed events stay
+-- here for ops to replay.
+
+CREATE TABLE payment_events (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id      VARCHAR(100) NOT NULL UNIQUE,
+    event_type    VARCHAR(100) NOT NULL,
+    payload       JSONB NOT NULL,
+    status        VARCHAR(20) NOT NULL DEFAULT 'received'
+                  CHECK (status IN ('received', 'processed', 'ignored', 'failed')),
+    attempts      INT NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    received_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_payment_events_status ON payment_events(status, received_at DESC);
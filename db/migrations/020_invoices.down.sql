@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_paid;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
@@ -0,0 +1,29 @@
+-- 020: GST tax invoices. Each paid landowner charge gets one invoice,
+-- numbered without gaps within its financial year.
+
+CREATE TABLE invoice_sequences (
+    financial_year  VARCHAR(7) PRIMARY KEY, -- e.g. 2026-27
+    last_number     INT NOT NULL
+);
+
+CREATE TABLE invoices (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_number  VARCHAR(30) NOT NULL UNIQUE,
+    financial_year  VARCHAR(7) NOT NULL,
+    sequence        INT NOT NULL,
+    transaction_id  UUID NOT NULL UNIQUE REFERENCES transactions(id),
+    user_id         UUID NOT NULL REFERENCES users(id),
+    place_of_supply VARCHAR(10) NOT NULL,
+    interstate      BOOLEAN NOT NULL,
+    taxable_amount  NUMERIC(10,2) NOT NULL,
+    cgst            NUMERIC(10,2) NOT NULL DEFAULT 0,
+    sgst            NUMERIC(10,2) NOT NULL DEFAULT 0,
+    igst            NUMERIC(10,2) NOT NULL DEFAULT 0,
+    total_amount    NUMERIC(10,2) NOT NULL,
+    s3_key          TEXT NOT NULL,
+    issued_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (financial_year, sequence)
+);
+
+CREATE INDEX idx_invoices_user ON invoices(user_id, issued_at DESC);
+CREATE INDEX idx_transactions_paid ON transactions(created_at) WHERE status = 'paid';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_task_queue_leases;
+ALTER TABLE task_queue DROP COLUMN IF EXISTS lease_expires_at;
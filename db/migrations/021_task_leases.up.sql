@@ -0,0 +1,11 @@
+-- 021: Task leases. A worker holds a claimed task until lease_expires_at;
+-- tasks still processing after their lease expires belonged to a worker that
+-- crashed or hung, and are requeued.
+
+ALTER TABLE task_queue ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+-- Tasks left processing by earlier versions have no lease; expire them now.
+UPDATE task_queue SET lease_expires_at = NOW() WHERE status = 'processing';
+
+CREATE INDEX idx_task_queue_leases ON task_queue(lease_expires_at)
+    WHERE status = 'processing';
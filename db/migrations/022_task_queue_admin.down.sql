@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_task_queue_type;
+DROP INDEX IF EXISTS idx_task_queue_finished;
@@ -0,0 +1,7 @@
+-- 022: Task queue administration. Ops can now cancel pending tasks, and
+-- finished tasks are indexed for stats and purging.
+
+CREATE INDEX idx_task_queue_finished ON task_queue(completed_at)
+    WHERE status IN ('completed', 'dead', 'cancelled');
+
+CREATE INDEX idx_task_queue_type ON task_queue(task_type, status, created_at DESC);
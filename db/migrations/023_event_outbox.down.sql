@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_deliveries;
+DROP TABLE IF EXISTS event_outbox;
@@ -0,0 +1,33 @@
+-- 023: Postgres event bus backend. Published events are written to an outbox;
+-- a relay fans each one out to a delivery per subscriber, which workers on
+-- any replica claim under a lease and acknowledge once handled.
+
+CREATE TABLE event_outbox (
+    id             BIGSERIAL PRIMARY KEY,
+    event_type     VARCHAR(100) NOT NULL,
+    payload        JSONB NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_event_outbox_undispatched ON event_outbox(id) WHERE dispatched_at IS NULL;
+CREATE INDEX idx_event_outbox_dispatched ON event_outbox(dispatched_at);
+
+CREATE TABLE event_deliveries (
+    id                BIGSERIAL PRIMARY KEY,
+    event_id          BIGINT NOT NULL REFERENCES event_outbox(id) ON DELETE CASCADE,
+    subscriber        VARCHAR(200) NOT NULL,
+    status            VARCHAR(20) NOT NULL DEFAULT 'pending'
+                      CHECK (status IN ('pending', 'processing', 'delivered', 'dead')),
+    attempts          INT NOT NULL DEFAULT 0,
+    available_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    lease_expires_at  TIMESTAMPTZ,
+    last_error        TEXT,
+    delivered_at      TIMESTAMPTZ,
+    UNIQUE (event_id, subscriber)
+);
+
+CREATE INDEX idx_event_deliveries_due ON event_deliveries(subscriber, available_at)
+    WHERE status = 'pending';
+CREATE INDEX idx_event_deliveries_leases ON event_deliveries(lease_expires_at)
+    WHERE status = 'processing';
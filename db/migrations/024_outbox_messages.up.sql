@@ -0,0 +1,18 @@
+-- 024: Transactional outbox. Domain writes record the events and tasks they
+-- cause in the same transaction; a relay hands them to the event bus or task
+-- queue after commit and marks them sent.
+
+CREATE TABLE outbox_messages (
+    id            BIGSERIAL PRIMARY KEY,
+    kind          VARCHAR(10) NOT NULL CHECK (kind IN ('event', 'task')),
+    name          VARCHAR(100) NOT NULL, -- event or task type
+    payload       JSONB NOT NULL,
+    attempts      INT NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    available_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_messages_unsent ON outbox_messages(id) WHERE sent_at IS NULL;
+CREATE INDEX idx_outbox_messages_sent ON outbox_messages(sent_at);
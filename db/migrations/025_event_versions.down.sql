@@ -0,0 +1,2 @@
+ALTER TABLE event_outbox DROP COLUMN IF EXISTS version;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS version;
@@ -0,0 +1,5 @@
+-- 025: Carry the schema version of typed event payloads through the outbox
+-- tables. Events written before typed events existed are version 0.
+
+ALTER TABLE outbox_messages ADD COLUMN version INT NOT NULL DEFAULT 0;
+ALTER TABLE event_outbox ADD COLUMN version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,37 @@
+-- 026: Outbound webhooks. A user subscribes an endpoint to event types; each
+-- matching event is POSTed to it, signed with the subscription's secret, by
+-- the task queue. Every delivery and its latest attempt are logged.
+
+CREATE TABLE webhook_subscriptions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id),
+    url           TEXT NOT NULL,
+    secret        TEXT NOT NULL,
+    event_types   TEXT[] NOT NULL,
+    description   TEXT,
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_subscriptions_user ON webhook_subscriptions(user_id);
+
+CREATE TABLE webhook_deliveries (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id  UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_type       VARCHAR(100) NOT NULL,
+    event_version    INT NOT NULL DEFAULT 0,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending'
+                     CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INT NOT NULL DEFAULT 0,
+    response_status  INT,
+    response_body    TEXT,
+    last_error       TEXT,
+    duration_ms      INT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at  TIMESTAMPTZ,
+    delivered_at     TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- 027: At most one risk score per survey job, so a retried scoring task does
+-- not record the same survey twice.
+
+CREATE UNIQUE INDEX idx_risk_job ON risk_scores(job_id) WHERE job_id IS NOT NULL;
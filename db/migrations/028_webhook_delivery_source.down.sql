@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_source;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS source_event_id;
@@ -0,0 +1,8 @@
+-- 028: Key webhook deliveries on the event they carry, so an event the bus
+-- redelivers to the webhook service does not log and send it again.
+
+ALTER TABLE webhook_deliveries ADD COLUMN source_event_id TEXT;
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_source
+    ON webhook_deliveries(subscription_id, source_event_id)
+    WHERE source_event_id IS NOT NULL;
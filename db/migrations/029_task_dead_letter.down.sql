@@ -0,0 +1 @@
+UPDATE task_queue SET status = 'failed' WHERE status = 'dead';
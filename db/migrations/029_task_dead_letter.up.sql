@@ -0,0 +1,4 @@
+-- 029: Dead-lettered tasks. A task that exhausts its retries is now dead,
+-- not failed; tasks that failed before retries existed are dead too.
+
+UPDATE task_queue SET status = 'dead' WHERE status = 'failed';
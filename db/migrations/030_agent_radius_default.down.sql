@@ -0,0 +1 @@
+ALTER TABLE agents ALTER COLUMN preferred_radius_km SET DEFAULT 25;
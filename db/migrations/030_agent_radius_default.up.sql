@@ -0,0 +1,7 @@
+-- 030: An agent's preferred radius is optional. The old default of 25 km hid
+-- agents who never set one from the wider expansion radii and ops
+-- re-dispatch, so it is dropped and agents still on it have no preference.
+
+ALTER TABLE agents ALTER COLUMN preferred_radius_km DROP DEFAULT;
+
+UPDATE agents SET preferred_radius_km = NULL WHERE preferred_radius_km = 25;
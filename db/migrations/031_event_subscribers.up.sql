@@ -0,0 +1,19 @@
+-- 031: Registry of event bus subscribers. The outbox relay fans each event
+-- out to every subscriber registered for its type, not just the ones the
+-- relaying replica runs, so a subscriber added in a rolling deploy still
+-- gets events published by replicas on the old release.
+
+CREATE TABLE event_subscribers (
+    event_type     VARCHAR(100) NOT NULL,
+    subscriber     VARCHAR(200) NOT NULL,
+    registered_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_type, subscriber)
+);
+
+-- Subscribers with deliveries on record were running before the registry
+INSERT INTO event_subscribers (event_type, subscriber)
+SELECT DISTINCT e.event_type, d.subscriber
+FROM event_deliveries d
+JOIN event_outbox e ON e.id = d.event_id
+ON CONFLICT DO NOTHING;
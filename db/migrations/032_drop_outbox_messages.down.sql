@@ -0,0 +1,15 @@
+CREATE TABLE outbox_messages (
+    id            BIGSERIAL PRIMARY KEY,
+    kind          VARCHAR(10) NOT NULL CHECK (kind IN ('event', 'task')),
+    name          VARCHAR(100) NOT NULL,
+    version       INT NOT NULL DEFAULT 0,
+    payload       JSONB NOT NULL,
+    attempts      INT NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    available_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_messages_unsent ON outbox_messages(id) WHERE sent_at IS NULL;
+CREATE INDEX idx_outbox_messages_sent ON outbox_messages(sent_at);
@@ -0,0 +1,17 @@
+-- 032: PublishTx writes to event_outbox (023) and EnqueueTx to task_queue
+-- directly, so the separate outbox_messages table (024) is retired. Anything
+-- still unsent in it is moved across first.
+
+INSERT INTO event_outbox (event_type, version, payload, created_at)
+SELECT name, version, payload, created_at
+FROM outbox_messages
+WHERE kind = 'event' AND sent_at IS NULL
+ORDER BY id;
+
+INSERT INTO task_queue (task_type, payload, created_at)
+SELECT name, payload, created_at
+FROM outbox_messages
+WHERE kind = 'task' AND sent_at IS NULL
+ORDER BY id;
+
+DROP TABLE outbox_messages;
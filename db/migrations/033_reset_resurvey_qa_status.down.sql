@@ -0,0 +1 @@
+-- 033: Nothing to undo; re-surveys awaiting QA keep their pending status.
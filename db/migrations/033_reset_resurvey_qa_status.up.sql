@@ -0,0 +1,4 @@
+-- 033: Re-surveyed jobs awaiting QA start from a pending QA status
+-- A submitted job whose last QA failed is a re-survey that has not been scored yet.
+UPDATE survey_jobs SET qa_score = NULL, qa_status = 'pending', qa_notes = NULL
+WHERE status = 'survey_submitted' AND qa_status = 'failed';
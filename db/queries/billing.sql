@@ -9,12 +9,20 @@ SELECT * FROM transactions WHERE id = $1;
 -- name: ListTransactionsByUser :many
 SELECT * FROM transactions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
 
+-- name: CreateVisitCharge :one
+INSERT INTO transactions (user_id, subscription_id, job_id, type, amount, status)
+VALUES ($1, $2, $3, 'on_demand_visit', $4, 'pending')
+RETURNING *;
+
+-- name: GetTransactionByJob :one
+SELECT * FROM transactions WHERE job_id = $1 ORDER BY created_at DESC LIMIT 1;
+
 -- name: UpdateTransactionStatus :exec
 UPDATE transactions SET status = $2 WHERE id = $1;
 
 -- name: CreateSubscription :one
-INSERT INTO subscriptions (user_id, parcel_id, plan, amount_per_cycle, current_period_start, current_period_end)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO subscriptions (user_id, parcel_id, plan, status, amount_per_cycle, current_period_start, current_period_end, on_demand_visits_remaining)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetSubscriptionByID :one
@@ -23,13 +31,326 @@ SELECT * FROM subscriptions WHERE id = $1;
 -- name: GetActiveSubscription :one
 SELECT * FROM subscriptions WHERE parcel_id = $1 AND status = 'active' LIMIT 1;
 
+-- name: GetActiveSubscriptionForUpdate :one
+SELECT * FROM subscriptions WHERE parcel_id = $1 AND status = 'active' LIMIT 1 FOR UPDATE;
+
+-- name: ConsumeOnDemandVisit :one
+UPDATE subscriptions SET
+    on_demand_visits_remaining = on_demand_visits_remaining - 1,
+    updated_at = NOW()
+WHERE id = $1 AND on_demand_visits_remaining > 0
+RETURNING *;
+
+-- name: IncrementSubscriptionVisits :exec
+UPDATE subscriptions SET
+    visits_used_this_period = COALESCE(visits_used_this_period, 0) + 1,
+    updated_at = NOW()
+WHERE id = $1;
+
 -- name: UpdateSubscriptionStatus :exec
 UPDATE subscriptions SET status = $2, updated_at = NOW() WHERE id = $1;
 
+-- name: GetLiveSubscription :one
+SELECT * FROM subscriptions
+WHERE parcel_id = $1 AND status IN ('pending', 'active', 'paused')
+LIMIT 1;
+
+-- name: GetLiveSubscriptionForUpdate :one
+SELECT * FROM subscriptions
+WHERE parcel_id = $1 AND status IN ('pending', 'active', 'paused')
+LIMIT 1 FOR UPDATE;
+
+-- name: GetSubscriptionForUpdate :one
+SELECT * FROM subscriptions WHERE id = $1 FOR UPDATE;
+
+-- name: ActivateSubscription :one
+UPDATE subscriptions SET
+    status = 'active',
+    current_period_start = $2,
+    current_period_end = $3,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: ChangeSubscriptionPlan :one
+UPDATE subscriptions SET
+    plan = $2,
+    amount_per_cycle = $3,
+    credit_balance = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: PauseSubscription :one
+UPDATE subscriptions SET status = 'paused', paused_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ResumeSubscription :one
+-- The period is extended by the time spent paused.
+UPDATE subscriptions SET
+    status = 'active',
+    current_period_end = current_period_end + (NOW() - paused_at),
+    paused_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'paused'
+RETURNING *;
+
+-- name: SetCancelAtPeriodEnd :one
+UPDATE subscriptions SET cancel_at_period_end = TRUE, updated_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: CancelSubscription :one
+UPDATE subscriptions SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'active', 'paused')
+RETURNING *;
+
+-- name: ClaimDueRenewals :many
+-- Claims active subscriptions whose period has ended. A claim that was not
+-- renewed within an hour, e.g. because its task failed, can be claimed again.
+UPDATE subscriptions SET renewal_enqueued_at = NOW()
+WHERE id IN (
+    SELECT d.id FROM subscriptions d
+    WHERE d.status = 'active'
+      AND d.current_period_end <= sqlc.arg(now)
+      AND (d.renewal_enqueued_at IS NULL OR d.renewal_enqueued_at < sqlc.arg(now) - INTERVAL '1 hour')
+    ORDER BY d.current_period_end
+    LIMIT sqlc.arg(lim)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, current_period_end;
+
+-- name: ReleaseRenewalClaim :exec
+UPDATE subscriptions SET renewal_enqueued_at = NULL WHERE id = $1;
+
+-- name: RenewSubscription :one
+UPDATE subscriptions SET
+    current_period_start = $2,
+    current_period_end = $3,
+    credit_balance = $4,
+    on_demand_visits_remaining = $5,
+    visits_used_this_period = 0,
+    renewal_enqueued_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetTransactionByOrderForUpdate :one
+SELECT * FROM transactions WHERE razorpay_order_id = $1 FOR UPDATE;
+
+-- name: MarkTransactionPaid :one
+UPDATE transactions SET status = 'paid', razorpay_payment_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CountTransactionsByUser :one
+SELECT count(*) FROM transactions WHERE user_id = $1;
+
 -- name: CreateAgentPayout :one
+-- Returns no row if the agent already has a payout for the period.
 INSERT INTO agent_payouts (agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (agent_id, period_start) DO NOTHING
 RETURNING *;
 
 -- name: ListPayoutsByAgent :many
 SELECT * FROM agent_payouts WHERE agent_id = $1 ORDER BY period_end DESC LIMIT $2 OFFSET $3;
+
+-- name: CountPayoutsByAgent :one
+SELECT count(*) FROM agent_payouts WHERE agent_id = $1;
+
+-- name: ClaimPayoutPeriod :one
+-- Returns no row if the period was already claimed.
+INSERT INTO payout_periods (period_start, period_end)
+VALUES ($1, $2)
+ON CONFLICT (period_start) DO NOTHING
+RETURNING *;
+
+-- name: ReleasePayoutPeriod :exec
+DELETE FROM payout_periods WHERE period_start = $1 AND settled_at IS NULL;
+
+-- name: MarkPayoutPeriodSettled :exec
+UPDATE payout_periods SET settled_at = NOW() WHERE period_start = $1;
+
+-- name: ListAgentsWithUnsettledJobs :many
+SELECT DISTINCT assigned_agent_id::uuid AS agent_id FROM survey_jobs
+WHERE status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL AND assigned_agent_id IS NOT NULL
+    AND completed_at < $1;
+
+-- name: AttachJobsToPayout :many
+-- Links an agent's unsettled QA-passed jobs completed before the cutoff to a
+-- payout and returns their payouts.
+UPDATE survey_jobs SET payout_id = $1, payout_status = 'settled', updated_at = NOW()
+WHERE assigned_agent_id = $2
+    AND status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL
+    AND completed_at < $3
+RETURNING id, total_payout;
+
+-- name: SetPayoutAmounts :one
+UPDATE agent_payouts SET
+    total_jobs = $2,
+    gross_amount = $3,
+    platform_commission = $4,
+    tds_deducted = $5,
+    net_amount = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePayout :exec
+DELETE FROM agent_payouts WHERE id = $1;
+
+-- name: GetAgentTaxableEarnings :one
+-- Earnings after commission already settled for an agent since a date, for
+-- the TDS threshold.
+SELECT COALESCE(sum(gross_amount - platform_commission), 0)::numeric AS taxable
+FROM agent_payouts
+WHERE agent_id = $1 AND period_start >= $2 AND status <> 'failed';
+
+-- name: ListUnsentPayouts :many
+SELECT * FROM agent_payouts
+WHERE status = 'pending' AND razorpay_payout_id IS NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: MarkPayoutSent :one
+UPDATE agent_payouts SET
+    status = $2,
+    razorpay_payout_id = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkPayoutFailed :one
+UPDATE agent_payouts SET
+    status = 'failed',
+    failure_reason = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetPayoutByProviderID :one
+SELECT * FROM agent_payouts WHERE razorpay_payout_id = $1;
+
+-- name: MarkPayoutPaid :one
+UPDATE agent_payouts SET
+    status = 'paid',
+    paid_at = NOW(),
+    failure_reason = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetJobsPayoutStatus :exec
+UPDATE survey_jobs SET payout_status = $2, updated_at = NOW() WHERE payout_id = $1;
+
+-- name: GetAgentPayoutTotals :one
+SELECT
+    COALESCE(sum(net_amount) FILTER (WHERE status = 'paid'), 0)::numeric AS paid,
+    COALESCE(sum(net_amount) FILTER (WHERE status IN ('pending', 'processing')), 0)::numeric AS in_transit,
+    COALESCE(sum(tds_deducted) FILTER (WHERE status <> 'failed'), 0)::numeric AS tds_deducted,
+    count(*) FILTER (WHERE status = 'failed') AS failed_payouts
+FROM agent_payouts
+WHERE agent_id = $1;
+
+-- name: GetAgentUnsettledEarnings :one
+SELECT count(*) AS jobs,
+    COALESCE(sum(total_payout), 0)::numeric AS amount
+FROM survey_jobs
+WHERE assigned_agent_id = $1
+    AND status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL;
+
+-- name: CreatePaymentEvent :one
+-- Returns no row if the event was already received.
+INSERT INTO payment_events (event_id, event_type, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetPaymentEventByEventID :one
+SELECT * FROM payment_events WHERE event_id = $1;
+
+-- name: GetPaymentEvent :one
+SELECT * FROM payment_events WHERE id = $1;
+
+-- name: GetPaymentEventForUpdate :one
+SELECT * FROM payment_events WHERE id = $1 FOR UPDATE;
+
+-- name: CompletePaymentEvent :exec
+UPDATE payment_events SET
+    status = $2,
+    attempts = attempts + 1,
+    last_error = NULL,
+    processed_at = NOW()
+WHERE id = $1;
+
+-- name: FailPaymentEvent :exec
+UPDATE payment_events SET
+    status = 'failed',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1;
+
+-- name: ReplayPaymentEvent :one
+UPDATE payment_events SET status = 'received'
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
+
+-- name: ListPaymentEvents :many
+SELECT * FROM payment_events
+WHERE sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')
+ORDER BY received_at DESC
+LIMIT sqlc.arg(lim) OFFSET sqlc.arg(off);
+
+-- name: CountPaymentEvents :one
+SELECT count(*) FROM payment_events
+WHERE sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status');
+
+-- name: MarkTransactionFailed :one
+UPDATE transactions SET status = 'failed'
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: ListUninvoicedTransactions :many
+SELECT t.* FROM transactions t
+LEFT JOIN invoices i ON i.transaction_id = t.id
+WHERE t.status = 'paid' AND i.id IS NULL
+ORDER BY t.created_at
+LIMIT $1;
+
+-- name: GetTransactionForUpdate :one
+SELECT * FROM transactions WHERE id = $1 FOR UPDATE;
+
+-- name: GetInvoiceByTransaction :one
+SELECT * FROM invoices WHERE transaction_id = $1;
+
+-- name: NextInvoiceSequence :one
+-- Locks the financial year's counter until the caller's transaction ends,
+-- so numbers are issued in order and a rolled-back invoice leaves no gap.
+INSERT INTO invoice_sequences (financial_year, last_number)
+VALUES ($1, 1)
+ON CONFLICT (financial_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+RETURNING last_number;
+
+-- name: CreateInvoice :one
+INSERT INTO invoices (
+    invoice_number, financial_year, sequence, transaction_id, user_id,
+    place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount,
+    s3_key, issued_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: GetInvoiceByID :one
+SELECT * FROM invoices WHERE id = $1;
+
+-- name: ListInvoicesByUser :many
+SELECT * FROM invoices WHERE user_id = $1
+ORDER BY issued_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountInvoicesByUser :one
+SELECT count(*) FROM invoices WHERE user_id = $1;
@@ -0,0 +1,93 @@
+-- name: InsertOutboxEvent :one
+INSERT INTO event_outbox (event_type, version, payload)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ClaimUndispatchedEvents :many
+SELECT id, event_type FROM event_outbox
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: LockUndispatchedEvents :many
+-- For the outbox relay of backends that do not read the outbox themselves.
+SELECT id, event_type, version, payload FROM event_outbox
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: RegisterEventSubscribers :exec
+-- Records the subscribers a replica runs, refreshing ones already known.
+INSERT INTO event_subscribers (event_type, subscriber)
+SELECT unnest(sqlc.arg('event_types')::text[]), unnest(sqlc.arg('subscribers')::text[])
+ON CONFLICT (event_type, subscriber) DO UPDATE SET last_seen_at = NOW();
+
+-- name: CreateEventDeliveries :exec
+-- Fans events out to a delivery per registered subscriber of their type.
+INSERT INTO event_deliveries (event_id, subscriber)
+SELECT e.id, s.subscriber
+FROM event_outbox e
+JOIN event_subscribers s ON s.event_type = e.event_type
+WHERE e.id = ANY(sqlc.arg('ids')::bigint[])
+ON CONFLICT (event_id, subscriber) DO NOTHING;
+
+-- name: MarkEventsDispatched :exec
+UPDATE event_outbox SET dispatched_at = NOW() WHERE id = ANY(sqlc.arg('ids')::bigint[]);
+
+-- name: ClaimEventDeliveries :many
+-- Claims due deliveries for the given subscribers, including ones whose
+-- previous claim's lease expired without an acknowledgement.
+UPDATE event_deliveries d
+SET status = 'processing', attempts = d.attempts + 1, lease_expires_at = sqlc.arg('lease_expires_at')
+FROM event_outbox e
+WHERE e.id = d.event_id AND d.id IN (
+    SELECT dd.id FROM event_deliveries dd
+    WHERE dd.subscriber = ANY(sqlc.arg('subscribers')::text[])
+      AND ((dd.status = 'pending' AND dd.available_at <= NOW())
+        OR (dd.status = 'processing' AND dd.lease_expires_at < NOW()))
+    ORDER BY dd.id
+    LIMIT sqlc.arg('lim')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.subscriber, d.attempts, e.id AS event_id, e.event_type, e.version, e.payload;
+
+-- name: AckEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'delivered', delivered_at = NOW(), lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing';
+
+-- name: RetryEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'pending', available_at = $3, last_error = $4, lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing';
+
+-- name: KillEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'dead', last_error = $3, lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing';
+
+-- name: DeleteDeliveredEvents :execrows
+-- Deletes events dispatched before the cutoff once no subscriber still has
+-- them to handle.
+DELETE FROM event_outbox e
+WHERE e.dispatched_at < $1
+  AND NOT EXISTS (
+    SELECT 1 FROM event_deliveries d
+    WHERE d.event_id = e.id AND d.status IN ('pending', 'processing')
+  );
+
+-- name: DeleteStaleEventSubscribers :execrows
+-- Forgets subscribers no replica has run since the cutoff and drops the
+-- deliveries they would never claim.
+WITH stale AS (
+    DELETE FROM event_subscribers WHERE last_seen_at < $1
+    RETURNING event_type, subscriber
+)
+DELETE FROM event_deliveries d
+USING stale s, event_outbox e
+WHERE e.id = d.event_id
+  AND e.event_type = s.event_type
+  AND d.subscriber = s.subscriber
+  AND d.status IN ('pending', 'processing');
@@ -5,9 +5,28 @@ INSERT INTO survey_jobs (
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
+-- name: CreateOnDemandJob :one
+INSERT INTO survey_jobs (
+    parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, base_payout, idempotency_key
+)
+VALUES ($1, $2, $3, $4, $5, $6, 'on_demand', $7, $8)
+RETURNING *;
+
+-- name: GetJobByIdempotencyKey :one
+SELECT * FROM survey_jobs WHERE parcel_id = $1 AND idempotency_key = $2;
+
+-- name: HasOpenJobForParcel :one
+SELECT EXISTS (
+    SELECT 1 FROM survey_jobs
+    WHERE parcel_id = $1 AND status NOT IN ('completed', 'cancelled')
+);
+
 -- name: GetSurveyJobByID :one
 SELECT * FROM survey_jobs WHERE id = $1;
 
+-- name: GetSurveyJobForUpdate :one
+SELECT * FROM survey_jobs WHERE id = $1 FOR UPDATE;
+
 -- name: UpdateJobStatus :one
 UPDATE survey_jobs SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
 
@@ -22,23 +41,44 @@ UPDATE survey_jobs SET
 WHERE id = $1
 RETURNING *;
 
--- name: RecordAgentArrival :exec
+-- name: RecordAgentArrival :one
 UPDATE survey_jobs SET
     agent_arrived_at = NOW(),
     arrival_location = ST_SetSRID(ST_MakePoint($2, $3), 4326),
     arrival_distance_m = $4,
     status = 'agent_on_site',
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1
+RETURNING *;
+
+-- name: StartSurvey :one
+UPDATE survey_jobs SET
+    survey_started_at = NOW(),
+    status = 'in_progress',
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SubmitJobSurvey :one
+UPDATE survey_jobs SET
+    survey_submitted_at = NOW(),
+    status = 'survey_submitted',
+    qa_score = NULL,
+    qa_status = 'pending',
+    qa_notes = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
 
--- name: CompleteJob :exec
+-- name: CompleteJob :one
 UPDATE survey_jobs SET
     completed_at = NOW(),
     status = 'completed',
-    qa_status = 'pending',
-    total_payout = base_payout + distance_bonus + urgency_bonus,
+    total_payout = COALESCE(base_payout, 0) + COALESCE(distance_bonus, 0)
+        + COALESCE(urgency_bonus, 0) + COALESCE(surge_bonus, 0),
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1
+RETURNING *;
 
 -- name: ListJobsByParcel :many
 SELECT * FROM survey_jobs
@@ -58,13 +98,33 @@ WHERE status IN ('pending_assignment', 'offered')
 ORDER BY deadline ASC
 LIMIT $1;
 
+-- name: ListUnplannedJobs :many
+-- Pending jobs still waiting for their first cascade round.
+SELECT j.* FROM survey_jobs j
+WHERE j.status = 'pending_assignment'
+  AND COALESCE(j.cascade_round, 0) = 0
+  AND NOT EXISTS (SELECT 1 FROM job_offers o WHERE o.job_id = j.id)
+ORDER BY j.deadline ASC
+LIMIT $1;
+
+-- name: IsJobUnplanned :one
+SELECT EXISTS (
+    SELECT 1 FROM survey_jobs j
+    WHERE j.id = $1
+      AND j.status = 'pending_assignment'
+      AND COALESCE(j.cascade_round, 0) = 0
+      AND NOT EXISTS (SELECT 1 FROM job_offers o WHERE o.job_id = j.id)
+);
+
 -- name: CreateJobOffer :one
 INSERT INTO job_offers (job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, expires_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
--- name: UpdateJobOfferStatus :exec
-UPDATE job_offers SET status = $2, responded_at = NOW(), decline_reason = $3 WHERE id = $1;
+-- name: DeclineJobOffer :one
+UPDATE job_offers SET status = 'declined', responded_at = NOW(), decline_reason = $2
+WHERE id = $1 AND status = 'sent'
+RETURNING *;
 
 -- name: ListOffersByJob :many
 SELECT * FROM job_offers WHERE job_id = $1 ORDER BY cascade_round, offer_rank;
@@ -77,7 +137,7 @@ ORDER BY sent_at DESC;
 -- name: CountActiveJobsByAgent :one
 SELECT count(*) FROM survey_jobs
 WHERE assigned_agent_id = $1
-    AND status IN ('assigned', 'agent_on_site', 'survey_in_progress');
+    AND status IN ('assigned', 'agent_en_route', 'agent_on_site', 'in_progress');
 
 -- name: GetOfferByJobAndAgent :one
 SELECT * FROM job_offers WHERE job_id = $1 AND agent_id = $2 AND status = 'sent';
@@ -88,6 +148,11 @@ SELECT * FROM job_offers WHERE id = $1 AND status = 'sent';
 -- name: ExpireOffers :exec
 UPDATE job_offers SET status = 'expired' WHERE expires_at < NOW() AND status = 'sent';
 
+-- name: LockJobPendingQA :one
+SELECT status = 'survey_submitted' AND COALESCE(qa_status, 'pending') = 'pending' AS pending
+FROM survey_jobs WHERE id = $1
+FOR UPDATE;
+
 -- name: UpdateJobQA :exec
 UPDATE survey_jobs SET
     qa_score = $2,
@@ -95,3 +160,155 @@ UPDATE survey_jobs SET
     qa_notes = $4,
     updated_at = NOW()
 WHERE id = $1;
+
+-- name: CreateQueuedJobOffer :one
+INSERT INTO job_offers (
+    job_id, agent_id, cascade_round, offer_rank, distance_km, match_score,
+    scoring_strategy, scoring_weights, status, sent_at, expires_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'queued', NULL, $9)
+RETURNING *;
+
+-- name: SendJobOffer :one
+UPDATE job_offers SET status = 'sent', sent_at = NOW(), expires_at = $2, payout_breakdown = $3
+WHERE id = $1 AND status = 'queued'
+RETURNING *;
+
+-- name: GetJobOfferByID :one
+SELECT * FROM job_offers WHERE id = $1;
+
+-- name: WithdrawOpenOffers :many
+UPDATE job_offers SET status = 'withdrawn', responded_at = NOW()
+WHERE job_id = $1 AND status IN ('queued', 'sent')
+RETURNING *;
+
+-- name: UpdateJobCascade :exec
+UPDATE survey_jobs SET
+    cascade_round = $2,
+    total_offers_sent = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: AcceptJobOffer :one
+UPDATE job_offers SET status = 'accepted', responded_at = NOW()
+WHERE id = $1 AND status = 'sent' AND expires_at > NOW()
+RETURNING *;
+
+-- name: ClaimJob :one
+-- Payout amounts are those of the accepted offer; NULL keeps the job's own.
+UPDATE survey_jobs SET
+    status = 'assigned',
+    assigned_agent_id = sqlc.arg('assigned_agent_id'),
+    assigned_at = NOW(),
+    base_payout = COALESCE(sqlc.narg('base_payout'), base_payout),
+    distance_bonus = COALESCE(sqlc.narg('distance_bonus'), distance_bonus),
+    urgency_bonus = COALESCE(sqlc.narg('urgency_bonus'), urgency_bonus),
+    surge_bonus = COALESCE(sqlc.narg('surge_bonus'), surge_bonus),
+    total_payout = COALESCE(sqlc.narg('total_payout'), total_payout),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND status IN ('pending_assignment', 'offered')
+RETURNING *;
+
+-- name: GetDistrictOfferStats :one
+-- Offers sent for jobs in a district since a given time and how agents
+-- responded, as a measure of local supply and demand.
+SELECT count(*) AS sent,
+    count(*) FILTER (WHERE o.status = 'declined') AS declined,
+    count(*) FILTER (WHERE o.status = 'expired') AS expired
+FROM job_offers o
+JOIN survey_jobs j ON j.id = o.job_id
+JOIN parcels p ON p.id = j.parcel_id
+WHERE p.state_code = $1 AND p.district = $2
+    AND o.sent_at >= $3;
+
+-- name: GetAgentAcceptanceStats :many
+SELECT agent_id,
+    count(*) FILTER (WHERE status = 'accepted') AS accepted,
+    count(*) AS responded
+FROM job_offers
+WHERE agent_id = ANY($1::uuid[])
+    AND status IN ('accepted', 'declined', 'expired')
+    AND sent_at > NOW() - INTERVAL '90 days'
+GROUP BY agent_id;
+
+-- name: CountOpenOffersByAgents :many
+SELECT agent_id, count(*) AS open_offers
+FROM job_offers
+WHERE agent_id = ANY($1::uuid[]) AND status = 'sent'
+GROUP BY agent_id;
+
+-- name: ListOpsJobs :many
+-- Jobs needing ops attention. view is one of:
+--   unassigned: the cascade gave up
+--   overdue:    past deadline and not yet surveyed
+--   stuck:      open but untouched since stuck_before
+SELECT j.*, p.state_code, p.district, count(*) OVER() AS total_count
+FROM survey_jobs j
+JOIN parcels p ON p.id = j.parcel_id
+WHERE (
+        (sqlc.arg('view')::text = 'unassigned' AND j.status = 'unassigned')
+        OR (sqlc.arg('view')::text = 'overdue' AND j.deadline < NOW()
+            AND j.status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'agent_on_site', 'in_progress'))
+        OR (sqlc.arg('view')::text = 'stuck' AND j.updated_at < sqlc.arg('stuck_before')::timestamptz
+            AND j.status IN ('pending_assignment', 'offered', 'assigned', 'agent_en_route', 'agent_on_site', 'in_progress', 'failed_qa'))
+    )
+    AND (sqlc.narg('state_code')::text IS NULL OR p.state_code = sqlc.narg('state_code'))
+    AND (sqlc.narg('district')::text IS NULL OR p.district = sqlc.narg('district'))
+    AND (sqlc.narg('survey_type')::text IS NULL OR j.survey_type = sqlc.narg('survey_type'))
+    AND (sqlc.narg('priority')::text IS NULL OR j.priority = sqlc.narg('priority'))
+    AND (sqlc.narg('agent_id')::uuid IS NULL OR j.assigned_agent_id = sqlc.narg('agent_id'))
+ORDER BY j.deadline ASC
+LIMIT sqlc.arg('lim') OFFSET sqlc.arg('off');
+
+-- name: AssignJobManually :one
+UPDATE survey_jobs SET
+    status = 'assigned',
+    assigned_agent_id = $2,
+    assigned_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'failed_qa')
+RETURNING *;
+
+-- name: RedispatchJob :one
+-- Puts a job back into dispatch with a new radius and round budget. Rounds
+-- continue from the job's latest round so earlier offers are kept.
+UPDATE survey_jobs SET
+    status = 'pending_assignment',
+    assigned_agent_id = NULL,
+    assigned_at = NULL,
+    dispatch_radius_km = sqlc.arg('radius_km'),
+    dispatch_round_limit = GREATEST(
+        COALESCE(cascade_round, 0),
+        (SELECT COALESCE(MAX(o.cascade_round), 0) FROM job_offers o WHERE o.job_id = survey_jobs.id)
+    ) + sqlc.arg('extra_rounds')::int,
+    updated_at = NOW()
+WHERE survey_jobs.id = sqlc.arg('id') AND survey_jobs.status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'failed_qa')
+RETURNING *;
+
+-- name: UpdateJobSchedule :one
+UPDATE survey_jobs SET
+    deadline = COALESCE(sqlc.narg('deadline'), deadline),
+    priority = COALESCE(sqlc.narg('priority'), priority),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND status NOT IN ('completed', 'cancelled')
+RETURNING *;
+
+-- name: CancelJob :one
+UPDATE survey_jobs SET status = 'cancelled', updated_at = NOW()
+WHERE id = $1 AND status NOT IN ('survey_submitted', 'completed', 'cancelled')
+RETURNING *;
+
+-- name: CreateJobAdminAction :one
+INSERT INTO job_admin_actions (job_id, actor_id, actor_name, action, reason, details)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListJobAdminActions :many
+SELECT * FROM job_admin_actions WHERE job_id = $1 ORDER BY created_at DESC;
+
+-- name: CreateJobStatusHistory :exec
+INSERT INTO job_status_history (job_id, from_status, to_status, actor_role, actor_id, reason)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListJobStatusHistory :many
+SELECT * FROM job_status_history WHERE job_id = $1 ORDER BY created_at, id;
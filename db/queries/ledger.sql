@@ -0,0 +1,92 @@
+-- name: GetLedgerAccountByCode :one
+SELECT * FROM ledger_accounts WHERE code = $1;
+
+-- name: GetAgentLedgerAccount :one
+SELECT * FROM ledger_accounts WHERE agent_id = $1;
+
+-- name: EnsureAgentLedgerAccount :one
+INSERT INTO ledger_accounts (code, type, agent_id)
+VALUES ('agent:' || sqlc.arg(agent_id)::text, 'liability', sqlc.arg(agent_id))
+ON CONFLICT (agent_id) DO UPDATE SET agent_id = EXCLUDED.agent_id
+RETURNING *;
+
+-- name: CreateLedgerTransaction :one
+INSERT INTO ledger_transactions (kind, reference_id, description, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (kind, reference_id) DO NOTHING
+RETURNING *;
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (transaction_id, account_id, direction, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: AddToWalletBalance :exec
+UPDATE agents SET
+    wallet_balance = COALESCE(wallet_balance, 0) + sqlc.arg(delta)::numeric,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(CASE direction WHEN 'credit' THEN amount ELSE -amount END), 0)::numeric AS balance
+FROM ledger_entries
+WHERE account_id = $1 AND created_at < sqlc.arg(before);
+
+-- name: CountLedgerEntries :one
+SELECT COUNT(*) FROM ledger_entries
+WHERE account_id = $1
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time);
+
+-- name: ListLedgerStatement :many
+-- balance_change is the running total of entries since from_time; add the
+-- opening balance to get the balance after each entry.
+SELECT e.id, e.transaction_id, t.kind, t.reference_id, t.description,
+    e.direction, e.amount, e.created_at,
+    (SUM(CASE e.direction WHEN 'credit' THEN e.amount ELSE -e.amount END)
+        OVER (ORDER BY e.id))::numeric AS balance_change
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account_id = sqlc.arg(account_id)
+  AND e.created_at >= sqlc.arg(from_time)
+  AND e.created_at < sqlc.arg(to_time)
+ORDER BY e.id DESC
+LIMIT sqlc.arg(lim) OFFSET sqlc.arg(off);
+
+-- name: ListWalletDrift :many
+SELECT a.id AS agent_id, a.full_name,
+    COALESCE(a.wallet_balance, 0)::numeric AS wallet_balance,
+    COALESCE(l.balance, 0)::numeric AS ledger_balance
+FROM agents a
+LEFT JOIN (
+    SELECT la.agent_id,
+        SUM(CASE e.direction WHEN 'credit' THEN e.amount ELSE -e.amount END) AS balance
+    FROM ledger_entries e
+    JOIN ledger_accounts la ON la.id = e.account_id
+    WHERE la.agent_id IS NOT NULL
+    GROUP BY la.agent_id
+) l ON l.agent_id = a.id
+WHERE COALESCE(a.wallet_balance, 0) <> COALESCE(l.balance, 0)
+ORDER BY ABS(COALESCE(a.wallet_balance, 0) - COALESCE(l.balance, 0)) DESC;
+
+-- name: ListUnbalancedLedgerTransactions :many
+SELECT t.id, t.kind, t.reference_id,
+    SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END)::numeric AS imbalance
+FROM ledger_transactions t
+LEFT JOIN ledger_entries e ON e.transaction_id = t.id
+GROUP BY t.id
+HAVING COALESCE(SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END), 0) <> 0
+    OR COUNT(e.id) = 0;
+
+-- name: ListSystemAccountBalances :many
+SELECT a.code, a.type,
+    COALESCE(SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END), 0)::numeric AS debit_balance
+FROM ledger_accounts a
+LEFT JOIN ledger_entries e ON e.account_id = a.id
+WHERE a.agent_id IS NULL
+GROUP BY a.id
+ORDER BY a.code;
+
+-- name: GetInTransitPayoutTotal :one
+SELECT COALESCE(SUM(net_amount), 0)::numeric AS total
+FROM agent_payouts
+WHERE status IN ('pending', 'processing') AND net_amount > 0;
@@ -9,6 +9,9 @@ RETURNING *;
 -- name: GetParcelByID :one
 SELECT * FROM parcels WHERE id = $1;
 
+-- name: GetParcelForUpdate :one
+SELECT * FROM parcels WHERE id = $1 FOR UPDATE;
+
 -- name: ListParcelsByUser :many
 SELECT * FROM parcels
 WHERE user_id = $1 AND status = 'active'
@@ -19,11 +22,29 @@ LIMIT $2 OFFSET $3;
 SELECT count(*) FROM parcels WHERE user_id = $1 AND status = 'active';
 
 -- name: FindParcelsNeedingSurvey :many
-SELECT p.* FROM parcels p
-LEFT JOIN survey_jobs sj ON sj.parcel_id = p.id AND sj.status NOT IN ('completed', 'cancelled')
-WHERE p.status = 'active' AND sj.id IS NULL
-ORDER BY p.monitoring_since ASC
-LIMIT $1;
+-- Active parcels with no open survey job, paged by ID, with their active
+-- subscription (if any), when they were last surveyed and how many jobs they
+-- have ever had.
+SELECT p.id, p.user_id,
+    s.id AS subscription_id, s.plan, s.visits_used_this_period,
+    lv.survey_submitted_at AS last_surveyed_at,
+    (SELECT count(*) FROM survey_jobs j WHERE j.parcel_id = p.id AND j.status <> 'cancelled') AS job_count
+FROM parcels p
+LEFT JOIN subscriptions s ON s.parcel_id = p.id AND s.status = 'active'
+LEFT JOIN LATERAL (
+    SELECT j.survey_submitted_at FROM survey_jobs j
+    WHERE j.parcel_id = p.id AND j.status = 'completed'
+    ORDER BY j.survey_submitted_at DESC NULLS LAST
+    LIMIT 1
+) lv ON true
+WHERE p.status = 'active'
+    AND p.id > sqlc.arg('after')
+    AND NOT EXISTS (
+        SELECT 1 FROM survey_jobs sj
+        WHERE sj.parcel_id = p.id AND sj.status NOT IN ('completed', 'cancelled')
+    )
+ORDER BY p.id
+LIMIT sqlc.arg('lim');
 
 -- name: UpdateParcelStatus :exec
 UPDATE parcels SET status = $2, updated_at = NOW() WHERE id = $1;
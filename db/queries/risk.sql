@@ -0,0 +1,41 @@
+-- name: CreateRiskScore :one
+INSERT INTO risk_scores (
+    parcel_id, job_id, overall_score, risk_level,
+    encroachment_score, boundary_score, environmental_score, neighborhood_score,
+    contributing_factors
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (job_id) WHERE job_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetLatestRiskScore :one
+SELECT * FROM risk_scores
+WHERE parcel_id = $1
+ORDER BY computed_at DESC
+LIMIT 1;
+
+-- name: ListRiskScores :many
+SELECT * FROM risk_scores
+WHERE parcel_id = $1
+ORDER BY computed_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountRiskScores :one
+SELECT count(*) FROM risk_scores WHERE parcel_id = $1;
+
+-- name: CountNearbyRiskIssues :one
+-- Counts the scored parcels within radius meters of a parcel and how many
+-- of them had encroachment findings in their latest score.
+WITH nearby AS (
+    SELECT DISTINCT ON (rs.parcel_id) rs.encroachment_score
+    FROM parcels p
+    JOIN parcels o ON o.id <> p.id
+        AND o.status = 'active'
+        AND ST_DWithin(o.centroid::geography, p.centroid::geography, sqlc.arg(radius)::float8)
+    JOIN risk_scores rs ON rs.parcel_id = o.id
+    WHERE p.id = sqlc.arg(parcel_id)
+    ORDER BY rs.parcel_id, rs.computed_at DESC
+)
+SELECT
+    count(*)::int AS scored,
+    (count(*) FILTER (WHERE encroachment_score > 0))::int AS with_issues
+FROM nearby;
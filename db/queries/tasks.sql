@@ -1,11 +1,12 @@
 -- name: EnqueueTask :one
-INSERT INTO task_queue (task_type, payload, priority, scheduled_at)
-VALUES ($1, $2, $3, COALESCE(sqlc.narg('scheduled_at'), NOW()))
+INSERT INTO task_queue (task_type, payload, priority, max_attempts, scheduled_at)
+VALUES ($1, $2, $3, $4, COALESCE(sqlc.narg('scheduled_at')::timestamptz, NOW()))
 RETURNING *;
 
 -- name: ClaimTask :one
 UPDATE task_queue
-SET status = 'processing', started_at = NOW(), attempts = attempts + 1
+SET status = 'processing', started_at = NOW(), attempts = attempts + 1,
+    lease_expires_at = sqlc.arg('lease_expires_at')
 WHERE id = (
     SELECT id FROM task_queue
     WHERE status = 'pending' AND scheduled_at <= NOW()
@@ -15,16 +16,92 @@ WHERE id = (
 )
 RETURNING *;
 
--- name: CompleteTask :exec
-UPDATE task_queue SET status = 'completed', completed_at = NOW() WHERE id = $1;
+-- name: ExtendTaskLease :exec
+-- Like CompleteTask and FailTask, guarded by attempts so a worker whose lease
+-- expired cannot touch a task that has since been requeued or claimed again.
+UPDATE task_queue SET lease_expires_at = $3
+WHERE id = $1 AND attempts = $2 AND status = 'processing';
 
--- name: FailTask :exec
+-- name: CompleteTask :execrows
+UPDATE task_queue SET status = 'completed', completed_at = NOW(), lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing';
+
+-- name: FailTask :one
+-- A task with attempts left goes back to pending, to be claimed again at
+-- retry_at; one that has used them all is dead.
+UPDATE task_queue SET
+    status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+    last_error = $3,
+    error_message = $3,
+    scheduled_at = CASE WHEN attempts >= max_attempts THEN scheduled_at ELSE sqlc.arg('retry_at') END,
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+    lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+RETURNING status;
+
+-- name: ReapExpiredTasks :many
+-- Requeues tasks whose worker lost its lease. The lost attempt counts towards
+-- max_attempts so a task that crashes its worker every time ends up dead.
 UPDATE task_queue SET
     status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
-    last_error = $2,
-    error_message = $2,
-    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END
-WHERE id = $1;
+    last_error = 'lease expired while processing',
+    error_message = 'lease expired while processing',
+    scheduled_at = NOW(),
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+    lease_expires_at = NULL
+WHERE status = 'processing' AND lease_expires_at < NOW()
+RETURNING id, task_type, status, attempts;
 
 -- name: CountPendingTasks :one
 SELECT count(*) FROM task_queue WHERE status = 'pending';
+
+-- name: NotifyTask :exec
+SELECT pg_notify(sqlc.arg('channel')::text, sqlc.arg('payload')::text);
+
+-- name: ListTasks :many
+SELECT * FROM task_queue
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('task_type')::text IS NULL OR task_type = sqlc.narg('task_type'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('lim') OFFSET sqlc.arg('off');
+
+-- name: CountTasks :one
+SELECT count(*) FROM task_queue
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('task_type')::text IS NULL OR task_type = sqlc.narg('task_type'));
+
+-- name: GetTask :one
+SELECT * FROM task_queue WHERE id = $1;
+
+-- name: RetryDeadTask :one
+-- Gives a dead task a fresh set of attempts. last_error is kept until the
+-- task next fails.
+UPDATE task_queue
+SET status = 'pending', attempts = 0, scheduled_at = NOW(), started_at = NULL, completed_at = NULL
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: CancelTask :one
+UPDATE task_queue SET status = 'cancelled', completed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: PurgeCompletedTasks :execrows
+DELETE FROM task_queue WHERE status = 'completed' AND completed_at < $1;
+
+-- name: GetTaskStats :many
+-- Per task type: the current backlog, and the tasks that finished since a
+-- given time.
+SELECT
+    task_type,
+    count(*) FILTER (WHERE status = 'pending')::bigint AS pending,
+    count(*) FILTER (WHERE status = 'pending' AND scheduled_at <= NOW())::bigint AS due,
+    count(*) FILTER (WHERE status = 'processing')::bigint AS processing,
+    count(*) FILTER (WHERE status = 'dead')::bigint AS dead,
+    COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(scheduled_at) FILTER (WHERE status = 'pending' AND scheduled_at <= NOW())), 0)::float8 AS oldest_due_seconds,
+    count(*) FILTER (WHERE status = 'completed' AND completed_at >= sqlc.arg('since'))::bigint AS completed_since,
+    count(*) FILTER (WHERE status = 'dead' AND completed_at >= sqlc.arg('since'))::bigint AS dead_since,
+    COALESCE(SUM(attempts) FILTER (WHERE status IN ('completed', 'dead') AND completed_at >= sqlc.arg('since')), 0)::bigint AS attempts_since
+FROM task_queue
+GROUP BY task_type
+ORDER BY task_type;
@@ -34,5 +34,10 @@ SELECT * FROM users
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
+-- name: ListActiveUsersByRoles :many
+SELECT * FROM users
+WHERE role = ANY($1::text[]) AND status = 'active'
+ORDER BY created_at;
+
 -- name: CountUsers :one
 SELECT count(*) FROM users;
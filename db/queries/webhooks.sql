@@ -0,0 +1,64 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, description)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions WHERE id = $1;
+
+-- name: GetUserWebhookSubscription :one
+SELECT * FROM webhook_subscriptions WHERE id = $1 AND user_id = $2;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListWebhookSubscriptionsForEvent :many
+-- Active subscriptions of a user to an event type.
+SELECT * FROM webhook_subscriptions
+WHERE user_id = $1 AND active AND sqlc.arg('event_type')::text = ANY(event_types);
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = COALESCE(sqlc.narg('url'), url),
+    event_types = COALESCE(sqlc.narg('event_types')::text[], event_types),
+    description = COALESCE(sqlc.narg('description'), description),
+    active = COALESCE(sqlc.narg('active'), active),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions WHERE id = $1 AND user_id = $2;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (subscription_id, event_type, event_version, payload, source_event_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (subscription_id, source_event_id) WHERE source_event_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = $1;
+
+-- name: RecordWebhookAttempt :one
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    response_status = $3,
+    response_body = $4,
+    last_error = $5,
+    duration_ms = $6,
+    last_attempt_at = NOW(),
+    delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+WHERE id = $1
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries WHERE subscription_id = $1;
@@ -7,15 +7,351 @@ package sqlc
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const activateSubscription = `-- name: ActivateSubscription :one
+UPDATE subscriptions SET
+    status = 'active',
+    current_period_start = $2,
+    current_period_end = $3,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+type ActivateSubscriptionParams struct {
+	ID                 uuid.UUID          `json:"id"`
+	CurrentPeriodStart pgtype.Timestamptz `json:"current_period_start"`
+	CurrentPeriodEnd   pgtype.Timestamptz `json:"current_period_end"`
+}
+
+func (q *Queries) ActivateSubscription(ctx context.Context, arg ActivateSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, activateSubscription, arg.ID, arg.CurrentPeriodStart, arg.CurrentPeriodEnd)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const attachJobsToPayout = `-- name: AttachJobsToPayout :many
+UPDATE survey_jobs SET payout_id = $1, payout_status = 'settled', updated_at = NOW()
+WHERE assigned_agent_id = $2
+    AND status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL
+    AND completed_at < $3
+RETURNING id, total_payout
+`
+
+type AttachJobsToPayoutParams struct {
+	PayoutID        pgtype.UUID        `json:"payout_id"`
+	AssignedAgentID pgtype.UUID        `json:"assigned_agent_id"`
+	CompletedAt     pgtype.Timestamptz `json:"completed_at"`
+}
+
+type AttachJobsToPayoutRow struct {
+	ID          uuid.UUID      `json:"id"`
+	TotalPayout pgtype.Numeric `json:"total_payout"`
+}
+
+// Links an agent's unsettled QA-passed jobs completed before the cutoff to a
+// payout and returns their payouts.
+func (q *Queries) AttachJobsToPayout(ctx context.Context, arg AttachJobsToPayoutParams) ([]AttachJobsToPayoutRow, error) {
+	rows, err := q.db.Query(ctx, attachJobsToPayout, arg.PayoutID, arg.AssignedAgentID, arg.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AttachJobsToPayoutRow{}
+	for rows.Next() {
+		var i AttachJobsToPayoutRow
+		if err := rows.Scan(&i.ID, &i.TotalPayout); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const cancelSubscription = `-- name: CancelSubscription :one
+UPDATE subscriptions SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'active', 'paused')
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+func (q *Queries) CancelSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, cancelSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const changeSubscriptionPlan = `-- name: ChangeSubscriptionPlan :one
+UPDATE subscriptions SET
+    plan = $2,
+    amount_per_cycle = $3,
+    credit_balance = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+type ChangeSubscriptionPlanParams struct {
+	ID             uuid.UUID      `json:"id"`
+	Plan           string         `json:"plan"`
+	AmountPerCycle pgtype.Numeric `json:"amount_per_cycle"`
+	CreditBalance  pgtype.Numeric `json:"credit_balance"`
+}
+
+func (q *Queries) ChangeSubscriptionPlan(ctx context.Context, arg ChangeSubscriptionPlanParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, changeSubscriptionPlan,
+		arg.ID,
+		arg.Plan,
+		arg.AmountPerCycle,
+		arg.CreditBalance,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const claimDueRenewals = `-- name: ClaimDueRenewals :many
+UPDATE subscriptions SET renewal_enqueued_at = NOW()
+WHERE id IN (
+    SELECT d.id FROM subscriptions d
+    WHERE d.status = 'active'
+      AND d.current_period_end <= $1
+      AND (d.renewal_enqueued_at IS NULL OR d.renewal_enqueued_at < $1 - INTERVAL '1 hour')
+    ORDER BY d.current_period_end
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, current_period_end
+`
+
+type ClaimDueRenewalsParams struct {
+	Now pgtype.Timestamptz `json:"now"`
+	Lim int32              `json:"lim"`
+}
+
+type ClaimDueRenewalsRow struct {
+	ID               uuid.UUID          `json:"id"`
+	CurrentPeriodEnd pgtype.Timestamptz `json:"current_period_end"`
+}
+
+// Claims active subscriptions whose period has ended. A claim that was not
+// renewed within an hour, e.g. because its task failed, can be claimed again.
+func (q *Queries) ClaimDueRenewals(ctx context.Context, arg ClaimDueRenewalsParams) ([]ClaimDueRenewalsRow, error) {
+	rows, err := q.db.Query(ctx, claimDueRenewals, arg.Now, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimDueRenewalsRow{}
+	for rows.Next() {
+		var i ClaimDueRenewalsRow
+		if err := rows.Scan(&i.ID, &i.CurrentPeriodEnd); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimPayoutPeriod = `-- name: ClaimPayoutPeriod :one
+INSERT INTO payout_periods (period_start, period_end)
+VALUES ($1, $2)
+ON CONFLICT (period_start) DO NOTHING
+RETURNING period_start, period_end, created_at, settled_at
+`
+
+type ClaimPayoutPeriodParams struct {
+	PeriodStart pgtype.Date `json:"period_start"`
+	PeriodEnd   pgtype.Date `json:"period_end"`
+}
+
+// Returns no row if the period was already claimed.
+func (q *Queries) ClaimPayoutPeriod(ctx context.Context, arg ClaimPayoutPeriodParams) (PayoutPeriod, error) {
+	row := q.db.QueryRow(ctx, claimPayoutPeriod, arg.PeriodStart, arg.PeriodEnd)
+	var i PayoutPeriod
+	err := row.Scan(
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.CreatedAt,
+		&i.SettledAt,
+	)
+	return i, err
+}
+
+const completePaymentEvent = `-- name: CompletePaymentEvent :exec
+UPDATE payment_events SET
+    status = $2,
+    attempts = attempts + 1,
+    last_error = NULL,
+    processed_at = NOW()
+WHERE id = $1
+`
+
+type CompletePaymentEventParams struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func (q *Queries) CompletePaymentEvent(ctx context.Context, arg CompletePaymentEventParams) error {
+	_, err := q.db.Exec(ctx, completePaymentEvent, arg.ID, arg.Status)
+	return err
+}
+
+const consumeOnDemandVisit = `-- name: ConsumeOnDemandVisit :one
+UPDATE subscriptions SET
+    on_demand_visits_remaining = on_demand_visits_remaining - 1,
+    updated_at = NOW()
+WHERE id = $1 AND on_demand_visits_remaining > 0
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+func (q *Queries) ConsumeOnDemandVisit(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, consumeOnDemandVisit, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const countInvoicesByUser = `-- name: CountInvoicesByUser :one
+SELECT count(*) FROM invoices WHERE user_id = $1
+`
+
+func (q *Queries) CountInvoicesByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countInvoicesByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPaymentEvents = `-- name: CountPaymentEvents :one
+SELECT count(*) FROM payment_events
+WHERE $1::text IS NULL OR status = $1
+`
+
+func (q *Queries) CountPaymentEvents(ctx context.Context, status *string) (int64, error) {
+	row := q.db.QueryRow(ctx, countPaymentEvents, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPayoutsByAgent = `-- name: CountPayoutsByAgent :one
+SELECT count(*) FROM agent_payouts WHERE agent_id = $1
+`
+
+func (q *Queries) CountPayoutsByAgent(ctx context.Context, agentID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countPayoutsByAgent, agentID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTransactionsByUser = `-- name: CountTransactionsByUser :one
+SELECT count(*) FROM transactions WHERE user_id = $1
+`
+
+func (q *Queries) CountTransactionsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransactionsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAgentPayout = `-- name: CreateAgentPayout :one
 INSERT INTO agent_payouts (agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at
+ON CONFLICT (agent_id, period_start) DO NOTHING
+RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at
 `
 
 type CreateAgentPayoutParams struct {
@@ -29,6 +365,7 @@ type CreateAgentPayoutParams struct {
 	NetAmount          pgtype.Numeric `json:"net_amount"`
 }
 
+// Returns no row if the agent already has a payout for the period.
 func (q *Queries) CreateAgentPayout(ctx context.Context, arg CreateAgentPayoutParams) (AgentPayout, error) {
 	row := q.db.QueryRow(ctx, createAgentPayout,
 		arg.AgentID,
@@ -55,23 +392,122 @@ func (q *Queries) CreateAgentPayout(ctx context.Context, arg CreateAgentPayoutPa
 		&i.RazorpayPayoutID,
 		&i.FailureReason,
 		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createInvoice = `-- name: CreateInvoice :one
+INSERT INTO invoices (
+    invoice_number, financial_year, sequence, transaction_id, user_id,
+    place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount,
+    s3_key, issued_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, invoice_number, financial_year, sequence, transaction_id, user_id, place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount, s3_key, issued_at
+`
+
+type CreateInvoiceParams struct {
+	InvoiceNumber string         `json:"invoice_number"`
+	FinancialYear string         `json:"financial_year"`
+	Sequence      int32          `json:"sequence"`
+	TransactionID uuid.UUID      `json:"transaction_id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	PlaceOfSupply string         `json:"place_of_supply"`
+	Interstate    bool           `json:"interstate"`
+	TaxableAmount pgtype.Numeric `json:"taxable_amount"`
+	Cgst          pgtype.Numeric `json:"cgst"`
+	Sgst          pgtype.Numeric `json:"sgst"`
+	Igst          pgtype.Numeric `json:"igst"`
+	TotalAmount   pgtype.Numeric `json:"total_amount"`
+	S3Key         string         `json:"s3_key"`
+	IssuedAt      time.Time      `json:"issued_at"`
+}
+
+func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (Invoice, error) {
+	row := q.db.QueryRow(ctx, createInvoice,
+		arg.InvoiceNumber,
+		arg.FinancialYear,
+		arg.Sequence,
+		arg.TransactionID,
+		arg.UserID,
+		arg.PlaceOfSupply,
+		arg.Interstate,
+		arg.TaxableAmount,
+		arg.Cgst,
+		arg.Sgst,
+		arg.Igst,
+		arg.TotalAmount,
+		arg.S3Key,
+		arg.IssuedAt,
+	)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceNumber,
+		&i.FinancialYear,
+		&i.Sequence,
+		&i.TransactionID,
+		&i.UserID,
+		&i.PlaceOfSupply,
+		&i.Interstate,
+		&i.TaxableAmount,
+		&i.Cgst,
+		&i.Sgst,
+		&i.Igst,
+		&i.TotalAmount,
+		&i.S3Key,
+		&i.IssuedAt,
+	)
+	return i, err
+}
+
+const createPaymentEvent = `-- name: CreatePaymentEvent :one
+INSERT INTO payment_events (event_id, event_type, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at
+`
+
+type CreatePaymentEventParams struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Returns no row if the event was already received.
+func (q *Queries) CreatePaymentEvent(ctx context.Context, arg CreatePaymentEventParams) (PaymentEvent, error) {
+	row := q.db.QueryRow(ctx, createPaymentEvent, arg.EventID, arg.EventType, arg.Payload)
+	var i PaymentEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
 
 const createSubscription = `-- name: CreateSubscription :one
-INSERT INTO subscriptions (user_id, parcel_id, plan, amount_per_cycle, current_period_start, current_period_end)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at
+INSERT INTO subscriptions (user_id, parcel_id, plan, status, amount_per_cycle, current_period_start, current_period_end, on_demand_visits_remaining)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
 `
 
 type CreateSubscriptionParams struct {
-	UserID             uuid.UUID          `json:"user_id"`
-	ParcelID           uuid.UUID          `json:"parcel_id"`
-	Plan               string             `json:"plan"`
-	AmountPerCycle     pgtype.Numeric     `json:"amount_per_cycle"`
-	CurrentPeriodStart pgtype.Timestamptz `json:"current_period_start"`
-	CurrentPeriodEnd   pgtype.Timestamptz `json:"current_period_end"`
+	UserID                  uuid.UUID          `json:"user_id"`
+	ParcelID                uuid.UUID          `json:"parcel_id"`
+	Plan                    string             `json:"plan"`
+	Status                  *string            `json:"status"`
+	AmountPerCycle          pgtype.Numeric     `json:"amount_per_cycle"`
+	CurrentPeriodStart      pgtype.Timestamptz `json:"current_period_start"`
+	CurrentPeriodEnd        pgtype.Timestamptz `json:"current_period_end"`
+	OnDemandVisitsRemaining *int32             `json:"on_demand_visits_remaining"`
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
@@ -79,9 +515,11 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.UserID,
 		arg.ParcelID,
 		arg.Plan,
+		arg.Status,
 		arg.AmountPerCycle,
 		arg.CurrentPeriodStart,
 		arg.CurrentPeriodEnd,
+		arg.OnDemandVisitsRemaining,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -98,6 +536,11 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.OnDemandVisitsRemaining,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
 	)
 	return i, err
 }
@@ -105,7 +548,7 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transactions (user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at
+RETURNING id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id
 `
 
 type CreateTransactionParams struct {
@@ -139,12 +582,76 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.RazorpayPaymentID,
 		&i.RazorpayOrderID,
 		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const createVisitCharge = `-- name: CreateVisitCharge :one
+INSERT INTO transactions (user_id, subscription_id, job_id, type, amount, status)
+VALUES ($1, $2, $3, 'on_demand_visit', $4, 'pending')
+RETURNING id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id
+`
+
+type CreateVisitChargeParams struct {
+	UserID         uuid.UUID      `json:"user_id"`
+	SubscriptionID pgtype.UUID    `json:"subscription_id"`
+	JobID          pgtype.UUID    `json:"job_id"`
+	Amount         pgtype.Numeric `json:"amount"`
+}
+
+func (q *Queries) CreateVisitCharge(ctx context.Context, arg CreateVisitChargeParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, createVisitCharge,
+		arg.UserID,
+		arg.SubscriptionID,
+		arg.JobID,
+		arg.Amount,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
 	)
 	return i, err
 }
 
+const deletePayout = `-- name: DeletePayout :exec
+DELETE FROM agent_payouts WHERE id = $1
+`
+
+func (q *Queries) DeletePayout(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deletePayout, id)
+	return err
+}
+
+const failPaymentEvent = `-- name: FailPaymentEvent :exec
+UPDATE payment_events SET
+    status = 'failed',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1
+`
+
+type FailPaymentEventParams struct {
+	ID        uuid.UUID `json:"id"`
+	LastError *string   `json:"last_error"`
+}
+
+func (q *Queries) FailPaymentEvent(ctx context.Context, arg FailPaymentEventParams) error {
+	_, err := q.db.Exec(ctx, failPaymentEvent, arg.ID, arg.LastError)
+	return err
+}
+
 const getActiveSubscription = `-- name: GetActiveSubscription :one
-SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at FROM subscriptions WHERE parcel_id = $1 AND status = 'active' LIMIT 1
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions WHERE parcel_id = $1 AND status = 'active' LIMIT 1
 `
 
 func (q *Queries) GetActiveSubscription(ctx context.Context, parcelID uuid.UUID) (Subscription, error) {
@@ -164,16 +671,21 @@ func (q *Queries) GetActiveSubscription(ctx context.Context, parcelID uuid.UUID)
 		&i.OnDemandVisitsRemaining,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
 	)
 	return i, err
 }
 
-const getSubscriptionByID = `-- name: GetSubscriptionByID :one
-SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at FROM subscriptions WHERE id = $1
+const getActiveSubscriptionForUpdate = `-- name: GetActiveSubscriptionForUpdate :one
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions WHERE parcel_id = $1 AND status = 'active' LIMIT 1 FOR UPDATE
 `
 
-func (q *Queries) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (Subscription, error) {
-	row := q.db.QueryRow(ctx, getSubscriptionByID, id)
+func (q *Queries) GetActiveSubscriptionForUpdate(ctx context.Context, parcelID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getActiveSubscriptionForUpdate, parcelID)
 	var i Subscription
 	err := row.Scan(
 		&i.ID,
@@ -189,33 +701,573 @@ func (q *Queries) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (Subscr
 		&i.OnDemandVisitsRemaining,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
 	)
 	return i, err
 }
 
-const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at FROM transactions WHERE id = $1
+const getAgentPayoutTotals = `-- name: GetAgentPayoutTotals :one
+SELECT
+    COALESCE(sum(net_amount) FILTER (WHERE status = 'paid'), 0)::numeric AS paid,
+    COALESCE(sum(net_amount) FILTER (WHERE status IN ('pending', 'processing')), 0)::numeric AS in_transit,
+    COALESCE(sum(tds_deducted) FILTER (WHERE status <> 'failed'), 0)::numeric AS tds_deducted,
+    count(*) FILTER (WHERE status = 'failed') AS failed_payouts
+FROM agent_payouts
+WHERE agent_id = $1
 `
 
-func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transaction, error) {
-	row := q.db.QueryRow(ctx, getTransactionByID, id)
-	var i Transaction
+type GetAgentPayoutTotalsRow struct {
+	Paid          pgtype.Numeric `json:"paid"`
+	InTransit     pgtype.Numeric `json:"in_transit"`
+	TdsDeducted   pgtype.Numeric `json:"tds_deducted"`
+	FailedPayouts int64          `json:"failed_payouts"`
+}
+
+func (q *Queries) GetAgentPayoutTotals(ctx context.Context, agentID uuid.UUID) (GetAgentPayoutTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getAgentPayoutTotals, agentID)
+	var i GetAgentPayoutTotalsRow
 	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.SubscriptionID,
-		&i.Type,
-		&i.Amount,
-		&i.Status,
-		&i.RazorpayPaymentID,
-		&i.RazorpayOrderID,
-		&i.CreatedAt,
+		&i.Paid,
+		&i.InTransit,
+		&i.TdsDeducted,
+		&i.FailedPayouts,
 	)
 	return i, err
 }
 
-const listPayoutsByAgent = `-- name: ListPayoutsByAgent :many
-SELECT id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at FROM agent_payouts WHERE agent_id = $1 ORDER BY period_end DESC LIMIT $2 OFFSET $3
+const getAgentTaxableEarnings = `-- name: GetAgentTaxableEarnings :one
+SELECT COALESCE(sum(gross_amount - platform_commission), 0)::numeric AS taxable
+FROM agent_payouts
+WHERE agent_id = $1 AND period_start >= $2 AND status <> 'failed'
+`
+
+type GetAgentTaxableEarningsParams struct {
+	AgentID     uuid.UUID   `json:"agent_id"`
+	PeriodStart pgtype.Date `json:"period_start"`
+}
+
+// Earnings after commission already settled for an agent since a date, for
+// the TDS threshold.
+func (q *Queries) GetAgentTaxableEarnings(ctx context.Context, arg GetAgentTaxableEarningsParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getAgentTaxableEarnings, arg.AgentID, arg.PeriodStart)
+	var taxable pgtype.Numeric
+	err := row.Scan(&taxable)
+	return taxable, err
+}
+
+const getAgentUnsettledEarnings = `-- name: GetAgentUnsettledEarnings :one
+SELECT count(*) AS jobs,
+    COALESCE(sum(total_payout), 0)::numeric AS amount
+FROM survey_jobs
+WHERE assigned_agent_id = $1
+    AND status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL
+`
+
+type GetAgentUnsettledEarningsRow struct {
+	Jobs   int64          `json:"jobs"`
+	Amount pgtype.Numeric `json:"amount"`
+}
+
+func (q *Queries) GetAgentUnsettledEarnings(ctx context.Context, assignedAgentID pgtype.UUID) (GetAgentUnsettledEarningsRow, error) {
+	row := q.db.QueryRow(ctx, getAgentUnsettledEarnings, assignedAgentID)
+	var i GetAgentUnsettledEarningsRow
+	err := row.Scan(&i.Jobs, &i.Amount)
+	return i, err
+}
+
+const getInvoiceByID = `-- name: GetInvoiceByID :one
+SELECT id, invoice_number, financial_year, sequence, transaction_id, user_id, place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount, s3_key, issued_at FROM invoices WHERE id = $1
+`
+
+func (q *Queries) GetInvoiceByID(ctx context.Context, id uuid.UUID) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getInvoiceByID, id)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceNumber,
+		&i.FinancialYear,
+		&i.Sequence,
+		&i.TransactionID,
+		&i.UserID,
+		&i.PlaceOfSupply,
+		&i.Interstate,
+		&i.TaxableAmount,
+		&i.Cgst,
+		&i.Sgst,
+		&i.Igst,
+		&i.TotalAmount,
+		&i.S3Key,
+		&i.IssuedAt,
+	)
+	return i, err
+}
+
+const getInvoiceByTransaction = `-- name: GetInvoiceByTransaction :one
+SELECT id, invoice_number, financial_year, sequence, transaction_id, user_id, place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount, s3_key, issued_at FROM invoices WHERE transaction_id = $1
+`
+
+func (q *Queries) GetInvoiceByTransaction(ctx context.Context, transactionID uuid.UUID) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getInvoiceByTransaction, transactionID)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceNumber,
+		&i.FinancialYear,
+		&i.Sequence,
+		&i.TransactionID,
+		&i.UserID,
+		&i.PlaceOfSupply,
+		&i.Interstate,
+		&i.TaxableAmount,
+		&i.Cgst,
+		&i.Sgst,
+		&i.Igst,
+		&i.TotalAmount,
+		&i.S3Key,
+		&i.IssuedAt,
+	)
+	return i, err
+}
+
+const getLiveSubscription = `-- name: GetLiveSubscription :one
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions
+WHERE parcel_id = $1 AND status IN ('pending', 'active', 'paused')
+LIMIT 1
+`
+
+func (q *Queries) GetLiveSubscription(ctx context.Context, parcelID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getLiveSubscription, parcelID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const getLiveSubscriptionForUpdate = `-- name: GetLiveSubscriptionForUpdate :one
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions
+WHERE parcel_id = $1 AND status IN ('pending', 'active', 'paused')
+LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetLiveSubscriptionForUpdate(ctx context.Context, parcelID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getLiveSubscriptionForUpdate, parcelID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const getPaymentEvent = `-- name: GetPaymentEvent :one
+SELECT id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at FROM payment_events WHERE id = $1
+`
+
+func (q *Queries) GetPaymentEvent(ctx context.Context, id uuid.UUID) (PaymentEvent, error) {
+	row := q.db.QueryRow(ctx, getPaymentEvent, id)
+	var i PaymentEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getPaymentEventByEventID = `-- name: GetPaymentEventByEventID :one
+SELECT id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at FROM payment_events WHERE event_id = $1
+`
+
+func (q *Queries) GetPaymentEventByEventID(ctx context.Context, eventID string) (PaymentEvent, error) {
+	row := q.db.QueryRow(ctx, getPaymentEventByEventID, eventID)
+	var i PaymentEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getPaymentEventForUpdate = `-- name: GetPaymentEventForUpdate :one
+SELECT id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at FROM payment_events WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetPaymentEventForUpdate(ctx context.Context, id uuid.UUID) (PaymentEvent, error) {
+	row := q.db.QueryRow(ctx, getPaymentEventForUpdate, id)
+	var i PaymentEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getPayoutByProviderID = `-- name: GetPayoutByProviderID :one
+SELECT id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at FROM agent_payouts WHERE razorpay_payout_id = $1
+`
+
+func (q *Queries) GetPayoutByProviderID(ctx context.Context, razorpayPayoutID *string) (AgentPayout, error) {
+	row := q.db.QueryRow(ctx, getPayoutByProviderID, razorpayPayoutID)
+	var i AgentPayout
+	err := row.Scan(
+		&i.ID,
+		&i.AgentID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TotalJobs,
+		&i.GrossAmount,
+		&i.PlatformCommission,
+		&i.TdsDeducted,
+		&i.NetAmount,
+		&i.Status,
+		&i.RazorpayPayoutID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSubscriptionByID = `-- name: GetSubscriptionByID :one
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions WHERE id = $1
+`
+
+func (q *Queries) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionByID, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const getSubscriptionForUpdate = `-- name: GetSubscriptionForUpdate :one
+SELECT id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at FROM subscriptions WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetSubscriptionForUpdate(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionForUpdate, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const getTransactionByID = `-- name: GetTransactionByID :one
+SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id FROM transactions WHERE id = $1
+`
+
+func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByID, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const getTransactionByJob = `-- name: GetTransactionByJob :one
+SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id FROM transactions WHERE job_id = $1 ORDER BY created_at DESC LIMIT 1
+`
+
+func (q *Queries) GetTransactionByJob(ctx context.Context, jobID pgtype.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByJob, jobID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const getTransactionByOrderForUpdate = `-- name: GetTransactionByOrderForUpdate :one
+SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id FROM transactions WHERE razorpay_order_id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetTransactionByOrderForUpdate(ctx context.Context, razorpayOrderID *string) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByOrderForUpdate, razorpayOrderID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const getTransactionForUpdate = `-- name: GetTransactionForUpdate :one
+SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id FROM transactions WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetTransactionForUpdate(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionForUpdate, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const incrementSubscriptionVisits = `-- name: IncrementSubscriptionVisits :exec
+UPDATE subscriptions SET
+    visits_used_this_period = COALESCE(visits_used_this_period, 0) + 1,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) IncrementSubscriptionVisits(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, incrementSubscriptionVisits, id)
+	return err
+}
+
+const listAgentsWithUnsettledJobs = `-- name: ListAgentsWithUnsettledJobs :many
+SELECT DISTINCT assigned_agent_id::uuid AS agent_id FROM survey_jobs
+WHERE status = 'completed' AND qa_status = 'passed'
+    AND payout_id IS NULL AND assigned_agent_id IS NOT NULL
+    AND completed_at < $1
+`
+
+func (q *Queries) ListAgentsWithUnsettledJobs(ctx context.Context, completedAt pgtype.Timestamptz) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listAgentsWithUnsettledJobs, completedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var agent_id uuid.UUID
+		if err := rows.Scan(&agent_id); err != nil {
+			return nil, err
+		}
+		items = append(items, agent_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvoicesByUser = `-- name: ListInvoicesByUser :many
+SELECT id, invoice_number, financial_year, sequence, transaction_id, user_id, place_of_supply, interstate, taxable_amount, cgst, sgst, igst, total_amount, s3_key, issued_at FROM invoices WHERE user_id = $1
+ORDER BY issued_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListInvoicesByUserParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+func (q *Queries) ListInvoicesByUser(ctx context.Context, arg ListInvoicesByUserParams) ([]Invoice, error) {
+	rows, err := q.db.Query(ctx, listInvoicesByUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Invoice{}
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceNumber,
+			&i.FinancialYear,
+			&i.Sequence,
+			&i.TransactionID,
+			&i.UserID,
+			&i.PlaceOfSupply,
+			&i.Interstate,
+			&i.TaxableAmount,
+			&i.Cgst,
+			&i.Sgst,
+			&i.Igst,
+			&i.TotalAmount,
+			&i.S3Key,
+			&i.IssuedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentEvents = `-- name: ListPaymentEvents :many
+SELECT id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at FROM payment_events
+WHERE $1::text IS NULL OR status = $1
+ORDER BY received_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListPaymentEventsParams struct {
+	Status *string `json:"status"`
+	Off    int32   `json:"off"`
+	Lim    int32   `json:"lim"`
+}
+
+func (q *Queries) ListPaymentEvents(ctx context.Context, arg ListPaymentEventsParams) ([]PaymentEvent, error) {
+	rows, err := q.db.Query(ctx, listPaymentEvents, arg.Status, arg.Off, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentEvent{}
+	for rows.Next() {
+		var i PaymentEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.ReceivedAt,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPayoutsByAgent = `-- name: ListPayoutsByAgent :many
+SELECT id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at FROM agent_payouts WHERE agent_id = $1 ORDER BY period_end DESC LIMIT $2 OFFSET $3
 `
 
 type ListPayoutsByAgentParams struct {
@@ -224,78 +1276,609 @@ type ListPayoutsByAgentParams struct {
 	Offset  int32     `json:"offset"`
 }
 
-func (q *Queries) ListPayoutsByAgent(ctx context.Context, arg ListPayoutsByAgentParams) ([]AgentPayout, error) {
-	rows, err := q.db.Query(ctx, listPayoutsByAgent, arg.AgentID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AgentPayout{}
-	for rows.Next() {
-		var i AgentPayout
-		if err := rows.Scan(
-			&i.ID,
-			&i.AgentID,
-			&i.PeriodStart,
-			&i.PeriodEnd,
-			&i.TotalJobs,
-			&i.GrossAmount,
-			&i.PlatformCommission,
-			&i.TdsDeducted,
-			&i.NetAmount,
-			&i.Status,
-			&i.RazorpayPayoutID,
-			&i.FailureReason,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) ListPayoutsByAgent(ctx context.Context, arg ListPayoutsByAgentParams) ([]AgentPayout, error) {
+	rows, err := q.db.Query(ctx, listPayoutsByAgent, arg.AgentID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AgentPayout{}
+	for rows.Next() {
+		var i AgentPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.AgentID,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.TotalJobs,
+			&i.GrossAmount,
+			&i.PlatformCommission,
+			&i.TdsDeducted,
+			&i.NetAmount,
+			&i.Status,
+			&i.RazorpayPayoutID,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.PaidAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsByUser = `-- name: ListTransactionsByUser :many
+SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id FROM transactions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+`
+
+type ListTransactionsByUserParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+func (q *Queries) ListTransactionsByUser(ctx context.Context, arg ListTransactionsByUserParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listTransactionsByUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.SubscriptionID,
+			&i.Type,
+			&i.Amount,
+			&i.Status,
+			&i.RazorpayPaymentID,
+			&i.RazorpayOrderID,
+			&i.CreatedAt,
+			&i.JobID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUninvoicedTransactions = `-- name: ListUninvoicedTransactions :many
+SELECT t.id, t.user_id, t.subscription_id, t.type, t.amount, t.status, t.razorpay_payment_id, t.razorpay_order_id, t.created_at, t.job_id FROM transactions t
+LEFT JOIN invoices i ON i.transaction_id = t.id
+WHERE t.status = 'paid' AND i.id IS NULL
+ORDER BY t.created_at
+LIMIT $1
+`
+
+func (q *Queries) ListUninvoicedTransactions(ctx context.Context, limit int32) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listUninvoicedTransactions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.SubscriptionID,
+			&i.Type,
+			&i.Amount,
+			&i.Status,
+			&i.RazorpayPaymentID,
+			&i.RazorpayOrderID,
+			&i.CreatedAt,
+			&i.JobID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnsentPayouts = `-- name: ListUnsentPayouts :many
+SELECT id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at FROM agent_payouts
+WHERE status = 'pending' AND razorpay_payout_id IS NULL
+ORDER BY created_at
+LIMIT $1
+`
+
+func (q *Queries) ListUnsentPayouts(ctx context.Context, limit int32) ([]AgentPayout, error) {
+	rows, err := q.db.Query(ctx, listUnsentPayouts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AgentPayout{}
+	for rows.Next() {
+		var i AgentPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.AgentID,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.TotalJobs,
+			&i.GrossAmount,
+			&i.PlatformCommission,
+			&i.TdsDeducted,
+			&i.NetAmount,
+			&i.Status,
+			&i.RazorpayPayoutID,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.PaidAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPayoutFailed = `-- name: MarkPayoutFailed :one
+UPDATE agent_payouts SET
+    status = 'failed',
+    failure_reason = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at
+`
+
+type MarkPayoutFailedParams struct {
+	ID            uuid.UUID `json:"id"`
+	FailureReason *string   `json:"failure_reason"`
+}
+
+func (q *Queries) MarkPayoutFailed(ctx context.Context, arg MarkPayoutFailedParams) (AgentPayout, error) {
+	row := q.db.QueryRow(ctx, markPayoutFailed, arg.ID, arg.FailureReason)
+	var i AgentPayout
+	err := row.Scan(
+		&i.ID,
+		&i.AgentID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TotalJobs,
+		&i.GrossAmount,
+		&i.PlatformCommission,
+		&i.TdsDeducted,
+		&i.NetAmount,
+		&i.Status,
+		&i.RazorpayPayoutID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const markPayoutPaid = `-- name: MarkPayoutPaid :one
+UPDATE agent_payouts SET
+    status = 'paid',
+    paid_at = NOW(),
+    failure_reason = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at
+`
+
+func (q *Queries) MarkPayoutPaid(ctx context.Context, id uuid.UUID) (AgentPayout, error) {
+	row := q.db.QueryRow(ctx, markPayoutPaid, id)
+	var i AgentPayout
+	err := row.Scan(
+		&i.ID,
+		&i.AgentID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TotalJobs,
+		&i.GrossAmount,
+		&i.PlatformCommission,
+		&i.TdsDeducted,
+		&i.NetAmount,
+		&i.Status,
+		&i.RazorpayPayoutID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const markPayoutPeriodSettled = `-- name: MarkPayoutPeriodSettled :exec
+UPDATE payout_periods SET settled_at = NOW() WHERE period_start = $1
+`
+
+func (q *Queries) MarkPayoutPeriodSettled(ctx context.Context, periodStart pgtype.Date) error {
+	_, err := q.db.Exec(ctx, markPayoutPeriodSettled, periodStart)
+	return err
+}
+
+const markPayoutSent = `-- name: MarkPayoutSent :one
+UPDATE agent_payouts SET
+    status = $2,
+    razorpay_payout_id = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at
+`
+
+type MarkPayoutSentParams struct {
+	ID               uuid.UUID `json:"id"`
+	Status           *string   `json:"status"`
+	RazorpayPayoutID *string   `json:"razorpay_payout_id"`
+}
+
+func (q *Queries) MarkPayoutSent(ctx context.Context, arg MarkPayoutSentParams) (AgentPayout, error) {
+	row := q.db.QueryRow(ctx, markPayoutSent, arg.ID, arg.Status, arg.RazorpayPayoutID)
+	var i AgentPayout
+	err := row.Scan(
+		&i.ID,
+		&i.AgentID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TotalJobs,
+		&i.GrossAmount,
+		&i.PlatformCommission,
+		&i.TdsDeducted,
+		&i.NetAmount,
+		&i.Status,
+		&i.RazorpayPayoutID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const markTransactionFailed = `-- name: MarkTransactionFailed :one
+UPDATE transactions SET status = 'failed'
+WHERE id = $1 AND status = 'pending'
+RETURNING id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id
+`
+
+func (q *Queries) MarkTransactionFailed(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, markTransactionFailed, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
 }
 
-const listTransactionsByUser = `-- name: ListTransactionsByUser :many
-SELECT id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at FROM transactions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+const markTransactionPaid = `-- name: MarkTransactionPaid :one
+UPDATE transactions SET status = 'paid', razorpay_payment_id = $2
+WHERE id = $1
+RETURNING id, user_id, subscription_id, type, amount, status, razorpay_payment_id, razorpay_order_id, created_at, job_id
 `
 
-type ListTransactionsByUserParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Limit  int32     `json:"limit"`
-	Offset int32     `json:"offset"`
+type MarkTransactionPaidParams struct {
+	ID                uuid.UUID `json:"id"`
+	RazorpayPaymentID *string   `json:"razorpay_payment_id"`
 }
 
-func (q *Queries) ListTransactionsByUser(ctx context.Context, arg ListTransactionsByUserParams) ([]Transaction, error) {
-	rows, err := q.db.Query(ctx, listTransactionsByUser, arg.UserID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Transaction{}
-	for rows.Next() {
-		var i Transaction
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.SubscriptionID,
-			&i.Type,
-			&i.Amount,
-			&i.Status,
-			&i.RazorpayPaymentID,
-			&i.RazorpayOrderID,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) MarkTransactionPaid(ctx context.Context, arg MarkTransactionPaidParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, markTransactionPaid, arg.ID, arg.RazorpayPaymentID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SubscriptionID,
+		&i.Type,
+		&i.Amount,
+		&i.Status,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.CreatedAt,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const nextInvoiceSequence = `-- name: NextInvoiceSequence :one
+INSERT INTO invoice_sequences (financial_year, last_number)
+VALUES ($1, 1)
+ON CONFLICT (financial_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+RETURNING last_number
+`
+
+// Locks the financial year's counter until the caller's transaction ends,
+// so numbers are issued in order and a rolled-back invoice leaves no gap.
+func (q *Queries) NextInvoiceSequence(ctx context.Context, financialYear string) (int32, error) {
+	row := q.db.QueryRow(ctx, nextInvoiceSequence, financialYear)
+	var last_number int32
+	err := row.Scan(&last_number)
+	return last_number, err
+}
+
+const pauseSubscription = `-- name: PauseSubscription :one
+UPDATE subscriptions SET status = 'paused', paused_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+func (q *Queries) PauseSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, pauseSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const releasePayoutPeriod = `-- name: ReleasePayoutPeriod :exec
+DELETE FROM payout_periods WHERE period_start = $1 AND settled_at IS NULL
+`
+
+func (q *Queries) ReleasePayoutPeriod(ctx context.Context, periodStart pgtype.Date) error {
+	_, err := q.db.Exec(ctx, releasePayoutPeriod, periodStart)
+	return err
+}
+
+const releaseRenewalClaim = `-- name: ReleaseRenewalClaim :exec
+UPDATE subscriptions SET renewal_enqueued_at = NULL WHERE id = $1
+`
+
+func (q *Queries) ReleaseRenewalClaim(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, releaseRenewalClaim, id)
+	return err
+}
+
+const renewSubscription = `-- name: RenewSubscription :one
+UPDATE subscriptions SET
+    current_period_start = $2,
+    current_period_end = $3,
+    credit_balance = $4,
+    on_demand_visits_remaining = $5,
+    visits_used_this_period = 0,
+    renewal_enqueued_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+type RenewSubscriptionParams struct {
+	ID                      uuid.UUID          `json:"id"`
+	CurrentPeriodStart      pgtype.Timestamptz `json:"current_period_start"`
+	CurrentPeriodEnd        pgtype.Timestamptz `json:"current_period_end"`
+	CreditBalance           pgtype.Numeric     `json:"credit_balance"`
+	OnDemandVisitsRemaining *int32             `json:"on_demand_visits_remaining"`
+}
+
+func (q *Queries) RenewSubscription(ctx context.Context, arg RenewSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, renewSubscription,
+		arg.ID,
+		arg.CurrentPeriodStart,
+		arg.CurrentPeriodEnd,
+		arg.CreditBalance,
+		arg.OnDemandVisitsRemaining,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const replayPaymentEvent = `-- name: ReplayPaymentEvent :one
+UPDATE payment_events SET status = 'received'
+WHERE id = $1 AND status = 'failed'
+RETURNING id, event_id, event_type, payload, status, attempts, last_error, received_at, processed_at
+`
+
+func (q *Queries) ReplayPaymentEvent(ctx context.Context, id uuid.UUID) (PaymentEvent, error) {
+	row := q.db.QueryRow(ctx, replayPaymentEvent, id)
+	var i PaymentEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const resumeSubscription = `-- name: ResumeSubscription :one
+UPDATE subscriptions SET
+    status = 'active',
+    current_period_end = current_period_end + (NOW() - paused_at),
+    paused_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'paused'
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+// The period is extended by the time spent paused.
+func (q *Queries) ResumeSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, resumeSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const setCancelAtPeriodEnd = `-- name: SetCancelAtPeriodEnd :one
+UPDATE subscriptions SET cancel_at_period_end = TRUE, updated_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING id, user_id, parcel_id, plan, status, amount_per_cycle, razorpay_subscription_id, current_period_start, current_period_end, visits_used_this_period, on_demand_visits_remaining, created_at, updated_at, credit_balance, paused_at, cancel_at_period_end, cancelled_at, renewal_enqueued_at
+`
+
+func (q *Queries) SetCancelAtPeriodEnd(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, setCancelAtPeriodEnd, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParcelID,
+		&i.Plan,
+		&i.Status,
+		&i.AmountPerCycle,
+		&i.RazorpaySubscriptionID,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.VisitsUsedThisPeriod,
+		&i.OnDemandVisitsRemaining,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreditBalance,
+		&i.PausedAt,
+		&i.CancelAtPeriodEnd,
+		&i.CancelledAt,
+		&i.RenewalEnqueuedAt,
+	)
+	return i, err
+}
+
+const setJobsPayoutStatus = `-- name: SetJobsPayoutStatus :exec
+UPDATE survey_jobs SET payout_status = $2, updated_at = NOW() WHERE payout_id = $1
+`
+
+type SetJobsPayoutStatusParams struct {
+	PayoutID     pgtype.UUID `json:"payout_id"`
+	PayoutStatus *string     `json:"payout_status"`
+}
+
+func (q *Queries) SetJobsPayoutStatus(ctx context.Context, arg SetJobsPayoutStatusParams) error {
+	_, err := q.db.Exec(ctx, setJobsPayoutStatus, arg.PayoutID, arg.PayoutStatus)
+	return err
+}
+
+const setPayoutAmounts = `-- name: SetPayoutAmounts :one
+UPDATE agent_payouts SET
+    total_jobs = $2,
+    gross_amount = $3,
+    platform_commission = $4,
+    tds_deducted = $5,
+    net_amount = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, agent_id, period_start, period_end, total_jobs, gross_amount, platform_commission, tds_deducted, net_amount, status, razorpay_payout_id, failure_reason, created_at, paid_at, updated_at
+`
+
+type SetPayoutAmountsParams struct {
+	ID                 uuid.UUID      `json:"id"`
+	TotalJobs          *int32         `json:"total_jobs"`
+	GrossAmount        pgtype.Numeric `json:"gross_amount"`
+	PlatformCommission pgtype.Numeric `json:"platform_commission"`
+	TdsDeducted        pgtype.Numeric `json:"tds_deducted"`
+	NetAmount          pgtype.Numeric `json:"net_amount"`
+}
+
+func (q *Queries) SetPayoutAmounts(ctx context.Context, arg SetPayoutAmountsParams) (AgentPayout, error) {
+	row := q.db.QueryRow(ctx, setPayoutAmounts,
+		arg.ID,
+		arg.TotalJobs,
+		arg.GrossAmount,
+		arg.PlatformCommission,
+		arg.TdsDeducted,
+		arg.NetAmount,
+	)
+	var i AgentPayout
+	err := row.Scan(
+		&i.ID,
+		&i.AgentID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TotalJobs,
+		&i.GrossAmount,
+		&i.PlatformCommission,
+		&i.TdsDeducted,
+		&i.NetAmount,
+		&i.Status,
+		&i.RazorpayPayoutID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.PaidAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
 const updateSubscriptionStatus = `-- name: UpdateSubscriptionStatus :exec
@@ -0,0 +1,308 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: events.sql
+
+package sqlc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const ackEventDelivery = `-- name: AckEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'delivered', delivered_at = NOW(), lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+`
+
+type AckEventDeliveryParams struct {
+	ID       int64 `json:"id"`
+	Attempts int32 `json:"attempts"`
+}
+
+func (q *Queries) AckEventDelivery(ctx context.Context, arg AckEventDeliveryParams) error {
+	_, err := q.db.Exec(ctx, ackEventDelivery, arg.ID, arg.Attempts)
+	return err
+}
+
+const claimEventDeliveries = `-- name: ClaimEventDeliveries :many
+UPDATE event_deliveries d
+SET status = 'processing', attempts = d.attempts + 1, lease_expires_at = $1
+FROM event_outbox e
+WHERE e.id = d.event_id AND d.id IN (
+    SELECT dd.id FROM event_deliveries dd
+    WHERE dd.subscriber = ANY($2::text[])
+      AND ((dd.status = 'pending' AND dd.available_at <= NOW())
+        OR (dd.status = 'processing' AND dd.lease_expires_at < NOW()))
+    ORDER BY dd.id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.subscriber, d.attempts, e.id AS event_id, e.event_type, e.version, e.payload
+`
+
+type ClaimEventDeliveriesParams struct {
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
+	Subscribers    []string           `json:"subscribers"`
+	Lim            int32              `json:"lim"`
+}
+
+type ClaimEventDeliveriesRow struct {
+	ID         int64           `json:"id"`
+	Subscriber string          `json:"subscriber"`
+	Attempts   int32           `json:"attempts"`
+	EventID    int64           `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Version    int32           `json:"version"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Claims due deliveries for the given subscribers, including ones whose
+// previous claim's lease expired without an acknowledgement.
+func (q *Queries) ClaimEventDeliveries(ctx context.Context, arg ClaimEventDeliveriesParams) ([]ClaimEventDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimEventDeliveries, arg.LeaseExpiresAt, arg.Subscribers, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimEventDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimEventDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Subscriber,
+			&i.Attempts,
+			&i.EventID,
+			&i.EventType,
+			&i.Version,
+			&i.Payload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimUndispatchedEvents = `-- name: ClaimUndispatchedEvents :many
+SELECT id, event_type FROM event_outbox
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimUndispatchedEventsRow struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+}
+
+func (q *Queries) ClaimUndispatchedEvents(ctx context.Context, limit int32) ([]ClaimUndispatchedEventsRow, error) {
+	rows, err := q.db.Query(ctx, claimUndispatchedEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimUndispatchedEventsRow{}
+	for rows.Next() {
+		var i ClaimUndispatchedEventsRow
+		if err := rows.Scan(&i.ID, &i.EventType); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createEventDeliveries = `-- name: CreateEventDeliveries :exec
+INSERT INTO event_deliveries (event_id, subscriber)
+SELECT e.id, s.subscriber
+FROM event_outbox e
+JOIN event_subscribers s ON s.event_type = e.event_type
+WHERE e.id = ANY($1::bigint[])
+ON CONFLICT (event_id, subscriber) DO NOTHING
+`
+
+// Fans events out to a delivery per registered subscriber of their type.
+func (q *Queries) CreateEventDeliveries(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, createEventDeliveries, ids)
+	return err
+}
+
+const deleteDeliveredEvents = `-- name: DeleteDeliveredEvents :execrows
+DELETE FROM event_outbox e
+WHERE e.dispatched_at < $1
+  AND NOT EXISTS (
+    SELECT 1 FROM event_deliveries d
+    WHERE d.event_id = e.id AND d.status IN ('pending', 'processing')
+  )
+`
+
+// Deletes events dispatched before the cutoff once no subscriber still has
+// them to handle.
+func (q *Queries) DeleteDeliveredEvents(ctx context.Context, dispatchedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDeliveredEvents, dispatchedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteStaleEventSubscribers = `-- name: DeleteStaleEventSubscribers :execrows
+WITH stale AS (
+    DELETE FROM event_subscribers WHERE last_seen_at < $1
+    RETURNING event_type, subscriber
+)
+DELETE FROM event_deliveries d
+USING stale s, event_outbox e
+WHERE e.id = d.event_id
+  AND e.event_type = s.event_type
+  AND d.subscriber = s.subscriber
+  AND d.status IN ('pending', 'processing')
+`
+
+// Forgets subscribers no replica has run since the cutoff and drops the
+// deliveries they would never claim.
+func (q *Queries) DeleteStaleEventSubscribers(ctx context.Context, lastSeenAt time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteStaleEventSubscribers, lastSeenAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :one
+INSERT INTO event_outbox (event_type, version, payload)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type InsertOutboxEventParams struct {
+	EventType string          `json:"event_type"`
+	Version   int32           `json:"version"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) (int64, error) {
+	row := q.db.QueryRow(ctx, insertOutboxEvent, arg.EventType, arg.Version, arg.Payload)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const killEventDelivery = `-- name: KillEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'dead', last_error = $3, lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+`
+
+type KillEventDeliveryParams struct {
+	ID        int64   `json:"id"`
+	Attempts  int32   `json:"attempts"`
+	LastError *string `json:"last_error"`
+}
+
+func (q *Queries) KillEventDelivery(ctx context.Context, arg KillEventDeliveryParams) error {
+	_, err := q.db.Exec(ctx, killEventDelivery, arg.ID, arg.Attempts, arg.LastError)
+	return err
+}
+
+const lockUndispatchedEvents = `-- name: LockUndispatchedEvents :many
+SELECT id, event_type, version, payload FROM event_outbox
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+type LockUndispatchedEventsRow struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	Version   int32           `json:"version"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// For the outbox relay of backends that do not read the outbox themselves.
+func (q *Queries) LockUndispatchedEvents(ctx context.Context, limit int32) ([]LockUndispatchedEventsRow, error) {
+	rows, err := q.db.Query(ctx, lockUndispatchedEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LockUndispatchedEventsRow{}
+	for rows.Next() {
+		var i LockUndispatchedEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Version,
+			&i.Payload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEventsDispatched = `-- name: MarkEventsDispatched :exec
+UPDATE event_outbox SET dispatched_at = NOW() WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) MarkEventsDispatched(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, markEventsDispatched, ids)
+	return err
+}
+
+const registerEventSubscribers = `-- name: RegisterEventSubscribers :exec
+INSERT INTO event_subscribers (event_type, subscriber)
+SELECT unnest($1::text[]), unnest($2::text[])
+ON CONFLICT (event_type, subscriber) DO UPDATE SET last_seen_at = NOW()
+`
+
+type RegisterEventSubscribersParams struct {
+	EventTypes  []string `json:"event_types"`
+	Subscribers []string `json:"subscribers"`
+}
+
+// Records the subscribers a replica runs, refreshing ones already known.
+func (q *Queries) RegisterEventSubscribers(ctx context.Context, arg RegisterEventSubscribersParams) error {
+	_, err := q.db.Exec(ctx, registerEventSubscribers, arg.EventTypes, arg.Subscribers)
+	return err
+}
+
+const retryEventDelivery = `-- name: RetryEventDelivery :exec
+UPDATE event_deliveries
+SET status = 'pending', available_at = $3, last_error = $4, lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+`
+
+type RetryEventDeliveryParams struct {
+	ID          int64     `json:"id"`
+	Attempts    int32     `json:"attempts"`
+	AvailableAt time.Time `json:"available_at"`
+	LastError   *string   `json:"last_error"`
+}
+
+func (q *Queries) RetryEventDelivery(ctx context.Context, arg RetryEventDeliveryParams) error {
+	_, err := q.db.Exec(ctx, retryEventDelivery,
+		arg.ID,
+		arg.Attempts,
+		arg.AvailableAt,
+		arg.LastError,
+	)
+	return err
+}
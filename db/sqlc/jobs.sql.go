@@ -7,12 +7,42 @@ package sqlc
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const acceptJobOffer = `-- name: AcceptJobOffer :one
+UPDATE job_offers SET status = 'accepted', responded_at = NOW()
+WHERE id = $1 AND status = 'sent' AND expires_at > NOW()
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
+`
+
+func (q *Queries) AcceptJobOffer(ctx context.Context, id uuid.UUID) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, acceptJobOffer, id)
+	var i JobOffer
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.AgentID,
+		&i.CascadeRound,
+		&i.OfferRank,
+		&i.DistanceKm,
+		&i.MatchScore,
+		&i.Status,
+		&i.SentAt,
+		&i.RespondedAt,
+		&i.ExpiresAt,
+		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
+	)
+	return i, err
+}
+
 const assignAgent = `-- name: AssignAgent :one
 UPDATE survey_jobs SET
     assigned_agent_id = $2,
@@ -22,7 +52,7 @@ UPDATE survey_jobs SET
     total_offers_sent = $4,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
 `
 
 type AssignAgentParams struct {
@@ -71,29 +101,257 @@ func (q *Queries) AssignAgent(ctx context.Context, arg AssignAgentParams) (Surve
 		&i.QaNotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const assignJobManually = `-- name: AssignJobManually :one
+UPDATE survey_jobs SET
+    status = 'assigned',
+    assigned_agent_id = $2,
+    assigned_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'failed_qa')
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type AssignJobManuallyParams struct {
+	ID              uuid.UUID   `json:"id"`
+	AssignedAgentID pgtype.UUID `json:"assigned_agent_id"`
+}
+
+func (q *Queries) AssignJobManually(ctx context.Context, arg AssignJobManuallyParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, assignJobManually, arg.ID, arg.AssignedAgentID)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const cancelJob = `-- name: CancelJob :one
+UPDATE survey_jobs SET status = 'cancelled', updated_at = NOW()
+WHERE id = $1 AND status NOT IN ('survey_submitted', 'completed', 'cancelled')
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+func (q *Queries) CancelJob(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, cancelJob, id)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const claimJob = `-- name: ClaimJob :one
+UPDATE survey_jobs SET
+    status = 'assigned',
+    assigned_agent_id = $1,
+    assigned_at = NOW(),
+    base_payout = COALESCE($2, base_payout),
+    distance_bonus = COALESCE($3, distance_bonus),
+    urgency_bonus = COALESCE($4, urgency_bonus),
+    surge_bonus = COALESCE($5, surge_bonus),
+    total_payout = COALESCE($6, total_payout),
+    updated_at = NOW()
+WHERE id = $7 AND status IN ('pending_assignment', 'offered')
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type ClaimJobParams struct {
+	AssignedAgentID pgtype.UUID    `json:"assigned_agent_id"`
+	BasePayout      pgtype.Numeric `json:"base_payout"`
+	DistanceBonus   pgtype.Numeric `json:"distance_bonus"`
+	UrgencyBonus    pgtype.Numeric `json:"urgency_bonus"`
+	SurgeBonus      pgtype.Numeric `json:"surge_bonus"`
+	TotalPayout     pgtype.Numeric `json:"total_payout"`
+	ID              uuid.UUID      `json:"id"`
+}
+
+// Payout amounts are those of the accepted offer; NULL keeps the job's own.
+func (q *Queries) ClaimJob(ctx context.Context, arg ClaimJobParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, claimJob,
+		arg.AssignedAgentID,
+		arg.BasePayout,
+		arg.DistanceBonus,
+		arg.UrgencyBonus,
+		arg.SurgeBonus,
+		arg.TotalPayout,
+		arg.ID,
+	)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
 	)
 	return i, err
 }
 
-const completeJob = `-- name: CompleteJob :exec
+const completeJob = `-- name: CompleteJob :one
 UPDATE survey_jobs SET
     completed_at = NOW(),
     status = 'completed',
-    qa_status = 'pending',
-    total_payout = base_payout + distance_bonus + urgency_bonus,
+    total_payout = COALESCE(base_payout, 0) + COALESCE(distance_bonus, 0)
+        + COALESCE(urgency_bonus, 0) + COALESCE(surge_bonus, 0),
     updated_at = NOW()
 WHERE id = $1
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
 `
 
-func (q *Queries) CompleteJob(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, completeJob, id)
-	return err
+func (q *Queries) CompleteJob(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, completeJob, id)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
 }
 
 const countActiveJobsByAgent = `-- name: CountActiveJobsByAgent :one
 SELECT count(*) FROM survey_jobs
 WHERE assigned_agent_id = $1
-    AND status IN ('assigned', 'agent_on_site', 'survey_in_progress')
+    AND status IN ('assigned', 'agent_en_route', 'agent_on_site', 'in_progress')
 `
 
 func (q *Queries) CountActiveJobsByAgent(ctx context.Context, assignedAgentID pgtype.UUID) (int64, error) {
@@ -103,10 +361,80 @@ func (q *Queries) CountActiveJobsByAgent(ctx context.Context, assignedAgentID pg
 	return count, err
 }
 
+const countOpenOffersByAgents = `-- name: CountOpenOffersByAgents :many
+SELECT agent_id, count(*) AS open_offers
+FROM job_offers
+WHERE agent_id = ANY($1::uuid[]) AND status = 'sent'
+GROUP BY agent_id
+`
+
+type CountOpenOffersByAgentsRow struct {
+	AgentID    uuid.UUID `json:"agent_id"`
+	OpenOffers int64     `json:"open_offers"`
+}
+
+func (q *Queries) CountOpenOffersByAgents(ctx context.Context, dollar_1 []uuid.UUID) ([]CountOpenOffersByAgentsRow, error) {
+	rows, err := q.db.Query(ctx, countOpenOffersByAgents, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountOpenOffersByAgentsRow{}
+	for rows.Next() {
+		var i CountOpenOffersByAgentsRow
+		if err := rows.Scan(&i.AgentID, &i.OpenOffers); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createJobAdminAction = `-- name: CreateJobAdminAction :one
+INSERT INTO job_admin_actions (job_id, actor_id, actor_name, action, reason, details)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, job_id, actor_id, actor_name, action, reason, details, created_at
+`
+
+type CreateJobAdminActionParams struct {
+	JobID     uuid.UUID       `json:"job_id"`
+	ActorID   string          `json:"actor_id"`
+	ActorName *string         `json:"actor_name"`
+	Action    string          `json:"action"`
+	Reason    *string         `json:"reason"`
+	Details   json.RawMessage `json:"details"`
+}
+
+func (q *Queries) CreateJobAdminAction(ctx context.Context, arg CreateJobAdminActionParams) (JobAdminAction, error) {
+	row := q.db.QueryRow(ctx, createJobAdminAction,
+		arg.JobID,
+		arg.ActorID,
+		arg.ActorName,
+		arg.Action,
+		arg.Reason,
+		arg.Details,
+	)
+	var i JobAdminAction
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.ActorID,
+		&i.ActorName,
+		&i.Action,
+		&i.Reason,
+		&i.Details,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createJobOffer = `-- name: CreateJobOffer :one
 INSERT INTO job_offers (job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, expires_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
 `
 
 type CreateJobOfferParams struct {
@@ -143,39 +471,68 @@ func (q *Queries) CreateJobOffer(ctx context.Context, arg CreateJobOfferParams)
 		&i.RespondedAt,
 		&i.ExpiresAt,
 		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
 	)
 	return i, err
 }
 
-const createSurveyJob = `-- name: CreateSurveyJob :one
+const createJobStatusHistory = `-- name: CreateJobStatusHistory :exec
+INSERT INTO job_status_history (job_id, from_status, to_status, actor_role, actor_id, reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateJobStatusHistoryParams struct {
+	JobID      uuid.UUID `json:"job_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	ActorID    *string   `json:"actor_id"`
+	Reason     *string   `json:"reason"`
+}
+
+func (q *Queries) CreateJobStatusHistory(ctx context.Context, arg CreateJobStatusHistoryParams) error {
+	_, err := q.db.Exec(ctx, createJobStatusHistory,
+		arg.JobID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.ActorRole,
+		arg.ActorID,
+		arg.Reason,
+	)
+	return err
+}
+
+const createOnDemandJob = `-- name: CreateOnDemandJob :one
 INSERT INTO survey_jobs (
-    parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, base_payout
+    parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, base_payout, idempotency_key
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5, $6, 'on_demand', $7, $8)
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
 `
 
-type CreateSurveyJobParams struct {
+type CreateOnDemandJobParams struct {
 	ParcelID       uuid.UUID      `json:"parcel_id"`
 	SubscriptionID pgtype.UUID    `json:"subscription_id"`
 	UserID         uuid.UUID      `json:"user_id"`
 	SurveyType     string         `json:"survey_type"`
 	Priority       *string        `json:"priority"`
 	Deadline       time.Time      `json:"deadline"`
-	Trigger        *string        `json:"trigger"`
 	BasePayout     pgtype.Numeric `json:"base_payout"`
+	IdempotencyKey *string        `json:"idempotency_key"`
 }
 
-func (q *Queries) CreateSurveyJob(ctx context.Context, arg CreateSurveyJobParams) (SurveyJob, error) {
-	row := q.db.QueryRow(ctx, createSurveyJob,
+func (q *Queries) CreateOnDemandJob(ctx context.Context, arg CreateOnDemandJobParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, createOnDemandJob,
 		arg.ParcelID,
 		arg.SubscriptionID,
 		arg.UserID,
 		arg.SurveyType,
 		arg.Priority,
 		arg.Deadline,
-		arg.Trigger,
 		arg.BasePayout,
+		arg.IdempotencyKey,
 	)
 	var i SurveyJob
 	err := row.Scan(
@@ -209,31 +566,343 @@ func (q *Queries) CreateSurveyJob(ctx context.Context, arg CreateSurveyJobParams
 		&i.QaNotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
 	)
 	return i, err
 }
 
-const expireOffers = `-- name: ExpireOffers :exec
-UPDATE job_offers SET status = 'expired' WHERE expires_at < NOW() AND status = 'sent'
-`
-
-func (q *Queries) ExpireOffers(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, expireOffers)
-	return err
-}
-
-const getOfferByJobAndAgent = `-- name: GetOfferByJobAndAgent :one
-SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason FROM job_offers WHERE job_id = $1 AND agent_id = $2 AND status = 'sent'
+const createQueuedJobOffer = `-- name: CreateQueuedJobOffer :one
+INSERT INTO job_offers (
+    job_id, agent_id, cascade_round, offer_rank, distance_km, match_score,
+    scoring_strategy, scoring_weights, status, sent_at, expires_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'queued', NULL, $9)
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
 `
 
-type GetOfferByJobAndAgentParams struct {
-	JobID   uuid.UUID `json:"job_id"`
-	AgentID uuid.UUID `json:"agent_id"`
+type CreateQueuedJobOfferParams struct {
+	JobID           uuid.UUID      `json:"job_id"`
+	AgentID         uuid.UUID      `json:"agent_id"`
+	CascadeRound    int32          `json:"cascade_round"`
+	OfferRank       int32          `json:"offer_rank"`
+	DistanceKm      *float32       `json:"distance_km"`
+	MatchScore      pgtype.Numeric `json:"match_score"`
+	ScoringStrategy *string        `json:"scoring_strategy"`
+	ScoringWeights  []byte         `json:"scoring_weights"`
+	ExpiresAt       time.Time      `json:"expires_at"`
 }
 
-func (q *Queries) GetOfferByJobAndAgent(ctx context.Context, arg GetOfferByJobAndAgentParams) (JobOffer, error) {
-	row := q.db.QueryRow(ctx, getOfferByJobAndAgent, arg.JobID, arg.AgentID)
-	var i JobOffer
+func (q *Queries) CreateQueuedJobOffer(ctx context.Context, arg CreateQueuedJobOfferParams) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, createQueuedJobOffer,
+		arg.JobID,
+		arg.AgentID,
+		arg.CascadeRound,
+		arg.OfferRank,
+		arg.DistanceKm,
+		arg.MatchScore,
+		arg.ScoringStrategy,
+		arg.ScoringWeights,
+		arg.ExpiresAt,
+	)
+	var i JobOffer
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.AgentID,
+		&i.CascadeRound,
+		&i.OfferRank,
+		&i.DistanceKm,
+		&i.MatchScore,
+		&i.Status,
+		&i.SentAt,
+		&i.RespondedAt,
+		&i.ExpiresAt,
+		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
+	)
+	return i, err
+}
+
+const createSurveyJob = `-- name: CreateSurveyJob :one
+INSERT INTO survey_jobs (
+    parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, base_payout
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type CreateSurveyJobParams struct {
+	ParcelID       uuid.UUID      `json:"parcel_id"`
+	SubscriptionID pgtype.UUID    `json:"subscription_id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	SurveyType     string         `json:"survey_type"`
+	Priority       *string        `json:"priority"`
+	Deadline       time.Time      `json:"deadline"`
+	Trigger        *string        `json:"trigger"`
+	BasePayout     pgtype.Numeric `json:"base_payout"`
+}
+
+func (q *Queries) CreateSurveyJob(ctx context.Context, arg CreateSurveyJobParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, createSurveyJob,
+		arg.ParcelID,
+		arg.SubscriptionID,
+		arg.UserID,
+		arg.SurveyType,
+		arg.Priority,
+		arg.Deadline,
+		arg.Trigger,
+		arg.BasePayout,
+	)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const declineJobOffer = `-- name: DeclineJobOffer :one
+UPDATE job_offers SET status = 'declined', responded_at = NOW(), decline_reason = $2
+WHERE id = $1 AND status = 'sent'
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
+`
+
+type DeclineJobOfferParams struct {
+	ID            uuid.UUID `json:"id"`
+	DeclineReason *string   `json:"decline_reason"`
+}
+
+func (q *Queries) DeclineJobOffer(ctx context.Context, arg DeclineJobOfferParams) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, declineJobOffer, arg.ID, arg.DeclineReason)
+	var i JobOffer
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.AgentID,
+		&i.CascadeRound,
+		&i.OfferRank,
+		&i.DistanceKm,
+		&i.MatchScore,
+		&i.Status,
+		&i.SentAt,
+		&i.RespondedAt,
+		&i.ExpiresAt,
+		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
+	)
+	return i, err
+}
+
+const expireOffers = `-- name: ExpireOffers :exec
+UPDATE job_offers SET status = 'expired' WHERE expires_at < NOW() AND status = 'sent'
+`
+
+func (q *Queries) ExpireOffers(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, expireOffers)
+	return err
+}
+
+const getAgentAcceptanceStats = `-- name: GetAgentAcceptanceStats :many
+SELECT agent_id,
+    count(*) FILTER (WHERE status = 'accepted') AS accepted,
+    count(*) AS responded
+FROM job_offers
+WHERE agent_id = ANY($1::uuid[])
+    AND status IN ('accepted', 'declined', 'expired')
+    AND sent_at > NOW() - INTERVAL '90 days'
+GROUP BY agent_id
+`
+
+type GetAgentAcceptanceStatsRow struct {
+	AgentID   uuid.UUID `json:"agent_id"`
+	Accepted  int64     `json:"accepted"`
+	Responded int64     `json:"responded"`
+}
+
+func (q *Queries) GetAgentAcceptanceStats(ctx context.Context, dollar_1 []uuid.UUID) ([]GetAgentAcceptanceStatsRow, error) {
+	rows, err := q.db.Query(ctx, getAgentAcceptanceStats, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAgentAcceptanceStatsRow{}
+	for rows.Next() {
+		var i GetAgentAcceptanceStatsRow
+		if err := rows.Scan(&i.AgentID, &i.Accepted, &i.Responded); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDistrictOfferStats = `-- name: GetDistrictOfferStats :one
+SELECT count(*) AS sent,
+    count(*) FILTER (WHERE o.status = 'declined') AS declined,
+    count(*) FILTER (WHERE o.status = 'expired') AS expired
+FROM job_offers o
+JOIN survey_jobs j ON j.id = o.job_id
+JOIN parcels p ON p.id = j.parcel_id
+WHERE p.state_code = $1 AND p.district = $2
+    AND o.sent_at >= $3
+`
+
+type GetDistrictOfferStatsParams struct {
+	StateCode string             `json:"state_code"`
+	District  string             `json:"district"`
+	SentAt    pgtype.Timestamptz `json:"sent_at"`
+}
+
+type GetDistrictOfferStatsRow struct {
+	Sent     int64 `json:"sent"`
+	Declined int64 `json:"declined"`
+	Expired  int64 `json:"expired"`
+}
+
+// Offers sent for jobs in a district since a given time and how agents
+// responded, as a measure of local supply and demand.
+func (q *Queries) GetDistrictOfferStats(ctx context.Context, arg GetDistrictOfferStatsParams) (GetDistrictOfferStatsRow, error) {
+	row := q.db.QueryRow(ctx, getDistrictOfferStats, arg.StateCode, arg.District, arg.SentAt)
+	var i GetDistrictOfferStatsRow
+	err := row.Scan(&i.Sent, &i.Declined, &i.Expired)
+	return i, err
+}
+
+const getJobByIdempotencyKey = `-- name: GetJobByIdempotencyKey :one
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs WHERE parcel_id = $1 AND idempotency_key = $2
+`
+
+type GetJobByIdempotencyKeyParams struct {
+	ParcelID       uuid.UUID `json:"parcel_id"`
+	IdempotencyKey *string   `json:"idempotency_key"`
+}
+
+func (q *Queries) GetJobByIdempotencyKey(ctx context.Context, arg GetJobByIdempotencyKeyParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, getJobByIdempotencyKey, arg.ParcelID, arg.IdempotencyKey)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const getJobOfferByID = `-- name: GetJobOfferByID :one
+SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown FROM job_offers WHERE id = $1
+`
+
+func (q *Queries) GetJobOfferByID(ctx context.Context, id uuid.UUID) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, getJobOfferByID, id)
+	var i JobOffer
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.AgentID,
+		&i.CascadeRound,
+		&i.OfferRank,
+		&i.DistanceKm,
+		&i.MatchScore,
+		&i.Status,
+		&i.SentAt,
+		&i.RespondedAt,
+		&i.ExpiresAt,
+		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
+	)
+	return i, err
+}
+
+const getOfferByJobAndAgent = `-- name: GetOfferByJobAndAgent :one
+SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown FROM job_offers WHERE job_id = $1 AND agent_id = $2 AND status = 'sent'
+`
+
+type GetOfferByJobAndAgentParams struct {
+	JobID   uuid.UUID `json:"job_id"`
+	AgentID uuid.UUID `json:"agent_id"`
+}
+
+func (q *Queries) GetOfferByJobAndAgent(ctx context.Context, arg GetOfferByJobAndAgentParams) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, getOfferByJobAndAgent, arg.JobID, arg.AgentID)
+	var i JobOffer
 	err := row.Scan(
 		&i.ID,
 		&i.JobID,
@@ -247,12 +916,15 @@ func (q *Queries) GetOfferByJobAndAgent(ctx context.Context, arg GetOfferByJobAn
 		&i.RespondedAt,
 		&i.ExpiresAt,
 		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
 	)
 	return i, err
 }
 
 const getPendingOfferByID = `-- name: GetPendingOfferByID :one
-SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason FROM job_offers WHERE id = $1 AND status = 'sent'
+SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown FROM job_offers WHERE id = $1 AND status = 'sent'
 `
 
 func (q *Queries) GetPendingOfferByID(ctx context.Context, id uuid.UUID) (JobOffer, error) {
@@ -271,12 +943,15 @@ func (q *Queries) GetPendingOfferByID(ctx context.Context, id uuid.UUID) (JobOff
 		&i.RespondedAt,
 		&i.ExpiresAt,
 		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
 	)
 	return i, err
 }
 
 const getSurveyJobByID = `-- name: GetSurveyJobByID :one
-SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at FROM survey_jobs WHERE id = $1
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs WHERE id = $1
 `
 
 func (q *Queries) GetSurveyJobByID(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
@@ -313,12 +988,161 @@ func (q *Queries) GetSurveyJobByID(ctx context.Context, id uuid.UUID) (SurveyJob
 		&i.QaNotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const getSurveyJobForUpdate = `-- name: GetSurveyJobForUpdate :one
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetSurveyJobForUpdate(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, getSurveyJobForUpdate, id)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
 	)
 	return i, err
 }
 
+const hasOpenJobForParcel = `-- name: HasOpenJobForParcel :one
+SELECT EXISTS (
+    SELECT 1 FROM survey_jobs
+    WHERE parcel_id = $1 AND status NOT IN ('completed', 'cancelled')
+)
+`
+
+func (q *Queries) HasOpenJobForParcel(ctx context.Context, parcelID uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, hasOpenJobForParcel, parcelID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isJobUnplanned = `-- name: IsJobUnplanned :one
+SELECT EXISTS (
+    SELECT 1 FROM survey_jobs j
+    WHERE j.id = $1
+      AND j.status = 'pending_assignment'
+      AND COALESCE(j.cascade_round, 0) = 0
+      AND NOT EXISTS (SELECT 1 FROM job_offers o WHERE o.job_id = j.id)
+)
+`
+
+func (q *Queries) IsJobUnplanned(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isJobUnplanned, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listJobAdminActions = `-- name: ListJobAdminActions :many
+SELECT id, job_id, actor_id, actor_name, action, reason, details, created_at FROM job_admin_actions WHERE job_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListJobAdminActions(ctx context.Context, jobID uuid.UUID) ([]JobAdminAction, error) {
+	rows, err := q.db.Query(ctx, listJobAdminActions, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JobAdminAction{}
+	for rows.Next() {
+		var i JobAdminAction
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.ActorID,
+			&i.ActorName,
+			&i.Action,
+			&i.Reason,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobStatusHistory = `-- name: ListJobStatusHistory :many
+SELECT id, job_id, from_status, to_status, actor_role, actor_id, reason, created_at FROM job_status_history WHERE job_id = $1 ORDER BY created_at, id
+`
+
+func (q *Queries) ListJobStatusHistory(ctx context.Context, jobID uuid.UUID) ([]JobStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listJobStatusHistory, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JobStatusHistory{}
+	for rows.Next() {
+		var i JobStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ActorRole,
+			&i.ActorID,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listJobsByAgent = `-- name: ListJobsByAgent :many
-SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at FROM survey_jobs
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs
 WHERE assigned_agent_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -370,6 +1194,11 @@ func (q *Queries) ListJobsByAgent(ctx context.Context, arg ListJobsByAgentParams
 			&i.QaNotes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DispatchRadiusKm,
+			&i.DispatchRoundLimit,
+			&i.IdempotencyKey,
+			&i.SurgeBonus,
+			&i.PayoutID,
 		); err != nil {
 			return nil, err
 		}
@@ -382,7 +1211,7 @@ func (q *Queries) ListJobsByAgent(ctx context.Context, arg ListJobsByAgentParams
 }
 
 const listJobsByParcel = `-- name: ListJobsByParcel :many
-SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at FROM survey_jobs
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs
 WHERE parcel_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -434,6 +1263,11 @@ func (q *Queries) ListJobsByParcel(ctx context.Context, arg ListJobsByParcelPara
 			&i.QaNotes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DispatchRadiusKm,
+			&i.DispatchRoundLimit,
+			&i.IdempotencyKey,
+			&i.SurgeBonus,
+			&i.PayoutID,
 		); err != nil {
 			return nil, err
 		}
@@ -446,7 +1280,7 @@ func (q *Queries) ListJobsByParcel(ctx context.Context, arg ListJobsByParcelPara
 }
 
 const listOffersByJob = `-- name: ListOffersByJob :many
-SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason FROM job_offers WHERE job_id = $1 ORDER BY cascade_round, offer_rank
+SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown FROM job_offers WHERE job_id = $1 ORDER BY cascade_round, offer_rank
 `
 
 func (q *Queries) ListOffersByJob(ctx context.Context, jobID uuid.UUID) ([]JobOffer, error) {
@@ -471,6 +1305,156 @@ func (q *Queries) ListOffersByJob(ctx context.Context, jobID uuid.UUID) ([]JobOf
 			&i.RespondedAt,
 			&i.ExpiresAt,
 			&i.DeclineReason,
+			&i.ScoringStrategy,
+			&i.ScoringWeights,
+			&i.PayoutBreakdown,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpsJobs = `-- name: ListOpsJobs :many
+SELECT j.id, j.parcel_id, j.subscription_id, j.user_id, j.survey_type, j.priority, j.deadline, j.trigger, j.status, j.assigned_agent_id, j.assigned_at, j.cascade_round, j.total_offers_sent, j.agent_arrived_at, j.survey_started_at, j.survey_submitted_at, j.completed_at, j.arrival_location, j.arrival_distance_m, j.base_payout, j.distance_bonus, j.urgency_bonus, j.total_payout, j.payout_status, j.landowner_rating, j.qa_score, j.qa_status, j.qa_notes, j.created_at, j.updated_at, j.dispatch_radius_km, j.dispatch_round_limit, j.idempotency_key, j.surge_bonus, j.payout_id, p.state_code, p.district, count(*) OVER() AS total_count
+FROM survey_jobs j
+JOIN parcels p ON p.id = j.parcel_id
+WHERE (
+        ($1::text = 'unassigned' AND j.status = 'unassigned')
+        OR ($1::text = 'overdue' AND j.deadline < NOW()
+            AND j.status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'agent_on_site', 'in_progress'))
+        OR ($1::text = 'stuck' AND j.updated_at < $2::timestamptz
+            AND j.status IN ('pending_assignment', 'offered', 'assigned', 'agent_en_route', 'agent_on_site', 'in_progress', 'failed_qa'))
+    )
+    AND ($3::text IS NULL OR p.state_code = $3)
+    AND ($4::text IS NULL OR p.district = $4)
+    AND ($5::text IS NULL OR j.survey_type = $5)
+    AND ($6::text IS NULL OR j.priority = $6)
+    AND ($7::uuid IS NULL OR j.assigned_agent_id = $7)
+ORDER BY j.deadline ASC
+LIMIT $9 OFFSET $8
+`
+
+type ListOpsJobsParams struct {
+	View        string      `json:"view"`
+	StuckBefore time.Time   `json:"stuck_before"`
+	StateCode   *string     `json:"state_code"`
+	District    *string     `json:"district"`
+	SurveyType  *string     `json:"survey_type"`
+	Priority    *string     `json:"priority"`
+	AgentID     pgtype.UUID `json:"agent_id"`
+	Off         int32       `json:"off"`
+	Lim         int32       `json:"lim"`
+}
+
+type ListOpsJobsRow struct {
+	ID                 uuid.UUID          `json:"id"`
+	ParcelID           uuid.UUID          `json:"parcel_id"`
+	SubscriptionID     pgtype.UUID        `json:"subscription_id"`
+	UserID             uuid.UUID          `json:"user_id"`
+	SurveyType         string             `json:"survey_type"`
+	Priority           *string            `json:"priority"`
+	Deadline           time.Time          `json:"deadline"`
+	Trigger            *string            `json:"trigger"`
+	Status             *string            `json:"status"`
+	AssignedAgentID    pgtype.UUID        `json:"assigned_agent_id"`
+	AssignedAt         pgtype.Timestamptz `json:"assigned_at"`
+	CascadeRound       *int32             `json:"cascade_round"`
+	TotalOffersSent    *int32             `json:"total_offers_sent"`
+	AgentArrivedAt     pgtype.Timestamptz `json:"agent_arrived_at"`
+	SurveyStartedAt    pgtype.Timestamptz `json:"survey_started_at"`
+	SurveySubmittedAt  pgtype.Timestamptz `json:"survey_submitted_at"`
+	CompletedAt        pgtype.Timestamptz `json:"completed_at"`
+	ArrivalLocation    interface{}        `json:"arrival_location"`
+	ArrivalDistanceM   *float32           `json:"arrival_distance_m"`
+	BasePayout         pgtype.Numeric     `json:"base_payout"`
+	DistanceBonus      pgtype.Numeric     `json:"distance_bonus"`
+	UrgencyBonus       pgtype.Numeric     `json:"urgency_bonus"`
+	TotalPayout        pgtype.Numeric     `json:"total_payout"`
+	PayoutStatus       *string            `json:"payout_status"`
+	LandownerRating    pgtype.Numeric     `json:"landowner_rating"`
+	QaScore            pgtype.Numeric     `json:"qa_score"`
+	QaStatus           *string            `json:"qa_status"`
+	QaNotes            *string            `json:"qa_notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	DispatchRadiusKm   *float32           `json:"dispatch_radius_km"`
+	DispatchRoundLimit *int32             `json:"dispatch_round_limit"`
+	IdempotencyKey     *string            `json:"idempotency_key"`
+	SurgeBonus         pgtype.Numeric     `json:"surge_bonus"`
+	PayoutID           pgtype.UUID        `json:"payout_id"`
+	StateCode          string             `json:"state_code"`
+	District           string             `json:"district"`
+	TotalCount         int64              `json:"total_count"`
+}
+
+// Jobs needing ops attention. view is one of:
+//
+//	unassigned: the cascade gave up
+//	overdue:    past deadline and not yet surveyed
+//	stuck:      open but untouched since stuck_before
+func (q *Queries) ListOpsJobs(ctx context.Context, arg ListOpsJobsParams) ([]ListOpsJobsRow, error) {
+	rows, err := q.db.Query(ctx, listOpsJobs,
+		arg.View,
+		arg.StuckBefore,
+		arg.StateCode,
+		arg.District,
+		arg.SurveyType,
+		arg.Priority,
+		arg.AgentID,
+		arg.Off,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOpsJobsRow{}
+	for rows.Next() {
+		var i ListOpsJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParcelID,
+			&i.SubscriptionID,
+			&i.UserID,
+			&i.SurveyType,
+			&i.Priority,
+			&i.Deadline,
+			&i.Trigger,
+			&i.Status,
+			&i.AssignedAgentID,
+			&i.AssignedAt,
+			&i.CascadeRound,
+			&i.TotalOffersSent,
+			&i.AgentArrivedAt,
+			&i.SurveyStartedAt,
+			&i.SurveySubmittedAt,
+			&i.CompletedAt,
+			&i.ArrivalLocation,
+			&i.ArrivalDistanceM,
+			&i.BasePayout,
+			&i.DistanceBonus,
+			&i.UrgencyBonus,
+			&i.TotalPayout,
+			&i.PayoutStatus,
+			&i.LandownerRating,
+			&i.QaScore,
+			&i.QaStatus,
+			&i.QaNotes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DispatchRadiusKm,
+			&i.DispatchRoundLimit,
+			&i.IdempotencyKey,
+			&i.SurgeBonus,
+			&i.PayoutID,
+			&i.StateCode,
+			&i.District,
+			&i.TotalCount,
 		); err != nil {
 			return nil, err
 		}
@@ -483,7 +1467,7 @@ func (q *Queries) ListOffersByJob(ctx context.Context, jobID uuid.UUID) ([]JobOf
 }
 
 const listPendingJobs = `-- name: ListPendingJobs :many
-SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at FROM survey_jobs
+SELECT id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id FROM survey_jobs
 WHERE status IN ('pending_assignment', 'offered')
 ORDER BY deadline ASC
 LIMIT $1
@@ -529,6 +1513,119 @@ func (q *Queries) ListPendingJobs(ctx context.Context, limit int32) ([]SurveyJob
 			&i.QaNotes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DispatchRadiusKm,
+			&i.DispatchRoundLimit,
+			&i.IdempotencyKey,
+			&i.SurgeBonus,
+			&i.PayoutID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOffersByAgent = `-- name: ListPendingOffersByAgent :many
+SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown FROM job_offers
+WHERE agent_id = $1 AND status = 'sent'
+ORDER BY sent_at DESC
+`
+
+func (q *Queries) ListPendingOffersByAgent(ctx context.Context, agentID uuid.UUID) ([]JobOffer, error) {
+	rows, err := q.db.Query(ctx, listPendingOffersByAgent, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JobOffer{}
+	for rows.Next() {
+		var i JobOffer
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.AgentID,
+			&i.CascadeRound,
+			&i.OfferRank,
+			&i.DistanceKm,
+			&i.MatchScore,
+			&i.Status,
+			&i.SentAt,
+			&i.RespondedAt,
+			&i.ExpiresAt,
+			&i.DeclineReason,
+			&i.ScoringStrategy,
+			&i.ScoringWeights,
+			&i.PayoutBreakdown,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnplannedJobs = `-- name: ListUnplannedJobs :many
+SELECT j.id, j.parcel_id, j.subscription_id, j.user_id, j.survey_type, j.priority, j.deadline, j.trigger, j.status, j.assigned_agent_id, j.assigned_at, j.cascade_round, j.total_offers_sent, j.agent_arrived_at, j.survey_started_at, j.survey_submitted_at, j.completed_at, j.arrival_location, j.arrival_distance_m, j.base_payout, j.distance_bonus, j.urgency_bonus, j.total_payout, j.payout_status, j.landowner_rating, j.qa_score, j.qa_status, j.qa_notes, j.created_at, j.updated_at, j.dispatch_radius_km, j.dispatch_round_limit, j.idempotency_key, j.surge_bonus, j.payout_id FROM survey_jobs j
+WHERE j.status = 'pending_assignment'
+  AND COALESCE(j.cascade_round, 0) = 0
+  AND NOT EXISTS (SELECT 1 FROM job_offers o WHERE o.job_id = j.id)
+ORDER BY j.deadline ASC
+LIMIT $1
+`
+
+// Pending jobs still waiting for their first cascade round.
+func (q *Queries) ListUnplannedJobs(ctx context.Context, limit int32) ([]SurveyJob, error) {
+	rows, err := q.db.Query(ctx, listUnplannedJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SurveyJob{}
+	for rows.Next() {
+		var i SurveyJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParcelID,
+			&i.SubscriptionID,
+			&i.UserID,
+			&i.SurveyType,
+			&i.Priority,
+			&i.Deadline,
+			&i.Trigger,
+			&i.Status,
+			&i.AssignedAgentID,
+			&i.AssignedAt,
+			&i.CascadeRound,
+			&i.TotalOffersSent,
+			&i.AgentArrivedAt,
+			&i.SurveyStartedAt,
+			&i.SurveySubmittedAt,
+			&i.CompletedAt,
+			&i.ArrivalLocation,
+			&i.ArrivalDistanceM,
+			&i.BasePayout,
+			&i.DistanceBonus,
+			&i.UrgencyBonus,
+			&i.TotalPayout,
+			&i.PayoutStatus,
+			&i.LandownerRating,
+			&i.QaScore,
+			&i.QaStatus,
+			&i.QaNotes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DispatchRadiusKm,
+			&i.DispatchRoundLimit,
+			&i.IdempotencyKey,
+			&i.SurgeBonus,
+			&i.PayoutID,
 		); err != nil {
 			return nil, err
 		}
@@ -540,84 +1637,309 @@ func (q *Queries) ListPendingJobs(ctx context.Context, limit int32) ([]SurveyJob
 	return items, nil
 }
 
-const listPendingOffersByAgent = `-- name: ListPendingOffersByAgent :many
-SELECT id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason FROM job_offers
-WHERE agent_id = $1 AND status = 'sent'
-ORDER BY sent_at DESC
+const lockJobPendingQA = `-- name: LockJobPendingQA :one
+SELECT status = 'survey_submitted' AND COALESCE(qa_status, 'pending') = 'pending' AS pending
+FROM survey_jobs WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockJobPendingQA(ctx context.Context, id uuid.UUID) (*bool, error) {
+	row := q.db.QueryRow(ctx, lockJobPendingQA, id)
+	var pending *bool
+	err := row.Scan(&pending)
+	return pending, err
+}
+
+const recordAgentArrival = `-- name: RecordAgentArrival :one
+UPDATE survey_jobs SET
+    agent_arrived_at = NOW(),
+    arrival_location = ST_SetSRID(ST_MakePoint($2, $3), 4326),
+    arrival_distance_m = $4,
+    status = 'agent_on_site',
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type RecordAgentArrivalParams struct {
+	ID               uuid.UUID   `json:"id"`
+	StMakepoint      interface{} `json:"st_makepoint"`
+	StMakepoint_2    interface{} `json:"st_makepoint_2"`
+	ArrivalDistanceM *float32    `json:"arrival_distance_m"`
+}
+
+func (q *Queries) RecordAgentArrival(ctx context.Context, arg RecordAgentArrivalParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, recordAgentArrival,
+		arg.ID,
+		arg.StMakepoint,
+		arg.StMakepoint_2,
+		arg.ArrivalDistanceM,
+	)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const redispatchJob = `-- name: RedispatchJob :one
+UPDATE survey_jobs SET
+    status = 'pending_assignment',
+    assigned_agent_id = NULL,
+    assigned_at = NULL,
+    dispatch_radius_km = $1,
+    dispatch_round_limit = GREATEST(
+        COALESCE(cascade_round, 0),
+        (SELECT COALESCE(MAX(o.cascade_round), 0) FROM job_offers o WHERE o.job_id = survey_jobs.id)
+    ) + $2::int,
+    updated_at = NOW()
+WHERE survey_jobs.id = $3 AND survey_jobs.status IN ('pending_assignment', 'offered', 'unassigned', 'assigned', 'agent_en_route', 'failed_qa')
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type RedispatchJobParams struct {
+	RadiusKm    *float32  `json:"radius_km"`
+	ExtraRounds int32     `json:"extra_rounds"`
+	ID          uuid.UUID `json:"id"`
+}
+
+// Puts a job back into dispatch with a new radius and round budget. Rounds
+// continue from the job's latest round so earlier offers are kept.
+func (q *Queries) RedispatchJob(ctx context.Context, arg RedispatchJobParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, redispatchJob, arg.RadiusKm, arg.ExtraRounds, arg.ID)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
+const sendJobOffer = `-- name: SendJobOffer :one
+UPDATE job_offers SET status = 'sent', sent_at = NOW(), expires_at = $2, payout_breakdown = $3
+WHERE id = $1 AND status = 'queued'
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
 `
 
-func (q *Queries) ListPendingOffersByAgent(ctx context.Context, agentID uuid.UUID) ([]JobOffer, error) {
-	rows, err := q.db.Query(ctx, listPendingOffersByAgent, agentID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []JobOffer{}
-	for rows.Next() {
-		var i JobOffer
-		if err := rows.Scan(
-			&i.ID,
-			&i.JobID,
-			&i.AgentID,
-			&i.CascadeRound,
-			&i.OfferRank,
-			&i.DistanceKm,
-			&i.MatchScore,
-			&i.Status,
-			&i.SentAt,
-			&i.RespondedAt,
-			&i.ExpiresAt,
-			&i.DeclineReason,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type SendJobOfferParams struct {
+	ID              uuid.UUID `json:"id"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	PayoutBreakdown []byte    `json:"payout_breakdown"`
+}
+
+func (q *Queries) SendJobOffer(ctx context.Context, arg SendJobOfferParams) (JobOffer, error) {
+	row := q.db.QueryRow(ctx, sendJobOffer, arg.ID, arg.ExpiresAt, arg.PayoutBreakdown)
+	var i JobOffer
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.AgentID,
+		&i.CascadeRound,
+		&i.OfferRank,
+		&i.DistanceKm,
+		&i.MatchScore,
+		&i.Status,
+		&i.SentAt,
+		&i.RespondedAt,
+		&i.ExpiresAt,
+		&i.DeclineReason,
+		&i.ScoringStrategy,
+		&i.ScoringWeights,
+		&i.PayoutBreakdown,
+	)
+	return i, err
 }
 
-const recordAgentArrival = `-- name: RecordAgentArrival :exec
+const startSurvey = `-- name: StartSurvey :one
 UPDATE survey_jobs SET
-    agent_arrived_at = NOW(),
-    arrival_location = ST_SetSRID(ST_MakePoint($2, $3), 4326),
-    arrival_distance_m = $4,
-    status = 'agent_on_site',
+    survey_started_at = NOW(),
+    status = 'in_progress',
     updated_at = NOW()
 WHERE id = $1
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
 `
 
-type RecordAgentArrivalParams struct {
-	ID               uuid.UUID   `json:"id"`
-	StMakepoint      interface{} `json:"st_makepoint"`
-	StMakepoint_2    interface{} `json:"st_makepoint_2"`
-	ArrivalDistanceM *float32    `json:"arrival_distance_m"`
+func (q *Queries) StartSurvey(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, startSurvey, id)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
 }
 
-func (q *Queries) RecordAgentArrival(ctx context.Context, arg RecordAgentArrivalParams) error {
-	_, err := q.db.Exec(ctx, recordAgentArrival,
-		arg.ID,
-		arg.StMakepoint,
-		arg.StMakepoint_2,
-		arg.ArrivalDistanceM,
+const submitJobSurvey = `-- name: SubmitJobSurvey :one
+UPDATE survey_jobs SET
+    survey_submitted_at = NOW(),
+    status = 'survey_submitted',
+    qa_score = NULL,
+    qa_status = 'pending',
+    qa_notes = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+func (q *Queries) SubmitJobSurvey(ctx context.Context, id uuid.UUID) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, submitJobSurvey, id)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
 	)
-	return err
+	return i, err
 }
 
-const updateJobOfferStatus = `-- name: UpdateJobOfferStatus :exec
-UPDATE job_offers SET status = $2, responded_at = NOW(), decline_reason = $3 WHERE id = $1
+const updateJobCascade = `-- name: UpdateJobCascade :exec
+UPDATE survey_jobs SET
+    cascade_round = $2,
+    total_offers_sent = $3,
+    updated_at = NOW()
+WHERE id = $1
 `
 
-type UpdateJobOfferStatusParams struct {
-	ID            uuid.UUID `json:"id"`
-	Status        *string   `json:"status"`
-	DeclineReason *string   `json:"decline_reason"`
+type UpdateJobCascadeParams struct {
+	ID              uuid.UUID `json:"id"`
+	CascadeRound    *int32    `json:"cascade_round"`
+	TotalOffersSent *int32    `json:"total_offers_sent"`
 }
 
-func (q *Queries) UpdateJobOfferStatus(ctx context.Context, arg UpdateJobOfferStatusParams) error {
-	_, err := q.db.Exec(ctx, updateJobOfferStatus, arg.ID, arg.Status, arg.DeclineReason)
+func (q *Queries) UpdateJobCascade(ctx context.Context, arg UpdateJobCascadeParams) error {
+	_, err := q.db.Exec(ctx, updateJobCascade, arg.ID, arg.CascadeRound, arg.TotalOffersSent)
 	return err
 }
 
@@ -647,8 +1969,66 @@ func (q *Queries) UpdateJobQA(ctx context.Context, arg UpdateJobQAParams) error
 	return err
 }
 
+const updateJobSchedule = `-- name: UpdateJobSchedule :one
+UPDATE survey_jobs SET
+    deadline = COALESCE($1, deadline),
+    priority = COALESCE($2, priority),
+    updated_at = NOW()
+WHERE id = $3 AND status NOT IN ('completed', 'cancelled')
+RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
+`
+
+type UpdateJobScheduleParams struct {
+	Deadline pgtype.Timestamptz `json:"deadline"`
+	Priority *string            `json:"priority"`
+	ID       uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) UpdateJobSchedule(ctx context.Context, arg UpdateJobScheduleParams) (SurveyJob, error) {
+	row := q.db.QueryRow(ctx, updateJobSchedule, arg.Deadline, arg.Priority, arg.ID)
+	var i SurveyJob
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.SurveyType,
+		&i.Priority,
+		&i.Deadline,
+		&i.Trigger,
+		&i.Status,
+		&i.AssignedAgentID,
+		&i.AssignedAt,
+		&i.CascadeRound,
+		&i.TotalOffersSent,
+		&i.AgentArrivedAt,
+		&i.SurveyStartedAt,
+		&i.SurveySubmittedAt,
+		&i.CompletedAt,
+		&i.ArrivalLocation,
+		&i.ArrivalDistanceM,
+		&i.BasePayout,
+		&i.DistanceBonus,
+		&i.UrgencyBonus,
+		&i.TotalPayout,
+		&i.PayoutStatus,
+		&i.LandownerRating,
+		&i.QaScore,
+		&i.QaStatus,
+		&i.QaNotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
+	)
+	return i, err
+}
+
 const updateJobStatus = `-- name: UpdateJobStatus :one
-UPDATE survey_jobs SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at
+UPDATE survey_jobs SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, parcel_id, subscription_id, user_id, survey_type, priority, deadline, trigger, status, assigned_agent_id, assigned_at, cascade_round, total_offers_sent, agent_arrived_at, survey_started_at, survey_submitted_at, completed_at, arrival_location, arrival_distance_m, base_payout, distance_bonus, urgency_bonus, total_payout, payout_status, landowner_rating, qa_score, qa_status, qa_notes, created_at, updated_at, dispatch_radius_km, dispatch_round_limit, idempotency_key, surge_bonus, payout_id
 `
 
 type UpdateJobStatusParams struct {
@@ -690,6 +2070,53 @@ func (q *Queries) UpdateJobStatus(ctx context.Context, arg UpdateJobStatusParams
 		&i.QaNotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DispatchRadiusKm,
+		&i.DispatchRoundLimit,
+		&i.IdempotencyKey,
+		&i.SurgeBonus,
+		&i.PayoutID,
 	)
 	return i, err
 }
+
+const withdrawOpenOffers = `-- name: WithdrawOpenOffers :many
+UPDATE job_offers SET status = 'withdrawn', responded_at = NOW()
+WHERE job_id = $1 AND status IN ('queued', 'sent')
+RETURNING id, job_id, agent_id, cascade_round, offer_rank, distance_km, match_score, status, sent_at, responded_at, expires_at, decline_reason, scoring_strategy, scoring_weights, payout_breakdown
+`
+
+func (q *Queries) WithdrawOpenOffers(ctx context.Context, jobID uuid.UUID) ([]JobOffer, error) {
+	rows, err := q.db.Query(ctx, withdrawOpenOffers, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JobOffer{}
+	for rows.Next() {
+		var i JobOffer
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.AgentID,
+			&i.CascadeRound,
+			&i.OfferRank,
+			&i.DistanceKm,
+			&i.MatchScore,
+			&i.Status,
+			&i.SentAt,
+			&i.RespondedAt,
+			&i.ExpiresAt,
+			&i.DeclineReason,
+			&i.ScoringStrategy,
+			&i.ScoringWeights,
+			&i.PayoutBreakdown,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,389 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: ledger.sql
+
+package sqlc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addToWalletBalance = `-- name: AddToWalletBalance :exec
+UPDATE agents SET
+    wallet_balance = COALESCE(wallet_balance, 0) + $1::numeric,
+    updated_at = NOW()
+WHERE id = $2
+`
+
+type AddToWalletBalanceParams struct {
+	Delta pgtype.Numeric `json:"delta"`
+	ID    uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) AddToWalletBalance(ctx context.Context, arg AddToWalletBalanceParams) error {
+	_, err := q.db.Exec(ctx, addToWalletBalance, arg.Delta, arg.ID)
+	return err
+}
+
+const countLedgerEntries = `-- name: CountLedgerEntries :one
+SELECT COUNT(*) FROM ledger_entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+`
+
+type CountLedgerEntriesParams struct {
+	AccountID uuid.UUID          `json:"account_id"`
+	FromTime  pgtype.Timestamptz `json:"from_time"`
+	ToTime    pgtype.Timestamptz `json:"to_time"`
+}
+
+func (q *Queries) CountLedgerEntries(ctx context.Context, arg CountLedgerEntriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countLedgerEntries, arg.AccountID, arg.FromTime, arg.ToTime)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createLedgerEntry = `-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (transaction_id, account_id, direction, amount)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateLedgerEntryParams struct {
+	TransactionID uuid.UUID      `json:"transaction_id"`
+	AccountID     uuid.UUID      `json:"account_id"`
+	Direction     string         `json:"direction"`
+	Amount        pgtype.Numeric `json:"amount"`
+}
+
+func (q *Queries) CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) error {
+	_, err := q.db.Exec(ctx, createLedgerEntry,
+		arg.TransactionID,
+		arg.AccountID,
+		arg.Direction,
+		arg.Amount,
+	)
+	return err
+}
+
+const createLedgerTransaction = `-- name: CreateLedgerTransaction :one
+INSERT INTO ledger_transactions (kind, reference_id, description, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (kind, reference_id) DO NOTHING
+RETURNING id, kind, reference_id, description, created_by, created_at
+`
+
+type CreateLedgerTransactionParams struct {
+	Kind        string    `json:"kind"`
+	ReferenceID uuid.UUID `json:"reference_id"`
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"created_by"`
+}
+
+func (q *Queries) CreateLedgerTransaction(ctx context.Context, arg CreateLedgerTransactionParams) (LedgerTransaction, error) {
+	row := q.db.QueryRow(ctx, createLedgerTransaction,
+		arg.Kind,
+		arg.ReferenceID,
+		arg.Description,
+		arg.CreatedBy,
+	)
+	var i LedgerTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.ReferenceID,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const ensureAgentLedgerAccount = `-- name: EnsureAgentLedgerAccount :one
+INSERT INTO ledger_accounts (code, type, agent_id)
+VALUES ('agent:' || $1::text, 'liability', $1)
+ON CONFLICT (agent_id) DO UPDATE SET agent_id = EXCLUDED.agent_id
+RETURNING id, code, type, agent_id, created_at
+`
+
+func (q *Queries) EnsureAgentLedgerAccount(ctx context.Context, agentID pgtype.UUID) (LedgerAccount, error) {
+	row := q.db.QueryRow(ctx, ensureAgentLedgerAccount, agentID)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Type,
+		&i.AgentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAgentLedgerAccount = `-- name: GetAgentLedgerAccount :one
+SELECT id, code, type, agent_id, created_at FROM ledger_accounts WHERE agent_id = $1
+`
+
+func (q *Queries) GetAgentLedgerAccount(ctx context.Context, agentID pgtype.UUID) (LedgerAccount, error) {
+	row := q.db.QueryRow(ctx, getAgentLedgerAccount, agentID)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Type,
+		&i.AgentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInTransitPayoutTotal = `-- name: GetInTransitPayoutTotal :one
+SELECT COALESCE(SUM(net_amount), 0)::numeric AS total
+FROM agent_payouts
+WHERE status IN ('pending', 'processing') AND net_amount > 0
+`
+
+func (q *Queries) GetInTransitPayoutTotal(ctx context.Context) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getInTransitPayoutTotal)
+	var total pgtype.Numeric
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getLedgerAccountBalance = `-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(CASE direction WHEN 'credit' THEN amount ELSE -amount END), 0)::numeric AS balance
+FROM ledger_entries
+WHERE account_id = $1 AND created_at < $2
+`
+
+type GetLedgerAccountBalanceParams struct {
+	AccountID uuid.UUID          `json:"account_id"`
+	Before    pgtype.Timestamptz `json:"before"`
+}
+
+func (q *Queries) GetLedgerAccountBalance(ctx context.Context, arg GetLedgerAccountBalanceParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getLedgerAccountBalance, arg.AccountID, arg.Before)
+	var balance pgtype.Numeric
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const getLedgerAccountByCode = `-- name: GetLedgerAccountByCode :one
+SELECT id, code, type, agent_id, created_at FROM ledger_accounts WHERE code = $1
+`
+
+func (q *Queries) GetLedgerAccountByCode(ctx context.Context, code string) (LedgerAccount, error) {
+	row := q.db.QueryRow(ctx, getLedgerAccountByCode, code)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Type,
+		&i.AgentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listLedgerStatement = `-- name: ListLedgerStatement :many
+SELECT e.id, e.transaction_id, t.kind, t.reference_id, t.description,
+    e.direction, e.amount, e.created_at,
+    (SUM(CASE e.direction WHEN 'credit' THEN e.amount ELSE -e.amount END)
+        OVER (ORDER BY e.id))::numeric AS balance_change
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account_id = $1
+  AND e.created_at >= $2
+  AND e.created_at < $3
+ORDER BY e.id DESC
+LIMIT $5 OFFSET $4
+`
+
+type ListLedgerStatementParams struct {
+	AccountID uuid.UUID          `json:"account_id"`
+	FromTime  pgtype.Timestamptz `json:"from_time"`
+	ToTime    pgtype.Timestamptz `json:"to_time"`
+	Off       int32              `json:"off"`
+	Lim       int32              `json:"lim"`
+}
+
+type ListLedgerStatementRow struct {
+	ID            int64              `json:"id"`
+	TransactionID uuid.UUID          `json:"transaction_id"`
+	Kind          string             `json:"kind"`
+	ReferenceID   uuid.UUID          `json:"reference_id"`
+	Description   string             `json:"description"`
+	Direction     string             `json:"direction"`
+	Amount        pgtype.Numeric     `json:"amount"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	BalanceChange pgtype.Numeric     `json:"balance_change"`
+}
+
+// balance_change is the running total of entries since from_time; add the
+// opening balance to get the balance after each entry.
+func (q *Queries) ListLedgerStatement(ctx context.Context, arg ListLedgerStatementParams) ([]ListLedgerStatementRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerStatement,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Off,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLedgerStatementRow{}
+	for rows.Next() {
+		var i ListLedgerStatementRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.Kind,
+			&i.ReferenceID,
+			&i.Description,
+			&i.Direction,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.BalanceChange,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSystemAccountBalances = `-- name: ListSystemAccountBalances :many
+SELECT a.code, a.type,
+    COALESCE(SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END), 0)::numeric AS debit_balance
+FROM ledger_accounts a
+LEFT JOIN ledger_entries e ON e.account_id = a.id
+WHERE a.agent_id IS NULL
+GROUP BY a.id
+ORDER BY a.code
+`
+
+type ListSystemAccountBalancesRow struct {
+	Code         string         `json:"code"`
+	Type         string         `json:"type"`
+	DebitBalance pgtype.Numeric `json:"debit_balance"`
+}
+
+func (q *Queries) ListSystemAccountBalances(ctx context.Context) ([]ListSystemAccountBalancesRow, error) {
+	rows, err := q.db.Query(ctx, listSystemAccountBalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSystemAccountBalancesRow{}
+	for rows.Next() {
+		var i ListSystemAccountBalancesRow
+		if err := rows.Scan(&i.Code, &i.Type, &i.DebitBalance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnbalancedLedgerTransactions = `-- name: ListUnbalancedLedgerTransactions :many
+SELECT t.id, t.kind, t.reference_id,
+    SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END)::numeric AS imbalance
+FROM ledger_transactions t
+LEFT JOIN ledger_entries e ON e.transaction_id = t.id
+GROUP BY t.id
+HAVING COALESCE(SUM(CASE e.direction WHEN 'debit' THEN e.amount ELSE -e.amount END), 0) <> 0
+    OR COUNT(e.id) = 0
+`
+
+type ListUnbalancedLedgerTransactionsRow struct {
+	ID          uuid.UUID      `json:"id"`
+	Kind        string         `json:"kind"`
+	ReferenceID uuid.UUID      `json:"reference_id"`
+	Imbalance   pgtype.Numeric `json:"imbalance"`
+}
+
+func (q *Queries) ListUnbalancedLedgerTransactions(ctx context.Context) ([]ListUnbalancedLedgerTransactionsRow, error) {
+	rows, err := q.db.Query(ctx, listUnbalancedLedgerTransactions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnbalancedLedgerTransactionsRow{}
+	for rows.Next() {
+		var i ListUnbalancedLedgerTransactionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.ReferenceID,
+			&i.Imbalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWalletDrift = `-- name: ListWalletDrift :many
+SELECT a.id AS agent_id, a.full_name,
+    COALESCE(a.wallet_balance, 0)::numeric AS wallet_balance,
+    COALESCE(l.balance, 0)::numeric AS ledger_balance
+FROM agents a
+LEFT JOIN (
+    SELECT la.agent_id,
+        SUM(CASE e.direction WHEN 'credit' THEN e.amount ELSE -e.amount END) AS balance
+    FROM ledger_entries e
+    JOIN ledger_accounts la ON la.id = e.account_id
+    WHERE la.agent_id IS NOT NULL
+    GROUP BY la.agent_id
+) l ON l.agent_id = a.id
+WHERE COALESCE(a.wallet_balance, 0) <> COALESCE(l.balance, 0)
+ORDER BY ABS(COALESCE(a.wallet_balance, 0) - COALESCE(l.balance, 0)) DESC
+`
+
+type ListWalletDriftRow struct {
+	AgentID       uuid.UUID      `json:"agent_id"`
+	FullName      string         `json:"full_name"`
+	WalletBalance pgtype.Numeric `json:"wallet_balance"`
+	LedgerBalance pgtype.Numeric `json:"ledger_balance"`
+}
+
+func (q *Queries) ListWalletDrift(ctx context.Context) ([]ListWalletDriftRow, error) {
+	rows, err := q.db.Query(ctx, listWalletDrift)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWalletDriftRow{}
+	for rows.Next() {
+		var i ListWalletDriftRow
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.FullName,
+			&i.WalletBalance,
+			&i.LedgerBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -65,6 +65,8 @@ type AgentPayout struct {
 	RazorpayPayoutID   *string            `json:"razorpay_payout_id"`
 	FailureReason      *string            `json:"failure_reason"`
 	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Alert struct {
@@ -97,19 +99,121 @@ type ChecklistTemplate struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
+type EventDelivery struct {
+	ID             int64              `json:"id"`
+	EventID        int64              `json:"event_id"`
+	Subscriber     string             `json:"subscriber"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	AvailableAt    time.Time          `json:"available_at"`
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
+	LastError      *string            `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+}
+
+type EventOutbox struct {
+	ID           int64              `json:"id"`
+	EventType    string             `json:"event_type"`
+	Payload      json.RawMessage    `json:"payload"`
+	CreatedAt    time.Time          `json:"created_at"`
+	DispatchedAt pgtype.Timestamptz `json:"dispatched_at"`
+	Version      int32              `json:"version"`
+}
+
+type EventSubscriber struct {
+	EventType    string    `json:"event_type"`
+	Subscriber   string    `json:"subscriber"`
+	RegisteredAt time.Time `json:"registered_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+type Invoice struct {
+	ID            uuid.UUID      `json:"id"`
+	InvoiceNumber string         `json:"invoice_number"`
+	FinancialYear string         `json:"financial_year"`
+	Sequence      int32          `json:"sequence"`
+	TransactionID uuid.UUID      `json:"transaction_id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	PlaceOfSupply string         `json:"place_of_supply"`
+	Interstate    bool           `json:"interstate"`
+	TaxableAmount pgtype.Numeric `json:"taxable_amount"`
+	Cgst          pgtype.Numeric `json:"cgst"`
+	Sgst          pgtype.Numeric `json:"sgst"`
+	Igst          pgtype.Numeric `json:"igst"`
+	TotalAmount   pgtype.Numeric `json:"total_amount"`
+	S3Key         string         `json:"s3_key"`
+	IssuedAt      time.Time      `json:"issued_at"`
+}
+
+type InvoiceSequence struct {
+	FinancialYear string `json:"financial_year"`
+	LastNumber    int32  `json:"last_number"`
+}
+
+type JobAdminAction struct {
+	ID        uuid.UUID          `json:"id"`
+	JobID     uuid.UUID          `json:"job_id"`
+	ActorID   string             `json:"actor_id"`
+	ActorName *string            `json:"actor_name"`
+	Action    string             `json:"action"`
+	Reason    *string            `json:"reason"`
+	Details   json.RawMessage    `json:"details"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type JobOffer struct {
-	ID            uuid.UUID          `json:"id"`
-	JobID         uuid.UUID          `json:"job_id"`
-	AgentID       uuid.UUID          `json:"agent_id"`
-	CascadeRound  int32              `json:"cascade_round"`
-	OfferRank     int32              `json:"offer_rank"`
-	DistanceKm    *float32           `json:"distance_km"`
-	MatchScore    pgtype.Numeric     `json:"match_score"`
-	Status        *string            `json:"status"`
-	SentAt        pgtype.Timestamptz `json:"sent_at"`
-	RespondedAt   pgtype.Timestamptz `json:"responded_at"`
-	ExpiresAt     time.Time          `json:"expires_at"`
-	DeclineReason *string            `json:"decline_reason"`
+	ID              uuid.UUID          `json:"id"`
+	JobID           uuid.UUID          `json:"job_id"`
+	AgentID         uuid.UUID          `json:"agent_id"`
+	CascadeRound    int32              `json:"cascade_round"`
+	OfferRank       int32              `json:"offer_rank"`
+	DistanceKm      *float32           `json:"distance_km"`
+	MatchScore      pgtype.Numeric     `json:"match_score"`
+	Status          *string            `json:"status"`
+	SentAt          pgtype.Timestamptz `json:"sent_at"`
+	RespondedAt     pgtype.Timestamptz `json:"responded_at"`
+	ExpiresAt       time.Time          `json:"expires_at"`
+	DeclineReason   *string            `json:"decline_reason"`
+	ScoringStrategy *string            `json:"scoring_strategy"`
+	ScoringWeights  []byte             `json:"scoring_weights"`
+	PayoutBreakdown []byte             `json:"payout_breakdown"`
+}
+
+type JobStatusHistory struct {
+	ID         uuid.UUID          `json:"id"`
+	JobID      uuid.UUID          `json:"job_id"`
+	FromStatus string             `json:"from_status"`
+	ToStatus   string             `json:"to_status"`
+	ActorRole  string             `json:"actor_role"`
+	ActorID    *string            `json:"actor_id"`
+	Reason     *string            `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type LedgerAccount struct {
+	ID        uuid.UUID          `json:"id"`
+	Code      string             `json:"code"`
+	Type      string             `json:"type"`
+	AgentID   pgtype.UUID        `json:"agent_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type LedgerEntry struct {
+	ID            int64              `json:"id"`
+	TransactionID uuid.UUID          `json:"transaction_id"`
+	AccountID     uuid.UUID          `json:"account_id"`
+	Direction     string             `json:"direction"`
+	Amount        pgtype.Numeric     `json:"amount"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type LedgerTransaction struct {
+	ID          uuid.UUID          `json:"id"`
+	Kind        string             `json:"kind"`
+	ReferenceID uuid.UUID          `json:"reference_id"`
+	Description string             `json:"description"`
+	CreatedBy   string             `json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type Parcel struct {
@@ -135,6 +239,25 @@ type Parcel struct {
 	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 }
 
+type PaymentEvent struct {
+	ID          uuid.UUID          `json:"id"`
+	EventID     string             `json:"event_id"`
+	EventType   string             `json:"event_type"`
+	Payload     json.RawMessage    `json:"payload"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	LastError   *string            `json:"last_error"`
+	ReceivedAt  time.Time          `json:"received_at"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
+}
+
+type PayoutPeriod struct {
+	PeriodStart pgtype.Date        `json:"period_start"`
+	PeriodEnd   pgtype.Date        `json:"period_end"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	SettledAt   pgtype.Timestamptz `json:"settled_at"`
+}
+
 type Report struct {
 	ID          uuid.UUID `json:"id"`
 	ParcelID    uuid.UUID `json:"parcel_id"`
@@ -174,39 +297,49 @@ type Subscription struct {
 	OnDemandVisitsRemaining *int32             `json:"on_demand_visits_remaining"`
 	CreatedAt               pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt               pgtype.Timestamptz `json:"updated_at"`
+	CreditBalance           pgtype.Numeric     `json:"credit_balance"`
+	PausedAt                pgtype.Timestamptz `json:"paused_at"`
+	CancelAtPeriodEnd       *bool              `json:"cancel_at_period_end"`
+	CancelledAt             pgtype.Timestamptz `json:"cancelled_at"`
+	RenewalEnqueuedAt       pgtype.Timestamptz `json:"renewal_enqueued_at"`
 }
 
 type SurveyJob struct {
-	ID                uuid.UUID          `json:"id"`
-	ParcelID          uuid.UUID          `json:"parcel_id"`
-	SubscriptionID    pgtype.UUID        `json:"subscription_id"`
-	UserID            uuid.UUID          `json:"user_id"`
-	SurveyType        string             `json:"survey_type"`
-	Priority          *string            `json:"priority"`
-	Deadline          time.Time          `json:"deadline"`
-	Trigger           *string            `json:"trigger"`
-	Status            *string            `json:"status"`
-	AssignedAgentID   pgtype.UUID        `json:"assigned_agent_id"`
-	AssignedAt        pgtype.Timestamptz `json:"assigned_at"`
-	CascadeRound      *int32             `json:"cascade_round"`
-	TotalOffersSent   *int32             `json:"total_offers_sent"`
-	AgentArrivedAt    pgtype.Timestamptz `json:"agent_arrived_at"`
-	SurveyStartedAt   pgtype.Timestamptz `json:"survey_started_at"`
-	SurveySubmittedAt pgtype.Timestamptz `json:"survey_submitted_at"`
-	CompletedAt       pgtype.Timestamptz `json:"completed_at"`
-	ArrivalLocation   interface{}        `json:"arrival_location"`
-	ArrivalDistanceM  *float32           `json:"arrival_distance_m"`
-	BasePayout        pgtype.Numeric     `json:"base_payout"`
-	DistanceBonus     pgtype.Numeric     `json:"distance_bonus"`
-	UrgencyBonus      pgtype.Numeric     `json:"urgency_bonus"`
-	TotalPayout       pgtype.Numeric     `json:"total_payout"`
-	PayoutStatus      *string            `json:"payout_status"`
-	LandownerRating   pgtype.Numeric     `json:"landowner_rating"`
-	QaScore           pgtype.Numeric     `json:"qa_score"`
-	QaStatus          *string            `json:"qa_status"`
-	QaNotes           *string            `json:"qa_notes"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	ID                 uuid.UUID          `json:"id"`
+	ParcelID           uuid.UUID          `json:"parcel_id"`
+	SubscriptionID     pgtype.UUID        `json:"subscription_id"`
+	UserID             uuid.UUID          `json:"user_id"`
+	SurveyType         string             `json:"survey_type"`
+	Priority           *string            `json:"priority"`
+	Deadline           time.Time          `json:"deadline"`
+	Trigger            *string            `json:"trigger"`
+	Status             *string            `json:"status"`
+	AssignedAgentID    pgtype.UUID        `json:"assigned_agent_id"`
+	AssignedAt         pgtype.Timestamptz `json:"assigned_at"`
+	CascadeRound       *int32             `json:"cascade_round"`
+	TotalOffersSent    *int32             `json:"total_offers_sent"`
+	AgentArrivedAt     pgtype.Timestamptz `json:"agent_arrived_at"`
+	SurveyStartedAt    pgtype.Timestamptz `json:"survey_started_at"`
+	SurveySubmittedAt  pgtype.Timestamptz `json:"survey_submitted_at"`
+	CompletedAt        pgtype.Timestamptz `json:"completed_at"`
+	ArrivalLocation    interface{}        `json:"arrival_location"`
+	ArrivalDistanceM   *float32           `json:"arrival_distance_m"`
+	BasePayout         pgtype.Numeric     `json:"base_payout"`
+	DistanceBonus      pgtype.Numeric     `json:"distance_bonus"`
+	UrgencyBonus       pgtype.Numeric     `json:"urgency_bonus"`
+	TotalPayout        pgtype.Numeric     `json:"total_payout"`
+	PayoutStatus       *string            `json:"payout_status"`
+	LandownerRating    pgtype.Numeric     `json:"landowner_rating"`
+	QaScore            pgtype.Numeric     `json:"qa_score"`
+	QaStatus           *string            `json:"qa_status"`
+	QaNotes            *string            `json:"qa_notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	DispatchRadiusKm   *float32           `json:"dispatch_radius_km"`
+	DispatchRoundLimit *int32             `json:"dispatch_round_limit"`
+	IdempotencyKey     *string            `json:"idempotency_key"`
+	SurgeBonus         pgtype.Numeric     `json:"surge_bonus"`
+	PayoutID           pgtype.UUID        `json:"payout_id"`
 }
 
 type SurveyMedium struct {
@@ -241,19 +374,20 @@ type SurveyResponse struct {
 }
 
 type TaskQueue struct {
-	ID           int64              `json:"id"`
-	TaskType     string             `json:"task_type"`
-	Payload      json.RawMessage    `json:"payload"`
-	Status       *string            `json:"status"`
-	Priority     *int32             `json:"priority"`
-	Attempts     *int32             `json:"attempts"`
-	MaxAttempts  *int32             `json:"max_attempts"`
-	LastError    *string            `json:"last_error"`
-	ErrorMessage *string            `json:"error_message"`
-	ScheduledAt  pgtype.Timestamptz `json:"scheduled_at"`
-	StartedAt    pgtype.Timestamptz `json:"started_at"`
-	CompletedAt  pgtype.Timestamptz `json:"completed_at"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ID             int64              `json:"id"`
+	TaskType       string             `json:"task_type"`
+	Payload        json.RawMessage    `json:"payload"`
+	Status         *string            `json:"status"`
+	Priority       *int32             `json:"priority"`
+	Attempts       *int32             `json:"attempts"`
+	MaxAttempts    *int32             `json:"max_attempts"`
+	LastError      *string            `json:"last_error"`
+	ErrorMessage   *string            `json:"error_message"`
+	ScheduledAt    pgtype.Timestamptz `json:"scheduled_at"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
 }
 
 type Transaction struct {
@@ -266,6 +400,7 @@ type Transaction struct {
 	RazorpayPaymentID *string            `json:"razorpay_payment_id"`
 	RazorpayOrderID   *string            `json:"razorpay_order_id"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	JobID             pgtype.UUID        `json:"job_id"`
 }
 
 type User struct {
@@ -286,3 +421,33 @@ type User struct {
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 }
+
+type WebhookDelivery struct {
+	ID             uuid.UUID          `json:"id"`
+	SubscriptionID uuid.UUID          `json:"subscription_id"`
+	EventType      string             `json:"event_type"`
+	EventVersion   int32              `json:"event_version"`
+	Payload        json.RawMessage    `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	ResponseStatus *int32             `json:"response_status"`
+	ResponseBody   *string            `json:"response_body"`
+	LastError      *string            `json:"last_error"`
+	DurationMs     *int32             `json:"duration_ms"`
+	CreatedAt      time.Time          `json:"created_at"`
+	LastAttemptAt  pgtype.Timestamptz `json:"last_attempt_at"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	SourceEventID  *string            `json:"source_event_id"`
+}
+
+type WebhookSubscription struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Url         string    `json:"url"`
+	Secret      string    `json:"secret"`
+	EventTypes  []string  `json:"event_types"`
+	Description *string   `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
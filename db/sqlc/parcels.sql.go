@@ -100,43 +100,63 @@ func (q *Queries) DeleteParcel(ctx context.Context, id uuid.UUID) error {
 }
 
 const findParcelsNeedingSurvey = `-- name: FindParcelsNeedingSurvey :many
-SELECT p.id, p.user_id, p.label, p.survey_number, p.village, p.taluk, p.district, p.state, p.state_code, p.pin_code, p.boundary, p.centroid, p.area_sqm, p.land_type, p.registered_area_sqm, p.title_deed_s3_key, p.status, p.monitoring_since, p.created_at, p.updated_at FROM parcels p
-LEFT JOIN survey_jobs sj ON sj.parcel_id = p.id AND sj.status NOT IN ('completed', 'cancelled')
-WHERE p.status = 'active' AND sj.id IS NULL
-ORDER BY p.monitoring_since ASC
-LIMIT $1
+SELECT p.id, p.user_id,
+    s.id AS subscription_id, s.plan, s.visits_used_this_period,
+    lv.survey_submitted_at AS last_surveyed_at,
+    (SELECT count(*) FROM survey_jobs j WHERE j.parcel_id = p.id AND j.status <> 'cancelled') AS job_count
+FROM parcels p
+LEFT JOIN subscriptions s ON s.parcel_id = p.id AND s.status = 'active'
+LEFT JOIN LATERAL (
+    SELECT j.survey_submitted_at FROM survey_jobs j
+    WHERE j.parcel_id = p.id AND j.status = 'completed'
+    ORDER BY j.survey_submitted_at DESC NULLS LAST
+    LIMIT 1
+) lv ON true
+WHERE p.status = 'active'
+    AND p.id > $1
+    AND NOT EXISTS (
+        SELECT 1 FROM survey_jobs sj
+        WHERE sj.parcel_id = p.id AND sj.status NOT IN ('completed', 'cancelled')
+    )
+ORDER BY p.id
+LIMIT $2
 `
 
-func (q *Queries) FindParcelsNeedingSurvey(ctx context.Context, limit int32) ([]Parcel, error) {
-	rows, err := q.db.Query(ctx, findParcelsNeedingSurvey, limit)
+type FindParcelsNeedingSurveyParams struct {
+	After uuid.UUID `json:"after"`
+	Lim   int32     `json:"lim"`
+}
+
+type FindParcelsNeedingSurveyRow struct {
+	ID                   uuid.UUID          `json:"id"`
+	UserID               uuid.UUID          `json:"user_id"`
+	SubscriptionID       pgtype.UUID        `json:"subscription_id"`
+	Plan                 *string            `json:"plan"`
+	VisitsUsedThisPeriod *int32             `json:"visits_used_this_period"`
+	LastSurveyedAt       pgtype.Timestamptz `json:"last_surveyed_at"`
+	JobCount             int64              `json:"job_count"`
+}
+
+// Active parcels with no open survey job, paged by ID, with their active
+// subscription (if any), when they were last surveyed and how many jobs they
+// have ever had.
+func (q *Queries) FindParcelsNeedingSurvey(ctx context.Context, arg FindParcelsNeedingSurveyParams) ([]FindParcelsNeedingSurveyRow, error) {
+	rows, err := q.db.Query(ctx, findParcelsNeedingSurvey, arg.After, arg.Lim)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Parcel{}
+	items := []FindParcelsNeedingSurveyRow{}
 	for rows.Next() {
-		var i Parcel
+		var i FindParcelsNeedingSurveyRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.Label,
-			&i.SurveyNumber,
-			&i.Village,
-			&i.Taluk,
-			&i.District,
-			&i.State,
-			&i.StateCode,
-			&i.PinCode,
-			&i.Boundary,
-			&i.Centroid,
-			&i.AreaSqm,
-			&i.LandType,
-			&i.RegisteredAreaSqm,
-			&i.TitleDeedS3Key,
-			&i.Status,
-			&i.MonitoringSince,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.SubscriptionID,
+			&i.Plan,
+			&i.VisitsUsedThisPeriod,
+			&i.LastSurveyedAt,
+			&i.JobCount,
 		); err != nil {
 			return nil, err
 		}
@@ -180,6 +200,38 @@ func (q *Queries) GetParcelByID(ctx context.Context, id uuid.UUID) (Parcel, erro
 	return i, err
 }
 
+const getParcelForUpdate = `-- name: GetParcelForUpdate :one
+SELECT id, user_id, label, survey_number, village, taluk, district, state, state_code, pin_code, boundary, centroid, area_sqm, land_type, registered_area_sqm, title_deed_s3_key, status, monitoring_since, created_at, updated_at FROM parcels WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetParcelForUpdate(ctx context.Context, id uuid.UUID) (Parcel, error) {
+	row := q.db.QueryRow(ctx, getParcelForUpdate, id)
+	var i Parcel
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Label,
+		&i.SurveyNumber,
+		&i.Village,
+		&i.Taluk,
+		&i.District,
+		&i.State,
+		&i.StateCode,
+		&i.PinCode,
+		&i.Boundary,
+		&i.Centroid,
+		&i.AreaSqm,
+		&i.LandType,
+		&i.RegisteredAreaSqm,
+		&i.TitleDeedS3Key,
+		&i.Status,
+		&i.MonitoringSince,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getParcelWithGeoJSON = `-- name: GetParcelWithGeoJSON :one
 SELECT id, user_id, label, survey_number, village, taluk, district, state, state_code, pin_code,
     ST_AsGeoJSON(boundary) AS boundary_geojson, centroid, area_sqm, land_type,
@@ -0,0 +1,182 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: risk.sql
+
+package sqlc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countNearbyRiskIssues = `-- name: CountNearbyRiskIssues :one
+WITH nearby AS (
+    SELECT DISTINCT ON (rs.parcel_id) rs.encroachment_score
+    FROM parcels p
+    JOIN parcels o ON o.id <> p.id
+        AND o.status = 'active'
+        AND ST_DWithin(o.centroid::geography, p.centroid::geography, $1::float8)
+    JOIN risk_scores rs ON rs.parcel_id = o.id
+    WHERE p.id = $2
+    ORDER BY rs.parcel_id, rs.computed_at DESC
+)
+SELECT
+    count(*)::int AS scored,
+    (count(*) FILTER (WHERE encroachment_score > 0))::int AS with_issues
+FROM nearby
+`
+
+type CountNearbyRiskIssuesParams struct {
+	Radius   float64   `json:"radius"`
+	ParcelID uuid.UUID `json:"parcel_id"`
+}
+
+type CountNearbyRiskIssuesRow struct {
+	Scored     int32 `json:"scored"`
+	WithIssues int32 `json:"with_issues"`
+}
+
+// Counts the scored parcels within radius meters of a parcel and how many
+// of them had encroachment findings in their latest score.
+func (q *Queries) CountNearbyRiskIssues(ctx context.Context, arg CountNearbyRiskIssuesParams) (CountNearbyRiskIssuesRow, error) {
+	row := q.db.QueryRow(ctx, countNearbyRiskIssues, arg.Radius, arg.ParcelID)
+	var i CountNearbyRiskIssuesRow
+	err := row.Scan(&i.Scored, &i.WithIssues)
+	return i, err
+}
+
+const countRiskScores = `-- name: CountRiskScores :one
+SELECT count(*) FROM risk_scores WHERE parcel_id = $1
+`
+
+func (q *Queries) CountRiskScores(ctx context.Context, parcelID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRiskScores, parcelID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRiskScore = `-- name: CreateRiskScore :one
+INSERT INTO risk_scores (
+    parcel_id, job_id, overall_score, risk_level,
+    encroachment_score, boundary_score, environmental_score, neighborhood_score,
+    contributing_factors
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (job_id) WHERE job_id IS NOT NULL DO NOTHING
+RETURNING id, parcel_id, job_id, overall_score, risk_level, encroachment_score, boundary_score, environmental_score, neighborhood_score, contributing_factors, computed_at
+`
+
+type CreateRiskScoreParams struct {
+	ParcelID            uuid.UUID      `json:"parcel_id"`
+	JobID               pgtype.UUID    `json:"job_id"`
+	OverallScore        pgtype.Numeric `json:"overall_score"`
+	RiskLevel           string         `json:"risk_level"`
+	EncroachmentScore   pgtype.Numeric `json:"encroachment_score"`
+	BoundaryScore       pgtype.Numeric `json:"boundary_score"`
+	EnvironmentalScore  pgtype.Numeric `json:"environmental_score"`
+	NeighborhoodScore   pgtype.Numeric `json:"neighborhood_score"`
+	ContributingFactors []byte         `json:"contributing_factors"`
+}
+
+func (q *Queries) CreateRiskScore(ctx context.Context, arg CreateRiskScoreParams) (RiskScore, error) {
+	row := q.db.QueryRow(ctx, createRiskScore,
+		arg.ParcelID,
+		arg.JobID,
+		arg.OverallScore,
+		arg.RiskLevel,
+		arg.EncroachmentScore,
+		arg.BoundaryScore,
+		arg.EnvironmentalScore,
+		arg.NeighborhoodScore,
+		arg.ContributingFactors,
+	)
+	var i RiskScore
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.JobID,
+		&i.OverallScore,
+		&i.RiskLevel,
+		&i.EncroachmentScore,
+		&i.BoundaryScore,
+		&i.EnvironmentalScore,
+		&i.NeighborhoodScore,
+		&i.ContributingFactors,
+		&i.ComputedAt,
+	)
+	return i, err
+}
+
+const getLatestRiskScore = `-- name: GetLatestRiskScore :one
+SELECT id, parcel_id, job_id, overall_score, risk_level, encroachment_score, boundary_score, environmental_score, neighborhood_score, contributing_factors, computed_at FROM risk_scores
+WHERE parcel_id = $1
+ORDER BY computed_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestRiskScore(ctx context.Context, parcelID uuid.UUID) (RiskScore, error) {
+	row := q.db.QueryRow(ctx, getLatestRiskScore, parcelID)
+	var i RiskScore
+	err := row.Scan(
+		&i.ID,
+		&i.ParcelID,
+		&i.JobID,
+		&i.OverallScore,
+		&i.RiskLevel,
+		&i.EncroachmentScore,
+		&i.BoundaryScore,
+		&i.EnvironmentalScore,
+		&i.NeighborhoodScore,
+		&i.ContributingFactors,
+		&i.ComputedAt,
+	)
+	return i, err
+}
+
+const listRiskScores = `-- name: ListRiskScores :many
+SELECT id, parcel_id, job_id, overall_score, risk_level, encroachment_score, boundary_score, environmental_score, neighborhood_score, contributing_factors, computed_at FROM risk_scores
+WHERE parcel_id = $1
+ORDER BY computed_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListRiskScoresParams struct {
+	ParcelID uuid.UUID `json:"parcel_id"`
+	Limit    int32     `json:"limit"`
+	Offset   int32     `json:"offset"`
+}
+
+func (q *Queries) ListRiskScores(ctx context.Context, arg ListRiskScoresParams) ([]RiskScore, error) {
+	rows, err := q.db.Query(ctx, listRiskScores, arg.ParcelID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RiskScore{}
+	for rows.Next() {
+		var i RiskScore
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParcelID,
+			&i.JobID,
+			&i.OverallScore,
+			&i.RiskLevel,
+			&i.EncroachmentScore,
+			&i.BoundaryScore,
+			&i.EnvironmentalScore,
+			&i.NeighborhoodScore,
+			&i.ContributingFactors,
+			&i.ComputedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
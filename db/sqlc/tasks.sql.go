@@ -8,11 +8,42 @@ package sqlc
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelTask = `-- name: CancelTask :one
+UPDATE task_queue SET status = 'cancelled', completed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at
+`
+
+func (q *Queries) CancelTask(ctx context.Context, id int64) (TaskQueue, error) {
+	row := q.db.QueryRow(ctx, cancelTask, id)
+	var i TaskQueue
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Status,
+		&i.Priority,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.ErrorMessage,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
+
 const claimTask = `-- name: ClaimTask :one
 UPDATE task_queue
-SET status = 'processing', started_at = NOW(), attempts = attempts + 1
+SET status = 'processing', started_at = NOW(), attempts = attempts + 1,
+    lease_expires_at = $1
 WHERE id = (
     SELECT id FROM task_queue
     WHERE status = 'pending' AND scheduled_at <= NOW()
@@ -20,11 +51,11 @@ WHERE id = (
     LIMIT 1
     FOR UPDATE SKIP LOCKED
 )
-RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at
+RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at
 `
 
-func (q *Queries) ClaimTask(ctx context.Context) (TaskQueue, error) {
-	row := q.db.QueryRow(ctx, claimTask)
+func (q *Queries) ClaimTask(ctx context.Context, leaseExpiresAt pgtype.Timestamptz) (TaskQueue, error) {
+	row := q.db.QueryRow(ctx, claimTask, leaseExpiresAt)
 	var i TaskQueue
 	err := row.Scan(
 		&i.ID,
@@ -40,17 +71,27 @@ func (q *Queries) ClaimTask(ctx context.Context) (TaskQueue, error) {
 		&i.StartedAt,
 		&i.CompletedAt,
 		&i.CreatedAt,
+		&i.LeaseExpiresAt,
 	)
 	return i, err
 }
 
-const completeTask = `-- name: CompleteTask :exec
-UPDATE task_queue SET status = 'completed', completed_at = NOW() WHERE id = $1
+const completeTask = `-- name: CompleteTask :execrows
+UPDATE task_queue SET status = 'completed', completed_at = NOW(), lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
 `
 
-func (q *Queries) CompleteTask(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, completeTask, id)
-	return err
+type CompleteTaskParams struct {
+	ID       int64  `json:"id"`
+	Attempts *int32 `json:"attempts"`
+}
+
+func (q *Queries) CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeTask, arg.ID, arg.Attempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const countPendingTasks = `-- name: CountPendingTasks :one
@@ -64,17 +105,36 @@ func (q *Queries) CountPendingTasks(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countTasks = `-- name: CountTasks :one
+SELECT count(*) FROM task_queue
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::text IS NULL OR task_type = $2)
+`
+
+type CountTasksParams struct {
+	Status   *string `json:"status"`
+	TaskType *string `json:"task_type"`
+}
+
+func (q *Queries) CountTasks(ctx context.Context, arg CountTasksParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTasks, arg.Status, arg.TaskType)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const enqueueTask = `-- name: EnqueueTask :one
-INSERT INTO task_queue (task_type, payload, priority, scheduled_at)
-VALUES ($1, $2, $3, COALESCE($4, NOW()))
-RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at
+INSERT INTO task_queue (task_type, payload, priority, max_attempts, scheduled_at)
+VALUES ($1, $2, $3, $4, COALESCE($5::timestamptz, NOW()))
+RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at
 `
 
 type EnqueueTaskParams struct {
-	TaskType    string          `json:"task_type"`
-	Payload     json.RawMessage `json:"payload"`
-	Priority    *int32          `json:"priority"`
-	ScheduledAt interface{}     `json:"scheduled_at"`
+	TaskType    string             `json:"task_type"`
+	Payload     json.RawMessage    `json:"payload"`
+	Priority    *int32             `json:"priority"`
+	MaxAttempts *int32             `json:"max_attempts"`
+	ScheduledAt pgtype.Timestamptz `json:"scheduled_at"`
 }
 
 func (q *Queries) EnqueueTask(ctx context.Context, arg EnqueueTaskParams) (TaskQueue, error) {
@@ -82,6 +142,7 @@ func (q *Queries) EnqueueTask(ctx context.Context, arg EnqueueTaskParams) (TaskQ
 		arg.TaskType,
 		arg.Payload,
 		arg.Priority,
+		arg.MaxAttempts,
 		arg.ScheduledAt,
 	)
 	var i TaskQueue
@@ -99,25 +160,302 @@ func (q *Queries) EnqueueTask(ctx context.Context, arg EnqueueTaskParams) (TaskQ
 		&i.StartedAt,
 		&i.CompletedAt,
 		&i.CreatedAt,
+		&i.LeaseExpiresAt,
 	)
 	return i, err
 }
 
-const failTask = `-- name: FailTask :exec
+const extendTaskLease = `-- name: ExtendTaskLease :exec
+UPDATE task_queue SET lease_expires_at = $3
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+`
+
+type ExtendTaskLeaseParams struct {
+	ID             int64              `json:"id"`
+	Attempts       *int32             `json:"attempts"`
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
+}
+
+// Like CompleteTask and FailTask, guarded by attempts so a worker whose lease
+// expired cannot touch a task that has since been requeued or claimed again.
+func (q *Queries) ExtendTaskLease(ctx context.Context, arg ExtendTaskLeaseParams) error {
+	_, err := q.db.Exec(ctx, extendTaskLease, arg.ID, arg.Attempts, arg.LeaseExpiresAt)
+	return err
+}
+
+const failTask = `-- name: FailTask :one
 UPDATE task_queue SET
     status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
-    last_error = $2,
-    error_message = $2,
-    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END
-WHERE id = $1
+    last_error = $3,
+    error_message = $3,
+    scheduled_at = CASE WHEN attempts >= max_attempts THEN scheduled_at ELSE $4 END,
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+    lease_expires_at = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'processing'
+RETURNING status
 `
 
 type FailTaskParams struct {
-	ID        int64   `json:"id"`
-	LastError *string `json:"last_error"`
+	ID        int64              `json:"id"`
+	Attempts  *int32             `json:"attempts"`
+	LastError *string            `json:"last_error"`
+	RetryAt   pgtype.Timestamptz `json:"retry_at"`
 }
 
-func (q *Queries) FailTask(ctx context.Context, arg FailTaskParams) error {
-	_, err := q.db.Exec(ctx, failTask, arg.ID, arg.LastError)
+// A task with attempts left goes back to pending, to be claimed again at
+// retry_at; one that has used them all is dead.
+func (q *Queries) FailTask(ctx context.Context, arg FailTaskParams) (*string, error) {
+	row := q.db.QueryRow(ctx, failTask,
+		arg.ID,
+		arg.Attempts,
+		arg.LastError,
+		arg.RetryAt,
+	)
+	var status *string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const getTask = `-- name: GetTask :one
+SELECT id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at FROM task_queue WHERE id = $1
+`
+
+func (q *Queries) GetTask(ctx context.Context, id int64) (TaskQueue, error) {
+	row := q.db.QueryRow(ctx, getTask, id)
+	var i TaskQueue
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Status,
+		&i.Priority,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.ErrorMessage,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
+
+const getTaskStats = `-- name: GetTaskStats :many
+SELECT
+    task_type,
+    count(*) FILTER (WHERE status = 'pending')::bigint AS pending,
+    count(*) FILTER (WHERE status = 'pending' AND scheduled_at <= NOW())::bigint AS due,
+    count(*) FILTER (WHERE status = 'processing')::bigint AS processing,
+    count(*) FILTER (WHERE status = 'dead')::bigint AS dead,
+    COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(scheduled_at) FILTER (WHERE status = 'pending' AND scheduled_at <= NOW())), 0)::float8 AS oldest_due_seconds,
+    count(*) FILTER (WHERE status = 'completed' AND completed_at >= $1)::bigint AS completed_since,
+    count(*) FILTER (WHERE status = 'dead' AND completed_at >= $1)::bigint AS dead_since,
+    COALESCE(SUM(attempts) FILTER (WHERE status IN ('completed', 'dead') AND completed_at >= $1), 0)::bigint AS attempts_since
+FROM task_queue
+GROUP BY task_type
+ORDER BY task_type
+`
+
+type GetTaskStatsRow struct {
+	TaskType         string  `json:"task_type"`
+	Pending          int64   `json:"pending"`
+	Due              int64   `json:"due"`
+	Processing       int64   `json:"processing"`
+	Dead             int64   `json:"dead"`
+	OldestDueSeconds float64 `json:"oldest_due_seconds"`
+	CompletedSince   int64   `json:"completed_since"`
+	DeadSince        int64   `json:"dead_since"`
+	AttemptsSince    int64   `json:"attempts_since"`
+}
+
+// Per task type: the current backlog, and the tasks that finished since a
+// given time.
+func (q *Queries) GetTaskStats(ctx context.Context, since pgtype.Timestamptz) ([]GetTaskStatsRow, error) {
+	rows, err := q.db.Query(ctx, getTaskStats, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTaskStatsRow{}
+	for rows.Next() {
+		var i GetTaskStatsRow
+		if err := rows.Scan(
+			&i.TaskType,
+			&i.Pending,
+			&i.Due,
+			&i.Processing,
+			&i.Dead,
+			&i.OldestDueSeconds,
+			&i.CompletedSince,
+			&i.DeadSince,
+			&i.AttemptsSince,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTasks = `-- name: ListTasks :many
+SELECT id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at FROM task_queue
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::text IS NULL OR task_type = $2)
+ORDER BY created_at DESC, id DESC
+LIMIT $4 OFFSET $3
+`
+
+type ListTasksParams struct {
+	Status   *string `json:"status"`
+	TaskType *string `json:"task_type"`
+	Off      int32   `json:"off"`
+	Lim      int32   `json:"lim"`
+}
+
+func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]TaskQueue, error) {
+	rows, err := q.db.Query(ctx, listTasks,
+		arg.Status,
+		arg.TaskType,
+		arg.Off,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TaskQueue{}
+	for rows.Next() {
+		var i TaskQueue
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskType,
+			&i.Payload,
+			&i.Status,
+			&i.Priority,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.LastError,
+			&i.ErrorMessage,
+			&i.ScheduledAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.LeaseExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyTask = `-- name: NotifyTask :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyTaskParams struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+}
+
+func (q *Queries) NotifyTask(ctx context.Context, arg NotifyTaskParams) error {
+	_, err := q.db.Exec(ctx, notifyTask, arg.Channel, arg.Payload)
 	return err
 }
+
+const purgeCompletedTasks = `-- name: PurgeCompletedTasks :execrows
+DELETE FROM task_queue WHERE status = 'completed' AND completed_at < $1
+`
+
+func (q *Queries) PurgeCompletedTasks(ctx context.Context, completedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeCompletedTasks, completedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reapExpiredTasks = `-- name: ReapExpiredTasks :many
+UPDATE task_queue SET
+    status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+    last_error = 'lease expired while processing',
+    error_message = 'lease expired while processing',
+    scheduled_at = NOW(),
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+    lease_expires_at = NULL
+WHERE status = 'processing' AND lease_expires_at < NOW()
+RETURNING id, task_type, status, attempts
+`
+
+type ReapExpiredTasksRow struct {
+	ID       int64   `json:"id"`
+	TaskType string  `json:"task_type"`
+	Status   *string `json:"status"`
+	Attempts *int32  `json:"attempts"`
+}
+
+// Requeues tasks whose worker lost its lease. The lost attempt counts towards
+// max_attempts so a task that crashes its worker every time ends up dead.
+func (q *Queries) ReapExpiredTasks(ctx context.Context) ([]ReapExpiredTasksRow, error) {
+	rows, err := q.db.Query(ctx, reapExpiredTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReapExpiredTasksRow{}
+	for rows.Next() {
+		var i ReapExpiredTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskType,
+			&i.Status,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryDeadTask = `-- name: RetryDeadTask :one
+UPDATE task_queue
+SET status = 'pending', attempts = 0, scheduled_at = NOW(), started_at = NULL, completed_at = NULL
+WHERE id = $1 AND status = 'dead'
+RETURNING id, task_type, payload, status, priority, attempts, max_attempts, last_error, error_message, scheduled_at, started_at, completed_at, created_at, lease_expires_at
+`
+
+// Gives a dead task a fresh set of attempts. last_error is kept until the
+// task next fails.
+func (q *Queries) RetryDeadTask(ctx context.Context, id int64) (TaskQueue, error) {
+	row := q.db.QueryRow(ctx, retryDeadTask, id)
+	var i TaskQueue
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Status,
+		&i.Priority,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.ErrorMessage,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
@@ -156,6 +156,49 @@ func (q *Queries) GetUserByPhone(ctx context.Context, phone string) (User, error
 	return i, err
 }
 
+const listActiveUsersByRoles = `-- name: ListActiveUsersByRoles :many
+SELECT id, phone, email, full_name, role, avatar_url, state_code, district_code, city, status, phone_verified, language, notification_prefs, keycloak_id, created_at, updated_at FROM users
+WHERE role = ANY($1::text[]) AND status = 'active'
+ORDER BY created_at
+`
+
+func (q *Queries) ListActiveUsersByRoles(ctx context.Context, dollar_1 []string) ([]User, error) {
+	rows, err := q.db.Query(ctx, listActiveUsersByRoles, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Phone,
+			&i.Email,
+			&i.FullName,
+			&i.Role,
+			&i.AvatarUrl,
+			&i.StateCode,
+			&i.DistrictCode,
+			&i.City,
+			&i.Status,
+			&i.PhoneVerified,
+			&i.Language,
+			&i.NotificationPrefs,
+			&i.KeycloakID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
 SELECT id, phone, email, full_name, role, avatar_url, state_code, district_code, city, status, phone_verified, language, notification_prefs, keycloak_id, created_at, updated_at FROM users
 ORDER BY created_at DESC
@@ -0,0 +1,157 @@
+package billing
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/agent"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// maxWebhookBody caps the size of payout provider webhook bodies.
+const maxWebhookBody = 1 << 20
+
+// Handler handles billing HTTP endpoints.
+type Handler struct {
+	repo      *Repository
+	agentRepo *agent.Repository
+	provider  PayoutProvider
+	logger    *slog.Logger
+}
+
+// NewHandler creates a billing handler.
+func NewHandler(repo *Repository, agentRepo *agent.Repository, provider PayoutProvider, logger *slog.Logger) *Handler {
+	return &Handler{
+		repo:      repo,
+		agentRepo: agentRepo,
+		provider:  provider,
+		logger:    logger,
+	}
+}
+
+// ListMyPayouts handles GET /v1/agents/me/payouts.
+func (h *Handler) ListMyPayouts(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	ag, err := h.agentRepo.GetAgentByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	payouts, total, err := h.repo.ListPayoutsByAgent(r.Context(), ag.ID, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]PayoutResponse, len(payouts))
+	for i, p := range payouts {
+		result[i] = payoutResponse(p)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// Earnings handles GET /v1/agents/me/earnings.
+func (h *Handler) Earnings(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	ag, err := h.agentRepo.GetAgentByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	summary, err := h.repo.GetEarningsSummary(r.Context(), ag.ID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, summary)
+}
+
+// PayoutWebhook handles POST /webhooks/payouts. The provider authenticates
+// with a body signature rather than a JWT.
+func (h *Handler) PayoutWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("reading webhook body"))
+		return
+	}
+
+	update, err := h.provider.ParseWebhook(r.Header, body)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if update == nil {
+		platform.JSON(w, http.StatusOK, map[string]string{"message": "ignored"})
+		return
+	}
+
+	payout, err := h.repo.ApplyPayoutUpdate(r.Context(), *update)
+	if err != nil {
+		if appErr, ok := platform.AsAppError(err); ok && appErr.Status == http.StatusNotFound {
+			// Not ours, or sent before we recorded it; a retry won't help
+			h.logger.Warn("payout webhook for unknown payout", "provider_id", update.ProviderID)
+			platform.JSON(w, http.StatusOK, map[string]string{"message": "ignored"})
+			return
+		}
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("payout updated", "payout_id", payout.ID, "status", update.Status)
+	platform.JSON(w, http.StatusOK, map[string]string{"message": "processed"})
+}
+
+// payoutResponse converts a payout row to its API representation.
+func payoutResponse(p sqlc.AgentPayout) PayoutResponse {
+	resp := PayoutResponse{
+		ID:                 p.ID,
+		PeriodStart:        p.PeriodStart.Time.Format(time.DateOnly),
+		PeriodEnd:          p.PeriodEnd.Time.Format(time.DateOnly),
+		GrossAmount:        numericToFloat64(p.GrossAmount),
+		PlatformCommission: numericToFloat64(p.PlatformCommission),
+		TDSDeducted:        numericToFloat64(p.TdsDeducted),
+		NetAmount:          numericToFloat64(p.NetAmount),
+		Status:             PayoutPending,
+		FailureReason:      p.FailureReason,
+		CreatedAt:          p.CreatedAt.Time,
+	}
+	if p.TotalJobs != nil {
+		resp.TotalJobs = *p.TotalJobs
+	}
+	if p.Status != nil {
+		resp.Status = *p.Status
+	}
+	if p.PaidAt.Valid {
+		resp.PaidAt = &p.PaidAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,56 @@
+package billing
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func billingRouter() chi.Router {
+	h := &Handler{provider: NewMockPayoutProvider(slog.Default()), logger: slog.Default()}
+	r := chi.NewRouter()
+	r.Get("/agents/me/payouts", h.ListMyPayouts)
+	r.Get("/agents/me/earnings", h.Earnings)
+	r.Post("/webhooks/payouts", h.PayoutWebhook)
+	return r
+}
+
+func TestAgentPayoutEndpoints_NoAuth(t *testing.T) {
+	for _, path := range []string{"/agents/me/payouts", "/agents/me/earnings"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			billingRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestPayoutWebhook_InvalidBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed JSON", `{"payout_id":`},
+		{"unknown status", `{"payout_id": "mock_pout_1", "status": "lost"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/payouts", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			billingRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
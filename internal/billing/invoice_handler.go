@@ -0,0 +1,120 @@
+package billing
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// InvoiceHandler handles landowner invoice endpoints.
+type InvoiceHandler struct {
+	repo     *Repository
+	invoicer *Invoicer
+	authRepo *auth.Repository
+	logger   *slog.Logger
+}
+
+// NewInvoiceHandler creates an invoice handler.
+func NewInvoiceHandler(repo *Repository, invoicer *Invoicer, authRepo *auth.Repository, logger *slog.Logger) *InvoiceHandler {
+	return &InvoiceHandler{
+		repo:     repo,
+		invoicer: invoicer,
+		authRepo: authRepo,
+		logger:   logger,
+	}
+}
+
+// List handles GET /v1/billing/invoices.
+func (h *InvoiceHandler) List(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	invoices, total, err := h.repo.ListInvoices(r.Context(), user.ID, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]InvoiceResponse, len(invoices))
+	for i, inv := range invoices {
+		result[i] = invoiceResponse(inv)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// Download handles GET /v1/billing/invoices/{id}/download.
+func (h *InvoiceHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid invoice ID"))
+		return
+	}
+
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	inv, err := h.repo.GetInvoice(r.Context(), user.ID, id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	url, err := h.invoicer.DownloadURL(r.Context(), inv.S3Key)
+	if err != nil {
+		platform.HandleError(w, platform.NewInternal("failed to generate download URL", err))
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, DownloadResponse{DownloadURL: url})
+}
+
+func invoiceResponse(inv sqlc.Invoice) InvoiceResponse {
+	return InvoiceResponse{
+		ID:            inv.ID,
+		InvoiceNumber: inv.InvoiceNumber,
+		TransactionID: inv.TransactionID,
+		PlaceOfSupply: inv.PlaceOfSupply,
+		Interstate:    inv.Interstate,
+		TaxableAmount: numericToFloat64(inv.TaxableAmount),
+		CGST:          numericToFloat64(inv.Cgst),
+		SGST:          numericToFloat64(inv.Sgst),
+		IGST:          numericToFloat64(inv.Igst),
+		TotalAmount:   numericToFloat64(inv.TotalAmount),
+		IssuedAt:      inv.IssuedAt,
+	}
+}
@@ -0,0 +1,289 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var invoiceTemplate = template.Must(template.ParseFS(templateFS, "templates/invoice.html"))
+
+const (
+	invoiceCheckInterval = 1 * time.Minute
+	invoiceBatchSize     = 50
+)
+
+// gstStates maps the state codes stored on users and parcels to GST state
+// codes and names, used for the place of supply.
+var gstStates = map[string]struct{ Code, Name string }{
+	"JK": {"01", "Jammu and Kashmir"},
+	"HP": {"02", "Himachal Pradesh"},
+	"PB": {"03", "Punjab"},
+	"CH": {"04", "Chandigarh"},
+	"UK": {"05", "Uttarakhand"},
+	"UT": {"05", "Uttarakhand"},
+	"HR": {"06", "Haryana"},
+	"DL": {"07", "Delhi"},
+	"RJ": {"08", "Rajasthan"},
+	"UP": {"09", "Uttar Pradesh"},
+	"BR": {"10", "Bihar"},
+	"SK": {"11", "Sikkim"},
+	"AR": {"12", "Arunachal Pradesh"},
+	"NL": {"13", "Nagaland"},
+	"MN": {"14", "Manipur"},
+	"MZ": {"15", "Mizoram"},
+	"TR": {"16", "Tripura"},
+	"ML": {"17", "Meghalaya"},
+	"AS": {"18", "Assam"},
+	"WB": {"19", "West Bengal"},
+	"JH": {"20", "Jharkhand"},
+	"OD": {"21", "Odisha"},
+	"OR": {"21", "Odisha"},
+	"CG": {"22", "Chhattisgarh"},
+	"CT": {"22", "Chhattisgarh"},
+	"MP": {"23", "Madhya Pradesh"},
+	"GJ": {"24", "Gujarat"},
+	"DD": {"26", "Dadra and Nagar Haveli and Daman and Diu"},
+	"DN": {"26", "Dadra and Nagar Haveli and Daman and Diu"},
+	"MH": {"27", "Maharashtra"},
+	"KA": {"29", "Karnataka"},
+	"GA": {"30", "Goa"},
+	"LD": {"31", "Lakshadweep"},
+	"KL": {"32", "Kerala"},
+	"TN": {"33", "Tamil Nadu"},
+	"PY": {"34", "Puducherry"},
+	"AN": {"35", "Andaman and Nicobar Islands"},
+	"TG": {"36", "Telangana"},
+	"TS": {"36", "Telangana"},
+	"AP": {"37", "Andhra Pradesh"},
+	"LA": {"38", "Ladakh"},
+}
+
+// InvoiceRules are how invoices are numbered and taxed.
+type InvoiceRules struct {
+	Prefix        string
+	GSTRate       float64
+	SACCode       string
+	SupplierState string
+}
+
+// TaxBreakdown splits a GST-inclusive amount into its taxable value and tax.
+type TaxBreakdown struct {
+	Taxable    float64
+	CGST       float64
+	SGST       float64
+	IGST       float64
+	Total      float64
+	Interstate bool
+}
+
+// tax splits a GST-inclusive total. Supply to a recipient in another state
+// is interstate and attracts IGST; otherwise the tax is split equally into
+// CGST and SGST. A recipient with no known state is taxed as local.
+func (r InvoiceRules) tax(total float64, recipientState string) TaxBreakdown {
+	t := TaxBreakdown{Total: paise(total)}
+	t.Taxable = paise(t.Total / (1 + r.GSTRate))
+	gst := paise(t.Total - t.Taxable)
+
+	t.Interstate = recipientState != "" && gstStateCode(recipientState) != gstStateCode(r.SupplierState)
+	if t.Interstate {
+		t.IGST = gst
+	} else {
+		t.CGST = paise(gst / 2)
+		t.SGST = paise(gst - t.CGST)
+	}
+	return t
+}
+
+// placeOfSupply returns a recipient's state as printed on invoices, e.g.
+// "29-Karnataka". A recipient with no known state is supplied in the
+// supplier's state.
+func (r InvoiceRules) placeOfSupply(recipientState string) string {
+	if recipientState == "" {
+		recipientState = r.SupplierState
+	}
+	state := strings.ToUpper(strings.TrimSpace(recipientState))
+	if s, ok := gstStates[state]; ok {
+		return s.Code + "-" + s.Name
+	}
+	return state
+}
+
+// gstStateCode normalizes a state code so aliases such as TS and TG compare equal.
+func gstStateCode(state string) string {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	if s, ok := gstStates[state]; ok {
+		return s.Code
+	}
+	return state
+}
+
+// invoiceNumber formats an invoice's number, e.g. "TS/2026-27/000042".
+func (r InvoiceRules) invoiceNumber(financialYear string, sequence int32) string {
+	return fmt.Sprintf("%s/%s/%06d", r.Prefix, financialYear, sequence)
+}
+
+// financialYear returns the label of the Indian financial year containing t,
+// e.g. "2026-27".
+func financialYear(t time.Time) string {
+	start := financialYearStart(t).Year()
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// percent formats a rate as a percentage, e.g. 0.09 as "9".
+func percent(rate float64) string {
+	return strconv.FormatFloat(paise(rate*100), 'f', -1, 64)
+}
+
+// chargeDescription is the invoice line for a charge.
+func chargeDescription(chargeType, plan string) string {
+	name := "Land monitoring"
+	if plan != "" {
+		name = strings.ToUpper(plan[:1]) + plan[1:] + " plan"
+	}
+	switch chargeType {
+	case ChargeSubscription:
+		return name + " subscription"
+	case ChargeRenewal:
+		return name + " subscription renewal"
+	case ChargeProration:
+		return name + " upgrade, prorated for the rest of the billing period"
+	case "on_demand_visit":
+		return "On-demand survey visit"
+	default:
+		return chargeType
+	}
+}
+
+// InvoiceDocument is the content of a rendered invoice.
+type InvoiceDocument struct {
+	Number        string
+	FinancialYear string
+	IssuedAt      time.Time
+	CustomerName  string
+	CustomerPhone string
+	PlaceOfSupply string
+	PaymentID     string
+	Description   string
+	SACCode       string
+	RatePct       string
+	HalfRatePct   string
+	Tax           TaxBreakdown
+}
+
+// invoiceData is the data the invoice template renders.
+type invoiceData struct {
+	Company struct{ Name, GSTIN, Address string }
+	Invoice InvoiceDocument
+}
+
+// Invoicer issues a GST tax invoice for every paid landowner charge.
+//
+// Every minute it finds paid transactions without an invoice and issues one
+// for each: it takes the financial year's next invoice number, renders the
+// invoice to HTML and stores it in S3. The number is only committed once the
+// invoice is stored, so numbering has no gaps.
+type Invoicer struct {
+	repo     *Repository
+	s3Client *platform.S3Client
+	cfg      platform.InvoiceConfig
+	rules    InvoiceRules
+	logger   *slog.Logger
+}
+
+// NewInvoicer creates an invoicing service.
+func NewInvoicer(cfg platform.InvoiceConfig, repo *Repository, s3Client *platform.S3Client, logger *slog.Logger) *Invoicer {
+	return &Invoicer{
+		repo:     repo,
+		s3Client: s3Client,
+		cfg:      cfg,
+		rules: InvoiceRules{
+			Prefix:        cfg.Prefix,
+			GSTRate:       cfg.GSTRate,
+			SACCode:       cfg.SACCode,
+			SupplierState: cfg.CompanyStateCode,
+		},
+		logger: logger,
+	}
+}
+
+// Start runs the invoicing loop. Call in a goroutine.
+func (s *Invoicer) Start(ctx context.Context) {
+	s.logger.Info("invoicing started", "interval", invoiceCheckInterval)
+
+	s.issuePending(ctx)
+
+	ticker := time.NewTicker(invoiceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("invoicing stopped")
+			return
+		case <-ticker.C:
+			s.issuePending(ctx)
+		}
+	}
+}
+
+// issuePending issues invoices for paid transactions that have none.
+func (s *Invoicer) issuePending(ctx context.Context) {
+	charges, err := s.repo.ListUninvoicedTransactions(ctx, invoiceBatchSize)
+	if err != nil {
+		s.logger.Error("invoicing: failed to list uninvoiced transactions", "error", err)
+		return
+	}
+
+	for _, c := range charges {
+		inv, err := s.repo.IssueInvoice(ctx, c.ID, s.rules, time.Now(), s.publish)
+		if err != nil {
+			// Retried on the next run
+			s.logger.Error("invoicing: failed to issue invoice", "transaction_id", c.ID, "error", err)
+			continue
+		}
+		s.logger.Info("invoice issued", "invoice_number", inv.InvoiceNumber, "transaction_id", c.ID)
+	}
+}
+
+// publish renders an invoice and stores it in S3, returning its key.
+func (s *Invoicer) publish(ctx context.Context, doc InvoiceDocument) (string, error) {
+	doc.RatePct = percent(s.rules.GSTRate)
+	doc.HalfRatePct = percent(s.rules.GSTRate / 2)
+
+	data := invoiceData{Invoice: doc}
+	data.Company.Name = s.cfg.CompanyName
+	data.Company.GSTIN = s.cfg.CompanyGSTIN
+	data.Company.Address = s.cfg.CompanyAddress
+
+	var buf bytes.Buffer
+	if err := invoiceTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering invoice template: %w", err)
+	}
+
+	key := fmt.Sprintf("invoices/%s/%s.html", doc.FinancialYear, strings.ReplaceAll(doc.Number, "/", "-"))
+	if err := s.s3Client.PutObject(ctx, key, "text/html", &buf); err != nil {
+		return "", fmt.Errorf("uploading invoice to S3: %w", err)
+	}
+	return key, nil
+}
+
+// DownloadURL generates a presigned URL for downloading an invoice.
+func (s *Invoicer) DownloadURL(ctx context.Context, s3Key string) (string, error) {
+	url, err := s.s3Client.GeneratePresignedGetURL(ctx, s3Key, 1*time.Hour)
+	if err != nil {
+		return "", fmt.Errorf("generating download URL: %w", err)
+	}
+	return url, nil
+}
@@ -0,0 +1,173 @@
+package billing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvoiceRulesTax(t *testing.T) {
+	rules := InvoiceRules{GSTRate: 0.18, SupplierState: "KA"}
+
+	tests := []struct {
+		name  string
+		total float64
+		state string
+		want  TaxBreakdown
+	}{
+		{
+			name:  "intra-state",
+			total: 1180,
+			state: "KA",
+			want:  TaxBreakdown{Taxable: 1000, CGST: 90, SGST: 90, Total: 1180},
+		},
+		{
+			name:  "inter-state",
+			total: 1180,
+			state: "MH",
+			want:  TaxBreakdown{Taxable: 1000, IGST: 180, Total: 1180, Interstate: true},
+		},
+		{
+			name:  "unknown state is local",
+			total: 1180,
+			state: "",
+			want:  TaxBreakdown{Taxable: 1000, CGST: 90, SGST: 90, Total: 1180},
+		},
+		{
+			name:  "lower case state",
+			total: 1180,
+			state: "ka",
+			want:  TaxBreakdown{Taxable: 1000, CGST: 90, SGST: 90, Total: 1180},
+		},
+		{
+			name:  "odd paisa of tax goes to CGST",
+			total: 100,
+			state: "KA",
+			want:  TaxBreakdown{Taxable: 84.75, CGST: 7.63, SGST: 7.62, Total: 100},
+		},
+		{
+			name:  "rounds to paise",
+			total: 1499,
+			state: "TN",
+			want:  TaxBreakdown{Taxable: 1270.34, IGST: 228.66, Total: 1499, Interstate: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules.tax(tt.total, tt.state)
+			if got != tt.want {
+				t.Errorf("tax() = %+v, want %+v", got, tt.want)
+			}
+			if sum := paise(got.Taxable + got.CGST + got.SGST + got.IGST); sum != got.Total {
+				t.Errorf("components sum to %v, want %v", sum, got.Total)
+			}
+		})
+	}
+}
+
+func TestInvoiceRulesStateAliases(t *testing.T) {
+	rules := InvoiceRules{GSTRate: 0.18, SupplierState: "TS"}
+	if got := rules.tax(1180, "TG"); got.Interstate {
+		t.Error("TG and TS are both Telangana, want intra-state")
+	}
+	if got := rules.placeOfSupply("tg"); got != "36-Telangana" {
+		t.Errorf("placeOfSupply() = %q, want 36-Telangana", got)
+	}
+	if got := rules.placeOfSupply(""); got != "36-Telangana" {
+		t.Errorf("placeOfSupply() = %q, want the supplier's state", got)
+	}
+}
+
+func TestFinancialYear(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, ist), "2026-27"},
+		{time.Date(2027, 3, 31, 23, 59, 0, 0, ist), "2026-27"},
+		{time.Date(2026, 3, 31, 19, 0, 0, 0, time.UTC), "2026-27"}, // 1 April in IST
+		{time.Date(2099, 12, 1, 0, 0, 0, 0, ist), "2099-00"},
+	}
+
+	for _, tt := range tests {
+		if got := financialYear(tt.at); got != tt.want {
+			t.Errorf("financialYear(%v) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestInvoiceNumber(t *testing.T) {
+	rules := InvoiceRules{Prefix: "TS"}
+	if got := rules.invoiceNumber("2026-27", 42); got != "TS/2026-27/000042" {
+		t.Errorf("invoiceNumber() = %q, want TS/2026-27/000042", got)
+	}
+}
+
+func TestChargeDescription(t *testing.T) {
+	tests := []struct {
+		chargeType string
+		plan       string
+		want       string
+	}{
+		{ChargeSubscription, PlanPro, "Pro plan subscription"},
+		{ChargeRenewal, PlanBasic, "Basic plan subscription renewal"},
+		{ChargeProration, PlanPremium, "Premium plan upgrade, prorated for the rest of the billing period"},
+		{"on_demand_visit", "", "On-demand survey visit"},
+		{ChargeRenewal, "", "Land monitoring subscription renewal"},
+	}
+
+	for _, tt := range tests {
+		if got := chargeDescription(tt.chargeType, tt.plan); got != tt.want {
+			t.Errorf("chargeDescription(%q, %q) = %q, want %q", tt.chargeType, tt.plan, got, tt.want)
+		}
+	}
+}
+
+func TestInvoiceTemplate(t *testing.T) {
+	rules := InvoiceRules{GSTRate: 0.18, SupplierState: "KA"}
+
+	tests := []struct {
+		name    string
+		state   string
+		want    []string
+		notWant string
+	}{
+		{"intra-state", "KA", []string{"CGST @ 9%", "SGST @ 9%"}, "IGST"},
+		{"inter-state", "MH", []string{"IGST @ 18%", "27-Maharashtra"}, "CGST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := InvoiceDocument{
+				Number:        "TS/2026-27/000001",
+				IssuedAt:      time.Date(2026, 5, 1, 0, 0, 0, 0, ist),
+				CustomerName:  "Asha <Rao>",
+				PlaceOfSupply: rules.placeOfSupply(tt.state),
+				Description:   "Pro plan subscription",
+				RatePct:       percent(rules.GSTRate),
+				HalfRatePct:   percent(rules.GSTRate / 2),
+				Tax:           rules.tax(1499, tt.state),
+			}
+
+			data := invoiceData{Invoice: doc}
+			data.Company.Name = "TerraScore"
+
+			var buf bytes.Buffer
+			if err := invoiceTemplate.Execute(&buf, data); err != nil {
+				t.Fatalf("rendering invoice: %v", err)
+			}
+
+			html := buf.String()
+			for _, want := range append(tt.want, "TS/2026-27/000001", "1499.00", "01 May 2026", "Asha &lt;Rao&gt;") {
+				if !strings.Contains(html, want) {
+					t.Errorf("invoice missing %q", want)
+				}
+			}
+			if strings.Contains(html, tt.notWant) {
+				t.Errorf("invoice unexpectedly contains %q", tt.notWant)
+			}
+		})
+	}
+}
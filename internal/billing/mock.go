@@ -0,0 +1,128 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// MockPayoutProvider logs payouts instead of sending them. Payouts stay
+// processing until a webhook marks them paid or failed.
+type MockPayoutProvider struct {
+	logger *slog.Logger
+}
+
+func NewMockPayoutProvider(logger *slog.Logger) *MockPayoutProvider {
+	return &MockPayoutProvider{logger: logger}
+}
+
+func (m *MockPayoutProvider) CreatePayout(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	id := "mock_pout_" + req.PayoutID.String()
+	m.logger.Info("[mock] payout created",
+		"payout_id", req.PayoutID,
+		"provider_id", id,
+		"agent_id", req.AgentID,
+		"amount_paise", req.AmountPaise,
+		"upi_id", req.UPIID,
+	)
+	return &PayoutResult{ProviderID: id, Status: PayoutProcessing}, nil
+}
+
+// ParseWebhook accepts unsigned JSON of the form
+// {"payout_id": "...", "status": "paid", "failure_reason": "..."}.
+func (m *MockPayoutProvider) ParseWebhook(header http.Header, body []byte) (*PayoutUpdate, error) {
+	var event struct {
+		PayoutID      string `json:"payout_id"`
+		Status        string `json:"status"`
+		FailureReason string `json:"failure_reason"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, platform.NewBadRequest("invalid webhook body")
+	}
+	switch event.Status {
+	case PayoutProcessing, PayoutPaid, PayoutFailed:
+	default:
+		return nil, platform.NewBadRequest(fmt.Sprintf("unknown payout status %q", event.Status))
+	}
+	return &PayoutUpdate{
+		ProviderID:    event.PayoutID,
+		Status:        event.Status,
+		FailureReason: event.FailureReason,
+	}, nil
+}
+
+// MockPaymentGateway captures every order immediately, so subscriptions
+// activate without a checkout step.
+type MockPaymentGateway struct {
+	logger *slog.Logger
+}
+
+func NewMockPaymentGateway(logger *slog.Logger) *MockPaymentGateway {
+	return &MockPaymentGateway{logger: logger}
+}
+
+func (m *MockPaymentGateway) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	id := "mock_order_" + uuid.NewString()
+	m.logger.Info("[mock] order paid",
+		"order_id", id,
+		"receipt", req.Receipt,
+		"amount_paise", req.AmountPaise,
+	)
+	return &Order{
+		ID:          id,
+		AmountPaise: req.AmountPaise,
+		Currency:    "INR",
+		PaymentID:   "mock_pay_" + uuid.NewString(),
+	}, nil
+}
+
+// VerifyPayment accepts any signature.
+func (m *MockPaymentGateway) VerifyPayment(orderID, paymentID, signature string) error {
+	return nil
+}
+
+// ParseWebhook accepts unsigned JSON of the form {"id": "evt_1", "event":
+// "payment.captured", "order_id": "...", "payment_id": "...", "error": "..."}.
+func (m *MockPaymentGateway) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	var event struct {
+		ID    string `json:"id"`
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Event == "" {
+		return nil, platform.NewBadRequest("invalid webhook body")
+	}
+	return &WebhookEvent{ID: event.ID, Type: event.Event}, nil
+}
+
+// DecodeEvent handles payment.captured and payment.failed.
+func (m *MockPaymentGateway) DecodeEvent(eventType string, payload []byte) (*ChargeUpdate, error) {
+	var status string
+	switch eventType {
+	case "payment.captured":
+		status = ChargePaid
+	case "payment.failed":
+		status = ChargeFailed
+	default:
+		return nil, nil
+	}
+
+	var event struct {
+		OrderID   string `json:"order_id"`
+		PaymentID string `json:"payment_id"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decoding %s event: %w", eventType, err)
+	}
+	return &ChargeUpdate{
+		OrderID:       event.OrderID,
+		PaymentID:     event.PaymentID,
+		Status:        status,
+		FailureReason: event.Error,
+	}, nil
+}
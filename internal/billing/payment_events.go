@@ -0,0 +1,99 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// TaskProcessPaymentEvent processes one stored payment webhook event.
+const TaskProcessPaymentEvent = "billing.process_payment_event"
+
+// PaymentEvents ingests payment gateway webhooks.
+//
+// A verified delivery is stored in payment_events under the gateway's event
+// ID, so retried deliveries are stored once, and a task is enqueued to apply
+// it to transactions and subscriptions. Events of types we don't handle are
+// stored and marked ignored. Events that fail to apply are marked failed
+// and can be replayed by ops.
+type PaymentEvents struct {
+	repo      *Repository
+	gateway   PaymentGateway
+	taskQueue *platform.TaskQueue
+	logger    *slog.Logger
+}
+
+// NewPaymentEvents creates a payment webhook ingestion service.
+func NewPaymentEvents(repo *Repository, gateway PaymentGateway, taskQueue *platform.TaskQueue, logger *slog.Logger) *PaymentEvents {
+	return &PaymentEvents{
+		repo:      repo,
+		gateway:   gateway,
+		taskQueue: taskQueue,
+		logger:    logger,
+	}
+}
+
+// Receive verifies and stores a webhook delivery and enqueues it for
+// processing. It returns false if the event was already received. A
+// redelivered event that is still awaiting processing is enqueued again, in
+// case the first enqueue failed; processing an event twice is a no-op.
+func (p *PaymentEvents) Receive(ctx context.Context, header http.Header, body []byte) (*sqlc.PaymentEvent, bool, error) {
+	webhook, err := p.gateway.ParseWebhook(header, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	event, created, err := p.repo.RecordPaymentEvent(ctx, *webhook, body)
+	if err != nil {
+		return nil, false, err
+	}
+	if created || event.Status == EventReceived {
+		if err := p.enqueue(ctx, event.ID); err != nil {
+			return nil, false, err
+		}
+	}
+	return event, created, nil
+}
+
+// Replay re-enqueues a failed event.
+func (p *PaymentEvents) Replay(ctx context.Context, id uuid.UUID) (*sqlc.PaymentEvent, error) {
+	event, err := p.repo.ReplayPaymentEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.enqueue(ctx, event.ID); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (p *PaymentEvents) enqueue(ctx context.Context, id uuid.UUID) error {
+	return p.taskQueue.Enqueue(ctx, TaskProcessPaymentEvent, ProcessPaymentEventPayload{EventID: id})
+}
+
+// HandleTask is the TaskQueue handler for "billing.process_payment_event".
+func (p *PaymentEvents) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
+	var pl ProcessPaymentEventPayload
+	if err := json.Unmarshal(payload, &pl); err != nil {
+		return fmt.Errorf("unmarshalling payment event payload: %w", err)
+	}
+
+	status, err := p.repo.ApplyPaymentEvent(ctx, p.gateway, pl.EventID, time.Now())
+	if err != nil {
+		p.logger.Error("payment event failed", "event_id", pl.EventID, "error", err)
+		if ferr := p.repo.FailPaymentEvent(ctx, pl.EventID, err.Error()); ferr != nil {
+			p.logger.Error("failed to record payment event failure", "event_id", pl.EventID, "error", ferr)
+		}
+		return err
+	}
+
+	p.logger.Info("payment event processed", "event_id", pl.EventID, "status", status)
+	return nil
+}
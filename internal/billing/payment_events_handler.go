@@ -0,0 +1,163 @@
+package billing
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// PaymentEventHandler handles payment webhooks and their ops endpoints.
+type PaymentEventHandler struct {
+	events *PaymentEvents
+	repo   *Repository
+	logger *slog.Logger
+}
+
+// NewPaymentEventHandler creates a payment event handler.
+func NewPaymentEventHandler(events *PaymentEvents, repo *Repository, logger *slog.Logger) *PaymentEventHandler {
+	return &PaymentEventHandler{
+		events: events,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Routes returns the ops payment event router.
+func (h *PaymentEventHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole("admin", "ops"))
+		r.Get("/", h.List)
+		r.Get("/{id}", h.Get)
+		r.Post("/{id}/replay", h.Replay)
+	})
+
+	return r
+}
+
+// Webhook handles POST /webhooks/payments. The gateway authenticates with a
+// body signature rather than a JWT. Events are acknowledged once stored;
+// they are applied asynchronously.
+func (h *PaymentEventHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("reading webhook body"))
+		return
+	}
+
+	event, created, err := h.events.Receive(r.Context(), r.Header, body)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	if !created {
+		h.logger.Info("duplicate payment event", "event_id", event.EventID, "type", event.EventType)
+		platform.JSON(w, http.StatusOK, map[string]string{"message": "duplicate"})
+		return
+	}
+	h.logger.Info("payment event received", "event_id", event.EventID, "type", event.EventType)
+	platform.JSON(w, http.StatusOK, map[string]string{"message": "received"})
+}
+
+// List handles GET /v1/admin/billing/payment-events?status=failed.
+func (h *PaymentEventHandler) List(w http.ResponseWriter, r *http.Request) {
+	var status *string
+	if s := r.URL.Query().Get("status"); s != "" {
+		switch s {
+		case EventReceived, EventProcessed, EventIgnored, EventFailed:
+			status = &s
+		default:
+			platform.HandleError(w, platform.NewBadRequest("status must be one of received, processed, ignored, failed"))
+			return
+		}
+	}
+
+	pg := platform.ParsePagination(r)
+	events, total, err := h.repo.ListPaymentEvents(r.Context(), status, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]PaymentEventResponse, len(events))
+	for i, e := range events {
+		result[i] = paymentEventResponse(e, false)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// Get handles GET /v1/admin/billing/payment-events/{id}, including the raw payload.
+func (h *PaymentEventHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid event ID"))
+		return
+	}
+
+	event, err := h.repo.GetPaymentEvent(r.Context(), id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, paymentEventResponse(*event, true))
+}
+
+// Replay handles POST /v1/admin/billing/payment-events/{id}/replay.
+func (h *PaymentEventHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid event ID"))
+		return
+	}
+
+	event, err := h.events.Replay(r.Context(), id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	actor := ""
+	if userCtx := auth.GetUser(r.Context()); userCtx != nil {
+		actor = userCtx.KeycloakID
+	}
+	h.logger.Info("payment event replayed", "id", id, "event_id", event.EventID, "actor", actor)
+	platform.JSON(w, http.StatusAccepted, paymentEventResponse(*event, false))
+}
+
+func paymentEventResponse(e sqlc.PaymentEvent, withPayload bool) PaymentEventResponse {
+	resp := PaymentEventResponse{
+		ID:         e.ID,
+		EventID:    e.EventID,
+		EventType:  e.EventType,
+		Status:     e.Status,
+		Attempts:   e.Attempts,
+		LastError:  e.LastError,
+		ReceivedAt: e.ReceivedAt,
+	}
+	if withPayload {
+		resp.Payload = e.Payload
+	}
+	if e.ProcessedAt.Valid {
+		resp.ProcessedAt = &e.ProcessedAt.Time
+	}
+	return resp
+}
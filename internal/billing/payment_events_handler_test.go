@@ -0,0 +1,68 @@
+package billing
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func paymentEventRouter() chi.Router {
+	events := &PaymentEvents{gateway: NewMockPaymentGateway(slog.Default()), logger: slog.Default()}
+	h := &PaymentEventHandler{events: events, logger: slog.Default()}
+	r := chi.NewRouter()
+	r.Post("/webhooks/payments", h.Webhook)
+	r.Get("/payment-events", h.List)
+	r.Get("/payment-events/{id}", h.Get)
+	r.Post("/payment-events/{id}/replay", h.Replay)
+	return r
+}
+
+func TestPaymentWebhook_InvalidBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed JSON", `{"id":`},
+		{"missing event ID", `{"event": "payment.captured"}`},
+		{"missing event type", `{"id": "evt_1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			paymentEventRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestPaymentEvents_BadRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/payment-events?status=lost"},
+		{http.MethodGet, "/payment-events/not-a-uuid"},
+		{http.MethodPost, "/payment-events/not-a-uuid/replay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			paymentEventRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/terrascore/api/internal/agent"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// TaskSettlePayouts settles one weekly payout period.
+	TaskSettlePayouts = "billing.settle_payouts"
+
+	settlementCheckInterval = 1 * time.Hour
+	sendBatchSize           = 100
+)
+
+// ist is the timezone payout weeks and financial years are reckoned in.
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+// PayoutRules are the deductions applied to an agent's gross earnings.
+type PayoutRules struct {
+	CommissionRate float64
+	TDSRate        float64
+	TDSSingleLimit float64
+	TDSAnnualLimit float64
+}
+
+// payoutAmounts is a payout's gross earnings and deductions, in INR.
+type payoutAmounts struct {
+	Gross      float64
+	Commission float64
+	TDS        float64
+	Net        float64
+}
+
+// apply computes a payout from gross earnings. Commission is taken first;
+// TDS is deducted from the remainder when it exceeds the single-payout limit
+// or takes the agent's financial-year earnings (after commission) past the
+// annual limit. taxableThisFY is what earlier payouts this year already
+// covered.
+func (r PayoutRules) apply(gross, taxableThisFY float64) payoutAmounts {
+	a := payoutAmounts{Gross: paise(gross)}
+	a.Commission = paise(a.Gross * r.CommissionRate)
+	taxable := a.Gross - a.Commission
+	if taxable > r.TDSSingleLimit || taxableThisFY+taxable > r.TDSAnnualLimit {
+		a.TDS = paise(taxable * r.TDSRate)
+	}
+	a.Net = paise(taxable - a.TDS)
+	return a
+}
+
+// paise rounds an amount to the nearest paisa.
+func paise(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// settlementPeriod returns the last full Monday-to-Sunday week before now,
+// in IST, as the dates of its first and last days.
+func settlementPeriod(now time.Time) (start, end time.Time) {
+	now = now.In(ist)
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	thisMonday := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, ist)
+	start = thisMonday.AddDate(0, 0, -7)
+	return start, start.AddDate(0, 0, 6)
+}
+
+// financialYearStart returns 1 April of the Indian financial year containing t.
+func financialYearStart(t time.Time) time.Time {
+	t = t.In(ist)
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return time.Date(year, time.April, 1, 0, 0, 0, 0, ist)
+}
+
+// Settlement pays agents weekly for their completed, QA-passed jobs.
+//
+// Every hour it claims the last full week in payout_periods and, the first
+// time, enqueues a settlement task for it. The task creates one payout per
+// agent covering all of their unsettled jobs completed before the week ended,
+// so jobs that passed QA late are picked up by the next week's run. Payouts
+// are then sent to the provider; any the provider didn't accept are retried
+// on the next run.
+type Settlement struct {
+	repo      *Repository
+	agentRepo *agent.Repository
+	provider  PayoutProvider
+	taskQueue *platform.TaskQueue
+	rules     PayoutRules
+	logger    *slog.Logger
+}
+
+// NewSettlement creates a payout settlement service.
+func NewSettlement(cfg platform.PayoutConfig, repo *Repository, agentRepo *agent.Repository, provider PayoutProvider, taskQueue *platform.TaskQueue, logger *slog.Logger) *Settlement {
+	return &Settlement{
+		repo:      repo,
+		agentRepo: agentRepo,
+		provider:  provider,
+		taskQueue: taskQueue,
+		rules: PayoutRules{
+			CommissionRate: cfg.CommissionRate,
+			TDSRate:        cfg.TDSRate,
+			TDSSingleLimit: cfg.TDSSingleLimit,
+			TDSAnnualLimit: cfg.TDSAnnualLimit,
+		},
+		logger: logger,
+	}
+}
+
+// Start runs the settlement scheduler loop. Call in a goroutine.
+func (s *Settlement) Start(ctx context.Context) {
+	s.logger.Info("payout settlement started", "interval", settlementCheckInterval)
+
+	s.schedule(ctx, time.Now())
+
+	ticker := time.NewTicker(settlementCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("payout settlement stopped")
+			return
+		case <-ticker.C:
+			s.schedule(ctx, time.Now())
+		}
+	}
+}
+
+// schedule enqueues the settlement task for the last full week, once.
+func (s *Settlement) schedule(ctx context.Context, now time.Time) {
+	start, end := settlementPeriod(now)
+
+	claimed, err := s.repo.ClaimPayoutPeriod(ctx, start, end)
+	if err != nil {
+		s.logger.Error("settlement: failed to claim period", "period_start", start, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	err = s.taskQueue.Enqueue(ctx, TaskSettlePayouts, SettlePayoutsPayload{
+		PeriodStart: start.Format(time.DateOnly),
+		PeriodEnd:   end.Format(time.DateOnly),
+	})
+	if err != nil {
+		s.logger.Error("settlement: failed to enqueue task", "period_start", start, "error", err)
+		if err := s.repo.ReleasePayoutPeriod(ctx, start); err != nil {
+			s.logger.Error("settlement: failed to release period", "period_start", start, "error", err)
+		}
+		return
+	}
+	s.logger.Info("settlement: enqueued", "period_start", start.Format(time.DateOnly))
+}
+
+// HandleTask is the TaskQueue handler for "billing.settle_payouts".
+func (s *Settlement) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
+	var p SettlePayoutsPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("unmarshalling settlement payload: %w", err)
+	}
+	start, err := time.ParseInLocation(time.DateOnly, p.PeriodStart, ist)
+	if err != nil {
+		return fmt.Errorf("parsing period start: %w", err)
+	}
+	end, err := time.ParseInLocation(time.DateOnly, p.PeriodEnd, ist)
+	if err != nil {
+		return fmt.Errorf("parsing period end: %w", err)
+	}
+	cutoff := end.AddDate(0, 0, 1)
+
+	agentIDs, err := s.repo.ListAgentsWithUnsettledJobs(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	created, failed := 0, 0
+	for _, agentID := range agentIDs {
+		payout, err := s.repo.SettleAgent(ctx, agentID, start, end, cutoff, s.rules)
+		if err != nil {
+			// The agent's jobs stay unsettled and roll into the next period
+			s.logger.Error("settlement: failed to settle agent", "agent_id", agentID, "error", err)
+			failed++
+			continue
+		}
+		if payout != nil {
+			created++
+		}
+	}
+
+	s.sendPending(ctx)
+
+	if failed == 0 {
+		if err := s.repo.MarkPayoutPeriodSettled(ctx, start); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("settlement complete",
+		"period_start", p.PeriodStart,
+		"agents", len(agentIDs),
+		"payouts_created", created,
+		"agents_failed", failed,
+	)
+	return nil
+}
+
+// sendPending sends pending payouts to the provider.
+func (s *Settlement) sendPending(ctx context.Context) {
+	payouts, err := s.repo.ListUnsentPayouts(ctx, sendBatchSize)
+	if err != nil {
+		s.logger.Error("settlement: failed to list unsent payouts", "error", err)
+		return
+	}
+
+	for _, p := range payouts {
+		net := numericToFloat64(p.NetAmount)
+		if net <= 0 {
+			// Nothing to transfer, e.g. jobs priced before payouts were tracked
+			if _, err := s.repo.MarkPayoutPaid(ctx, p.ID); err != nil {
+				s.logger.Error("settlement: failed to close empty payout", "payout_id", p.ID, "error", err)
+			}
+			continue
+		}
+
+		ag, err := s.agentRepo.GetAgentByID(ctx, p.AgentID)
+		if err != nil {
+			s.logger.Error("settlement: failed to load agent", "payout_id", p.ID, "agent_id", p.AgentID, "error", err)
+			continue
+		}
+		if ag.UpiID == nil || *ag.UpiID == "" {
+			if _, err := s.repo.MarkPayoutFailed(ctx, p.ID, "no UPI ID on file"); err != nil {
+				s.logger.Error("settlement: failed to mark payout failed", "payout_id", p.ID, "error", err)
+			}
+			continue
+		}
+
+		result, err := s.provider.CreatePayout(ctx, PayoutRequest{
+			PayoutID:    p.ID,
+			AgentID:     ag.ID,
+			AgentName:   ag.FullName,
+			Phone:       ag.Phone,
+			UPIID:       *ag.UpiID,
+			AmountPaise: int64(math.Round(net * 100)),
+			Narration:   "TerraScore payout",
+		})
+		if err != nil {
+			// Left pending; the next run retries with the same idempotency key
+			s.logger.Error("settlement: payout request failed", "payout_id", p.ID, "error", err)
+			continue
+		}
+
+		if result.Status == PayoutFailed {
+			_, err = s.repo.MarkPayoutFailed(ctx, p.ID, "rejected by payout provider")
+		} else {
+			_, err = s.repo.MarkPayoutSent(ctx, p.ID, result.ProviderID, PayoutProcessing)
+			if err == nil && result.Status == PayoutPaid {
+				_, err = s.repo.MarkPayoutPaid(ctx, p.ID)
+			}
+		}
+		if err != nil {
+			s.logger.Error("settlement: failed to record payout", "payout_id", p.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPayoutRulesApply(t *testing.T) {
+	rules := PayoutRules{
+		CommissionRate: 0.15,
+		TDSRate:        0.01,
+		TDSSingleLimit: 30000,
+		TDSAnnualLimit: 100000,
+	}
+
+	tests := []struct {
+		name    string
+		gross   float64
+		priorFY float64
+		want    payoutAmounts
+	}{
+		{
+			name:  "below both limits",
+			gross: 10000,
+			want:  payoutAmounts{Gross: 10000, Commission: 1500, TDS: 0, Net: 8500},
+		},
+		{
+			name:  "single payout over limit",
+			gross: 40000,
+			want:  payoutAmounts{Gross: 40000, Commission: 6000, TDS: 340, Net: 33660},
+		},
+		{
+			name:    "annual limit crossed",
+			gross:   10000,
+			priorFY: 95000,
+			want:    payoutAmounts{Gross: 10000, Commission: 1500, TDS: 85, Net: 8415},
+		},
+		{
+			name:    "annual limit reached exactly",
+			gross:   10000,
+			priorFY: 91500,
+			want:    payoutAmounts{Gross: 10000, Commission: 1500, TDS: 0, Net: 8500},
+		},
+		{
+			name:  "rounds to paise",
+			gross: 333.33,
+			want:  payoutAmounts{Gross: 333.33, Commission: 50, TDS: 0, Net: 283.33},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules.apply(tt.gross, tt.priorFY)
+			if got != tt.want {
+				t.Errorf("apply(%v, %v) = %+v, want %+v", tt.gross, tt.priorFY, got, tt.want)
+			}
+			if got.Commission+got.TDS+got.Net != got.Gross {
+				t.Errorf("deductions and net %v don't add up to gross %v", got.Commission+got.TDS+got.Net, got.Gross)
+			}
+		})
+	}
+}
+
+func TestSettlementPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "midweek",
+			now:       time.Date(2026, 10, 14, 12, 0, 0, 0, ist), // Wednesday
+			wantStart: "2026-10-05",
+			wantEnd:   "2026-10-11",
+		},
+		{
+			name:      "Monday just after midnight IST",
+			now:       time.Date(2026, 10, 12, 0, 5, 0, 0, ist),
+			wantStart: "2026-10-05",
+			wantEnd:   "2026-10-11",
+		},
+		{
+			name:      "Sunday night IST",
+			now:       time.Date(2026, 10, 11, 23, 59, 0, 0, ist),
+			wantStart: "2026-09-28",
+			wantEnd:   "2026-10-04",
+		},
+		{
+			name:      "UTC Sunday evening is Monday in IST",
+			now:       time.Date(2026, 10, 11, 19, 0, 0, 0, time.UTC),
+			wantStart: "2026-10-05",
+			wantEnd:   "2026-10-11",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := settlementPeriod(tt.now)
+			if got := start.Format(time.DateOnly); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format(time.DateOnly); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestFinancialYearStart(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 16, 0, 0, 0, 0, ist), "2026-04-01"},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, ist), "2026-04-01"},
+		{time.Date(2026, 3, 31, 23, 59, 0, 0, ist), "2025-04-01"},
+		{time.Date(2027, 1, 5, 0, 0, 0, 0, ist), "2026-04-01"},
+	}
+
+	for _, tt := range tests {
+		if got := financialYearStart(tt.t).Format(time.DateOnly); got != tt.want {
+			t.Errorf("financialYearStart(%s) = %s, want %s", tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package billing
+
+import "time"
+
+// Plan names.
+const (
+	PlanBasic   = "basic"
+	PlanPro     = "pro"
+	PlanPremium = "premium"
+)
+
+// Plan describes what a subscription plan includes.
+type Plan struct {
+	Name string
+
+	// Price is charged per monthly billing period (INR).
+	Price string
+
+	// Scheduled visits: one every VisitInterval, due within GracePeriod of
+	// the due date, at most VisitsPerPeriod per billing period.
+	VisitInterval   time.Duration
+	GracePeriod     time.Duration
+	VisitsPerPeriod int
+
+	SurveyType string // checklist used for every visit
+	AgentTier  string // minimum agent tier; the matcher allows SurveyType to this tier and above
+
+	// OnDemandVisits landowner-requested visits are included each period;
+	// after that each costs OnDemandPrice (INR).
+	OnDemandVisits int
+	OnDemandPrice  string
+}
+
+// DefaultOnDemandPrice is charged for a requested visit to a parcel without
+// a subscription (INR).
+const DefaultOnDemandPrice = "1199.00"
+
+const day = 24 * time.Hour
+
+var plans = map[string]Plan{
+	PlanBasic: {
+		Name:            PlanBasic,
+		Price:           "499.00",
+		VisitInterval:   90 * day,
+		GracePeriod:     7 * day,
+		VisitsPerPeriod: 1,
+		SurveyType:      "basic_check",
+		AgentTier:       "basic",
+		OnDemandPrice:   "999.00",
+	},
+	PlanPro: {
+		Name:            PlanPro,
+		Price:           "1499.00",
+		VisitInterval:   30 * day,
+		GracePeriod:     3 * day,
+		VisitsPerPeriod: 1,
+		SurveyType:      "detailed_survey",
+		AgentTier:       "experienced",
+		OnDemandPrice:   "799.00",
+	},
+	PlanPremium: {
+		Name:            PlanPremium,
+		Price:           "2999.00",
+		VisitInterval:   15 * day,
+		GracePeriod:     2 * day,
+		VisitsPerPeriod: 2,
+		SurveyType:      "premium_inspection",
+		AgentTier:       "senior",
+		OnDemandVisits:  1,
+		OnDemandPrice:   "599.00",
+	},
+}
+
+// GetPlan returns the plan with the given name.
+func GetPlan(name string) (Plan, bool) {
+	p, ok := plans[name]
+	return p, ok
+}
+
+// Plans returns every plan.
+func Plans() []Plan {
+	return []Plan{plans[PlanBasic], plans[PlanPro], plans[PlanPremium]}
+}
@@ -0,0 +1,156 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+// RazorpayClient collects landowner payments through Razorpay orders. The
+// client app opens checkout for the order and forwards the signed result.
+type RazorpayClient struct {
+	baseURL       string
+	keyID         string
+	keySecret     string
+	webhookSecret string
+	httpClient    *http.Client
+}
+
+// NewRazorpayClient creates a Razorpay payments client.
+func NewRazorpayClient(cfg platform.PaymentConfig) *RazorpayClient {
+	return &RazorpayClient{
+		baseURL:       strings.TrimRight(cfg.RazorpayBaseURL, "/"),
+		keyID:         cfg.RazorpayKeyID,
+		keySecret:     cfg.RazorpayKeySecret,
+		webhookSecret: cfg.RazorpayWebhookSecret,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// CreateOrder handles POST /v1/orders.
+func (c *RazorpayClient) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	body, _ := json.Marshal(map[string]any{
+		"amount":   req.AmountPaise,
+		"currency": "INR",
+		"receipt":  req.Receipt,
+		"notes":    req.Notes,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/orders", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building order request: %w", err)
+	}
+	httpReq.SetBasicAuth(c.keyID, c.keySecret)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("creating order: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return nil, fmt.Errorf("creating order: razorpay returned %d: %s", resp.StatusCode, apiErr.Error.Description)
+	}
+
+	var order struct {
+		ID       string `json:"id"`
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(respBody, &order); err != nil {
+		return nil, fmt.Errorf("decoding order response: %w", err)
+	}
+	return &Order{ID: order.ID, AmountPaise: order.Amount, Currency: order.Currency}, nil
+}
+
+// VerifyPayment checks checkout's razorpay_signature, an HMAC-SHA256 of
+// "order_id|payment_id" keyed with the API key secret.
+func (c *RazorpayClient) VerifyPayment(orderID, paymentID, signature string) error {
+	if !verifySignature(c.keySecret, []byte(orderID+"|"+paymentID), signature) {
+		return platform.NewUnauthorized("invalid payment signature")
+	}
+	return nil
+}
+
+// ParseWebhook verifies the X-Razorpay-Signature of a webhook body. Razorpay
+// sends the same X-Razorpay-Event-Id when it retries a delivery.
+func (c *RazorpayClient) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !verifySignature(c.webhookSecret, body, header.Get("X-Razorpay-Signature")) {
+		return nil, platform.NewUnauthorized("invalid webhook signature")
+	}
+
+	var event struct {
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.Event == "" {
+		return nil, platform.NewBadRequest("invalid webhook body")
+	}
+	id := header.Get("X-Razorpay-Event-Id")
+	if id == "" {
+		return nil, platform.NewBadRequest("missing X-Razorpay-Event-Id header")
+	}
+	return &WebhookEvent{ID: id, Type: event.Event}, nil
+}
+
+// DecodeEvent handles payment.captured, payment.failed and order.paid.
+func (c *RazorpayClient) DecodeEvent(eventType string, payload []byte) (*ChargeUpdate, error) {
+	var status string
+	switch eventType {
+	case "payment.captured", "order.paid":
+		status = ChargePaid
+	case "payment.failed":
+		status = ChargeFailed
+	default:
+		return nil, nil
+	}
+
+	var event struct {
+		Payload struct {
+			Payment struct {
+				Entity struct {
+					ID               string `json:"id"`
+					OrderID          string `json:"order_id"`
+					ErrorDescription string `json:"error_description"`
+				} `json:"entity"`
+			} `json:"payment"`
+			Order struct {
+				Entity struct {
+					ID string `json:"id"`
+				} `json:"entity"`
+			} `json:"order"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decoding %s event: %w", eventType, err)
+	}
+
+	payment := event.Payload.Payment.Entity
+	update := &ChargeUpdate{
+		OrderID:       payment.OrderID,
+		PaymentID:     payment.ID,
+		Status:        status,
+		FailureReason: payment.ErrorDescription,
+	}
+	if update.OrderID == "" {
+		update.OrderID = event.Payload.Order.Entity.ID
+	}
+	if update.OrderID == "" {
+		return nil, fmt.Errorf("%s event has no order ID", eventType)
+	}
+	return update, nil
+}
@@ -0,0 +1,180 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+func TestRazorpayCreateOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/orders" {
+			t.Errorf("path = %s, want /v1/orders", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "key" || pass != "secret" {
+			t.Errorf("basic auth = %q/%q, want key/secret", user, pass)
+		}
+
+		var body struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+			Receipt  string `json:"receipt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Amount != 149900 || body.Currency != "INR" || body.Receipt != "sub_1" {
+			t.Errorf("unexpected order body: %+v", body)
+		}
+
+		w.Write([]byte(`{"id": "order_123", "amount": 149900, "currency": "INR", "status": "created"}`))
+	}))
+	defer srv.Close()
+
+	client := NewRazorpayClient(platform.PaymentConfig{
+		RazorpayBaseURL:   srv.URL,
+		RazorpayKeyID:     "key",
+		RazorpayKeySecret: "secret",
+	})
+
+	order, err := client.CreateOrder(context.Background(), OrderRequest{Receipt: "sub_1", AmountPaise: 149900})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if order.ID != "order_123" || order.AmountPaise != 149900 || order.PaymentID != "" {
+		t.Errorf("order = %+v, want unpaid order_123", order)
+	}
+}
+
+func TestRazorpayCreateOrderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": "BAD_REQUEST_ERROR", "description": "amount too small"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewRazorpayClient(platform.PaymentConfig{RazorpayBaseURL: srv.URL})
+	if _, err := client.CreateOrder(context.Background(), OrderRequest{AmountPaise: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRazorpayVerifyPayment(t *testing.T) {
+	client := NewRazorpayClient(platform.PaymentConfig{RazorpayKeySecret: "secret"})
+	valid := sign("secret", []byte("order_123|pay_456"))
+
+	tests := []struct {
+		name      string
+		paymentID string
+		signature string
+		wantErr   bool
+	}{
+		{"valid signature", "pay_456", valid, false},
+		{"other payment", "pay_789", valid, true},
+		{"wrong secret", "pay_456", sign("other", []byte("order_123|pay_456")), true},
+		{"missing signature", "pay_456", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.VerifyPayment("order_123", tt.paymentID, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRazorpayParseWebhook(t *testing.T) {
+	client := NewRazorpayClient(platform.PaymentConfig{RazorpayWebhookSecret: "whsec"})
+	body := []byte(`{"event": "payment.captured", "payload": {}}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		eventID   string
+		wantErr   bool
+	}{
+		{"valid", sign("whsec", body), "evt_1", false},
+		{"bad signature", sign("other", body), "evt_1", true},
+		{"missing event ID", sign("whsec", body), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("X-Razorpay-Signature", tt.signature)
+			header.Set("X-Razorpay-Event-Id", tt.eventID)
+
+			event, err := client.ParseWebhook(header, body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (event.ID != "evt_1" || event.Type != "payment.captured") {
+				t.Errorf("event = %+v, want evt_1/payment.captured", event)
+			}
+		})
+	}
+}
+
+func TestRazorpayDecodeEvent(t *testing.T) {
+	client := NewRazorpayClient(platform.PaymentConfig{})
+
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		want      *ChargeUpdate
+		wantErr   bool
+	}{
+		{
+			name:      "payment captured",
+			eventType: "payment.captured",
+			payload:   `{"payload": {"payment": {"entity": {"id": "pay_1", "order_id": "order_1", "status": "captured"}}}}`,
+			want:      &ChargeUpdate{OrderID: "order_1", PaymentID: "pay_1", Status: ChargePaid},
+		},
+		{
+			name:      "payment failed",
+			eventType: "payment.failed",
+			payload:   `{"payload": {"payment": {"entity": {"id": "pay_2", "order_id": "order_1", "error_description": "card declined"}}}}`,
+			want:      &ChargeUpdate{OrderID: "order_1", PaymentID: "pay_2", Status: ChargeFailed, FailureReason: "card declined"},
+		},
+		{
+			name:      "order paid without payment order ID",
+			eventType: "order.paid",
+			payload:   `{"payload": {"payment": {"entity": {"id": "pay_3"}}, "order": {"entity": {"id": "order_2"}}}}`,
+			want:      &ChargeUpdate{OrderID: "order_2", PaymentID: "pay_3", Status: ChargePaid},
+		},
+		{
+			name:      "unhandled type",
+			eventType: "refund.created",
+			payload:   `{}`,
+		},
+		{
+			name:      "no order",
+			eventType: "payment.captured",
+			payload:   `{"payload": {}}`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.DecodeEvent(tt.eventType, []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("DecodeEvent() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("DecodeEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
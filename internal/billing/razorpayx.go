@@ -0,0 +1,155 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+// RazorpayXClient makes payouts through the RazorpayX composite payouts API,
+// which creates the agent's contact and UPI fund account with the payout.
+type RazorpayXClient struct {
+	baseURL       string
+	keyID         string
+	keySecret     string
+	accountNumber string
+	webhookSecret string
+	httpClient    *http.Client
+}
+
+// NewRazorpayXClient creates a RazorpayX payouts client.
+func NewRazorpayXClient(cfg platform.PayoutConfig) *RazorpayXClient {
+	return &RazorpayXClient{
+		baseURL:       strings.TrimRight(cfg.RazorpayXBaseURL, "/"),
+		keyID:         cfg.RazorpayXKeyID,
+		keySecret:     cfg.RazorpayXKeySecret,
+		accountNumber: cfg.RazorpayXAccountNumber,
+		webhookSecret: cfg.RazorpayXWebhookSecret,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type razorpayXPayout struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// CreatePayout handles POST /v1/payouts with the payout ID as idempotency key.
+func (c *RazorpayXClient) CreatePayout(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	body, _ := json.Marshal(map[string]any{
+		"account_number": c.accountNumber,
+		"amount":         req.AmountPaise,
+		"currency":       "INR",
+		"mode":           "UPI",
+		"purpose":        "payout",
+		"fund_account": map[string]any{
+			"account_type": "vpa",
+			"vpa":          map[string]string{"address": req.UPIID},
+			"contact": map[string]string{
+				"name":         req.AgentName,
+				"contact":      req.Phone,
+				"type":         "vendor",
+				"reference_id": req.AgentID.String(),
+			},
+		},
+		"queue_if_low_balance": true,
+		"reference_id":         req.PayoutID.String(),
+		"narration":            req.Narration,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/payouts", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building payout request: %w", err)
+	}
+	httpReq.SetBasicAuth(c.keyID, c.keySecret)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Payout-Idempotency", req.PayoutID.String())
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("creating payout: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Code        string `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return nil, fmt.Errorf("creating payout: razorpayx returned %d: %s", resp.StatusCode, apiErr.Error.Description)
+	}
+
+	var payout razorpayXPayout
+	if err := json.Unmarshal(respBody, &payout); err != nil {
+		return nil, fmt.Errorf("decoding payout response: %w", err)
+	}
+	return &PayoutResult{ProviderID: payout.ID, Status: razorpayXStatus(payout.Status)}, nil
+}
+
+// ParseWebhook verifies the X-Razorpay-Signature header, an HMAC-SHA256 of
+// the body keyed with the webhook secret, and decodes payout.* events.
+func (c *RazorpayXClient) ParseWebhook(header http.Header, body []byte) (*PayoutUpdate, error) {
+	if !verifySignature(c.webhookSecret, body, header.Get("X-Razorpay-Signature")) {
+		return nil, platform.NewUnauthorized("invalid webhook signature")
+	}
+
+	var event struct {
+		Event   string `json:"event"`
+		Payload struct {
+			Payout struct {
+				Entity razorpayXPayout `json:"entity"`
+			} `json:"payout"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, platform.NewBadRequest("invalid webhook body")
+	}
+	if !strings.HasPrefix(event.Event, "payout.") {
+		return nil, nil
+	}
+
+	payout := event.Payload.Payout.Entity
+	return &PayoutUpdate{
+		ProviderID:    payout.ID,
+		Status:        razorpayXStatus(payout.Status),
+		FailureReason: payout.FailureReason,
+	}, nil
+}
+
+// razorpayXStatus maps a RazorpayX payout status to ours.
+func razorpayXStatus(status string) string {
+	switch status {
+	case "processed":
+		return PayoutPaid
+	case "rejected", "cancelled", "failed", "reversed":
+		return PayoutFailed
+	default: // queued, pending, scheduled, processing
+		return PayoutProcessing
+	}
+}
+
+// verifySignature checks a hex HMAC-SHA256 signature of body.
+func verifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
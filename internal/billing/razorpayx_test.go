@@ -0,0 +1,171 @@
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRazorpayXCreatePayout(t *testing.T) {
+	payoutID := uuid.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/payouts" {
+			t.Errorf("path = %s, want /v1/payouts", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "key" || pass != "secret" {
+			t.Errorf("basic auth = %q/%q, want key/secret", user, pass)
+		}
+		if got := r.Header.Get("X-Payout-Idempotency"); got != payoutID.String() {
+			t.Errorf("idempotency key = %q, want %q", got, payoutID)
+		}
+
+		var body struct {
+			Amount      int64  `json:"amount"`
+			Mode        string `json:"mode"`
+			FundAccount struct {
+				VPA struct {
+					Address string `json:"address"`
+				} `json:"vpa"`
+			} `json:"fund_account"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Amount != 850000 || body.Mode != "UPI" || body.FundAccount.VPA.Address != "agent@upi" {
+			t.Errorf("unexpected payout body: %+v", body)
+		}
+
+		w.Write([]byte(`{"id": "pout_123", "status": "queued"}`))
+	}))
+	defer srv.Close()
+
+	client := NewRazorpayXClient(platform.PayoutConfig{
+		RazorpayXBaseURL:   srv.URL + "/",
+		RazorpayXKeyID:     "key",
+		RazorpayXKeySecret: "secret",
+	})
+
+	result, err := client.CreatePayout(context.Background(), PayoutRequest{
+		PayoutID:    payoutID,
+		AgentID:     uuid.New(),
+		UPIID:       "agent@upi",
+		AmountPaise: 850000,
+	})
+	if err != nil {
+		t.Fatalf("CreatePayout: %v", err)
+	}
+	if result.ProviderID != "pout_123" || result.Status != PayoutProcessing {
+		t.Errorf("result = %+v, want pout_123/processing", result)
+	}
+}
+
+func TestRazorpayXCreatePayoutError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": "BAD_REQUEST_ERROR", "description": "invalid vpa"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewRazorpayXClient(platform.PayoutConfig{RazorpayXBaseURL: srv.URL})
+	if _, err := client.CreatePayout(context.Background(), PayoutRequest{PayoutID: uuid.New()}); err == nil {
+		t.Error("expected error for rejected payout request")
+	}
+}
+
+func TestRazorpayXParseWebhook(t *testing.T) {
+	client := NewRazorpayXClient(platform.PayoutConfig{RazorpayXWebhookSecret: "whsec"})
+
+	processed := []byte(`{"event": "payout.processed", "payload": {"payout": {"entity": {"id": "pout_1", "status": "processed"}}}}`)
+	reversed := []byte(`{"event": "payout.reversed", "payload": {"payout": {"entity": {"id": "pout_2", "status": "reversed", "failure_reason": "beneficiary bank offline"}}}}`)
+	other := []byte(`{"event": "transaction.created", "payload": {}}`)
+
+	tests := []struct {
+		name       string
+		body       []byte
+		signature  string
+		want       *PayoutUpdate
+		wantStatus int // AppError status, 0 for success
+	}{
+		{
+			name:      "processed",
+			body:      processed,
+			signature: sign("whsec", processed),
+			want:      &PayoutUpdate{ProviderID: "pout_1", Status: PayoutPaid},
+		},
+		{
+			name:      "reversed",
+			body:      reversed,
+			signature: sign("whsec", reversed),
+			want:      &PayoutUpdate{ProviderID: "pout_2", Status: PayoutFailed, FailureReason: "beneficiary bank offline"},
+		},
+		{
+			name:      "non-payout event ignored",
+			body:      other,
+			signature: sign("whsec", other),
+		},
+		{
+			name:       "wrong secret",
+			body:       processed,
+			signature:  sign("other", processed),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing signature",
+			body:       processed,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set("X-Razorpay-Signature", tt.signature)
+			}
+
+			got, err := client.ParseWebhook(header, tt.body)
+			if tt.wantStatus != 0 {
+				appErr, ok := platform.AsAppError(err)
+				if !ok || appErr.Status != tt.wantStatus {
+					t.Errorf("err = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("update = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRazorpayXStatus(t *testing.T) {
+	tests := map[string]string{
+		"processed":  PayoutPaid,
+		"reversed":   PayoutFailed,
+		"rejected":   PayoutFailed,
+		"cancelled":  PayoutFailed,
+		"queued":     PayoutProcessing,
+		"processing": PayoutProcessing,
+	}
+	for in, want := range tests {
+		if got := razorpayXStatus(in); got != want {
+			t.Errorf("razorpayXStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,1064 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/ledger"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Repository handles billing persistence.
+type Repository struct {
+	q  *sqlc.Queries
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a billing repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		q:  sqlc.New(db),
+		db: db,
+	}
+}
+
+// ClaimPayoutPeriod records that a period is being settled. It returns false
+// if the period was already claimed.
+func (r *Repository) ClaimPayoutPeriod(ctx context.Context, start, end time.Time) (bool, error) {
+	_, err := r.q.ClaimPayoutPeriod(ctx, sqlc.ClaimPayoutPeriodParams{
+		PeriodStart: date(start),
+		PeriodEnd:   date(end),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("claiming payout period: %w", err)
+	}
+	return true, nil
+}
+
+// ReleasePayoutPeriod removes the claim on an unsettled period so it can be
+// claimed again.
+func (r *Repository) ReleasePayoutPeriod(ctx context.Context, start time.Time) error {
+	if err := r.q.ReleasePayoutPeriod(ctx, date(start)); err != nil {
+		return fmt.Errorf("releasing payout period: %w", err)
+	}
+	return nil
+}
+
+// MarkPayoutPeriodSettled records that every agent's payout for a period was created.
+func (r *Repository) MarkPayoutPeriodSettled(ctx context.Context, start time.Time) error {
+	if err := r.q.MarkPayoutPeriodSettled(ctx, date(start)); err != nil {
+		return fmt.Errorf("marking payout period settled: %w", err)
+	}
+	return nil
+}
+
+// ListAgentsWithUnsettledJobs returns agents with QA-passed jobs completed
+// before the cutoff that no payout covers yet.
+func (r *Repository) ListAgentsWithUnsettledJobs(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	ids, err := r.q.ListAgentsWithUnsettledJobs(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("listing agents with unsettled jobs: %w", err)
+	}
+	return ids, nil
+}
+
+// SettleAgent creates an agent's payout for a period from their unsettled
+// jobs completed before the cutoff, linking the jobs to it. It returns nil if
+// the agent already has a payout for the period or has nothing to settle.
+func (r *Repository) SettleAgent(ctx context.Context, agentID uuid.UUID, start, end, cutoff time.Time, rules PayoutRules) (*sqlc.AgentPayout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning settlement transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	zero := inr(0)
+	payout, err := q.CreateAgentPayout(ctx, sqlc.CreateAgentPayoutParams{
+		AgentID:            agentID,
+		PeriodStart:        date(start),
+		PeriodEnd:          date(end),
+		GrossAmount:        zero,
+		PlatformCommission: zero,
+		TdsDeducted:        zero,
+		NetAmount:          zero,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // settled by an earlier run
+		}
+		return nil, fmt.Errorf("creating agent payout: %w", err)
+	}
+
+	jobs, err := q.AttachJobsToPayout(ctx, sqlc.AttachJobsToPayoutParams{
+		PayoutID:        pgtype.UUID{Bytes: payout.ID, Valid: true},
+		AssignedAgentID: pgtype.UUID{Bytes: agentID, Valid: true},
+		CompletedAt:     pgtype.Timestamptz{Time: cutoff, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attaching jobs to payout: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil // rolled back
+	}
+
+	var gross float64
+	for _, j := range jobs {
+		gross += numericToFloat64(j.TotalPayout)
+	}
+
+	taxable, err := q.GetAgentTaxableEarnings(ctx, sqlc.GetAgentTaxableEarningsParams{
+		AgentID:     agentID,
+		PeriodStart: date(financialYearStart(start)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting taxable earnings: %w", err)
+	}
+
+	amounts := rules.apply(gross, numericToFloat64(taxable))
+	totalJobs := int32(len(jobs))
+	payout, err = q.SetPayoutAmounts(ctx, sqlc.SetPayoutAmountsParams{
+		ID:                 payout.ID,
+		TotalJobs:          &totalJobs,
+		GrossAmount:        inr(amounts.Gross),
+		PlatformCommission: inr(amounts.Commission),
+		TdsDeducted:        inr(amounts.TDS),
+		NetAmount:          inr(amounts.Net),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setting payout amounts: %w", err)
+	}
+	if _, err := ledger.Post(ctx, q, payoutTransaction(payout)); err != nil {
+		return nil, fmt.Errorf("debiting agent wallet: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing settlement: %w", err)
+	}
+	return &payout, nil
+}
+
+// ListUnsentPayouts returns pending payouts not yet accepted by the provider.
+func (r *Repository) ListUnsentPayouts(ctx context.Context, limit int32) ([]sqlc.AgentPayout, error) {
+	payouts, err := r.q.ListUnsentPayouts(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing unsent payouts: %w", err)
+	}
+	return payouts, nil
+}
+
+// MarkPayoutSent records the provider's ID and status for a payout.
+func (r *Repository) MarkPayoutSent(ctx context.Context, id uuid.UUID, providerID, status string) (*sqlc.AgentPayout, error) {
+	payout, err := r.q.MarkPayoutSent(ctx, sqlc.MarkPayoutSentParams{
+		ID:               id,
+		Status:           &status,
+		RazorpayPayoutID: &providerID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marking payout sent: %w", err)
+	}
+	return &payout, nil
+}
+
+// MarkPayoutFailed fails a payout and the payout status of its jobs.
+func (r *Repository) MarkPayoutFailed(ctx context.Context, id uuid.UUID, reason string) (*sqlc.AgentPayout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning payout failure transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	payout, err := markFailed(ctx, r.q.WithTx(tx), id, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing payout failure: %w", err)
+	}
+	return payout, nil
+}
+
+// ApplyPayoutUpdate applies a provider's status update to the payout it
+// refers to and its jobs.
+func (r *Repository) ApplyPayoutUpdate(ctx context.Context, update PayoutUpdate) (*sqlc.AgentPayout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning payout update transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	payout, err := q.GetPayoutByProviderID(ctx, &update.ProviderID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("payout not found")
+		}
+		return nil, fmt.Errorf("getting payout by provider ID: %w", err)
+	}
+
+	result := &payout
+	switch update.Status {
+	case PayoutPaid:
+		if result, err = markPaid(ctx, q, payout.ID); err != nil {
+			return nil, err
+		}
+	case PayoutFailed:
+		reason := update.FailureReason
+		if reason == "" {
+			reason = "rejected by payout provider"
+		}
+		if result, err = markFailed(ctx, q, payout.ID, reason); err != nil {
+			return nil, err
+		}
+	default:
+		return result, nil // still in flight
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing payout update: %w", err)
+	}
+	return result, nil
+}
+
+// MarkPayoutPaid marks a payout and its jobs paid.
+func (r *Repository) MarkPayoutPaid(ctx context.Context, id uuid.UUID) (*sqlc.AgentPayout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning payout paid transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	payout, err := markPaid(ctx, r.q.WithTx(tx), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing payout paid: %w", err)
+	}
+	return payout, nil
+}
+
+// markPaid marks a payout and its jobs paid within the caller's transaction.
+func markPaid(ctx context.Context, q *sqlc.Queries, id uuid.UUID) (*sqlc.AgentPayout, error) {
+	payout, err := q.MarkPayoutPaid(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("marking payout paid: %w", err)
+	}
+	status := PayoutPaid
+	if err := q.SetJobsPayoutStatus(ctx, sqlc.SetJobsPayoutStatusParams{
+		PayoutID:     pgtype.UUID{Bytes: id, Valid: true},
+		PayoutStatus: &status,
+	}); err != nil {
+		return nil, fmt.Errorf("marking jobs paid: %w", err)
+	}
+	if _, err := ledger.Post(ctx, q, ledger.PayoutPaid(id, numericToFloat64(payout.NetAmount))); err != nil {
+		return nil, fmt.Errorf("recording payout transfer: %w", err)
+	}
+	return &payout, nil
+}
+
+// markFailed fails a payout and its jobs within the caller's transaction.
+func markFailed(ctx context.Context, q *sqlc.Queries, id uuid.UUID, reason string) (*sqlc.AgentPayout, error) {
+	payout, err := q.MarkPayoutFailed(ctx, sqlc.MarkPayoutFailedParams{ID: id, FailureReason: &reason})
+	if err != nil {
+		return nil, fmt.Errorf("marking payout failed: %w", err)
+	}
+	status := PayoutFailed
+	if err := q.SetJobsPayoutStatus(ctx, sqlc.SetJobsPayoutStatusParams{
+		PayoutID:     pgtype.UUID{Bytes: id, Valid: true},
+		PayoutStatus: &status,
+	}); err != nil {
+		return nil, fmt.Errorf("marking jobs payout failed: %w", err)
+	}
+	// The money was never transferred, so it goes back to the agent's wallet
+	reversal := payoutTransaction(payout).Reversal(ledger.KindPayoutReversal, "Payout failed: "+reason)
+	if _, err := ledger.Post(ctx, q, reversal); err != nil {
+		return nil, fmt.Errorf("reversing payout in wallet: %w", err)
+	}
+	return &payout, nil
+}
+
+// payoutTransaction is the ledger transaction debiting a payout from the
+// agent's wallet.
+func payoutTransaction(p sqlc.AgentPayout) ledger.Transaction {
+	return ledger.Payout(p.AgentID, p.ID,
+		numericToFloat64(p.GrossAmount),
+		numericToFloat64(p.PlatformCommission),
+		numericToFloat64(p.TdsDeducted),
+		numericToFloat64(p.NetAmount),
+	)
+}
+
+// ListPayoutsByAgent returns an agent's payouts, newest first, and their total count.
+func (r *Repository) ListPayoutsByAgent(ctx context.Context, agentID uuid.UUID, limit, offset int32) ([]sqlc.AgentPayout, int64, error) {
+	payouts, err := r.q.ListPayoutsByAgent(ctx, sqlc.ListPayoutsByAgentParams{
+		AgentID: agentID,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing payouts by agent: %w", err)
+	}
+	total, err := r.q.CountPayoutsByAgent(ctx, agentID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting payouts by agent: %w", err)
+	}
+	return payouts, total, nil
+}
+
+// GetEarningsSummary totals an agent's payouts and unsettled earnings.
+func (r *Repository) GetEarningsSummary(ctx context.Context, agentID uuid.UUID) (*EarningsSummary, error) {
+	totals, err := r.q.GetAgentPayoutTotals(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting payout totals: %w", err)
+	}
+	unsettled, err := r.q.GetAgentUnsettledEarnings(ctx, pgtype.UUID{Bytes: agentID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("getting unsettled earnings: %w", err)
+	}
+	return &EarningsSummary{
+		Paid:          numericToFloat64(totals.Paid),
+		InTransit:     numericToFloat64(totals.InTransit),
+		Unsettled:     numericToFloat64(unsettled.Amount),
+		UnsettledJobs: unsettled.Jobs,
+		TDSDeducted:   numericToFloat64(totals.TdsDeducted),
+		FailedPayouts: totals.FailedPayouts,
+	}, nil
+}
+
+// SubscriptionChange is a subscription after an operation on it, and the
+// charge the operation raised, if any.
+type SubscriptionChange struct {
+	Subscription sqlc.Subscription
+	Charge       *sqlc.Transaction
+}
+
+// CreateSubscription subscribes a landowner's parcel to a plan and charges
+// the first period. The subscription stays pending until that charge is
+// paid; its first period starts on payment.
+func (r *Repository) CreateSubscription(ctx context.Context, gateway PaymentGateway, userID, parcelID uuid.UUID, plan Plan, now time.Time) (*SubscriptionChange, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning subscription transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	parcel, err := q.GetParcelForUpdate(ctx, parcelID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("parcel not found")
+		}
+		return nil, fmt.Errorf("locking parcel: %w", err)
+	}
+	if parcel.UserID != userID {
+		return nil, platform.NewForbidden("you do not own this parcel")
+	}
+	if parcel.Status == nil || *parcel.Status != "active" {
+		return nil, platform.NewConflict("parcel is not active")
+	}
+
+	if _, err := q.GetLiveSubscription(ctx, parcelID); err == nil {
+		return nil, platform.NewConflict("parcel already has a subscription")
+	} else if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("getting live subscription: %w", err)
+	}
+
+	status := SubscriptionPending
+	onDemand := int32(plan.OnDemandVisits)
+	sub, err := q.CreateSubscription(ctx, sqlc.CreateSubscriptionParams{
+		UserID:                  userID,
+		ParcelID:                parcelID,
+		Plan:                    plan.Name,
+		Status:                  &status,
+		AmountPerCycle:          inr(amount(plan.Price)),
+		OnDemandVisitsRemaining: &onDemand,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating subscription: %w", err)
+	}
+
+	charge, err := raiseCharge(ctx, q, gateway, sub, ChargeSubscription, amount(plan.Price))
+	if err != nil {
+		return nil, err
+	}
+	if *charge.Status == ChargePaid {
+		if sub, err = activate(ctx, q, sub.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing subscription: %w", err)
+	}
+	return &SubscriptionChange{Subscription: sub, Charge: charge}, nil
+}
+
+// GetSubscription returns a parcel's pending, active or paused subscription.
+func (r *Repository) GetSubscription(ctx context.Context, userID, parcelID uuid.UUID) (*sqlc.Subscription, error) {
+	sub, err := r.q.GetLiveSubscription(ctx, parcelID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("parcel has no subscription")
+		}
+		return nil, fmt.Errorf("getting subscription: %w", err)
+	}
+	if sub.UserID != userID {
+		return nil, platform.NewForbidden("you do not own this parcel")
+	}
+	return &sub, nil
+}
+
+// ChangePlan moves an active subscription to another plan immediately. An
+// upgrade charges the price difference for the rest of the period, less any
+// credit; a downgrade credits the difference against the next renewal.
+func (r *Repository) ChangePlan(ctx context.Context, gateway PaymentGateway, userID, parcelID uuid.UUID, plan Plan, upgrade bool, now time.Time) (*SubscriptionChange, error) {
+	var charge *sqlc.Transaction
+	sub, err := r.updateSubscription(ctx, userID, parcelID, func(q *sqlc.Queries, sub sqlc.Subscription) (sqlc.Subscription, error) {
+		if *sub.Status != SubscriptionActive {
+			return sub, platform.NewConflict("only an active subscription can change plan")
+		}
+		if sub.Plan == plan.Name {
+			return sub, platform.NewConflict("subscription is already on the " + plan.Name + " plan")
+		}
+
+		oldPrice, newPrice := numericToFloat64(sub.AmountPerCycle), amount(plan.Price)
+		if upgrade && newPrice <= oldPrice {
+			return sub, platform.NewValidation("the " + plan.Name + " plan is not an upgrade")
+		}
+		if !upgrade && newPrice >= oldPrice {
+			return sub, platform.NewValidation("the " + plan.Name + " plan is not a downgrade")
+		}
+
+		due := prorate(oldPrice, newPrice, sub.CurrentPeriodStart.Time, sub.CurrentPeriodEnd.Time, now)
+		credit := numericToFloat64(sub.CreditBalance)
+		var owed float64
+		if due > 0 {
+			owed, credit = applyCredit(due, credit)
+		} else {
+			credit = paise(credit - due)
+		}
+
+		sub, err := q.ChangeSubscriptionPlan(ctx, sqlc.ChangeSubscriptionPlanParams{
+			ID:             sub.ID,
+			Plan:           plan.Name,
+			AmountPerCycle: inr(newPrice),
+			CreditBalance:  inr(credit),
+		})
+		if err != nil {
+			return sub, fmt.Errorf("changing subscription plan: %w", err)
+		}
+		if owed > 0 {
+			if charge, err = raiseCharge(ctx, q, gateway, sub, ChargeProration, owed); err != nil {
+				return sub, err
+			}
+		}
+		return sub, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SubscriptionChange{Subscription: *sub, Charge: charge}, nil
+}
+
+// PauseSubscription pauses an active subscription. No visits are scheduled
+// and the period does not run while it is paused.
+func (r *Repository) PauseSubscription(ctx context.Context, userID, parcelID uuid.UUID) (*sqlc.Subscription, error) {
+	return r.updateSubscription(ctx, userID, parcelID, func(q *sqlc.Queries, sub sqlc.Subscription) (sqlc.Subscription, error) {
+		paused, err := q.PauseSubscription(ctx, sub.ID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return sub, platform.NewConflict("only an active subscription can be paused")
+			}
+			return sub, fmt.Errorf("pausing subscription: %w", err)
+		}
+		return paused, nil
+	})
+}
+
+// ResumeSubscription resumes a paused subscription, extending its period by
+// the time it was paused.
+func (r *Repository) ResumeSubscription(ctx context.Context, userID, parcelID uuid.UUID) (*sqlc.Subscription, error) {
+	return r.updateSubscription(ctx, userID, parcelID, func(q *sqlc.Queries, sub sqlc.Subscription) (sqlc.Subscription, error) {
+		resumed, err := q.ResumeSubscription(ctx, sub.ID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return sub, platform.NewConflict("subscription is not paused")
+			}
+			return sub, fmt.Errorf("resuming subscription: %w", err)
+		}
+		return resumed, nil
+	})
+}
+
+// CancelSubscription cancels a subscription. An active subscription runs to
+// the end of the period it was paid for; a pending or paused one ends now.
+func (r *Repository) CancelSubscription(ctx context.Context, userID, parcelID uuid.UUID) (*sqlc.Subscription, error) {
+	return r.updateSubscription(ctx, userID, parcelID, func(q *sqlc.Queries, sub sqlc.Subscription) (sqlc.Subscription, error) {
+		if *sub.Status == SubscriptionActive {
+			sub, err := q.SetCancelAtPeriodEnd(ctx, sub.ID)
+			if err != nil {
+				return sub, fmt.Errorf("setting cancel at period end: %w", err)
+			}
+			return sub, nil
+		}
+		sub, err := q.CancelSubscription(ctx, sub.ID)
+		if err != nil {
+			return sub, fmt.Errorf("cancelling subscription: %w", err)
+		}
+		return sub, nil
+	})
+}
+
+// updateSubscription locks a landowner's live subscription on a parcel and
+// applies fn to it in one transaction.
+func (r *Repository) updateSubscription(ctx context.Context, userID, parcelID uuid.UUID, fn func(q *sqlc.Queries, sub sqlc.Subscription) (sqlc.Subscription, error)) (*sqlc.Subscription, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning subscription transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	sub, err := q.GetLiveSubscriptionForUpdate(ctx, parcelID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("parcel has no subscription")
+		}
+		return nil, fmt.Errorf("locking subscription: %w", err)
+	}
+	if sub.UserID != userID {
+		return nil, platform.NewForbidden("you do not own this parcel")
+	}
+
+	if sub, err = fn(q, sub); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing subscription update: %w", err)
+	}
+	return &sub, nil
+}
+
+// ConfirmPayment records a landowner's checkout payment for a pending
+// charge, activating the subscription if it paid for the first period.
+// Confirming a charge twice returns it unchanged.
+func (r *Repository) ConfirmPayment(ctx context.Context, gateway PaymentGateway, userID uuid.UUID, orderID, paymentID, signature string, now time.Time) (*sqlc.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning payment transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	charge, err := q.GetTransactionByOrderForUpdate(ctx, &orderID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("order not found")
+		}
+		return nil, fmt.Errorf("getting transaction by order: %w", err)
+	}
+	if charge.UserID != userID {
+		return nil, platform.NewNotFound("order not found")
+	}
+	if err := gateway.VerifyPayment(orderID, paymentID, signature); err != nil {
+		return nil, err
+	}
+	if charge.Status != nil && *charge.Status == ChargePaid {
+		return &charge, nil
+	}
+
+	if charge, err = markChargePaid(ctx, q, charge, paymentID, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing payment: %w", err)
+	}
+	return &charge, nil
+}
+
+// markChargePaid records the payment of a charge within the caller's
+// transaction, activating the subscription if it paid for the first period.
+func markChargePaid(ctx context.Context, q *sqlc.Queries, charge sqlc.Transaction, paymentID string, now time.Time) (sqlc.Transaction, error) {
+	charge, err := q.MarkTransactionPaid(ctx, sqlc.MarkTransactionPaidParams{
+		ID:                charge.ID,
+		RazorpayPaymentID: &paymentID,
+	})
+	if err != nil {
+		return charge, fmt.Errorf("marking transaction paid: %w", err)
+	}
+	if charge.Type == ChargeSubscription && charge.SubscriptionID.Valid {
+		// Already active, or cancelled before it was paid for
+		if _, err := activate(ctx, q, uuid.UUID(charge.SubscriptionID.Bytes), now); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return charge, err
+		}
+	}
+	return charge, nil
+}
+
+// RecordPaymentEvent stores a webhook event. It returns false with the
+// stored event if the event ID was already received.
+func (r *Repository) RecordPaymentEvent(ctx context.Context, event WebhookEvent, payload []byte) (*sqlc.PaymentEvent, bool, error) {
+	stored, err := r.q.CreatePaymentEvent(ctx, sqlc.CreatePaymentEventParams{
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   payload,
+	})
+	if err == nil {
+		return &stored, true, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, false, fmt.Errorf("storing payment event: %w", err)
+	}
+
+	stored, err = r.q.GetPaymentEventByEventID(ctx, event.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("getting payment event: %w", err)
+	}
+	return &stored, false, nil
+}
+
+// ApplyPaymentEvent applies a stored webhook event to the charge it refers
+// to and records the outcome. An event already processed or ignored is left
+// as is. It returns the event's new status.
+func (r *Repository) ApplyPaymentEvent(ctx context.Context, gateway PaymentGateway, id uuid.UUID, now time.Time) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("beginning payment event transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	event, err := q.GetPaymentEventForUpdate(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", platform.NewNotFound("payment event not found")
+		}
+		return "", fmt.Errorf("locking payment event: %w", err)
+	}
+	if event.Status == EventProcessed || event.Status == EventIgnored {
+		return event.Status, nil
+	}
+
+	update, err := gateway.DecodeEvent(event.EventType, event.Payload)
+	if err != nil {
+		return "", err
+	}
+	status := EventIgnored
+	if update != nil {
+		if err := applyChargeUpdate(ctx, q, *update, now); err != nil {
+			return "", err
+		}
+		status = EventProcessed
+	}
+
+	if err := q.CompletePaymentEvent(ctx, sqlc.CompletePaymentEventParams{ID: id, Status: status}); err != nil {
+		return "", fmt.Errorf("completing payment event: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("committing payment event: %w", err)
+	}
+	return status, nil
+}
+
+// applyChargeUpdate applies a gateway's charge status change within the
+// caller's transaction. Only pending charges change: a payment confirmed at
+// checkout first, or a failure reported after a successful retry, is a no-op.
+func applyChargeUpdate(ctx context.Context, q *sqlc.Queries, update ChargeUpdate, now time.Time) error {
+	charge, err := q.GetTransactionByOrderForUpdate(ctx, &update.OrderID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return platform.NewNotFound("no transaction for order " + update.OrderID)
+		}
+		return fmt.Errorf("getting transaction by order: %w", err)
+	}
+	if charge.Status == nil || *charge.Status != ChargePending {
+		return nil
+	}
+
+	switch update.Status {
+	case ChargePaid:
+		_, err = markChargePaid(ctx, q, charge, update.PaymentID, now)
+		return err
+	case ChargeFailed:
+		if _, err := q.MarkTransactionFailed(ctx, charge.ID); err != nil {
+			return fmt.Errorf("marking transaction failed: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown charge status %q", update.Status)
+	}
+}
+
+// FailPaymentEvent records that processing a webhook event failed.
+func (r *Repository) FailPaymentEvent(ctx context.Context, id uuid.UUID, reason string) error {
+	if err := r.q.FailPaymentEvent(ctx, sqlc.FailPaymentEventParams{ID: id, LastError: &reason}); err != nil {
+		return fmt.Errorf("failing payment event: %w", err)
+	}
+	return nil
+}
+
+// ReplayPaymentEvent returns a failed webhook event to the received state so
+// it can be processed again.
+func (r *Repository) ReplayPaymentEvent(ctx context.Context, id uuid.UUID) (*sqlc.PaymentEvent, error) {
+	event, err := r.q.ReplayPaymentEvent(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if _, err := r.GetPaymentEvent(ctx, id); err != nil {
+				return nil, err
+			}
+			return nil, platform.NewConflict("only failed events can be replayed")
+		}
+		return nil, fmt.Errorf("replaying payment event: %w", err)
+	}
+	return &event, nil
+}
+
+// GetPaymentEvent returns a stored webhook event.
+func (r *Repository) GetPaymentEvent(ctx context.Context, id uuid.UUID) (*sqlc.PaymentEvent, error) {
+	event, err := r.q.GetPaymentEvent(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("payment event not found")
+		}
+		return nil, fmt.Errorf("getting payment event: %w", err)
+	}
+	return &event, nil
+}
+
+// ListPaymentEvents returns stored webhook events, newest first, optionally
+// filtered by status, and their total count.
+func (r *Repository) ListPaymentEvents(ctx context.Context, status *string, limit, offset int32) ([]sqlc.PaymentEvent, int64, error) {
+	events, err := r.q.ListPaymentEvents(ctx, sqlc.ListPaymentEventsParams{
+		Status: status,
+		Lim:    limit,
+		Off:    offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing payment events: %w", err)
+	}
+	total, err := r.q.CountPaymentEvents(ctx, status)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting payment events: %w", err)
+	}
+	return events, total, nil
+}
+
+// ListTransactions returns a landowner's charges, newest first, and their total count.
+func (r *Repository) ListTransactions(ctx context.Context, userID uuid.UUID, limit, offset int32) ([]sqlc.Transaction, int64, error) {
+	transactions, err := r.q.ListTransactionsByUser(ctx, sqlc.ListTransactionsByUserParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing transactions by user: %w", err)
+	}
+	total, err := r.q.CountTransactionsByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting transactions by user: %w", err)
+	}
+	return transactions, total, nil
+}
+
+// ClaimDueRenewals claims up to limit active subscriptions whose period
+// ended by now.
+func (r *Repository) ClaimDueRenewals(ctx context.Context, now time.Time, limit int32) ([]sqlc.ClaimDueRenewalsRow, error) {
+	due, err := r.q.ClaimDueRenewals(ctx, sqlc.ClaimDueRenewalsParams{
+		Now: pgtype.Timestamptz{Time: now, Valid: true},
+		Lim: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claiming due renewals: %w", err)
+	}
+	return due, nil
+}
+
+// ReleaseRenewalClaim lets a subscription's renewal be claimed again.
+func (r *Repository) ReleaseRenewalClaim(ctx context.Context, id uuid.UUID) error {
+	if err := r.q.ReleaseRenewalClaim(ctx, id); err != nil {
+		return fmt.Errorf("releasing renewal claim: %w", err)
+	}
+	return nil
+}
+
+// RenewSubscription ends a subscription's period that ended at periodEnd:
+// it cancels the subscription if it was set to end, or starts the next
+// period and charges for it. It returns nil if the subscription is no longer
+// active or that period was already renewed.
+func (r *Repository) RenewSubscription(ctx context.Context, gateway PaymentGateway, id uuid.UUID, periodEnd, now time.Time) (*SubscriptionChange, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning renewal transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	sub, err := q.GetSubscriptionForUpdate(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("subscription not found")
+		}
+		return nil, fmt.Errorf("locking subscription: %w", err)
+	}
+	if sub.Status == nil || *sub.Status != SubscriptionActive || !sub.CurrentPeriodEnd.Time.Equal(periodEnd) {
+		return nil, nil
+	}
+
+	change := &SubscriptionChange{}
+	if sub.CancelAtPeriodEnd != nil && *sub.CancelAtPeriodEnd {
+		if change.Subscription, err = q.CancelSubscription(ctx, id); err != nil {
+			return nil, fmt.Errorf("cancelling subscription: %w", err)
+		}
+	} else {
+		plan, ok := GetPlan(sub.Plan)
+		if !ok {
+			return nil, fmt.Errorf("subscription %s has unknown plan %q", id, sub.Plan)
+		}
+		owed, credit := applyCredit(numericToFloat64(sub.AmountPerCycle), numericToFloat64(sub.CreditBalance))
+		start, end := renewalPeriod(periodEnd, now)
+		onDemand := int32(plan.OnDemandVisits)
+		if change.Subscription, err = q.RenewSubscription(ctx, sqlc.RenewSubscriptionParams{
+			ID:                      id,
+			CurrentPeriodStart:      pgtype.Timestamptz{Time: start, Valid: true},
+			CurrentPeriodEnd:        pgtype.Timestamptz{Time: end, Valid: true},
+			CreditBalance:           inr(credit),
+			OnDemandVisitsRemaining: &onDemand,
+		}); err != nil {
+			return nil, fmt.Errorf("renewing subscription: %w", err)
+		}
+		if owed > 0 {
+			if change.Charge, err = raiseCharge(ctx, q, gateway, change.Subscription, ChargeRenewal, owed); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing renewal: %w", err)
+	}
+	return change, nil
+}
+
+// activate starts a pending subscription's first period at now. It fails
+// with pgx.ErrNoRows if the subscription is not pending.
+func activate(ctx context.Context, q *sqlc.Queries, id uuid.UUID, now time.Time) (sqlc.Subscription, error) {
+	sub, err := q.ActivateSubscription(ctx, sqlc.ActivateSubscriptionParams{
+		ID:                 id,
+		CurrentPeriodStart: pgtype.Timestamptz{Time: now, Valid: true},
+		CurrentPeriodEnd:   pgtype.Timestamptz{Time: nextPeriodEnd(now), Valid: true},
+	})
+	if err != nil {
+		return sub, fmt.Errorf("activating subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// raiseCharge opens a gateway order for an amount due on a subscription and
+// records it as a transaction, paid if the gateway captured it immediately.
+func raiseCharge(ctx context.Context, q *sqlc.Queries, gateway PaymentGateway, sub sqlc.Subscription, chargeType string, due float64) (*sqlc.Transaction, error) {
+	order, err := gateway.CreateOrder(ctx, OrderRequest{
+		Receipt:     sub.ID.String(),
+		AmountPaise: int64(math.Round(due * 100)),
+		Notes: map[string]string{
+			"subscription_id": sub.ID.String(),
+			"type":            chargeType,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating payment order: %w", err)
+	}
+
+	status := ChargePending
+	var paymentID *string
+	if order.PaymentID != "" {
+		status, paymentID = ChargePaid, &order.PaymentID
+	}
+	charge, err := q.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+		UserID:            sub.UserID,
+		SubscriptionID:    pgtype.UUID{Bytes: sub.ID, Valid: true},
+		Type:              chargeType,
+		Amount:            inr(due),
+		Status:            &status,
+		RazorpayPaymentID: paymentID,
+		RazorpayOrderID:   &order.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating transaction: %w", err)
+	}
+	return &charge, nil
+}
+
+// ListUninvoicedTransactions returns up to limit paid transactions, oldest
+// first, that have no invoice yet.
+func (r *Repository) ListUninvoicedTransactions(ctx context.Context, limit int32) ([]sqlc.Transaction, error) {
+	charges, err := r.q.ListUninvoicedTransactions(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing uninvoiced transactions: %w", err)
+	}
+	return charges, nil
+}
+
+// IssueInvoice numbers and records the invoice for a paid transaction,
+// returning the existing invoice if it has one. publish renders and stores
+// the invoice and returns its S3 key; it runs before the invoice number is
+// committed, so an invoice that fails to publish leaves no gap.
+func (r *Repository) IssueInvoice(ctx context.Context, transactionID uuid.UUID, rules InvoiceRules, now time.Time, publish func(ctx context.Context, doc InvoiceDocument) (string, error)) (*sqlc.Invoice, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning invoice transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	charge, err := q.GetTransactionForUpdate(ctx, transactionID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("transaction not found")
+		}
+		return nil, fmt.Errorf("locking transaction: %w", err)
+	}
+	if charge.Status == nil || *charge.Status != ChargePaid {
+		return nil, platform.NewConflict("only paid transactions are invoiced")
+	}
+	if existing, err := q.GetInvoiceByTransaction(ctx, transactionID); err == nil {
+		return &existing, nil
+	} else if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("getting invoice by transaction: %w", err)
+	}
+
+	user, err := q.GetUserByID(ctx, charge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	var plan string
+	if charge.SubscriptionID.Valid {
+		sub, err := q.GetSubscriptionByID(ctx, uuid.UUID(charge.SubscriptionID.Bytes))
+		if err != nil {
+			return nil, fmt.Errorf("getting subscription: %w", err)
+		}
+		plan = sub.Plan
+	}
+
+	var state string
+	if user.StateCode != nil {
+		state = *user.StateCode
+	}
+	fy := financialYear(now)
+	sequence, err := q.NextInvoiceSequence(ctx, fy)
+	if err != nil {
+		return nil, fmt.Errorf("allocating invoice number: %w", err)
+	}
+
+	doc := InvoiceDocument{
+		Number:        rules.invoiceNumber(fy, sequence),
+		FinancialYear: fy,
+		IssuedAt:      now.In(ist),
+		CustomerName:  user.FullName,
+		CustomerPhone: user.Phone,
+		PlaceOfSupply: rules.placeOfSupply(state),
+		Description:   chargeDescription(charge.Type, plan),
+		SACCode:       rules.SACCode,
+		Tax:           rules.tax(numericToFloat64(charge.Amount), state),
+	}
+	if charge.RazorpayPaymentID != nil {
+		doc.PaymentID = *charge.RazorpayPaymentID
+	}
+
+	key, err := publish(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := q.CreateInvoice(ctx, sqlc.CreateInvoiceParams{
+		InvoiceNumber: doc.Number,
+		FinancialYear: fy,
+		Sequence:      sequence,
+		TransactionID: transactionID,
+		UserID:        charge.UserID,
+		PlaceOfSupply: doc.PlaceOfSupply,
+		Interstate:    doc.Tax.Interstate,
+		TaxableAmount: inr(doc.Tax.Taxable),
+		Cgst:          inr(doc.Tax.CGST),
+		Sgst:          inr(doc.Tax.SGST),
+		Igst:          inr(doc.Tax.IGST),
+		TotalAmount:   inr(doc.Tax.Total),
+		S3Key:         key,
+		IssuedAt:      now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating invoice: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing invoice: %w", err)
+	}
+	return &inv, nil
+}
+
+// ListInvoices returns a landowner's invoices, newest first, and their total count.
+func (r *Repository) ListInvoices(ctx context.Context, userID uuid.UUID, limit, offset int32) ([]sqlc.Invoice, int64, error) {
+	invoices, err := r.q.ListInvoicesByUser(ctx, sqlc.ListInvoicesByUserParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing invoices by user: %w", err)
+	}
+	total, err := r.q.CountInvoicesByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting invoices by user: %w", err)
+	}
+	return invoices, total, nil
+}
+
+// GetInvoice returns one of a landowner's invoices.
+func (r *Repository) GetInvoice(ctx context.Context, userID, id uuid.UUID) (*sqlc.Invoice, error) {
+	inv, err := r.q.GetInvoiceByID(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("invoice not found")
+		}
+		return nil, fmt.Errorf("getting invoice: %w", err)
+	}
+	if inv.UserID != userID {
+		return nil, platform.NewNotFound("invoice not found")
+	}
+	return &inv, nil
+}
+
+// date converts a time to a DATE value.
+func date(t time.Time) pgtype.Date {
+	return pgtype.Date{Time: t, Valid: true}
+}
+
+// inr converts an amount to a NUMERIC(10,2) value.
+func inr(amount float64) pgtype.Numeric {
+	n := pgtype.Numeric{}
+	n.Scan(fmt.Sprintf("%.2f", amount))
+	return n
+}
+
+// numericToFloat64 converts pgtype.Numeric to float64 (defaults to 0).
+func numericToFloat64(n pgtype.Numeric) float64 {
+	if !n.Valid || n.Int == nil {
+		return 0
+	}
+	f, _ := n.Float64Value()
+	if !f.Valid {
+		return 0
+	}
+	return math.Round(f.Float64*100) / 100
+}
@@ -0,0 +1,310 @@
+package billing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// SubscriptionHandler handles landowner subscription and payment endpoints.
+type SubscriptionHandler struct {
+	repo     *Repository
+	gateway  PaymentGateway
+	authRepo *auth.Repository
+	logger   *slog.Logger
+}
+
+// NewSubscriptionHandler creates a subscription handler.
+func NewSubscriptionHandler(repo *Repository, gateway PaymentGateway, authRepo *auth.Repository, logger *slog.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		repo:     repo,
+		gateway:  gateway,
+		authRepo: authRepo,
+		logger:   logger,
+	}
+}
+
+// ListPlans handles GET /v1/billing/plans.
+func (h *SubscriptionHandler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	all := Plans()
+	result := make([]PlanResponse, len(all))
+	for i, p := range all {
+		result[i] = PlanResponse{
+			Name:              p.Name,
+			Price:             amount(p.Price),
+			VisitIntervalDays: int(p.VisitInterval / day),
+			VisitsPerPeriod:   p.VisitsPerPeriod,
+			SurveyType:        p.SurveyType,
+			OnDemandVisits:    p.OnDemandVisits,
+			OnDemandPrice:     amount(p.OnDemandPrice),
+		}
+	}
+	platform.JSON(w, http.StatusOK, result)
+}
+
+// ListTransactions handles GET /v1/billing/transactions.
+func (h *SubscriptionHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	transactions, total, err := h.repo.ListTransactions(r.Context(), user.ID, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]ChargeResponse, len(transactions))
+	for i, t := range transactions {
+		result[i] = chargeResponse(t)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// VerifyPayment handles POST /v1/billing/payments/verify, the checkout
+// result for a pending charge.
+func (h *SubscriptionHandler) VerifyPayment(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerifyPaymentRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.OrderID == "" || req.PaymentID == "" || req.Signature == "" {
+		platform.HandleError(w, platform.NewValidation("razorpay_order_id, razorpay_payment_id and razorpay_signature are required"))
+		return
+	}
+
+	charge, err := h.repo.ConfirmPayment(r.Context(), h.gateway, user.ID, req.OrderID, req.PaymentID, req.Signature, time.Now())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("payment confirmed", "transaction_id", charge.ID, "order_id", req.OrderID)
+	platform.JSON(w, http.StatusOK, chargeResponse(*charge))
+}
+
+// CreateSubscription handles POST /v1/parcels/{parcelId}/subscription.
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	user, parcelID, ok := h.parcelRequest(w, r)
+	if !ok {
+		return
+	}
+	plan, ok := decodePlan(w, r)
+	if !ok {
+		return
+	}
+
+	change, err := h.repo.CreateSubscription(r.Context(), h.gateway, user.ID, parcelID, plan, time.Now())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("subscription created",
+		"subscription_id", change.Subscription.ID,
+		"parcel_id", parcelID,
+		"plan", plan.Name,
+		"status", *change.Subscription.Status,
+	)
+	platform.JSON(w, http.StatusCreated, subscriptionResponse(change.Subscription, change.Charge))
+}
+
+// GetSubscription handles GET /v1/parcels/{parcelId}/subscription.
+func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	user, parcelID, ok := h.parcelRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.repo.GetSubscription(r.Context(), user.ID, parcelID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, subscriptionResponse(*sub, nil))
+}
+
+// Upgrade handles POST /v1/parcels/{parcelId}/subscription/upgrade.
+func (h *SubscriptionHandler) Upgrade(w http.ResponseWriter, r *http.Request) {
+	h.changePlan(w, r, true)
+}
+
+// Downgrade handles POST /v1/parcels/{parcelId}/subscription/downgrade.
+func (h *SubscriptionHandler) Downgrade(w http.ResponseWriter, r *http.Request) {
+	h.changePlan(w, r, false)
+}
+
+func (h *SubscriptionHandler) changePlan(w http.ResponseWriter, r *http.Request, upgrade bool) {
+	user, parcelID, ok := h.parcelRequest(w, r)
+	if !ok {
+		return
+	}
+	plan, ok := decodePlan(w, r)
+	if !ok {
+		return
+	}
+
+	change, err := h.repo.ChangePlan(r.Context(), h.gateway, user.ID, parcelID, plan, upgrade, time.Now())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("subscription plan changed",
+		"subscription_id", change.Subscription.ID,
+		"plan", plan.Name,
+		"upgrade", upgrade,
+	)
+	platform.JSON(w, http.StatusOK, subscriptionResponse(change.Subscription, change.Charge))
+}
+
+// Pause handles POST /v1/parcels/{parcelId}/subscription/pause.
+func (h *SubscriptionHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, "paused", h.repo.PauseSubscription)
+}
+
+// Resume handles POST /v1/parcels/{parcelId}/subscription/resume.
+func (h *SubscriptionHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, "resumed", h.repo.ResumeSubscription)
+}
+
+// Cancel handles POST /v1/parcels/{parcelId}/subscription/cancel.
+func (h *SubscriptionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, "cancelled", h.repo.CancelSubscription)
+}
+
+func (h *SubscriptionHandler) update(w http.ResponseWriter, r *http.Request, action string, fn func(ctx context.Context, userID, parcelID uuid.UUID) (*sqlc.Subscription, error)) {
+	user, parcelID, ok := h.parcelRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := fn(r.Context(), user.ID, parcelID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("subscription "+action, "subscription_id", sub.ID, "parcel_id", parcelID)
+	platform.JSON(w, http.StatusOK, subscriptionResponse(*sub, nil))
+}
+
+// currentUser resolves the authenticated landowner, writing an error
+// response if there is none.
+func (h *SubscriptionHandler) currentUser(w http.ResponseWriter, r *http.Request) (*sqlc.User, bool) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return nil, false
+	}
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// parcelRequest resolves the landowner and the parcel in the URL.
+func (h *SubscriptionHandler) parcelRequest(w http.ResponseWriter, r *http.Request) (*sqlc.User, uuid.UUID, bool) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return nil, uuid.Nil, false
+	}
+	parcelID, err := uuid.Parse(chi.URLParam(r, "parcelId"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid parcel ID"))
+		return nil, uuid.Nil, false
+	}
+	user, ok := h.currentUser(w, r)
+	return user, parcelID, ok
+}
+
+// decodePlan reads a SubscriptionRequest and looks up its plan.
+func decodePlan(w http.ResponseWriter, r *http.Request) (Plan, bool) {
+	var req SubscriptionRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return Plan{}, false
+	}
+	plan, ok := GetPlan(req.Plan)
+	if !ok {
+		platform.HandleError(w, platform.NewValidation("plan must be one of basic, pro, premium"))
+		return Plan{}, false
+	}
+	return plan, true
+}
+
+func subscriptionResponse(s sqlc.Subscription, charge *sqlc.Transaction) SubscriptionResponse {
+	resp := SubscriptionResponse{
+		ID:                s.ID,
+		ParcelID:          s.ParcelID,
+		Plan:              s.Plan,
+		AmountPerCycle:    numericToFloat64(s.AmountPerCycle),
+		CreditBalance:     numericToFloat64(s.CreditBalance),
+		CancelAtPeriodEnd: s.CancelAtPeriodEnd != nil && *s.CancelAtPeriodEnd,
+	}
+	if s.Status != nil {
+		resp.Status = *s.Status
+	}
+	if s.CurrentPeriodStart.Valid {
+		resp.CurrentPeriodStart = &s.CurrentPeriodStart.Time
+	}
+	if s.CurrentPeriodEnd.Valid {
+		resp.CurrentPeriodEnd = &s.CurrentPeriodEnd.Time
+	}
+	if s.VisitsUsedThisPeriod != nil {
+		resp.VisitsUsedThisPeriod = *s.VisitsUsedThisPeriod
+	}
+	if s.OnDemandVisitsRemaining != nil {
+		resp.OnDemandVisitsRemaining = *s.OnDemandVisitsRemaining
+	}
+	if s.PausedAt.Valid {
+		resp.PausedAt = &s.PausedAt.Time
+	}
+	if charge != nil {
+		c := chargeResponse(*charge)
+		resp.Charge = &c
+	}
+	return resp
+}
+
+func chargeResponse(t sqlc.Transaction) ChargeResponse {
+	resp := ChargeResponse{
+		ID:        t.ID,
+		Type:      t.Type,
+		Amount:    numericToFloat64(t.Amount),
+		OrderID:   t.RazorpayOrderID,
+		CreatedAt: t.CreatedAt.Time,
+	}
+	if t.Status != nil {
+		resp.Status = *t.Status
+	}
+	return resp
+}
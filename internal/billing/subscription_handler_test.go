@@ -0,0 +1,86 @@
+package billing
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func subscriptionRouter() chi.Router {
+	h := &SubscriptionHandler{gateway: NewMockPaymentGateway(slog.Default()), logger: slog.Default()}
+	r := chi.NewRouter()
+	r.Get("/billing/plans", h.ListPlans)
+	r.Get("/billing/transactions", h.ListTransactions)
+	r.Post("/billing/payments/verify", h.VerifyPayment)
+	r.Post("/parcels/{parcelId}/subscription", h.CreateSubscription)
+	r.Get("/parcels/{parcelId}/subscription", h.GetSubscription)
+	r.Post("/parcels/{parcelId}/subscription/upgrade", h.Upgrade)
+	r.Post("/parcels/{parcelId}/subscription/pause", h.Pause)
+	return r
+}
+
+func TestListPlans(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/billing/plans", nil)
+	w := httptest.NewRecorder()
+	subscriptionRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data []PlanResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(resp.Data) != 3 || resp.Data[1].Name != PlanPro || resp.Data[1].Price != 1499 || resp.Data[1].VisitIntervalDays != 30 {
+		t.Errorf("unexpected plans: %+v", resp.Data)
+	}
+}
+
+func TestSubscriptionEndpoints_NoAuth(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/billing/transactions"},
+		{http.MethodPost, "/billing/payments/verify"},
+		{http.MethodPost, "/parcels/" + testParcelID + "/subscription"},
+		{http.MethodGet, "/parcels/" + testParcelID + "/subscription"},
+		{http.MethodPost, "/parcels/" + testParcelID + "/subscription/upgrade"},
+		{http.MethodPost, "/parcels/" + testParcelID + "/subscription/pause"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			w := httptest.NewRecorder()
+			subscriptionRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestSubscriptionEndpoints_InvalidParcelID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/parcels/not-a-uuid/subscription", strings.NewReader(`{"plan":"pro"}`))
+	ctx := auth.SetUser(req.Context(), &auth.UserContext{KeycloakID: "kc-1", Roles: []string{"landowner"}})
+	w := httptest.NewRecorder()
+	subscriptionRouter().ServeHTTP(w, req.WithContext(ctx))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+// testParcelID is any valid parcel ID; the handlers reject the request
+// before looking it up.
+const testParcelID = "6f1c2b1e-3d4a-4b5c-8d9e-0a1b2c3d4e5f"
@@ -0,0 +1,159 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// TaskRenewSubscription renews one subscription whose period has ended.
+	TaskRenewSubscription = "billing.renew_subscription"
+
+	renewalCheckInterval = 15 * time.Minute
+	renewalBatchSize     = 100
+)
+
+// nextPeriodEnd returns the end of a monthly billing period starting at start.
+func nextPeriodEnd(start time.Time) time.Time {
+	return start.AddDate(0, 1, 0)
+}
+
+// prorate returns what switching from oldPrice to newPrice at now costs for
+// the rest of the period [start, end): positive for an upgrade, negative (a
+// credit) for a downgrade.
+func prorate(oldPrice, newPrice float64, start, end, now time.Time) float64 {
+	total := end.Sub(start)
+	if total <= 0 {
+		return 0
+	}
+	remaining := float64(end.Sub(now)) / float64(total)
+	remaining = math.Max(0, math.Min(1, remaining))
+	return paise((newPrice - oldPrice) * remaining)
+}
+
+// applyCredit takes what it can of an amount due from a credit balance and
+// returns the amount left to charge and the credit left over.
+func applyCredit(due, credit float64) (charge, remaining float64) {
+	if credit <= 0 {
+		return paise(due), credit
+	}
+	if credit >= due {
+		return 0, paise(credit - due)
+	}
+	return paise(due - credit), 0
+}
+
+// renewalPeriod returns the period following one that ended at end. A
+// subscription that missed a whole period, e.g. while renewals were down,
+// restarts from now rather than being billed for the time it was not served.
+func renewalPeriod(end, now time.Time) (start, newEnd time.Time) {
+	start = end
+	if nextPeriodEnd(end).Before(now) {
+		start = now
+	}
+	return start, nextPeriodEnd(start)
+}
+
+// amount parses an INR amount held as a NUMERIC string.
+func amount(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// Subscriptions renews landowner subscriptions at the end of each period.
+//
+// Every 15 minutes it claims active subscriptions whose period has ended and
+// enqueues a renewal task for each. The task starts the next period, resets
+// the period's visit quotas and charges the plan price less any credit from
+// downgrades, or cancels the subscription if it was set to end.
+type Subscriptions struct {
+	repo      *Repository
+	gateway   PaymentGateway
+	taskQueue *platform.TaskQueue
+	logger    *slog.Logger
+}
+
+// NewSubscriptions creates a subscription renewal service.
+func NewSubscriptions(repo *Repository, gateway PaymentGateway, taskQueue *platform.TaskQueue, logger *slog.Logger) *Subscriptions {
+	return &Subscriptions{
+		repo:      repo,
+		gateway:   gateway,
+		taskQueue: taskQueue,
+		logger:    logger,
+	}
+}
+
+// Start runs the renewal scheduler loop. Call in a goroutine.
+func (s *Subscriptions) Start(ctx context.Context) {
+	s.logger.Info("subscription renewals started", "interval", renewalCheckInterval)
+
+	s.schedule(ctx, time.Now())
+
+	ticker := time.NewTicker(renewalCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("subscription renewals stopped")
+			return
+		case <-ticker.C:
+			s.schedule(ctx, time.Now())
+		}
+	}
+}
+
+// schedule enqueues a renewal task for each subscription whose period has ended.
+func (s *Subscriptions) schedule(ctx context.Context, now time.Time) {
+	due, err := s.repo.ClaimDueRenewals(ctx, now, renewalBatchSize)
+	if err != nil {
+		s.logger.Error("renewals: failed to claim due subscriptions", "error", err)
+		return
+	}
+
+	for _, d := range due {
+		err := s.taskQueue.Enqueue(ctx, TaskRenewSubscription, RenewSubscriptionPayload{
+			SubscriptionID: d.ID,
+			PeriodEnd:      d.CurrentPeriodEnd.Time,
+		})
+		if err != nil {
+			s.logger.Error("renewals: failed to enqueue task", "subscription_id", d.ID, "error", err)
+			if err := s.repo.ReleaseRenewalClaim(ctx, d.ID); err != nil {
+				s.logger.Error("renewals: failed to release claim", "subscription_id", d.ID, "error", err)
+			}
+		}
+	}
+	if len(due) > 0 {
+		s.logger.Info("renewals: enqueued", "count", len(due))
+	}
+}
+
+// HandleTask is the TaskQueue handler for "billing.renew_subscription".
+func (s *Subscriptions) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
+	var p RenewSubscriptionPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("unmarshalling renewal payload: %w", err)
+	}
+
+	change, err := s.repo.RenewSubscription(ctx, s.gateway, p.SubscriptionID, p.PeriodEnd, time.Now())
+	if err != nil {
+		return err
+	}
+	if change == nil {
+		return nil // renewed, paused or cancelled since it was claimed
+	}
+
+	attrs := []any{"subscription_id", p.SubscriptionID, "status", *change.Subscription.Status}
+	if change.Charge != nil {
+		attrs = append(attrs, "charge", numericToFloat64(change.Charge.Amount), "charge_status", *change.Charge.Status)
+	}
+	s.logger.Info("subscription renewed", attrs...)
+	return nil
+}
@@ -0,0 +1,91 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProrate(t *testing.T) {
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, ist)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, ist) // 30 days
+
+	tests := []struct {
+		name     string
+		oldPrice float64
+		newPrice float64
+		now      time.Time
+		want     float64
+	}{
+		{"upgrade at start", 499, 1499, start, 1000},
+		{"upgrade halfway", 499, 1499, start.AddDate(0, 0, 15), 500},
+		{"downgrade with 10 days left", 2999, 1499, start.AddDate(0, 0, 20), -500},
+		{"rounds to paise", 499, 1499, start.AddDate(0, 0, 1), 966.67},
+		{"after period end", 499, 1499, end.Add(time.Hour), 0},
+		{"before period start", 499, 1499, start.Add(-time.Hour), 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prorate(tt.oldPrice, tt.newPrice, start, end, tt.now); got != tt.want {
+				t.Errorf("prorate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProrateEmptyPeriod(t *testing.T) {
+	now := time.Now()
+	if got := prorate(499, 1499, now, now, now); got != 0 {
+		t.Errorf("prorate() = %v, want 0", got)
+	}
+}
+
+func TestApplyCredit(t *testing.T) {
+	tests := []struct {
+		name       string
+		due        float64
+		credit     float64
+		wantCharge float64
+		wantCredit float64
+	}{
+		{"no credit", 1499, 0, 1499, 0},
+		{"partial credit", 1499, 500, 999, 0},
+		{"credit covers charge", 499, 500, 0, 1},
+		{"exact credit", 499, 499, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charge, credit := applyCredit(tt.due, tt.credit)
+			if charge != tt.wantCharge || credit != tt.wantCredit {
+				t.Errorf("applyCredit() = (%v, %v), want (%v, %v)", charge, credit, tt.wantCharge, tt.wantCredit)
+			}
+		})
+	}
+}
+
+func TestRenewalPeriod(t *testing.T) {
+	end := time.Date(2026, 1, 31, 10, 0, 0, 0, ist)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantStart time.Time
+	}{
+		{"on time", end.Add(10 * time.Minute), end},
+		{"late within a period", end.AddDate(0, 0, 20), end},
+		{"missed a whole period", end.AddDate(0, 2, 0), end.AddDate(0, 2, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, newEnd := renewalPeriod(end, tt.now)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("start = %v, want %v", start, tt.wantStart)
+			}
+			if !newEnd.Equal(nextPeriodEnd(start)) {
+				t.Errorf("end = %v, want %v", newEnd, nextPeriodEnd(start))
+			}
+		})
+	}
+}
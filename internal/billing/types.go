@@ -0,0 +1,258 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payout status values.
+const (
+	PayoutPending    = "pending"    // created, not yet accepted by the provider
+	PayoutProcessing = "processing" // accepted by the provider, awaiting confirmation
+	PayoutPaid       = "paid"
+	PayoutFailed     = "failed"
+)
+
+// PayoutProvider sends money to agents and reports the outcome by webhook.
+type PayoutProvider interface {
+	// CreatePayout starts a payout. The request's PayoutID must be used as
+	// the provider's idempotency key so a retried request pays only once.
+	CreatePayout(ctx context.Context, req PayoutRequest) (*PayoutResult, error)
+
+	// ParseWebhook verifies a webhook delivery and extracts the payout
+	// update. It returns nil for events that don't change a payout's status.
+	ParseWebhook(header http.Header, body []byte) (*PayoutUpdate, error)
+}
+
+// PayoutRequest is a payout to one agent.
+type PayoutRequest struct {
+	PayoutID    uuid.UUID
+	AgentID     uuid.UUID
+	AgentName   string
+	Phone       string
+	UPIID       string
+	AmountPaise int64
+	Narration   string
+}
+
+// PayoutResult is the provider's acknowledgement of a payout.
+type PayoutResult struct {
+	ProviderID string
+	Status     string // PayoutProcessing, PayoutPaid or PayoutFailed
+}
+
+// PayoutUpdate is a payout status change reported by the provider.
+type PayoutUpdate struct {
+	ProviderID    string
+	Status        string // PayoutProcessing, PayoutPaid or PayoutFailed
+	FailureReason string
+}
+
+// SettlePayoutsPayload is the task queue payload for settling a period.
+type SettlePayoutsPayload struct {
+	PeriodStart string `json:"period_start"` // YYYY-MM-DD, a Monday
+	PeriodEnd   string `json:"period_end"`   // YYYY-MM-DD, the following Sunday
+}
+
+// PayoutResponse is the API representation of an agent payout.
+type PayoutResponse struct {
+	ID                 uuid.UUID  `json:"id"`
+	PeriodStart        string     `json:"period_start"`
+	PeriodEnd          string     `json:"period_end"`
+	TotalJobs          int32      `json:"total_jobs"`
+	GrossAmount        float64    `json:"gross_amount"`
+	PlatformCommission float64    `json:"platform_commission"`
+	TDSDeducted        float64    `json:"tds_deducted"`
+	NetAmount          float64    `json:"net_amount"`
+	Status             string     `json:"status"`
+	FailureReason      *string    `json:"failure_reason,omitempty"`
+	PaidAt             *time.Time `json:"paid_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// EarningsSummary is an agent's earnings across payouts.
+type EarningsSummary struct {
+	Paid          float64 `json:"paid"`           // net amount paid out
+	InTransit     float64 `json:"in_transit"`     // net amount of payouts not yet confirmed
+	Unsettled     float64 `json:"unsettled"`      // gross earnings awaiting the next settlement
+	UnsettledJobs int64   `json:"unsettled_jobs"` // QA-passed jobs awaiting the next settlement
+	TDSDeducted   float64 `json:"tds_deducted"`
+	FailedPayouts int64   `json:"failed_payouts"`
+}
+
+// Subscription status values.
+const (
+	SubscriptionPending   = "pending" // awaiting the first payment
+	SubscriptionActive    = "active"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// Landowner charge types and statuses, stored in transactions.
+const (
+	ChargeSubscription = "subscription" // first period of a new subscription
+	ChargeRenewal      = "renewal"
+	ChargeProration    = "proration" // upgrade for the rest of the current period
+
+	ChargePending = "pending"
+	ChargePaid    = "paid"
+	ChargeFailed  = "failed"
+)
+
+// PaymentGateway collects landowner payments through orders paid at checkout.
+type PaymentGateway interface {
+	// CreateOrder opens an order for an amount due. If the gateway captures
+	// payment immediately the returned order has a PaymentID.
+	CreateOrder(ctx context.Context, req OrderRequest) (*Order, error)
+
+	// VerifyPayment checks the signature checkout returns for a payment
+	// against an order.
+	VerifyPayment(orderID, paymentID, signature string) error
+
+	// ParseWebhook verifies a webhook delivery and identifies its event.
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+
+	// DecodeEvent extracts the charge update from a stored event body. It
+	// returns nil for events that don't change a charge's status.
+	DecodeEvent(eventType string, payload []byte) (*ChargeUpdate, error)
+}
+
+// WebhookEvent identifies a gateway webhook event.
+type WebhookEvent struct {
+	ID   string // the gateway's event ID, unique per event across retries
+	Type string
+}
+
+// ChargeUpdate is a charge status change reported by the gateway.
+type ChargeUpdate struct {
+	OrderID       string
+	PaymentID     string
+	Status        string // ChargePaid or ChargeFailed
+	FailureReason string
+}
+
+// OrderRequest is an amount due from a landowner.
+type OrderRequest struct {
+	Receipt     string // our reference, at most 40 characters
+	AmountPaise int64
+	Notes       map[string]string
+}
+
+// Order is a gateway order awaiting payment.
+type Order struct {
+	ID          string
+	AmountPaise int64
+	Currency    string
+	PaymentID   string // set if the payment was captured with the order
+}
+
+// RenewSubscriptionPayload is the task queue payload for renewing a
+// subscription whose period ended at PeriodEnd.
+type RenewSubscriptionPayload struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	PeriodEnd      time.Time `json:"period_end"`
+}
+
+// Payment event status values.
+const (
+	EventReceived  = "received" // stored, awaiting processing
+	EventProcessed = "processed"
+	EventIgnored   = "ignored" // stored, but changes nothing we track
+	EventFailed    = "failed"  // may be replayed by ops
+)
+
+// ProcessPaymentEventPayload is the task queue payload for processing a
+// stored webhook event.
+type ProcessPaymentEventPayload struct {
+	EventID uuid.UUID `json:"event_id"`
+}
+
+// PaymentEventResponse is the API representation of a stored webhook event.
+type PaymentEventResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+// SubscriptionRequest is the body for creating or changing a subscription.
+type SubscriptionRequest struct {
+	Plan string `json:"plan"`
+}
+
+// VerifyPaymentRequest is the checkout callback forwarded by the client.
+type VerifyPaymentRequest struct {
+	OrderID   string `json:"razorpay_order_id"`
+	PaymentID string `json:"razorpay_payment_id"`
+	Signature string `json:"razorpay_signature"`
+}
+
+// PlanResponse is the API representation of a subscription plan.
+type PlanResponse struct {
+	Name              string  `json:"name"`
+	Price             float64 `json:"price"`
+	VisitIntervalDays int     `json:"visit_interval_days"`
+	VisitsPerPeriod   int     `json:"visits_per_period"`
+	SurveyType        string  `json:"survey_type"`
+	OnDemandVisits    int     `json:"on_demand_visits"`
+	OnDemandPrice     float64 `json:"on_demand_price"`
+}
+
+// SubscriptionResponse is the API representation of a subscription.
+type SubscriptionResponse struct {
+	ID                      uuid.UUID  `json:"id"`
+	ParcelID                uuid.UUID  `json:"parcel_id"`
+	Plan                    string     `json:"plan"`
+	Status                  string     `json:"status"`
+	AmountPerCycle          float64    `json:"amount_per_cycle"`
+	CreditBalance           float64    `json:"credit_balance"`
+	CurrentPeriodStart      *time.Time `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd        *time.Time `json:"current_period_end,omitempty"`
+	VisitsUsedThisPeriod    int32      `json:"visits_used_this_period"`
+	OnDemandVisitsRemaining int32      `json:"on_demand_visits_remaining"`
+	CancelAtPeriodEnd       bool       `json:"cancel_at_period_end"`
+	PausedAt                *time.Time `json:"paused_at,omitempty"`
+
+	// Charge is raised by the operation, if any; pending charges are paid at
+	// checkout with the order ID.
+	Charge *ChargeResponse `json:"charge,omitempty"`
+}
+
+// ChargeResponse is the API representation of a landowner charge.
+type ChargeResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Status    string    `json:"status"`
+	OrderID   *string   `json:"order_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvoiceResponse is the API representation of a tax invoice.
+type InvoiceResponse struct {
+	ID            uuid.UUID `json:"id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	PlaceOfSupply string    `json:"place_of_supply"`
+	Interstate    bool      `json:"interstate"`
+	TaxableAmount float64   `json:"taxable_amount"`
+	CGST          float64   `json:"cgst"`
+	SGST          float64   `json:"sgst"`
+	IGST          float64   `json:"igst"`
+	TotalAmount   float64   `json:"total_amount"`
+	IssuedAt      time.Time `json:"issued_at"`
+}
+
+// DownloadResponse holds a presigned URL for downloading a document.
+type DownloadResponse struct {
+	DownloadURL string `json:"download_url"`
+}
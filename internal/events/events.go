@@ -0,0 +1,173 @@
+// Package events defines the typed payloads published on the event bus.
+//
+// Each payload names the event type it is published as and carries a schema
+// version. Change a payload's fields compatibly (add optional fields) under
+// the same version; bump the version for anything subscribers on an older
+// release could not decode, since they reject events of another version.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Compile-time checks that every definition is an event payload.
+var (
+	_ platform.EventPayload = ParcelRegistered{}
+	_ platform.EventPayload = JobCreated{}
+	_ platform.EventPayload = JobAssigned{}
+	_ platform.EventPayload = JobUnassigned{}
+	_ platform.EventPayload = JobRedispatched{}
+	_ platform.EventPayload = JobUpdated{}
+	_ platform.EventPayload = JobCancelled{}
+	_ platform.EventPayload = SurveySubmitted{}
+	_ platform.EventPayload = QACompleted{}
+	_ platform.EventPayload = ReportGenerated{}
+	_ platform.EventPayload = RiskChanged{}
+)
+
+// ParcelRegistered is published when a landowner registers a parcel.
+type ParcelRegistered struct {
+	ParcelID          uuid.UUID `json:"parcel_id"`
+	UserID            uuid.UUID `json:"user_id"`
+	District          string    `json:"district"`
+	State             string    `json:"state"`
+	StateCode         string    `json:"state_code"`
+	AreaSqm           *float32  `json:"area_sqm,omitempty"`
+	RegisteredAreaSqm *float32  `json:"registered_area_sqm,omitempty"`
+	LandType          *string   `json:"land_type,omitempty"`
+}
+
+func (ParcelRegistered) EventName() string { return "parcel.registered" }
+func (ParcelRegistered) EventVersion() int { return 1 }
+
+// JobCreated is published when a survey job is created, by the scheduler or
+// an on-demand visit request.
+type JobCreated struct {
+	JobID      uuid.UUID `json:"job_id"`
+	ParcelID   uuid.UUID `json:"parcel_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	SurveyType string    `json:"survey_type"`
+	Priority   string    `json:"priority"`
+	Deadline   time.Time `json:"deadline"`
+	Trigger    string    `json:"trigger"`
+}
+
+func (JobCreated) EventName() string { return "job.created" }
+func (JobCreated) EventVersion() int { return 1 }
+
+// JobAssigned is published when an agent accepts an offer or ops assign a
+// job by hand.
+type JobAssigned struct {
+	JobID    uuid.UUID `json:"job_id"`
+	ParcelID uuid.UUID `json:"parcel_id"`
+	UserID   uuid.UUID `json:"user_id"` // the landowner
+	AgentID  uuid.UUID `json:"agent_id"`
+}
+
+func (JobAssigned) EventName() string { return "job.assigned" }
+func (JobAssigned) EventVersion() int { return 1 }
+
+// JobUnassigned is published when the dispatcher gives up on placing a job,
+// so ops can step in.
+type JobUnassigned struct {
+	JobID    uuid.UUID `json:"job_id"`
+	ParcelID uuid.UUID `json:"parcel_id"`
+	Reason   string    `json:"reason"`
+}
+
+func (JobUnassigned) EventName() string { return "job.unassigned" }
+func (JobUnassigned) EventVersion() int { return 1 }
+
+// JobAction describes an ops action on a job. It is embedded in the events
+// for each action.
+type JobAction struct {
+	JobID           uuid.UUID  `json:"job_id"`
+	ParcelID        uuid.UUID  `json:"parcel_id"`
+	Action          string     `json:"action"`
+	ActorID         string     `json:"actor_id"`
+	Reason          string     `json:"reason"`
+	PreviousAgentID *uuid.UUID `json:"previous_agent_id,omitempty"` // agent removed from the job, if any
+}
+
+// JobRedispatched is published when ops restart a job's dispatch.
+type JobRedispatched struct {
+	JobAction
+}
+
+func (JobRedispatched) EventName() string { return "job.redispatched" }
+func (JobRedispatched) EventVersion() int { return 1 }
+
+// JobUpdated is published when ops change a job's priority or deadline.
+type JobUpdated struct {
+	JobAction
+}
+
+func (JobUpdated) EventName() string { return "job.updated" }
+func (JobUpdated) EventVersion() int { return 1 }
+
+// JobCancelled is published when ops cancel a job.
+type JobCancelled struct {
+	JobAction
+}
+
+func (JobCancelled) EventName() string { return "job.cancelled" }
+func (JobCancelled) EventVersion() int { return 1 }
+
+// SurveySubmitted is published when an agent submits a survey. It starts the
+// QA pipeline.
+type SurveySubmitted struct {
+	JobID            uuid.UUID `json:"job_id"`
+	ParcelID         uuid.UUID `json:"parcel_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	AgentID          uuid.UUID `json:"agent_id"`
+	SurveyResponseID uuid.UUID `json:"survey_response_id"`
+}
+
+func (SurveySubmitted) EventName() string { return "survey.submitted" }
+func (SurveySubmitted) EventVersion() int { return 1 }
+
+// QACompleted is published when QA has scored a submitted survey. Status is
+// "passed", "flagged" or "failed".
+type QACompleted struct {
+	JobID    uuid.UUID `json:"job_id"`
+	ParcelID uuid.UUID `json:"parcel_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Score    float64   `json:"score"`
+	Status   string    `json:"status"`
+}
+
+func (QACompleted) EventName() string { return "qa.completed" }
+func (QACompleted) EventVersion() int { return 1 }
+
+// ReportGenerated is published when a survey report is ready to download.
+type ReportGenerated struct {
+	ReportID   uuid.UUID `json:"report_id"`
+	JobID      uuid.UUID `json:"job_id"`
+	ParcelID   uuid.UUID `json:"parcel_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	ReportType string    `json:"report_type"`
+	Format     string    `json:"format"`
+}
+
+func (ReportGenerated) EventName() string { return "report.generated" }
+func (ReportGenerated) EventVersion() int { return 1 }
+
+// RiskChanged is published when a parcel's risk level changes, including
+// when it is scored for the first time. PreviousLevel and PreviousScore are
+// empty then.
+type RiskChanged struct {
+	ParcelID      uuid.UUID `json:"parcel_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	JobID         uuid.UUID `json:"job_id"`
+	RiskScoreID   uuid.UUID `json:"risk_score_id"`
+	Level         string    `json:"level"`
+	Score         float64   `json:"score"`
+	PreviousLevel string    `json:"previous_level,omitempty"`
+	PreviousScore *float64  `json:"previous_score,omitempty"`
+}
+
+func (RiskChanged) EventName() string { return "risk.changed" }
+func (RiskChanged) EventVersion() int { return 1 }
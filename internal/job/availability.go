@@ -0,0 +1,90 @@
+package job
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// parcelTZ is the local time zone for parcels. All parcels are in India,
+// which has a single zone and no daylight saving.
+var parcelTZ = time.FixedZone("IST", 5*60*60+30*60)
+
+// Travel speeds in km/h by agent vehicle_type, for rural/peri-urban roads.
+var vehicleSpeedKmh = map[string]float64{
+	"car":        35,
+	"bike":       30,
+	"motorcycle": 30,
+	"scooter":    30,
+	"bicycle":    12,
+	"none":       5,
+}
+
+const (
+	// referenceSpeedKmh is used for agents with no (or an unknown) vehicle
+	// type, and as the baseline the distance score is normalized against.
+	referenceSpeedKmh = 30
+
+	// roadFactor converts straight-line distance into approximate road distance.
+	roadFactor = 1.3
+)
+
+// EstimateTravelMinutes estimates how long an agent needs to cover distKm.
+func EstimateTravelMinutes(distKm float64, vehicleType string) float64 {
+	speed, ok := vehicleSpeedKmh[strings.ToLower(vehicleType)]
+	if !ok {
+		speed = referenceSpeedKmh
+	}
+	return distKm * roadFactor / speed * 60
+}
+
+// availableDuring reports whether an agent's weekly schedule covers the whole
+// window [from, to] in parcel local time. Unset schedule fields don't restrict.
+func availableDuring(days []string, start, end pgtype.Time, from, to time.Time) bool {
+	from, to = from.In(parcelTZ), to.In(parcelTZ)
+
+	if len(days) > 0 {
+		for t := from; ; t = t.AddDate(0, 0, 1) {
+			if !slices.Contains(days, strings.ToLower(t.Weekday().String()[:3])) {
+				return false
+			}
+			if sameDay(t, to) || t.After(to) {
+				break
+			}
+		}
+	}
+
+	if !start.Valid && !end.Valid {
+		return true
+	}
+	// A bounded daily shift can't cover a window that spans midnight
+	if !sameDay(from, to) {
+		return false
+	}
+	if start.Valid && microsSinceMidnight(from) < start.Microseconds {
+		return false
+	}
+	if end.Valid && microsSinceMidnight(to) > end.Microseconds {
+		return false
+	}
+	return true
+}
+
+// withinPreferredRadius reports whether a parcel distKm away is inside the
+// agent's preferred working radius. Agents without a preference take any distance.
+func withinPreferredRadius(distKm float64, preferredKm *int32) bool {
+	return preferredKm == nil || *preferredKm <= 0 || distKm <= float64(*preferredKm)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func microsSinceMidnight(t time.Time) int64 {
+	h, m, s := t.Clock()
+	return int64(h*3600+m*60+s) * int64(time.Second/time.Microsecond)
+}
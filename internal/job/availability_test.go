@@ -0,0 +1,80 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func clock(h, m int) pgtype.Time {
+	return pgtype.Time{Microseconds: int64(h*3600+m*60) * 1_000_000, Valid: true}
+}
+
+func TestAvailableDuring(t *testing.T) {
+	weekdays := []string{"mon", "tue", "wed", "thu", "fri", "sat"}
+	// Wednesday 2026-01-07, times in IST
+	at := func(day, h, m int) time.Time { return time.Date(2026, 1, day, h, m, 0, 0, parcelTZ) }
+
+	tests := []struct {
+		name     string
+		days     []string
+		start    pgtype.Time
+		end      pgtype.Time
+		from     time.Time
+		to       time.Time
+		expected bool
+	}{
+		{"inside shift", weekdays, clock(8, 0), clock(18, 0), at(7, 10, 0), at(7, 10, 30), true},
+		{"before shift", weekdays, clock(8, 0), clock(18, 0), at(7, 7, 45), at(7, 8, 15), false},
+		{"runs past shift end", weekdays, clock(8, 0), clock(18, 0), at(7, 17, 45), at(7, 18, 15), false},
+		{"day off", weekdays, clock(8, 0), clock(18, 0), at(11, 10, 0), at(11, 10, 30), false},
+		{"crosses midnight", nil, clock(0, 0), clock(23, 59), at(7, 23, 45), at(8, 0, 15), false},
+		{"no schedule set", nil, pgtype.Time{}, pgtype.Time{}, at(11, 23, 45), at(12, 0, 15), true},
+		{"utc input converted", weekdays, clock(8, 0), clock(18, 0),
+			time.Date(2026, 1, 7, 4, 30, 0, 0, time.UTC), time.Date(2026, 1, 7, 5, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := availableDuring(tt.days, tt.start, tt.end, tt.from, tt.to)
+			if got != tt.expected {
+				t.Errorf("availableDuring = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithinPreferredRadius(t *testing.T) {
+	r := int32(15)
+	if !withinPreferredRadius(12, &r) {
+		t.Error("12km should be within 15km preference")
+	}
+	if withinPreferredRadius(18, &r) {
+		t.Error("18km should be outside 15km preference")
+	}
+	if !withinPreferredRadius(80, nil) {
+		t.Error("no preference should accept any distance")
+	}
+}
+
+func TestTravelTimeOutranksDistance(t *testing.T) {
+	s := DefaultStrategy()
+	base := ScoreFactors{MaxDistanceKm: 25, AvgRating: 4, CompletionRate: 0.9, HoursSinceJob: 24}
+
+	cyclist := base
+	cyclist.DistanceKm, cyclist.VehicleType = 20, "bicycle"
+	rider := base
+	rider.DistanceKm, rider.VehicleType = 22, "bike"
+
+	if s.Score(cyclist) >= s.Score(rider) {
+		t.Errorf("cyclist at 20km (%f) should not outrank rider at 22km (%f)", s.Score(cyclist), s.Score(rider))
+	}
+
+	if EstimateTravelMinutes(10, "car") >= EstimateTravelMinutes(10, "bicycle") {
+		t.Error("car should be faster than bicycle")
+	}
+	if EstimateTravelMinutes(10, "hovercraft") != EstimateTravelMinutes(10, "") {
+		t.Error("unknown vehicle should use reference speed")
+	}
+}
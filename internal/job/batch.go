@@ -0,0 +1,414 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// batchCandidatesPerJob is how many ranked agents each job contributes to
+	// the assignment problem. Wider than a cascade round so jobs can fall back
+	// to their second or third choice when a shared top agent is taken.
+	batchCandidatesPerJob = 20
+
+	// infeasibleCost marks job/agent pairs that must not be assigned.
+	infeasibleCost = 1e6
+
+	// urgencyHorizon is how far ahead of a deadline a job starts gaining
+	// priority over other jobs competing for the same agents.
+	urgencyHorizon = 72 * time.Hour
+
+	// batchInterval is the default when DispatchConfig leaves it unset.
+	batchInterval = 2 * time.Minute
+
+	// batchMaxJobs caps the jobs in one assignment problem. The solver is
+	// O(n²m), so each region is solved in chunks of this size, most urgent
+	// chunk first.
+	batchMaxJobs = 50
+)
+
+// BatchAssigner plans first-round offers for all pending jobs at once. Each
+// job.created cascade otherwise picks its best agent independently, so a burst
+// of jobs piles offers onto the same few agents. The batch assigner instead
+// solves a min-cost assignment between jobs and agent capacity and queues one
+// offer per job; the dispatcher sends it and runs later rounds as usual.
+type BatchAssigner struct {
+	interval   time.Duration
+	dispatcher *Dispatcher
+	matcher    *Matcher
+	jobRepo    *Repository
+	logger     *slog.Logger
+}
+
+// NewBatchAssigner creates a batch assigner.
+func NewBatchAssigner(cfg platform.DispatchConfig, dispatcher *Dispatcher, matcher *Matcher, jobRepo *Repository, logger *slog.Logger) *BatchAssigner {
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = batchInterval
+	}
+	return &BatchAssigner{
+		interval:   cfg.BatchInterval,
+		dispatcher: dispatcher,
+		matcher:    matcher,
+		jobRepo:    jobRepo,
+		logger:     logger,
+	}
+}
+
+// Start runs the batch assigner on its interval. Call in a goroutine.
+func (b *BatchAssigner) Start(ctx context.Context) {
+	b.logger.Info("batch assigner started", "interval", b.interval)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("batch assigner stopped")
+			return
+		case <-ticker.C:
+			if err := b.run(ctx); err != nil {
+				b.logger.Error("batch assigner: run failed", "error", err)
+			}
+		}
+	}
+}
+
+// batchJob is a pending job and its ranked candidates.
+type batchJob struct {
+	job        sqlc.SurveyJob
+	region     Region
+	candidates []Candidate
+}
+
+// batchAssignment pairs a job (by index) with the agent chosen for it.
+type batchAssignment struct {
+	jobIdx    int
+	candidate Candidate
+}
+
+// run collects unplanned jobs, solves the assignment and queues the offers.
+func (b *BatchAssigner) run(ctx context.Context) error {
+	pending, err := b.jobRepo.ListUnplannedJobs(ctx, sweepBatchSize)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var jobs []batchJob
+	agentIDs := map[uuid.UUID]bool{}
+
+	for _, j := range pending {
+		if j.Deadline.Before(now) {
+			b.dispatcher.markUnassigned(ctx, j.ID, "deadline passed before planning")
+			b.logger.Warn("batch assigner: deadline passed before planning", "job_id", j.ID)
+			continue
+		}
+
+		lng, lat, region, err := b.dispatcher.getParcelLocation(ctx, j.ParcelID)
+		if err != nil {
+			b.logger.Error("batch assigner: failed to locate parcel", "job_id", j.ID, "error", err)
+			continue
+		}
+		candidates, err := b.matcher.findCandidates(ctx, lng, lat, j.SurveyType, region, nil, b.matcher.radii, batchCandidatesPerJob)
+		if err != nil {
+			b.logger.Error("batch assigner: matching failed", "job_id", j.ID, "error", err)
+			continue
+		}
+
+		jobs = append(jobs, batchJob{job: j, region: region, candidates: candidates})
+		for _, c := range candidates {
+			agentIDs[c.AgentID] = true
+		}
+	}
+
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	// Offers already out elsewhere count against an agent's capacity
+	ids := make([]uuid.UUID, 0, len(agentIDs))
+	for id := range agentIDs {
+		ids = append(ids, id)
+	}
+	openOffers, err := b.jobRepo.CountOpenOffers(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	slots := map[uuid.UUID]int{}
+	for _, bj := range jobs {
+		for _, c := range bj.candidates {
+			n := max(c.OpenSlots-openOffers[c.AgentID], 0)
+			if cur, ok := slots[c.AgentID]; !ok || n < cur {
+				slots[c.AgentID] = n
+			}
+		}
+	}
+
+	assignments := solvePartitioned(jobs, slots, now, batchMaxJobs)
+	for _, a := range assignments {
+		b.queueOffer(ctx, &jobs[a.jobIdx].job, a.candidate)
+	}
+
+	b.logger.Info("batch assigner: run complete",
+		"jobs", len(jobs),
+		"agents", len(agentIDs),
+		"assigned", len(assignments),
+	)
+	return nil
+}
+
+// queueOffer stores the solution as the job's first round and lets the
+// dispatcher send it.
+func (b *BatchAssigner) queueOffer(ctx context.Context, j *sqlc.SurveyJob, c Candidate) {
+	unlock, locked, err := b.jobRepo.TryLockJob(ctx, j.ID)
+	if err != nil {
+		b.logger.Error("batch assigner: failed to lock job", "job_id", j.ID, "error", err)
+		return
+	}
+	if !locked {
+		return // the dispatcher or another replica holds this job
+	}
+
+	// Re-check under the lock: another replica may have planned this job
+	ok, err := b.jobRepo.IsJobUnplanned(ctx, j.ID)
+	if err != nil || !ok {
+		if err != nil {
+			b.logger.Error("batch assigner: failed to check job", "job_id", j.ID, "error", err)
+		}
+		unlock()
+		return
+	}
+
+	distKm := float32(c.DistanceKm)
+	strategy := c.Strategy.Name()
+
+	_, err = b.jobRepo.CreateQueuedOffer(ctx, sqlc.CreateQueuedJobOfferParams{
+		JobID:           j.ID,
+		AgentID:         c.AgentID,
+		CascadeRound:    1,
+		OfferRank:       1,
+		DistanceKm:      &distKm,
+		MatchScore:      matchScore(c.CompositeScore),
+		ScoringStrategy: &strategy,
+		ScoringWeights:  weightsJSON(c.Strategy),
+		ExpiresAt:       j.Deadline, // replaced with the real expiry when sent
+	})
+	if err == nil {
+		err = b.jobRepo.UpdateJobCascade(ctx, j.ID, 1, 0)
+	}
+	unlock()
+
+	if err != nil {
+		b.logger.Error("batch assigner: failed to queue offer", "job_id", j.ID, "agent_id", c.AgentID, "error", err)
+		return
+	}
+	b.dispatcher.advance(ctx, j.ID)
+}
+
+// solvePartitioned splits jobs by parcel region and solves each region in
+// chunks of at most maxJobs, soonest deadline first. Slots taken by one chunk
+// are gone for the next, so urgent jobs still win scarce agents.
+func solvePartitioned(jobs []batchJob, slots map[uuid.UUID]int, now time.Time, maxJobs int) []batchAssignment {
+	order := make([]int, len(jobs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return jobs[order[a]].job.Deadline.Before(jobs[order[b]].job.Deadline)
+	})
+
+	var regions []Region
+	byRegion := map[Region][]int{}
+	for _, i := range order {
+		r := jobs[i].region
+		if _, ok := byRegion[r]; !ok {
+			regions = append(regions, r)
+		}
+		byRegion[r] = append(byRegion[r], i)
+	}
+
+	remaining := maps.Clone(slots)
+	var out []batchAssignment
+	for _, r := range regions {
+		idx := byRegion[r]
+		for start := 0; start < len(idx); start += maxJobs {
+			chunk := idx[start:min(start+maxJobs, len(idx))]
+			sub := make([]batchJob, len(chunk))
+			for k, i := range chunk {
+				sub[k] = jobs[i]
+			}
+			for _, a := range solveBatch(sub, remaining, now) {
+				remaining[a.candidate.AgentID]--
+				out = append(out, batchAssignment{jobIdx: chunk[a.jobIdx], candidate: a.candidate})
+			}
+		}
+	}
+	return out
+}
+
+// solveBatch assigns jobs to agent capacity at minimum total cost. Each agent
+// contributes one column per open slot; each job also gets a private
+// "unassigned" column whose cost grows as its deadline nears, so when agents
+// are scarce the most urgent jobs are served first. Pairs the agent can't
+// reach before the deadline are infeasible.
+func solveBatch(jobs []batchJob, slots map[uuid.UUID]int, now time.Time) []batchAssignment {
+	type column struct {
+		agentID uuid.UUID
+		dummy   int // job index for unassigned columns, -1 for agent slots
+	}
+
+	// An agent never needs more columns than jobs that list it
+	wanted := map[uuid.UUID]int{}
+	for _, bj := range jobs {
+		for _, c := range bj.candidates {
+			wanted[c.AgentID]++
+		}
+	}
+
+	var cols []column
+	seen := map[uuid.UUID]bool{}
+	for _, bj := range jobs {
+		for _, c := range bj.candidates {
+			if seen[c.AgentID] {
+				continue
+			}
+			seen[c.AgentID] = true
+			for k := 0; k < min(slots[c.AgentID], wanted[c.AgentID]); k++ {
+				cols = append(cols, column{agentID: c.AgentID, dummy: -1})
+			}
+		}
+	}
+	agentCols := len(cols)
+	for i := range jobs {
+		cols = append(cols, column{dummy: i})
+	}
+
+	cost := make([][]float64, len(jobs))
+	for i, bj := range jobs {
+		byAgent := make(map[uuid.UUID]Candidate, len(bj.candidates))
+		for _, c := range bj.candidates {
+			byAgent[c.AgentID] = c
+		}
+
+		row := make([]float64, len(cols))
+		for j, col := range cols {
+			row[j] = infeasibleCost
+			if col.dummy == i {
+				row[j] = unassignedCost(bj.job.Deadline, now)
+				continue
+			}
+			if col.dummy >= 0 {
+				continue
+			}
+			c, ok := byAgent[col.agentID]
+			if !ok || now.Add(time.Duration(c.TravelMinutes*float64(time.Minute))).After(bj.job.Deadline) {
+				continue
+			}
+			row[j] = 1 - math.Min(math.Max(c.CompositeScore, 0), 1)
+		}
+		cost[i] = row
+	}
+
+	var out []batchAssignment
+	for i, j := range hungarian(cost) {
+		if j >= agentCols || cost[i][j] >= infeasibleCost {
+			continue
+		}
+		for _, c := range jobs[i].candidates {
+			if c.AgentID == cols[j].agentID {
+				out = append(out, batchAssignment{jobIdx: i, candidate: c})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// unassignedCost is the price of leaving a job without an offer this run.
+// It always exceeds any real assignment (cost ≤ 1), and rises from 2 to 3 as
+// the deadline approaches within urgencyHorizon.
+func unassignedCost(deadline, now time.Time) float64 {
+	urgency := 1 - deadline.Sub(now).Hours()/urgencyHorizon.Hours()
+	return 2 + math.Min(math.Max(urgency, 0), 1)
+}
+
+// hungarian solves the rectangular assignment problem for an n×m cost matrix
+// with n <= m, returning the column assigned to each row. O(n²m).
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+
+	// Potentials and matching are 1-indexed; column 0 is a sentinel.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1) // p[j] = row matched to column j
+	way := make([]int, m+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assign := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			assign[p[j]-1] = j - 1
+		}
+	}
+	return assign
+}
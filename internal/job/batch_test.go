@@ -0,0 +1,160 @@
+package job
+
+import (
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func TestHungarian(t *testing.T) {
+	cost := [][]float64{
+		{4, 1, 3},
+		{2, 0, 5},
+		{3, 2, 2},
+	}
+	assign := hungarian(cost)
+
+	total := 0.0
+	for i, j := range assign {
+		total += cost[i][j]
+	}
+	// Optimal: row0→col1 (1), row1→col0 (2), row2→col2 (2)
+	if math.Abs(total-5) > 1e-9 {
+		t.Errorf("total cost = %f, want 5 (assignment %v)", total, assign)
+	}
+
+	// Rectangular: more columns than rows
+	rect := hungarian([][]float64{{5, 1, 9, 9}, {1, 5, 9, 9}})
+	if rect[0] != 1 || rect[1] != 0 {
+		t.Errorf("rectangular assignment = %v, want [1 0]", rect)
+	}
+
+	if hungarian(nil) != nil {
+		t.Error("empty matrix should return nil")
+	}
+}
+
+func batchCandidate(agentID uuid.UUID, score float64) Candidate {
+	return Candidate{AgentID: agentID, CompositeScore: score, TravelMinutes: 30, Strategy: DefaultStrategy()}
+}
+
+func TestSolveBatchSpreadsJobs(t *testing.T) {
+	now := time.Now()
+	star, other := uuid.New(), uuid.New()
+
+	// Both jobs prefer the star agent, who has one slot left
+	jobs := []batchJob{
+		{job: sqlc.SurveyJob{Deadline: now.Add(48 * time.Hour)}, candidates: []Candidate{
+			batchCandidate(star, 0.9), batchCandidate(other, 0.8),
+		}},
+		{job: sqlc.SurveyJob{Deadline: now.Add(48 * time.Hour)}, candidates: []Candidate{
+			batchCandidate(star, 0.9), batchCandidate(other, 0.4),
+		}},
+	}
+	slots := map[uuid.UUID]int{star: 1, other: 1}
+
+	got := map[int]uuid.UUID{}
+	for _, a := range solveBatch(jobs, slots, now) {
+		got[a.jobIdx] = a.candidate.AgentID
+	}
+
+	// Greedy would give job 0 the star and job 1 the weak match; the optimum
+	// gives the star to job 1, which has no good alternative.
+	if got[0] != other || got[1] != star {
+		t.Errorf("assignment = %v, want job0→other, job1→star", got)
+	}
+}
+
+func TestSolveBatchPrefersUrgentJobs(t *testing.T) {
+	now := time.Now()
+	agent := uuid.New()
+
+	jobs := []batchJob{
+		{job: sqlc.SurveyJob{Deadline: now.Add(70 * time.Hour)}, candidates: []Candidate{batchCandidate(agent, 0.9)}},
+		{job: sqlc.SurveyJob{Deadline: now.Add(6 * time.Hour)}, candidates: []Candidate{batchCandidate(agent, 0.5)}},
+	}
+
+	got := solveBatch(jobs, map[uuid.UUID]int{agent: 1}, now)
+	if len(got) != 1 || got[0].jobIdx != 1 {
+		t.Errorf("assignments = %+v, want only the urgent job (1)", got)
+	}
+}
+
+func TestSolveBatchRespectsDeadlinesAndCapacity(t *testing.T) {
+	now := time.Now()
+	agent, busy := uuid.New(), uuid.New()
+
+	far := batchCandidate(agent, 0.9)
+	far.TravelMinutes = 180
+
+	jobs := []batchJob{
+		// Agent can't arrive before the deadline
+		{job: sqlc.SurveyJob{Deadline: now.Add(time.Hour)}, candidates: []Candidate{far}},
+		// Agent has no open slots
+		{job: sqlc.SurveyJob{Deadline: now.Add(48 * time.Hour)}, candidates: []Candidate{batchCandidate(busy, 0.9)}},
+	}
+
+	if got := solveBatch(jobs, map[uuid.UUID]int{agent: 1, busy: 0}, now); len(got) != 0 {
+		t.Errorf("assignments = %+v, want none", got)
+	}
+}
+
+func TestSolvePartitionedMoreJobsThanSlots(t *testing.T) {
+	now := time.Now()
+	agent := uuid.New()
+
+	// Five equally good jobs compete for two slots, listed out of deadline
+	// order, so only urgency separates them.
+	var jobs []batchJob
+	for _, h := range []int{60, 5, 40, 10, 70} {
+		jobs = append(jobs, batchJob{
+			job:        sqlc.SurveyJob{Deadline: now.Add(time.Duration(h) * time.Hour)},
+			candidates: []Candidate{batchCandidate(agent, 0.7)},
+		})
+	}
+
+	// A chunk smaller than the job count must still serve the urgent jobs
+	for _, maxJobs := range []int{2, 3, batchMaxJobs} {
+		got := map[int]bool{}
+		for _, a := range solvePartitioned(jobs, map[uuid.UUID]int{agent: 2}, now, maxJobs) {
+			got[a.jobIdx] = true
+		}
+		if len(got) != 2 || !got[1] || !got[3] {
+			t.Errorf("maxJobs %d: assigned jobs %v, want the two most urgent (1 and 3)", maxJobs, got)
+		}
+	}
+}
+
+func TestSolvePartitionedByRegion(t *testing.T) {
+	now := time.Now()
+	north, south := uuid.New(), uuid.New()
+	deadline := now.Add(24 * time.Hour)
+
+	jobs := []batchJob{
+		{job: sqlc.SurveyJob{Deadline: deadline}, region: Region{StateCode: "KA"}, candidates: []Candidate{batchCandidate(south, 0.9)}},
+		{job: sqlc.SurveyJob{Deadline: deadline}, region: Region{StateCode: "PB"}, candidates: []Candidate{batchCandidate(north, 0.9)}},
+		{job: sqlc.SurveyJob{Deadline: deadline}, region: Region{StateCode: "KA"}, candidates: []Candidate{batchCandidate(south, 0.8)}},
+	}
+
+	got := map[int]uuid.UUID{}
+	for _, a := range solvePartitioned(jobs, map[uuid.UUID]int{north: 1, south: 1}, now, batchMaxJobs) {
+		got[a.jobIdx] = a.candidate.AgentID
+	}
+	if len(got) != 2 || got[0] != south || got[1] != north {
+		t.Errorf("assignment = %v, want job0→south, job1→north", got)
+	}
+}
+
+func TestNewBatchAssignerDefaultsInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		b := NewBatchAssigner(platform.DispatchConfig{BatchInterval: interval}, nil, nil, nil, slog.Default())
+		if b.interval != batchInterval {
+			t.Errorf("interval %v: expected default %v, got %v", interval, batchInterval, b.interval)
+		}
+	}
+}
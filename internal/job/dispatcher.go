@@ -3,26 +3,61 @@ package job
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
 	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
 	"github.com/terrascore/api/internal/platform"
 )
 
 const (
-	offerTimeout   = 30 * time.Minute
-	maxRounds      = 3
-	agentsPerRound = 5
+	// Defaults when DispatchConfig leaves them unset
+	offerTimeout = 30 * time.Minute
+	maxRounds    = 3
+
+	sweepInterval  = 1 * time.Minute
+	sweepBatchSize = 500
+)
+
+// Dispatch modes.
+const (
+	modeSequential = "sequential"
+	modeBroadcast  = "broadcast"
+)
+
+// Offer status values.
+const (
+	OfferQueued    = "queued"
+	OfferSent      = "sent"
+	OfferAccepted  = "accepted"
+	OfferDeclined  = "declined"
+	OfferExpired   = "expired"
+	OfferWithdrawn = "withdrawn"
 )
 
 // Dispatcher handles cascade dispatch of job offers to ranked agents.
+//
+// Cascade state is persisted rather than held in memory: the current round is
+// stored in survey_jobs.cascade_round and each round's ranked candidates are
+// written to job_offers as 'queued' rows, promoted to 'sent' one at a time.
+// Any instance can therefore pick up a cascade after a restart.
+//
+// In sequential mode one offer is outstanding at a time. In broadcast mode the
+// top-ranked candidates of a round are offered at once and the first agent to
+// accept claims the job (see Repository.ClaimOffer).
 type Dispatcher struct {
+	cfg      platform.DispatchConfig
 	matcher  *Matcher
+	pricer   *Pricer
 	jobRepo  *Repository
 	rdb      *redis.Client
 	eventBus *platform.EventBus
@@ -30,9 +65,11 @@ type Dispatcher struct {
 }
 
 // NewDispatcher creates a cascade dispatcher.
-func NewDispatcher(matcher *Matcher, jobRepo *Repository, rdb *redis.Client, eventBus *platform.EventBus, logger *slog.Logger) *Dispatcher {
+func NewDispatcher(cfg platform.DispatchConfig, matcher *Matcher, pricer *Pricer, jobRepo *Repository, rdb *redis.Client, eventBus *platform.EventBus, logger *slog.Logger) *Dispatcher {
 	return &Dispatcher{
+		cfg:      dispatchDefaults(cfg),
 		matcher:  matcher,
+		pricer:   pricer,
 		jobRepo:  jobRepo,
 		rdb:      rdb,
 		eventBus: eventBus,
@@ -41,207 +78,526 @@ func NewDispatcher(matcher *Matcher, jobRepo *Repository, rdb *redis.Client, eve
 }
 
 // HandleJobCreated is the EventBus handler for "job.created" events.
-// It spawns a goroutine to run the cascade dispatch for the job.
-func (d *Dispatcher) HandleJobCreated(ctx context.Context, event platform.Event) {
-	job, ok := event.Payload.(*sqlc.SurveyJob)
-	if !ok {
-		d.logger.Error("dispatcher: invalid event payload type")
+// It advances the new job's cascade, which plans round 1 and sends the first
+// offer. With batch assignment enabled, round 1 is left to the BatchAssigner.
+func (d *Dispatcher) HandleJobCreated(ctx context.Context, e events.JobCreated) error {
+	if d.cfg.BatchEnabled {
+		return nil
+	}
+
+	d.advance(ctx, e.JobID)
+	return nil
+}
+
+// HandleJobRedispatched is the EventBus handler for "job.redispatched"
+// events. Ops re-dispatch resets the job's round budget and radius, so its
+// cascade is advanced straight away even with batch assignment enabled.
+func (d *Dispatcher) HandleJobRedispatched(ctx context.Context, e events.JobRedispatched) error {
+	d.advance(ctx, e.JobID)
+	return nil
+}
+
+// Start resumes in-flight cascades and keeps them moving. Offer responses on
+// Redis advance their job immediately; a periodic sweep expires stale offers
+// and advances every job still awaiting assignment. Call in a goroutine.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.logger.Info("dispatcher started", "sweep_interval", sweepInterval)
+
+	// Resume cascades interrupted by a restart
+	d.sweep(ctx)
+
+	sub := d.rdb.PSubscribe(ctx, "offer:*:response")
+	defer sub.Close()
+	responses := sub.Channel()
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("dispatcher stopped")
+			return
+		case msg, ok := <-responses:
+			if !ok {
+				responses = nil
+				continue
+			}
+			go d.handleResponse(ctx, msg)
+		case <-ticker.C:
+			d.sweep(ctx)
+		}
+	}
+}
+
+// handleResponse advances the job behind an offer after the agent responds.
+func (d *Dispatcher) handleResponse(ctx context.Context, msg *redis.Message) {
+	// Channel format: "offer:{id}:response"
+	idStr := strings.TrimSuffix(strings.TrimPrefix(msg.Channel, "offer:"), ":response")
+	offerID, err := uuid.Parse(idStr)
+	if err != nil {
+		d.logger.Warn("dispatcher: invalid offer response channel", "channel", msg.Channel)
 		return
 	}
 
-	// Spawn cascade goroutine per job
-	go d.dispatchJob(ctx, job)
+	offer, err := d.jobRepo.GetOfferByID(ctx, offerID)
+	if err != nil {
+		d.logger.Error("dispatcher: failed to load offer", "offer_id", offerID, "error", err)
+		return
+	}
+
+	d.logger.Info("dispatcher: received response",
+		"offer_id", offerID,
+		"job_id", offer.JobID,
+		"response", msg.Payload,
+	)
+	d.advance(ctx, offer.JobID)
 }
 
-// dispatchJob runs the full cascade dispatch flow for a single job.
-func (d *Dispatcher) dispatchJob(ctx context.Context, job *sqlc.SurveyJob) {
-	d.logger.Info("dispatcher: starting cascade", "job_id", job.ID)
+// sweep expires stale offers and advances every job awaiting assignment.
+func (d *Dispatcher) sweep(ctx context.Context) {
+	if err := d.jobRepo.ExpireOffers(ctx); err != nil {
+		d.logger.Error("dispatcher: failed to expire offers", "error", err)
+	}
 
-	// We need the parcel centroid to find nearby agents.
-	// The parcel boundary centroid is stored in parcels.centroid (PostGIS computed column).
-	// For simplicity, we'll pass lng=0, lat=0 as a fallback.
-	// In production, we'd query the parcel to get its centroid.
-	// For now, get parcel centroid from the parcel table via a simple query.
-	lng, lat, err := d.getParcelCentroid(ctx, job.ParcelID)
+	jobs, err := d.jobRepo.ListPendingJobs(ctx, sweepBatchSize)
 	if err != nil {
-		d.logger.Error("dispatcher: failed to get parcel centroid",
+		d.logger.Error("dispatcher: failed to list pending jobs", "error", err)
+		return
+	}
+
+	for _, j := range jobs {
+		d.advance(ctx, j.ID)
+	}
+}
+
+// advance moves a job's cascade forward from its persisted state. It is
+// idempotent, and a per-job advisory lock keeps concurrent callers (events,
+// sweeps, other replicas) from advancing the same job twice.
+func (d *Dispatcher) advance(ctx context.Context, jobID uuid.UUID) {
+	unlock, locked, err := d.jobRepo.TryLockJob(ctx, jobID)
+	if err != nil {
+		d.logger.Error("dispatcher: failed to lock job", "job_id", jobID, "error", err)
+		return
+	}
+	if !locked {
+		return // another worker is advancing this job
+	}
+	defer unlock()
+
+	job, err := d.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		d.logger.Error("dispatcher: failed to load job", "job_id", jobID, "error", err)
+		return
+	}
+	if !awaitingAssignment(job) {
+		return
+	}
+
+	offers, err := d.jobRepo.ListOffersByJob(ctx, jobID)
+	if err != nil {
+		d.logger.Error("dispatcher: failed to load offers", "job_id", jobID, "error", err)
+		return
+	}
+
+	round := int32(0)
+	if job.CascadeRound != nil {
+		round = *job.CascadeRound
+	}
+
+	batch := offerBatch(d.cfg, job)
+	for {
+		step := nextCascadeStep(round, offers, batch, d.roundLimit(job))
+		round = step.Round
+
+		switch step.Action {
+		case StepWait:
+			return
+
+		case StepSend:
+			d.sendOffers(ctx, job, step.Offers, offers)
+			return
+
+		case StepExhausted:
+			d.markUnassigned(ctx, job.ID, "all cascade rounds exhausted")
+			d.logger.Warn("dispatcher: all rounds exhausted, job unassigned", "job_id", job.ID)
+			return
+
+		case StepNextRound:
+			if round == 0 && d.cfg.BatchEnabled && job.DispatchRoundLimit == nil {
+				return // first round is planned by the batch assigner
+			}
+			round++
+			offers, err = d.planRound(ctx, job, round, offers)
+			if err != nil {
+				d.logger.Error("dispatcher: failed to plan round",
+					"job_id", job.ID,
+					"round", round,
+					"error", err,
+				)
+				// The round is not recorded, so the next sweep plans it again
+				if !replanLater(err, job.Deadline, time.Now()) {
+					d.markUnassigned(ctx, job.ID, "failed to plan cascade round")
+				}
+				return
+			}
+		}
+	}
+}
+
+// planRound ranks candidates for a new cascade round and stores them as queued offers.
+// It returns an error, without recording the round, if matching fails.
+func (d *Dispatcher) planRound(ctx context.Context, job *sqlc.SurveyJob, round int32, offers []sqlc.JobOffer) ([]sqlc.JobOffer, error) {
+	lng, lat, region, err := d.getParcelLocation(ctx, job.ParcelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []Candidate
+	if job.DispatchRadiusKm != nil {
+		candidates, err = d.matcher.FindCandidatesWithin(ctx, lng, lat, job.SurveyType, region, offeredAgentIDs(offers), float64(*job.DispatchRadiusKm))
+	} else {
+		candidates, err = d.matcher.FindCandidatesAtLocation(ctx, lng, lat, job.SurveyType, region, offeredAgentIDs(offers))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("matching agents: %w", err)
+	}
+
+	if len(candidates) == 0 {
+		d.logger.Info("dispatcher: no candidates in round",
 			"job_id", job.ID,
-			"parcel_id", job.ParcelID,
-			"error", err,
+			"round", round,
 		)
-		d.markUnassigned(ctx, job.ID)
-		return
 	}
 
-	excludeIDs := []uuid.UUID{}
-	totalOffersSent := int32(0)
+	for rank, candidate := range candidates {
+		distKm := float32(candidate.DistanceKm)
+		strategy := candidate.Strategy.Name()
 
-	for round := int32(1); round <= maxRounds; round++ {
-		candidates, err := d.matcher.FindCandidatesAtLocation(ctx, lng, lat, job.SurveyType, excludeIDs)
+		offer, err := d.jobRepo.CreateQueuedOffer(ctx, sqlc.CreateQueuedJobOfferParams{
+			JobID:           job.ID,
+			AgentID:         candidate.AgentID,
+			CascadeRound:    round,
+			OfferRank:       int32(rank + 1),
+			DistanceKm:      &distKm,
+			MatchScore:      matchScore(candidate.CompositeScore),
+			ScoringStrategy: &strategy,
+			ScoringWeights:  weightsJSON(candidate.Strategy),
+			ExpiresAt:       job.Deadline, // replaced with the real expiry when sent
+		})
 		if err != nil {
-			d.logger.Error("dispatcher: matching failed",
+			d.logger.Error("dispatcher: failed to queue offer",
 				"job_id", job.ID,
-				"round", round,
+				"agent_id", candidate.AgentID,
 				"error", err,
 			)
 			continue
 		}
+		offers = append(offers, *offer)
+	}
+
+	if err := d.jobRepo.UpdateJobCascade(ctx, job.ID, round, countSent(offers)); err != nil {
+		return nil, err
+	}
 
-		if len(candidates) == 0 {
-			d.logger.Info("dispatcher: no candidates in round",
+	return offers, nil
+}
+
+// sendOffers prices queued offers, promotes them to sent and notifies each agent.
+func (d *Dispatcher) sendOffers(ctx context.Context, job *sqlc.SurveyJob, queued, offers []sqlc.JobOffer) {
+	now := time.Now()
+	expiresAt := now.Add(d.cfg.OfferTimeout)
+	sent := countSent(offers)
+	round := queued[0].CascadeRound
+
+	areaSqm, demand, err := d.jobRepo.ParcelDemand(ctx, job.ParcelID, now.Add(-demandWindow))
+	if err != nil {
+		// Price without area and surge rather than hold up the cascade
+		d.logger.Error("dispatcher: failed to load pricing inputs", "job_id", job.ID, "error", err)
+	}
+
+	for _, q := range queued {
+		in := PricingInput{
+			SurveyType: job.SurveyType,
+			AreaSqm:    areaSqm,
+			TimeLeft:   job.Deadline.Sub(now),
+			Round:      q.CascadeRound,
+			Demand:     demand,
+		}
+		if q.DistanceKm != nil {
+			in.DistanceKm = float64(*q.DistanceKm)
+		}
+		payout := d.pricer.Price(in)
+
+		offer, err := d.jobRepo.SendOffer(ctx, q.ID, expiresAt, payout)
+		if err != nil {
+			d.logger.Error("dispatcher: failed to send offer",
 				"job_id", job.ID,
-				"round", round,
+				"offer_id", q.ID,
+				"error", err,
 			)
 			continue
 		}
+		sent++
 
-		for rank, candidate := range candidates {
-			// Create offer
-			distKm := float32(candidate.DistanceKm)
-			matchScore := pgtype.Numeric{}
-			matchScore.Scan(fmt.Sprintf("%.4f", candidate.CompositeScore))
-
-			offer, err := d.jobRepo.CreateOffer(ctx, sqlc.CreateJobOfferParams{
-				JobID:        job.ID,
-				AgentID:      candidate.AgentID,
-				CascadeRound: round,
-				OfferRank:    int32(rank + 1),
-				DistanceKm:   &distKm,
-				MatchScore:   matchScore,
-				ExpiresAt:    time.Now().Add(offerTimeout),
-			})
-			if err != nil {
-				d.logger.Error("dispatcher: failed to create offer",
-					"job_id", job.ID,
-					"agent_id", candidate.AgentID,
-					"error", err,
-				)
-				continue
-			}
+		// Publish to Redis for real-time notification to agent
+		d.publishOfferToAgent(ctx, offer.AgentID, offer, payout)
 
-			totalOffersSent++
+		// FCM push notification placeholder (Phase 1: log only)
+		d.logger.Info("dispatcher: FCM push placeholder",
+			"agent_id", offer.AgentID,
+			"job_id", job.ID,
+			"offer_id", offer.ID,
+		)
+	}
 
-			// Update job status to offered
-			d.jobRepo.UpdateJobStatus(ctx, job.ID, "offered")
+	if jobStatus(job) != StatusOffered {
+		if _, _, err := d.jobRepo.Transition(ctx, job.ID, StatusOffered, systemActor("dispatcher"), "offers sent"); err != nil {
+			d.logger.Error("dispatcher: failed to mark job offered", "job_id", job.ID, "error", err)
+		}
+	}
+	if err := d.jobRepo.UpdateJobCascade(ctx, job.ID, round, sent); err != nil {
+		d.logger.Error("dispatcher: failed to update cascade", "job_id", job.ID, "error", err)
+	}
+}
 
-			// Publish to Redis for real-time notification to agent
-			d.publishOfferToAgent(ctx, candidate.AgentID, offer)
+// CascadeAction is the next thing the dispatcher should do for a job.
+type CascadeAction int
 
-			// FCM push notification placeholder (Phase 1: log only)
-			d.logger.Info("dispatcher: FCM push placeholder",
-				"agent_id", candidate.AgentID,
-				"job_id", job.ID,
-				"offer_id", offer.ID,
-			)
+// Cascade actions.
+const (
+	StepWait      CascadeAction = iota // an offer is outstanding
+	StepSend                           // send the next queued offer(s)
+	StepNextRound                      // current round is spent, plan another
+	StepExhausted                      // all rounds spent, give up
+)
 
-			// Wait for agent response via Redis pub/sub
-			accepted := d.waitForResponse(ctx, offer.ID)
-
-			if accepted {
-				// Assign agent to job
-				_, err := d.jobRepo.AssignAgent(ctx, sqlc.AssignAgentParams{
-					ID:              job.ID,
-					AssignedAgentID: pgtype.UUID{Bytes: candidate.AgentID, Valid: true},
-					CascadeRound:    &round,
-					TotalOffersSent: &totalOffersSent,
-				})
-				if err != nil {
-					d.logger.Error("dispatcher: failed to assign agent",
-						"job_id", job.ID,
-						"agent_id", candidate.AgentID,
-						"error", err,
-					)
-					continue
-				}
+// CascadeStep is the result of evaluating a job's persisted cascade state.
+type CascadeStep struct {
+	Action CascadeAction
+	Round  int32
+	Offers []sqlc.JobOffer // offers to send, lowest rank first
+}
 
-				d.eventBus.Publish(platform.Event{
-					Type:    "job.assigned",
-					Payload: job,
-				})
+// nextCascadeStep decides what to do next from the job's round and its offers,
+// sending up to batch queued offers at once and giving up after rounds. The
+// effective round is the later of the stored round and the newest offer's
+// round, so a crash between queuing offers and saving the round loses
+// nothing. Accepted offers never reach here: claiming a job moves it out of
+// the dispatch phase in the same transaction.
+func nextCascadeStep(round int32, offers []sqlc.JobOffer, batch int, rounds int32) CascadeStep {
+	for _, o := range offers {
+		if o.CascadeRound > round {
+			round = o.CascadeRound
+		}
+	}
 
-				d.logger.Info("dispatcher: job assigned",
-					"job_id", job.ID,
-					"agent_id", candidate.AgentID,
-					"round", round,
-					"rank", rank+1,
-				)
-				return
+	var queued []sqlc.JobOffer
+	for _, o := range offers {
+		switch offerStatus(o) {
+		case OfferSent:
+			return CascadeStep{Action: StepWait, Round: round}
+		case OfferQueued:
+			if o.CascadeRound == round {
+				queued = append(queued, o)
 			}
+		}
+	}
 
-			// Agent declined or timed out; try next candidate
-			excludeIDs = append(excludeIDs, candidate.AgentID)
+	if len(queued) > 0 {
+		sort.Slice(queued, func(i, j int) bool { return queued[i].OfferRank < queued[j].OfferRank })
+		if len(queued) > batch {
+			queued = queued[:batch]
 		}
+		return CascadeStep{Action: StepSend, Round: round, Offers: queued}
+	}
+	if round < rounds {
+		return CascadeStep{Action: StepNextRound, Round: round}
+	}
+	return CascadeStep{Action: StepExhausted, Round: round}
+}
+
+// CascadePolicy makes the dispatcher's cascade decisions for a job whose
+// offers are held in memory rather than in job_offers, so the dispatch
+// simulator replays the policy the Dispatcher runs.
+type CascadePolicy struct {
+	cfg platform.DispatchConfig
+}
+
+// NewCascadePolicy creates a cascade policy for cfg, with the Dispatcher's
+// defaults for an unset offer timeout and round limit.
+func NewCascadePolicy(cfg platform.DispatchConfig) CascadePolicy {
+	return CascadePolicy{cfg: dispatchDefaults(cfg)}
+}
+
+// OfferTimeout returns how long an offer stays open.
+func (p CascadePolicy) OfferTimeout() time.Duration {
+	return p.cfg.OfferTimeout
+}
+
+// Next decides what to do next for a job of surveyType and priority from its
+// stored round and its offers.
+func (p CascadePolicy) Next(surveyType, priority string, round int32, offers []sqlc.JobOffer) CascadeStep {
+	job := &sqlc.SurveyJob{SurveyType: surveyType, Priority: &priority}
+	return nextCascadeStep(round, offers, offerBatch(p.cfg, job), int32(p.cfg.MaxRounds))
+}
+
+// dispatchDefaults fills in the offer timeout and round limit when cfg
+// leaves them unset.
+func dispatchDefaults(cfg platform.DispatchConfig) platform.DispatchConfig {
+	if cfg.OfferTimeout <= 0 {
+		cfg.OfferTimeout = offerTimeout
+	}
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = maxRounds
 	}
+	return cfg
+}
 
-	// All rounds exhausted
-	d.markUnassigned(ctx, job.ID)
-	d.logger.Warn("dispatcher: all rounds exhausted, job unassigned", "job_id", job.ID)
+// offerBatch returns how many queued offers are sent at once for a job.
+func offerBatch(cfg platform.DispatchConfig, job *sqlc.SurveyJob) int {
+	if dispatchMode(cfg, job) == modeBroadcast {
+		return max(cfg.BroadcastSize, 1)
+	}
+	return 1
 }
 
-// waitForResponse waits for an agent's accept/decline on a Redis channel.
-// Returns true if accepted, false if declined or timed out.
-func (d *Dispatcher) waitForResponse(ctx context.Context, offerID uuid.UUID) bool {
-	channel := fmt.Sprintf("offer:%s:response", offerID)
+// roundLimit returns the last cascade round allowed for a job: the configured
+// maximum, or the extended limit set when ops re-dispatched it.
+func (d *Dispatcher) roundLimit(job *sqlc.SurveyJob) int32 {
+	if job.DispatchRoundLimit != nil {
+		return *job.DispatchRoundLimit
+	}
+	return int32(d.cfg.MaxRounds)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, offerTimeout)
-	defer cancel()
+// dispatchMode picks sequential or broadcast dispatch for a job. Survey types
+// and priorities listed for broadcast override the default mode.
+func dispatchMode(cfg platform.DispatchConfig, job *sqlc.SurveyJob) string {
+	if slices.Contains(cfg.BroadcastSurveyTypes, job.SurveyType) {
+		return modeBroadcast
+	}
+	if job.Priority != nil && slices.Contains(cfg.BroadcastPriorities, *job.Priority) {
+		return modeBroadcast
+	}
+	if cfg.Mode == modeBroadcast {
+		return modeBroadcast
+	}
+	return modeSequential
+}
 
-	sub := d.rdb.Subscribe(ctx, channel)
-	defer sub.Close()
+// awaitingAssignment reports whether a job is still in the dispatch phase.
+func awaitingAssignment(job *sqlc.SurveyJob) bool {
+	status := jobStatus(job)
+	return status == StatusPendingAssignment || status == StatusOffered
+}
 
-	ch := sub.Channel()
-	select {
-	case msg := <-ch:
-		if msg == nil {
-			return false
+// offerStatus returns an offer's status, defaulting to sent.
+func offerStatus(o sqlc.JobOffer) string {
+	if o.Status == nil {
+		return OfferSent
+	}
+	return *o.Status
+}
+
+// countSent returns how many offers have actually been sent to agents.
+func countSent(offers []sqlc.JobOffer) int32 {
+	var n int32
+	for _, o := range offers {
+		if o.SentAt.Valid {
+			n++
 		}
-		d.logger.Info("dispatcher: received response",
-			"offer_id", offerID,
-			"response", msg.Payload,
-		)
-		return msg.Payload == "accepted"
-	case <-ctx.Done():
-		// Timeout — expire the offer
-		d.logger.Info("dispatcher: offer timed out", "offer_id", offerID)
-		expired := "expired"
-		d.jobRepo.UpdateOfferStatus(ctx, offerID, expired, nil)
-		return false
 	}
+	return n
+}
+
+// offeredAgentIDs returns every agent already offered (or queued for) the job.
+func offeredAgentIDs(offers []sqlc.JobOffer) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(offers))
+	for _, o := range offers {
+		ids = append(ids, o.AgentID)
+	}
+	return ids
 }
 
-// publishOfferToAgent sends an offer notification to the agent's Redis channel.
-func (d *Dispatcher) publishOfferToAgent(ctx context.Context, agentID uuid.UUID, offer *sqlc.JobOffer) {
+// publishOfferToAgent sends an offer notification, with the payout on
+// offer, to the agent's Redis channel.
+func (d *Dispatcher) publishOfferToAgent(ctx context.Context, agentID uuid.UUID, offer *sqlc.JobOffer, payout PayoutBreakdown) {
 	channel := fmt.Sprintf("agent:%s:offers", agentID)
 	payload, _ := json.Marshal(map[string]interface{}{
+		"type":       "offer",
 		"offer_id":   offer.ID,
 		"job_id":     offer.JobID,
 		"expires_at": offer.ExpiresAt,
+		"payout":     payout,
 	})
 	d.rdb.Publish(ctx, channel, payload)
 }
 
-// getParcelCentroid fetches the centroid coordinates for a parcel.
+// Reasons sent to agents whose offers are withdrawn.
+const (
+	withdrawnAssignedElsewhere = "assigned_elsewhere"
+	withdrawnRedispatched      = "redispatched"
+	withdrawnCancelled         = "cancelled"
+)
+
+// publishOfferWithdrawn tells an agent that their offer was withdrawn, e.g.
+// because another agent claimed the job.
+func publishOfferWithdrawn(ctx context.Context, rdb *redis.Client, offer sqlc.JobOffer, reason string) {
+	channel := fmt.Sprintf("agent:%s:offers", offer.AgentID)
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":     "offer_withdrawn",
+		"offer_id": offer.ID,
+		"job_id":   offer.JobID,
+		"reason":   reason,
+	})
+	rdb.Publish(ctx, channel, payload)
+}
+
+// getParcelLocation fetches the centroid coordinates and region for a parcel.
 // Uses a direct query since we need centroid as lng/lat.
-func (d *Dispatcher) getParcelCentroid(ctx context.Context, parcelID uuid.UUID) (lng, lat float64, err error) {
+func (d *Dispatcher) getParcelLocation(ctx context.Context, parcelID uuid.UUID) (lng, lat float64, region Region, err error) {
 	var lngVal, latVal *float64
 	row := d.jobRepo.db.QueryRow(ctx,
-		"SELECT ST_X(centroid), ST_Y(centroid) FROM parcels WHERE id = $1",
+		"SELECT ST_X(centroid), ST_Y(centroid), state_code, district FROM parcels WHERE id = $1",
 		parcelID,
 	)
-	err = row.Scan(&lngVal, &latVal)
+	err = row.Scan(&lngVal, &latVal, &region.StateCode, &region.District)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, region, fmt.Errorf("parcel %s not found: %w", parcelID, errNoParcelLocation)
+	}
 	if err != nil {
-		return 0, 0, fmt.Errorf("getting parcel centroid: %w", err)
+		return 0, 0, region, fmt.Errorf("getting parcel centroid: %w", err)
 	}
 	if lngVal == nil || latVal == nil {
-		return 0, 0, fmt.Errorf("parcel %s has no centroid", parcelID)
+		return 0, 0, region, fmt.Errorf("parcel %s has no centroid: %w", parcelID, errNoParcelLocation)
 	}
-	return *lngVal, *latVal, nil
+	return *lngVal, *latVal, region, nil
 }
 
-// markUnassigned sets job status to unassigned.
-func (d *Dispatcher) markUnassigned(ctx context.Context, jobID uuid.UUID) {
-	_, err := d.jobRepo.UpdateJobStatus(ctx, jobID, "unassigned")
+// errNoParcelLocation means a job's parcel can never be searched around, so
+// replanning its round cannot succeed.
+var errNoParcelLocation = errors.New("parcel has no location")
+
+// replanLater reports whether a round that failed to plan should be planned
+// again on the next sweep: only if the error may clear up and the job's
+// deadline has not passed.
+func replanLater(err error, deadline, now time.Time) bool {
+	return !errors.Is(err, errNoParcelLocation) && now.Before(deadline)
+}
+
+// markUnassigned sets job status to unassigned and publishes job.unassigned
+// so ops can be alerted.
+func (d *Dispatcher) markUnassigned(ctx context.Context, jobID uuid.UUID, reason string) {
+	job, _, err := d.jobRepo.Transition(ctx, jobID, StatusUnassigned, systemActor("dispatcher"), reason)
 	if err != nil {
 		d.logger.Error("dispatcher: failed to mark unassigned", "job_id", jobID, "error", err)
+		return
 	}
+
+	d.eventBus.Publish(platform.NewEvent(events.JobUnassigned{
+		JobID:    job.ID,
+		ParcelID: job.ParcelID,
+		Reason:   reason,
+	}))
 }
@@ -0,0 +1,165 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func testOffer(round, rank int32, status string) sqlc.JobOffer {
+	o := sqlc.JobOffer{
+		ID:           uuid.New(),
+		AgentID:      uuid.New(),
+		CascadeRound: round,
+		OfferRank:    rank,
+		Status:       &status,
+	}
+	if status != OfferQueued {
+		o.SentAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	return o
+}
+
+func TestNextCascadeStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		round      int32
+		offers     []sqlc.JobOffer
+		batch      int
+		wantAction CascadeAction
+		wantRound  int32
+		wantRanks  []int32
+	}{
+		{"new job plans round 1", 0, nil, 1, StepNextRound, 0, nil},
+		{"outstanding offer waits", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferSent),
+			testOffer(1, 2, OfferQueued),
+		}, 1, StepWait, 1, nil},
+		{"declined offer sends next rank", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferDeclined),
+			testOffer(1, 3, OfferQueued),
+			testOffer(1, 2, OfferQueued),
+		}, 1, StepSend, 1, []int32{2}},
+		{"expired round plans next", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferExpired),
+			testOffer(1, 2, OfferDeclined),
+		}, 1, StepNextRound, 1, nil},
+		{"round recovered from offers", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferExpired),
+			testOffer(2, 1, OfferQueued),
+		}, 1, StepSend, 2, []int32{1}},
+		{"last round exhausted", maxRounds, []sqlc.JobOffer{
+			testOffer(maxRounds, 1, OfferDeclined),
+		}, 1, StepExhausted, maxRounds, nil},
+		{"broadcast sends top ranks at once", 1, []sqlc.JobOffer{
+			testOffer(1, 4, OfferQueued),
+			testOffer(1, 2, OfferQueued),
+			testOffer(1, 1, OfferQueued),
+			testOffer(1, 3, OfferQueued),
+		}, 3, StepSend, 1, []int32{1, 2, 3}},
+		{"broadcast waits while any offer is out", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferDeclined),
+			testOffer(1, 2, OfferSent),
+			testOffer(1, 4, OfferQueued),
+		}, 3, StepWait, 1, nil},
+		{"broadcast sends remainder after top ranks decline", 1, []sqlc.JobOffer{
+			testOffer(1, 1, OfferDeclined),
+			testOffer(1, 2, OfferExpired),
+			testOffer(1, 3, OfferQueued),
+		}, 3, StepSend, 1, []int32{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := nextCascadeStep(tt.round, tt.offers, tt.batch, maxRounds)
+			if step.Action != tt.wantAction {
+				t.Errorf("action = %d, want %d", step.Action, tt.wantAction)
+			}
+			if step.Round != tt.wantRound {
+				t.Errorf("round = %d, want %d", step.Round, tt.wantRound)
+			}
+			var ranks []int32
+			for _, o := range step.Offers {
+				ranks = append(ranks, o.OfferRank)
+			}
+			if !slices.Equal(ranks, tt.wantRanks) {
+				t.Errorf("offer ranks = %v, want %v", ranks, tt.wantRanks)
+			}
+		})
+	}
+}
+
+func TestCountSent(t *testing.T) {
+	offers := []sqlc.JobOffer{
+		testOffer(1, 1, OfferDeclined),
+		testOffer(1, 2, OfferSent),
+		testOffer(1, 3, OfferQueued),
+		testOffer(1, 4, OfferWithdrawn),
+	}
+	if got := countSent(offers); got != 3 {
+		t.Errorf("countSent = %d, want 3", got)
+	}
+}
+
+func TestDispatchMode(t *testing.T) {
+	cfg := platform.DispatchConfig{
+		Mode:                 modeSequential,
+		BroadcastSurveyTypes: []string{"premium_inspection"},
+		BroadcastPriorities:  []string{"urgent"},
+	}
+	urgent, normal := "urgent", "normal"
+
+	tests := []struct {
+		name       string
+		cfg        platform.DispatchConfig
+		surveyType string
+		priority   *string
+		expected   string
+	}{
+		{"default sequential", cfg, "basic_check", &normal, modeSequential},
+		{"nil priority", cfg, "basic_check", nil, modeSequential},
+		{"broadcast survey type", cfg, "premium_inspection", &normal, modeBroadcast},
+		{"broadcast priority", cfg, "basic_check", &urgent, modeBroadcast},
+		{"broadcast default", platform.DispatchConfig{Mode: modeBroadcast}, "basic_check", &normal, modeBroadcast},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &sqlc.SurveyJob{SurveyType: tt.surveyType, Priority: tt.priority}
+			if got := dispatchMode(tt.cfg, job); got != tt.expected {
+				t.Errorf("dispatchMode = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReplanLater(t *testing.T) {
+	now := time.Now()
+	transient := errors.New("connection reset")
+	permanent := fmt.Errorf("parcel p-1 has no centroid: %w", errNoParcelLocation)
+
+	tests := []struct {
+		name     string
+		err      error
+		deadline time.Time
+		want     bool
+	}{
+		{"transient before deadline", transient, now.Add(time.Hour), true},
+		{"transient past deadline", transient, now.Add(-time.Minute), false},
+		{"no location", permanent, now.Add(time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replanLater(tt.err, tt.deadline, now); got != tt.want {
+				t.Errorf("replanLater = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
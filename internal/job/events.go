@@ -0,0 +1,36 @@
+package job
+
+import (
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
+)
+
+// jobCreated builds the job.created event for a new job.
+func jobCreated(job *sqlc.SurveyJob) events.JobCreated {
+	e := events.JobCreated{
+		JobID:      job.ID,
+		ParcelID:   job.ParcelID,
+		UserID:     job.UserID,
+		SurveyType: job.SurveyType,
+		Deadline:   job.Deadline,
+	}
+	if job.Priority != nil {
+		e.Priority = *job.Priority
+	}
+	if job.Trigger != nil {
+		e.Trigger = *job.Trigger
+	}
+	return e
+}
+
+// jobAssigned builds the job.assigned event for a job that has just been
+// given to an agent.
+func jobAssigned(job *sqlc.SurveyJob) events.JobAssigned {
+	return events.JobAssigned{
+		JobID:    job.ID,
+		ParcelID: job.ParcelID,
+		UserID:   job.UserID,
+		AgentID:  uuid.UUID(job.AssignedAgentID.Bytes),
+	}
+}
@@ -6,16 +6,19 @@ import (
 	"math"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/redis/go-redis/v9"
 	"github.com/terrascore/api/db/sqlc"
 	"github.com/terrascore/api/internal/agent"
 	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/events"
 	"github.com/terrascore/api/internal/platform"
 	"github.com/terrascore/api/internal/survey"
 )
@@ -27,19 +30,17 @@ type Handler struct {
 	surveyRepo *survey.Repository
 	s3Client   *platform.S3Client
 	rdb        *redis.Client
-	eventBus   *platform.EventBus
 	logger     *slog.Logger
 }
 
 // NewHandler creates a job handler.
-func NewHandler(jobRepo *Repository, agentRepo *agent.Repository, surveyRepo *survey.Repository, s3Client *platform.S3Client, rdb *redis.Client, eventBus *platform.EventBus, logger *slog.Logger) *Handler {
+func NewHandler(jobRepo *Repository, agentRepo *agent.Repository, surveyRepo *survey.Repository, s3Client *platform.S3Client, rdb *redis.Client, logger *slog.Logger) *Handler {
 	return &Handler{
 		jobRepo:    jobRepo,
 		agentRepo:  agentRepo,
 		surveyRepo: surveyRepo,
 		s3Client:   s3Client,
 		rdb:        rdb,
-		eventBus:   eventBus,
 		logger:     logger,
 	}
 }
@@ -54,13 +55,17 @@ func (h *Handler) Routes() chi.Router {
 		r.Post("/{id}/accept", h.AcceptOffer)
 		r.Post("/{id}/decline", h.DeclineOffer)
 		r.Get("/{id}", h.GetJob)
+		r.Post("/{id}/en-route", h.EnRoute)
 		r.Post("/{id}/arrive", h.Arrive)
+		r.Post("/{id}/start", h.StartSurvey)
 		r.Get("/{id}/media/presigned", h.PresignedURL)
 		r.Post("/{id}/media", h.RecordMedia)
 		r.Post("/{id}/survey", h.SubmitSurvey)
 		r.Get("/{id}/template", h.GetTemplate)
 	})
 
+	r.With(auth.RequireRole("agent", "admin", "ops")).Get("/{id}/history", h.GetHistory)
+
 	return r
 }
 
@@ -92,14 +97,33 @@ func (h *Handler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update offer status to accepted
-	accepted := "accepted"
-	if err := h.jobRepo.UpdateOfferStatus(r.Context(), offer.ID, accepted, nil); err != nil {
+	// Claim the job and record job.assigned in one transaction. Only the first
+	// agent to accept wins; later accepts (broadcast mode, or an offer that
+	// has since expired) get a conflict.
+	var (
+		job       *sqlc.SurveyJob
+		withdrawn []sqlc.JobOffer
+	)
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		if job, withdrawn, err = h.jobRepo.WithTx(tx).ClaimOffer(r.Context(), offer.ID); err != nil {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(jobAssigned(job)))
+	})
+	if err != nil {
 		platform.HandleError(w, err)
 		return
 	}
 
-	// Publish response to Redis so the dispatcher goroutine picks it up
+	// Tell agents whose offers were still outstanding that the job is gone
+	for _, o := range withdrawn {
+		if o.SentAt.Valid {
+			publishOfferWithdrawn(r.Context(), h.rdb, o, withdrawnAssignedElsewhere)
+		}
+	}
+
+	// Publish response to Redis so the dispatcher stops waiting on this job
 	channel := fmt.Sprintf("offer:%s:response", offer.ID)
 	h.rdb.Publish(r.Context(), channel, "accepted")
 
@@ -107,15 +131,10 @@ func (h *Handler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
 		"agent_id", ag.ID,
 		"job_id", jobID,
 		"offer_id", offer.ID,
+		"round", offer.CascadeRound,
+		"withdrawn", len(withdrawn),
 	)
 
-	// Return the job details
-	job, err := h.jobRepo.GetJobByID(r.Context(), jobID)
-	if err != nil {
-		platform.HandleError(w, err)
-		return
-	}
-
 	platform.JSON(w, http.StatusOK, JobResponseFromSqlc(
 		job.ID, job.ParcelID, job.UserID, job.SurveyType, job.Priority,
 		job.Deadline, job.Status, job.AssignedAgentID, job.AssignedAt, job.CreatedAt,
@@ -156,13 +175,12 @@ func (h *Handler) DeclineOffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update offer status to declined
-	declined := "declined"
+	// Decline only a sent offer; it may have been accepted, expired or withdrawn
 	var reason *string
 	if req.Reason != "" {
 		reason = &req.Reason
 	}
-	if err := h.jobRepo.UpdateOfferStatus(r.Context(), offer.ID, declined, reason); err != nil {
+	if _, err := h.jobRepo.DeclineOffer(r.Context(), offer.ID, reason); err != nil {
 		platform.HandleError(w, err)
 		return
 	}
@@ -275,12 +293,129 @@ func (h *Handler) ListAgentOffers(w http.ResponseWriter, r *http.Request) {
 			t := o.SentAt.Time
 			resp.SentAt = &t
 		}
+		if payout, err := parseBreakdown(o.PayoutBreakdown); err == nil {
+			resp.Payout = payout
+		}
 		result[i] = resp
 	}
 
 	platform.JSON(w, http.StatusOK, result)
 }
 
+// EnRoute handles POST /v1/jobs/{id}/en-route.
+// Marks the assigned agent as travelling to the parcel.
+func (h *Handler) EnRoute(w http.ResponseWriter, r *http.Request) {
+	h.agentTransition(w, r, StatusEnRoute, "agent en route")
+}
+
+// StartSurvey handles POST /v1/jobs/{id}/start.
+// Marks the survey as started once the agent is on site.
+func (h *Handler) StartSurvey(w http.ResponseWriter, r *http.Request) {
+	h.agentTransition(w, r, StatusInProgress, "survey started")
+}
+
+// agentTransition moves the caller's own job to a new status.
+func (h *Handler) agentTransition(w http.ResponseWriter, r *http.Request, to, reason string) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	ag, err := h.agentRepo.GetAgentByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	job, err := h.jobRepo.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	if !job.AssignedAgentID.Valid || uuid.UUID(job.AssignedAgentID.Bytes) != ag.ID {
+		platform.HandleError(w, platform.NewForbidden("job not assigned to you"))
+		return
+	}
+
+	updated, _, err := h.jobRepo.Transition(r.Context(), jobID, to, agentActor(ag.ID), reason)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("agent updated job status",
+		"agent_id", ag.ID,
+		"job_id", jobID,
+		"status", to,
+	)
+
+	platform.JSON(w, http.StatusOK, jobResponse(updated))
+}
+
+// GetHistory handles GET /v1/jobs/{id}/history.
+// Agents may only see the history of jobs assigned to them.
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	job, err := h.jobRepo.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	if !slices.Contains(userCtx.Roles, RoleAdmin) && !slices.Contains(userCtx.Roles, RoleOps) {
+		ag, err := h.agentRepo.GetAgentByKeycloakID(r.Context(), userCtx.KeycloakID)
+		if err != nil {
+			platform.HandleError(w, err)
+			return
+		}
+		if !job.AssignedAgentID.Valid || uuid.UUID(job.AssignedAgentID.Bytes) != ag.ID {
+			platform.HandleError(w, platform.NewForbidden("job not assigned to you"))
+			return
+		}
+	}
+
+	history, err := h.jobRepo.ListStatusHistory(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]StatusHistoryResponse, len(history))
+	for i, e := range history {
+		result[i] = StatusHistoryResponse{
+			FromStatus: e.FromStatus,
+			ToStatus:   e.ToStatus,
+			ActorRole:  e.ActorRole,
+			ActorID:    e.ActorID,
+			Reason:     e.Reason,
+		}
+		if e.CreatedAt.Valid {
+			result[i].CreatedAt = e.CreatedAt.Time
+		}
+	}
+
+	platform.JSON(w, http.StatusOK, result)
+}
+
 // Arrive handles POST /v1/jobs/{id}/arrive.
 // Validates geofence (agent must be within 500m of parcel centroid).
 func (h *Handler) Arrive(w http.ResponseWriter, r *http.Request) {
@@ -326,8 +461,8 @@ func (h *Handler) Arrive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if job.Status == nil || *job.Status != "assigned" {
-		platform.HandleError(w, platform.NewBadRequest("job is not in assigned status"))
+	if err := checkTransition(jobStatus(job), StatusOnSite, RoleAgent); err != nil {
+		platform.HandleError(w, err)
 		return
 	}
 
@@ -347,12 +482,12 @@ func (h *Handler) Arrive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	arrivalDist := float32(math.Round(distM*100) / 100)
-	err = h.jobRepo.RecordAgentArrival(r.Context(), sqlc.RecordAgentArrivalParams{
+	_, err = h.jobRepo.RecordAgentArrival(r.Context(), sqlc.RecordAgentArrivalParams{
 		ID:               jobID,
 		StMakepoint:      req.Lng,
 		StMakepoint_2:    req.Lat,
 		ArrivalDistanceM: &arrivalDist,
-	})
+	}, agentActor(ag.ID))
 	if err != nil {
 		platform.HandleError(w, err)
 		return
@@ -529,6 +664,11 @@ func (h *Handler) SubmitSurvey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkTransition(jobStatus(job), StatusSubmitted, RoleAgent); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
 	// Build params
 	params := sqlc.CreateSurveyResponseParams{
 		JobID:     jobID,
@@ -554,31 +694,34 @@ func (h *Handler) SubmitSurvey(w http.ResponseWriter, r *http.Request) {
 
 	params.DurationMinutes = req.DurationMinutes
 
-	surveyResp, err := h.surveyRepo.CreateSurveyResponse(r.Context(), params)
-	if err != nil {
-		platform.HandleError(w, err)
-		return
-	}
-
-	// Update job status to survey_submitted
-	_, err = h.jobRepo.UpdateJobStatus(r.Context(), jobID, "survey_submitted")
+	// Store the response, move the job to survey_submitted and record the
+	// event that triggers the QA pipeline in one transaction
+	var surveyResp *sqlc.SurveyResponse
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		if surveyResp, err = h.surveyRepo.WithTx(tx).CreateSurveyResponse(r.Context(), params); err != nil {
+			return err
+		}
+		if _, _, err = h.jobRepo.WithTx(tx).Transition(r.Context(), jobID, StatusSubmitted, agentActor(ag.ID), "survey submitted"); err != nil {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(events.SurveySubmitted{
+			JobID:            jobID,
+			ParcelID:         job.ParcelID,
+			UserID:           job.UserID,
+			AgentID:          ag.ID,
+			SurveyResponseID: surveyResp.ID,
+		}))
+	})
 	if err != nil {
-		h.logger.Error("failed to update job status after survey submit",
+		h.logger.Error("failed to submit survey",
 			"job_id", jobID,
 			"error", err,
 		)
+		platform.HandleError(w, err)
+		return
 	}
 
-	// Publish event to trigger QA pipeline
-	h.eventBus.Publish(platform.Event{
-		Type: "survey.submitted",
-		Payload: map[string]string{
-			"job_id":    jobID.String(),
-			"parcel_id": job.ParcelID.String(),
-			"user_id":   job.UserID.String(),
-		},
-	})
-
 	h.logger.Info("survey submitted",
 		"agent_id", ag.ID,
 		"job_id", jobID,
@@ -160,6 +160,72 @@ func TestArrive_InvalidJobID(t *testing.T) {
 	}
 }
 
+func TestEnRoute_NoAuth(t *testing.T) {
+	h := newTestHandler()
+	r := chi.NewRouter()
+	r.Post("/jobs/{id}/en-route", h.EnRoute)
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/00000000-0000-0000-0000-000000000001/en-route", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestStartSurvey_InvalidJobID(t *testing.T) {
+	h := newTestHandler()
+	r := chi.NewRouter()
+	r.Post("/jobs/{id}/start", h.StartSurvey)
+
+	ctx := auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-kc-id",
+		Roles:      []string{"agent"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/not-a-uuid/start", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetHistory_NoAuth(t *testing.T) {
+	h := newTestHandler()
+	r := chi.NewRouter()
+	r.Get("/jobs/{id}/history", h.GetHistory)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/00000000-0000-0000-0000-000000000001/history", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestGetHistory_InvalidJobID(t *testing.T) {
+	h := newTestHandler()
+	r := chi.NewRouter()
+	r.Get("/jobs/{id}/history", h.GetHistory)
+
+	ctx := auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-kc-id",
+		Roles:      []string{"ops"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/not-a-uuid/history", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestPresignedURL_NoAuth(t *testing.T) {
 	h := newTestHandler()
 	r := chi.NewRouter()
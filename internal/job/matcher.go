@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"math"
+	"slices"
 	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/billing"
 )
 
 // Candidate represents a scored agent candidate for a job.
@@ -20,26 +21,76 @@ type Candidate struct {
 	AvgRating      float64
 	CompletionRate float64
 	HoursSinceJob  float64
+	QAPassRate     float64
+	AcceptanceRate float64
+	VehicleType    string
+	TravelMinutes  float64
+	OpenSlots      int // jobs the agent can still take before hitting maxConcurrentJobs
 	Tier           string
 	CompositeScore float64
+	Strategy       ScoringStrategy // strategy that produced CompositeScore
+}
+
+// Region identifies where a parcel is, for regional scoring overrides.
+type Region struct {
+	StateCode string
+	District  string
+}
+
+// AgentFinder looks up online agents near a point. *sqlc.Queries implements it.
+type AgentFinder interface {
+	FindMatchableAgents(ctx context.Context, arg sqlc.FindMatchableAgentsParams) ([]sqlc.FindMatchableAgentsRow, error)
+}
+
+// AgentLoad reports agents' current workload and offer history. *Repository implements it.
+type AgentLoad interface {
+	CountActiveJobsByAgent(ctx context.Context, agentID uuid.UUID) (int64, error)
+	GetAcceptanceRates(ctx context.Context, agentIDs []uuid.UUID) (map[uuid.UUID]float64, error)
 }
 
 // Matcher finds and scores nearby agents for survey jobs.
 type Matcher struct {
-	agentQ *sqlc.Queries // direct sqlc access for FindMatchableAgents
-	jobRepo *Repository
-	logger  *slog.Logger
+	agentQ     AgentFinder // direct sqlc access for FindMatchableAgents
+	jobRepo    AgentLoad
+	strategies *Strategies
+	radii      []float64 // meters
+	window     time.Duration // offer timeout; agents must be on shift for all of it
+	now        func() time.Time
+	logger     *slog.Logger
 }
 
-// NewMatcher creates a matcher with agent query access and job repository.
-func NewMatcher(agentQ *sqlc.Queries, jobRepo *Repository, logger *slog.Logger) *Matcher {
+// NewMatcher creates a matcher with agent query access, job repository and
+// scoring strategies. Empty radiiKm falls back to the default expansion radii.
+// Agents only match if they are on shift for the whole offer window, timeout
+// from now; a timeout that is not positive falls back to the default.
+func NewMatcher(agentQ AgentFinder, jobRepo AgentLoad, strategies *Strategies, radiiKm []float64, timeout time.Duration, logger *slog.Logger) *Matcher {
+	radii := expansionRadii
+	if len(radiiKm) > 0 {
+		radii = make([]float64, len(radiiKm))
+		for i, km := range radiiKm {
+			radii[i] = km * 1000
+		}
+	}
+	if timeout <= 0 {
+		timeout = offerTimeout
+	}
 	return &Matcher{
-		agentQ:  agentQ,
-		jobRepo: jobRepo,
-		logger:  logger,
+		agentQ:     agentQ,
+		jobRepo:    jobRepo,
+		strategies: strategies,
+		radii:      radii,
+		window:     timeout,
+		now:        time.Now,
+		logger:     logger,
 	}
 }
 
+// SetClock makes the matcher read the current time from now, for running it
+// on a simulated clock.
+func (m *Matcher) SetClock(now func() time.Time) {
+	m.now = now
+}
+
 // Scoring weights.
 const (
 	weightDistance   = 0.40
@@ -51,18 +102,54 @@ const (
 	maxConcurrentJobs = 3
 )
 
-// Expansion radii in meters for PostGIS ST_DWithin.
+// Default expansion radii in meters for PostGIS ST_DWithin.
 var expansionRadii = []float64{25000, 50000, 100000} // 25km, 50km, 100km
 
-// tierMinimum maps survey types to minimum agent tiers.
-var tierMinimum = map[string][]string{
-	"basic_check":       {"basic", "experienced", "senior"},
-	"detailed_survey":   {"experienced", "senior"},
-	"premium_inspection": {"senior"},
+// agentTiers lists agent tiers from lowest to highest.
+var agentTiers = []string{"basic", "experienced", "senior"}
+
+// tierMinimum maps survey types to the agent tiers allowed to survey them.
+var tierMinimum = tiersBySurveyType(billing.Plans())
+
+// tiersBySurveyType allows each plan's survey type to agents of the plan's
+// minimum tier and above.
+func tiersBySurveyType(plans []billing.Plan) map[string][]string {
+	out := make(map[string][]string, len(plans))
+	for _, p := range plans {
+		if i := slices.Index(agentTiers, p.AgentTier); i >= 0 {
+			out[p.SurveyType] = agentTiers[i:]
+		}
+	}
+	return out
+}
+
+// FindCandidatesAtLocation finds candidates near a given lng/lat for a survey type,
+// scored with the strategy configured for the parcel's region.
+func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64, surveyType string, region Region, excludeIDs []uuid.UUID) ([]Candidate, error) {
+	return m.findCandidates(ctx, lng, lat, surveyType, region, excludeIDs, m.radii, maxCandidates)
+}
+
+// FindCandidatesWithin is FindCandidatesAtLocation with the search expanding
+// out to radiusKm instead of the configured radii, for jobs ops re-dispatched
+// with a wider radius.
+func (m *Matcher) FindCandidatesWithin(ctx context.Context, lng, lat float64, surveyType string, region Region, excludeIDs []uuid.UUID, radiusKm float64) ([]Candidate, error) {
+	return m.findCandidates(ctx, lng, lat, surveyType, region, excludeIDs, radiiUpTo(m.radii, radiusKm*1000), maxCandidates)
+}
+
+// radiiUpTo returns the expansion radii below maxM followed by maxM itself.
+func radiiUpTo(radii []float64, maxM float64) []float64 {
+	var out []float64
+	for _, r := range radii {
+		if r < maxM {
+			out = append(out, r)
+		}
+	}
+	return append(out, maxM)
 }
 
-// FindCandidatesAtLocation finds candidates near a given lng/lat for a survey type.
-func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64, surveyType string, excludeIDs []uuid.UUID) ([]Candidate, error) {
+// findCandidates returns up to limit ranked candidates for a location,
+// searching each radius in turn until one yields candidates.
+func (m *Matcher) findCandidates(ctx context.Context, lng, lat float64, surveyType string, region Region, excludeIDs []uuid.UUID, radii []float64, limit int) ([]Candidate, error) {
 	allowedTiers := tierMinimum[surveyType]
 	if allowedTiers == nil {
 		allowedTiers = tierMinimum["basic_check"]
@@ -72,7 +159,9 @@ func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64
 		excludeIDs = []uuid.UUID{}
 	}
 
-	for _, radiusM := range expansionRadii {
+	strategy := m.strategies.For(region.StateCode, region.District)
+
+	for _, radiusM := range radii {
 		agents, err := m.agentQ.FindMatchableAgents(ctx, sqlc.FindMatchableAgentsParams{
 			StMakepoint:   lng,
 			StMakepoint_2: lat,
@@ -89,7 +178,7 @@ func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64
 			continue
 		}
 
-		candidates, err := m.scoreAndFilter(ctx, agents, allowedTiers, radiusM/1000)
+		candidates, err := m.scoreAndFilter(ctx, agents, allowedTiers, radiusM/1000, strategy, limit)
 		if err != nil {
 			return nil, err
 		}
@@ -99,6 +188,7 @@ func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64
 				"count", len(candidates),
 				"radius_m", radiusM,
 				"survey_type", surveyType,
+				"strategy", strategy.Name(),
 			)
 			return candidates, nil
 		}
@@ -109,9 +199,28 @@ func (m *Matcher) FindCandidatesAtLocation(ctx context.Context, lng, lat float64
 }
 
 // scoreAndFilter filters by tier and concurrent load, then scores and ranks agents.
-func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchableAgentsRow, allowedTiers []string, maxDistKm float64) ([]Candidate, error) {
+func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchableAgentsRow, allowedTiers []string, maxDistKm float64, strategy ScoringStrategy, limit int) ([]Candidate, error) {
 	var candidates []Candidate
 
+	// Agents must be on shift for the whole offer window
+	windowStart := m.now()
+	windowEnd := windowStart.Add(m.window)
+
+	// Acceptance history is only fetched when the strategy uses it
+	acceptance := map[uuid.UUID]float64{}
+	if usesFactor(strategy, factorAcceptance) {
+		ids := make([]uuid.UUID, len(agents))
+		for i, a := range agents {
+			ids[i] = a.ID
+		}
+		rates, err := m.jobRepo.GetAcceptanceRates(ctx, ids)
+		if err != nil {
+			m.logger.Error("failed to load acceptance rates", "error", err)
+		} else {
+			acceptance = rates
+		}
+	}
+
 	for _, a := range agents {
 		// Filter by tier
 		agentTier := "basic"
@@ -122,6 +231,14 @@ func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchabl
 			continue
 		}
 
+		// Filter by working hours and preferred radius
+		if !availableDuring(a.AvailableDays, a.AvailableStart, a.AvailableEnd, windowStart, windowEnd) {
+			continue
+		}
+		if !withinPreferredRadius(float64(a.DistanceKm), a.PreferredRadiusKm) {
+			continue
+		}
+
 		// Filter by concurrent load
 		activeCount, err := m.jobRepo.CountActiveJobsByAgent(ctx, a.ID)
 		if err != nil {
@@ -136,18 +253,27 @@ func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchabl
 		distKm := float64(a.DistanceKm)
 		avgRating := numericToFloat64(a.AvgRating)
 		completionRate := numericToFloat64(a.CompletionRate)
-		hoursSinceJob := hoursSince(a.LastJobCompletedAt)
-
-		// Compute scores
-		distScore := (1.0 - distKm/maxDistKm) * weightDistance
-		if distScore < 0 {
-			distScore = 0
+		hoursSinceJob := hoursSinceAt(a.LastJobCompletedAt, windowStart)
+		qaPassRate := numericToFloat64(a.QaPassRate)
+		vehicleType := ""
+		if a.VehicleType != nil {
+			vehicleType = *a.VehicleType
+		}
+		acceptanceRate, ok := acceptance[a.ID]
+		if !ok {
+			acceptanceRate = 1.0 // no history yet: don't penalize new agents
 		}
-		ratingScore := (avgRating / 5.0) * weightRating
-		completionScore := completionRate * weightCompletion
-		freshnessScore := math.Min(hoursSinceJob/48.0, 1.0) * weightFreshness
 
-		composite := distScore + ratingScore + completionScore + freshnessScore
+		composite := strategy.Score(ScoreFactors{
+			DistanceKm:     distKm,
+			MaxDistanceKm:  maxDistKm,
+			VehicleType:    vehicleType,
+			AvgRating:      avgRating,
+			CompletionRate: completionRate,
+			QAPassRate:     qaPassRate,
+			AcceptanceRate: acceptanceRate,
+			HoursSinceJob:  hoursSinceJob,
+		})
 
 		candidates = append(candidates, Candidate{
 			AgentID:        a.ID,
@@ -155,8 +281,14 @@ func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchabl
 			AvgRating:      avgRating,
 			CompletionRate: completionRate,
 			HoursSinceJob:  hoursSinceJob,
+			QAPassRate:     qaPassRate,
+			AcceptanceRate: acceptanceRate,
+			VehicleType:    vehicleType,
+			TravelMinutes:  EstimateTravelMinutes(distKm, vehicleType),
+			OpenSlots:      maxConcurrentJobs - int(activeCount),
 			Tier:           agentTier,
 			CompositeScore: composite,
+			Strategy:       strategy,
 		})
 	}
 
@@ -166,8 +298,8 @@ func (m *Matcher) scoreAndFilter(ctx context.Context, agents []sqlc.FindMatchabl
 	})
 
 	// Return top N
-	if len(candidates) > maxCandidates {
-		candidates = candidates[:maxCandidates]
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
 	}
 
 	return candidates, nil
@@ -197,8 +329,13 @@ func numericToFloat64(n pgtype.Numeric) float64 {
 
 // hoursSince returns hours elapsed since a timestamp (defaults to 48 if invalid).
 func hoursSince(ts pgtype.Timestamptz) float64 {
+	return hoursSinceAt(ts, time.Now())
+}
+
+// hoursSinceAt returns hours elapsed between a timestamp and now (defaults to 48 if invalid).
+func hoursSinceAt(ts pgtype.Timestamptz, now time.Time) float64 {
 	if !ts.Valid {
 		return 48 // Default: treat as "long ago" for freshness score
 	}
-	return time.Since(ts.Time).Hours()
+	return now.Sub(ts.Time).Hours()
 }
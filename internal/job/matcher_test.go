@@ -1,10 +1,16 @@
 package job
 
 import (
+	"context"
+	"log/slog"
 	"math"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
 )
 
 func TestTierAllowed(t *testing.T) {
@@ -113,3 +119,81 @@ func TestTierMinimumMappings(t *testing.T) {
 		t.Error("premium_inspection should allow senior tier")
 	}
 }
+
+func TestRadiiUpTo(t *testing.T) {
+	radii := []float64{25000, 50000, 100000}
+
+	tests := []struct {
+		name string
+		maxM float64
+		want []float64
+	}{
+		{"wider than all", 150000, []float64{25000, 50000, 100000, 150000}},
+		{"between radii", 60000, []float64{25000, 50000, 60000}},
+		{"equal to one", 50000, []float64{25000, 50000}},
+		{"narrower than all", 10000, []float64{10000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := radiiUpTo(radii, tt.maxM)
+			if len(got) != len(tt.want) {
+				t.Fatalf("radiiUpTo(%v) = %v, want %v", tt.maxM, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("radiiUpTo(%v) = %v, want %v", tt.maxM, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+type stubFinder []sqlc.FindMatchableAgentsRow
+
+func (f stubFinder) FindMatchableAgents(context.Context, sqlc.FindMatchableAgentsParams) ([]sqlc.FindMatchableAgentsRow, error) {
+	return f, nil
+}
+
+type stubLoad struct{}
+
+func (stubLoad) CountActiveJobsByAgent(context.Context, uuid.UUID) (int64, error) { return 0, nil }
+
+func (stubLoad) GetAcceptanceRates(context.Context, []uuid.UUID) (map[uuid.UUID]float64, error) {
+	return map[uuid.UUID]float64{}, nil
+}
+
+func TestMatcherOfferWindow(t *testing.T) {
+	strategies, err := NewStrategies(platform.MatcherConfig{})
+	if err != nil {
+		t.Fatalf("NewStrategies: %v", err)
+	}
+	// Shift ends at 18:00 and it is 17:40 on a Wednesday, IST
+	agents := stubFinder{{ID: uuid.New(), AvailableEnd: clock(18, 0), DistanceKm: 5}}
+	now := time.Date(2026, 1, 7, 17, 40, 0, 0, parcelTZ)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    int
+	}{
+		{"offer ends before shift", 15 * time.Minute, 1},
+		{"offer outlasts shift", 45 * time.Minute, 0},
+		{"default 30m outlasts shift", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatcher(agents, stubLoad{}, strategies, nil, tt.timeout, slog.Default())
+			m.now = func() time.Time { return now }
+
+			got, err := m.FindCandidatesAtLocation(context.Background(), 77.6, 12.9, "basic_check", Region{}, nil)
+			if err != nil {
+				t.Fatalf("FindCandidatesAtLocation: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expected %d candidates, got %d", tt.want, len(got))
+			}
+		})
+	}
+}
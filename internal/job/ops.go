@@ -0,0 +1,665 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/agent"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/events"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Ops views of jobs needing attention.
+const (
+	viewUnassigned = "unassigned"
+	viewOverdue    = "overdue"
+	viewStuck      = "stuck"
+)
+
+// Ops actions, recorded in job_admin_actions.
+const (
+	actionAssign     = "assign"
+	actionRedispatch = "redispatch"
+	actionUpdate     = "update"
+	actionCancel     = "cancel"
+)
+
+const (
+	defaultStuckHours     = 24
+	maxRedispatchRadiusKm = 300
+)
+
+var validPriorities = map[string]bool{"low": true, "normal": true, "high": true, "urgent": true}
+
+// OpsHandler handles the ops console endpoints for jobs the dispatcher could
+// not place or that have stalled.
+type OpsHandler struct {
+	cfg       platform.DispatchConfig
+	jobRepo   *Repository
+	agentRepo *agent.Repository
+	rdb       *redis.Client
+	logger    *slog.Logger
+}
+
+// NewOpsHandler creates an ops console handler.
+func NewOpsHandler(cfg platform.DispatchConfig, jobRepo *Repository, agentRepo *agent.Repository, rdb *redis.Client, logger *slog.Logger) *OpsHandler {
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = maxRounds
+	}
+	return &OpsHandler{
+		cfg:       cfg,
+		jobRepo:   jobRepo,
+		agentRepo: agentRepo,
+		rdb:       rdb,
+		logger:    logger,
+	}
+}
+
+// Routes returns the ops console router.
+func (h *OpsHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole("admin", "ops"))
+		r.Get("/", h.ListJobs)
+		r.Get("/{id}/actions", h.ListActions)
+		r.Post("/{id}/assign", h.Assign)
+		r.Post("/{id}/redispatch", h.Redispatch)
+		r.Patch("/{id}", h.Update)
+		r.Post("/{id}/cancel", h.Cancel)
+	})
+
+	return r
+}
+
+// OpsJobResponse is the ops console representation of a job.
+type OpsJobResponse struct {
+	JobResponse
+	StateCode       string    `json:"state_code"`
+	District        string    `json:"district"`
+	CascadeRound    *int32    `json:"cascade_round,omitempty"`
+	TotalOffersSent *int32    `json:"total_offers_sent,omitempty"`
+	Overdue         bool      `json:"overdue"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AdminActionResponse is the API representation of an audited ops action.
+type AdminActionResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	JobID     uuid.UUID       `json:"job_id"`
+	ActorID   string          `json:"actor_id"`
+	ActorName *string         `json:"actor_name,omitempty"`
+	Action    string          `json:"action"`
+	Reason    *string         `json:"reason,omitempty"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AssignRequest is the payload for manually assigning a job.
+type AssignRequest struct {
+	AgentID uuid.UUID `json:"agent_id"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// RedispatchRequest is the payload for re-dispatching a job.
+type RedispatchRequest struct {
+	RadiusKm float64 `json:"radius_km"`
+	Rounds   int     `json:"rounds,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// UpdateJobRequest is the payload for changing a job's deadline or priority.
+type UpdateJobRequest struct {
+	Deadline *time.Time `json:"deadline,omitempty"`
+	Priority *string    `json:"priority,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// CancelRequest is the payload for cancelling a job.
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListJobs handles GET /v1/admin/jobs?view=unassigned|overdue|stuck.
+// Optional filters: state_code, district, survey_type, priority, agent_id,
+// and stuck_hours for the stuck view.
+func (h *OpsHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	params, err := parseOpsFilters(r, time.Now())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	params.Lim = int32(pg.PerPage)
+	params.Off = int32(pg.Offset)
+
+	rows, err := h.jobRepo.ListOpsJobs(r.Context(), params)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	total := 0
+	result := make([]OpsJobResponse, len(rows))
+	for i, j := range rows {
+		total = int(j.TotalCount)
+		result[i] = OpsJobResponse{
+			JobResponse: JobResponseFromSqlc(
+				j.ID, j.ParcelID, j.UserID, j.SurveyType, j.Priority,
+				j.Deadline, j.Status, j.AssignedAgentID, j.AssignedAt, j.CreatedAt,
+			),
+			StateCode:       j.StateCode,
+			District:        j.District,
+			CascadeRound:    j.CascadeRound,
+			TotalOffersSent: j.TotalOffersSent,
+			Overdue:         j.Deadline.Before(time.Now()),
+		}
+		if j.UpdatedAt.Valid {
+			result[i].UpdatedAt = j.UpdatedAt.Time
+		}
+	}
+
+	totalPages := total / pg.PerPage
+	if total%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// parseOpsFilters builds the list query from the request's view and filters.
+func parseOpsFilters(r *http.Request, now time.Time) (sqlc.ListOpsJobsParams, error) {
+	q := r.URL.Query()
+	params := sqlc.ListOpsJobsParams{View: q.Get("view")}
+
+	switch params.View {
+	case "":
+		params.View = viewUnassigned
+	case viewUnassigned, viewOverdue, viewStuck:
+	default:
+		return params, platform.NewBadRequest("view must be unassigned, overdue or stuck")
+	}
+
+	stuckHours := defaultStuckHours
+	if s := q.Get("stuck_hours"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return params, platform.NewBadRequest("stuck_hours must be a positive integer")
+		}
+		stuckHours = n
+	}
+	params.StuckBefore = now.Add(-time.Duration(stuckHours) * time.Hour)
+
+	optional := func(key string) *string {
+		if v := strings.TrimSpace(q.Get(key)); v != "" {
+			return &v
+		}
+		return nil
+	}
+	params.StateCode = optional("state_code")
+	params.District = optional("district")
+	params.SurveyType = optional("survey_type")
+	params.Priority = optional("priority")
+
+	if s := q.Get("agent_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return params, platform.NewBadRequest("invalid agent ID")
+		}
+		params.AgentID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	return params, nil
+}
+
+// ListActions handles GET /v1/admin/jobs/{id}/actions.
+func (h *OpsHandler) ListActions(w http.ResponseWriter, r *http.Request) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	actions, err := h.jobRepo.ListAdminActions(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]AdminActionResponse, len(actions))
+	for i, a := range actions {
+		result[i] = AdminActionResponse{
+			ID:        a.ID,
+			JobID:     a.JobID,
+			ActorID:   a.ActorID,
+			ActorName: a.ActorName,
+			Action:    a.Action,
+			Reason:    a.Reason,
+			Details:   a.Details,
+		}
+		if a.CreatedAt.Valid {
+			result[i].CreatedAt = a.CreatedAt.Time
+		}
+	}
+
+	platform.JSON(w, http.StatusOK, result)
+}
+
+// Assign handles POST /v1/admin/jobs/{id}/assign.
+// Assigns the job to a specific agent, bypassing the cascade.
+func (h *OpsHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	var req AssignRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.AgentID == uuid.Nil {
+		platform.HandleError(w, platform.NewBadRequest("agent_id is required"))
+		return
+	}
+
+	ag, err := h.agentRepo.GetAgentByID(r.Context(), req.AgentID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if ag.Status == nil || *ag.Status != "active" {
+		platform.HandleError(w, platform.NewConflict("agent is not active"))
+		return
+	}
+
+	job, unlock, err := h.lockJob(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	defer unlock()
+
+	previous := assignedAgent(job)
+	var (
+		updated   *sqlc.SurveyJob
+		withdrawn []sqlc.JobOffer
+	)
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		updated, withdrawn, err = h.jobRepo.WithTx(tx).AssignJobManually(r.Context(), jobID, ag.ID, opsActor(userCtx),
+			auditParams(userCtx, jobID, actionAssign, req.Reason, map[string]any{
+				"agent_id":          ag.ID,
+				"previous_agent_id": previous,
+			}))
+		if err != nil {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(jobAssigned(updated)))
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	for _, o := range withdrawn {
+		if o.SentAt.Valid {
+			publishOfferWithdrawn(r.Context(), h.rdb, o, withdrawnAssignedElsewhere)
+		}
+	}
+
+	// FCM push notification placeholder (Phase 1: log only)
+	h.logger.Info("ops: FCM push placeholder",
+		"agent_id", ag.ID,
+		"job_id", jobID,
+	)
+
+	h.logger.Info("ops assigned job",
+		"job_id", jobID,
+		"agent_id", ag.ID,
+		"previous_agent_id", previous,
+		"actor", userCtx.KeycloakID,
+	)
+
+	platform.JSON(w, http.StatusOK, jobResponse(updated))
+}
+
+// Redispatch handles POST /v1/admin/jobs/{id}/redispatch.
+// Puts the job back into the cascade with a wider search radius and a fresh
+// budget of rounds. Agents already offered the job are not offered it again.
+func (h *OpsHandler) Redispatch(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	var req RedispatchRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.RadiusKm <= 0 || req.RadiusKm > maxRedispatchRadiusKm {
+		platform.HandleError(w, platform.NewBadRequest("radius_km must be between 0 and 300"))
+		return
+	}
+	if req.Rounds < 0 {
+		platform.HandleError(w, platform.NewBadRequest("rounds must not be negative"))
+		return
+	}
+	if req.Rounds == 0 {
+		req.Rounds = h.cfg.MaxRounds
+	}
+
+	job, unlock, err := h.lockJob(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	defer unlock()
+
+	previous := assignedAgent(job)
+	radiusKm := float32(req.RadiusKm)
+	var (
+		updated   *sqlc.SurveyJob
+		withdrawn []sqlc.JobOffer
+	)
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		updated, withdrawn, err = h.jobRepo.WithTx(tx).RedispatchJob(r.Context(), sqlc.RedispatchJobParams{
+			ID:          jobID,
+			RadiusKm:    &radiusKm,
+			ExtraRounds: int32(req.Rounds),
+		}, opsActor(userCtx), auditParams(userCtx, jobID, actionRedispatch, req.Reason, map[string]any{
+			"radius_km":         req.RadiusKm,
+			"rounds":            req.Rounds,
+			"previous_agent_id": previous,
+		}))
+		if err != nil {
+			return err
+		}
+		// The dispatcher advances the cascade on this event
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(events.JobRedispatched{
+			JobAction: jobAction(actionRedispatch, updated, userCtx, req.Reason, previous),
+		}))
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	for _, o := range withdrawn {
+		if o.SentAt.Valid {
+			publishOfferWithdrawn(r.Context(), h.rdb, o, withdrawnRedispatched)
+		}
+	}
+
+	h.logger.Info("ops re-dispatched job",
+		"job_id", jobID,
+		"radius_km", req.RadiusKm,
+		"rounds", req.Rounds,
+		"actor", userCtx.KeycloakID,
+	)
+
+	platform.JSON(w, http.StatusOK, jobResponse(updated))
+}
+
+// Update handles PATCH /v1/admin/jobs/{id}.
+// Changes the job's deadline and/or priority.
+func (h *OpsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	var req UpdateJobRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.Deadline == nil && req.Priority == nil {
+		platform.HandleError(w, platform.NewBadRequest("deadline or priority is required"))
+		return
+	}
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		platform.HandleError(w, platform.NewBadRequest("deadline must be in the future"))
+		return
+	}
+	if req.Priority != nil && !validPriorities[*req.Priority] {
+		platform.HandleError(w, platform.NewBadRequest("priority must be low, normal, high or urgent"))
+		return
+	}
+
+	job, unlock, err := h.lockJob(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	defer unlock()
+
+	// Re-check under the lock; the audit records the values it replaces
+	if status := jobStatus(job); status == StatusCompleted || status == StatusCancelled {
+		platform.HandleError(w, platform.NewConflict(fmt.Sprintf("cannot update a %s job", status)))
+		return
+	}
+
+	details := map[string]any{}
+	params := sqlc.UpdateJobScheduleParams{ID: jobID, Priority: req.Priority}
+	if req.Deadline != nil {
+		params.Deadline = pgtype.Timestamptz{Time: *req.Deadline, Valid: true}
+		details["deadline"] = map[string]any{"from": job.Deadline, "to": *req.Deadline}
+	}
+	if req.Priority != nil {
+		details["priority"] = map[string]any{"from": job.Priority, "to": *req.Priority}
+	}
+
+	var updated *sqlc.SurveyJob
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		updated, err = h.jobRepo.WithTx(tx).RescheduleJob(r.Context(), params,
+			auditParams(userCtx, jobID, actionUpdate, req.Reason, details))
+		if err != nil {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(events.JobUpdated{
+			JobAction: jobAction(actionUpdate, updated, userCtx, req.Reason, nil),
+		}))
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("ops updated job",
+		"job_id", jobID,
+		"deadline", req.Deadline,
+		"priority", req.Priority,
+		"actor", userCtx.KeycloakID,
+	)
+
+	platform.JSON(w, http.StatusOK, jobResponse(updated))
+}
+
+// Cancel handles POST /v1/admin/jobs/{id}/cancel. A reason is required.
+func (h *OpsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid job ID"))
+		return
+	}
+
+	var req CancelRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		platform.HandleError(w, platform.NewBadRequest("reason is required"))
+		return
+	}
+
+	job, unlock, err := h.lockJob(r.Context(), jobID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	defer unlock()
+
+	previous := assignedAgent(job)
+	var (
+		updated   *sqlc.SurveyJob
+		withdrawn []sqlc.JobOffer
+	)
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		updated, withdrawn, err = h.jobRepo.WithTx(tx).CancelJob(r.Context(), jobID, opsActor(userCtx),
+			auditParams(userCtx, jobID, actionCancel, req.Reason, map[string]any{
+				"previous_status":   job.Status,
+				"previous_agent_id": previous,
+			}))
+		if err != nil {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(events.JobCancelled{
+			JobAction: jobAction(actionCancel, updated, userCtx, req.Reason, previous),
+		}))
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	for _, o := range withdrawn {
+		if o.SentAt.Valid {
+			publishOfferWithdrawn(r.Context(), h.rdb, o, withdrawnCancelled)
+		}
+	}
+
+	h.logger.Info("ops cancelled job",
+		"job_id", jobID,
+		"reason", req.Reason,
+		"actor", userCtx.KeycloakID,
+	)
+
+	platform.JSON(w, http.StatusOK, jobResponse(updated))
+}
+
+// lockJob loads a job under its dispatch lock so an ops action can't race the
+// dispatcher advancing the same cascade.
+func (h *OpsHandler) lockJob(ctx context.Context, jobID uuid.UUID) (*sqlc.SurveyJob, func(), error) {
+	unlock, locked, err := h.jobRepo.TryLockJob(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !locked {
+		return nil, nil, platform.NewConflict("job is being dispatched, try again")
+	}
+
+	job, err := h.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return job, unlock, nil
+}
+
+// jobAction describes an ops action on a job for the event published with it.
+func jobAction(action string, job *sqlc.SurveyJob, userCtx *auth.UserContext, reason string, previous *uuid.UUID) events.JobAction {
+	return events.JobAction{
+		JobID:           job.ID,
+		ParcelID:        job.ParcelID,
+		Action:          action,
+		ActorID:         userCtx.KeycloakID,
+		Reason:          reason,
+		PreviousAgentID: previous,
+	}
+}
+
+// auditParams builds the job_admin_actions row for an ops action.
+func auditParams(userCtx *auth.UserContext, jobID uuid.UUID, action, reason string, details map[string]any) sqlc.CreateJobAdminActionParams {
+	params := sqlc.CreateJobAdminActionParams{
+		JobID:   jobID,
+		ActorID: userCtx.KeycloakID,
+		Action:  action,
+	}
+	if userCtx.Username != "" {
+		params.ActorName = &userCtx.Username
+	}
+	if reason != "" {
+		params.Reason = &reason
+	}
+	params.Details, _ = json.Marshal(details)
+	return params
+}
+
+// assignedAgent returns the job's assigned agent, if any.
+func assignedAgent(job *sqlc.SurveyJob) *uuid.UUID {
+	if !job.AssignedAgentID.Valid {
+		return nil
+	}
+	id := uuid.UUID(job.AssignedAgentID.Bytes)
+	return &id
+}
+
+// jobResponse maps a sqlc.SurveyJob to a JobResponse.
+func jobResponse(j *sqlc.SurveyJob) JobResponse {
+	return JobResponseFromSqlc(
+		j.ID, j.ParcelID, j.UserID, j.SurveyType, j.Priority,
+		j.Deadline, j.Status, j.AssignedAgentID, j.AssignedAt, j.CreatedAt,
+	)
+}
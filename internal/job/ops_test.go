@@ -0,0 +1,186 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func opsContext() context.Context {
+	return auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-ops-id",
+		Roles:      []string{"ops"},
+	})
+}
+
+func TestOpsRoutes_RequireOpsRole(t *testing.T) {
+	h := &OpsHandler{}
+
+	ctx := auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-kc-id",
+		Roles:      []string{"agent"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.Routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestOpsListJobs_NoAuth(t *testing.T) {
+	h := &OpsHandler{}
+	r := chi.NewRouter()
+	r.Get("/admin/jobs", h.ListJobs)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/jobs", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestOpsListJobs_InvalidView(t *testing.T) {
+	h := &OpsHandler{}
+	r := chi.NewRouter()
+	r.Get("/admin/jobs", h.ListJobs)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/jobs?view=everything", nil).WithContext(opsContext())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestOpsActions_InvalidJobID(t *testing.T) {
+	h := &OpsHandler{}
+
+	tests := []struct {
+		name    string
+		method  string
+		pattern string
+		target  string
+		handler http.HandlerFunc
+	}{
+		{"actions", http.MethodGet, "/admin/jobs/{id}/actions", "/admin/jobs/not-a-uuid/actions", h.ListActions},
+		{"assign", http.MethodPost, "/admin/jobs/{id}/assign", "/admin/jobs/not-a-uuid/assign", h.Assign},
+		{"redispatch", http.MethodPost, "/admin/jobs/{id}/redispatch", "/admin/jobs/not-a-uuid/redispatch", h.Redispatch},
+		{"update", http.MethodPatch, "/admin/jobs/{id}", "/admin/jobs/not-a-uuid", h.Update},
+		{"cancel", http.MethodPost, "/admin/jobs/{id}/cancel", "/admin/jobs/not-a-uuid/cancel", h.Cancel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Method(tt.method, tt.pattern, tt.handler)
+
+			req := httptest.NewRequest(tt.method, tt.target, nil).WithContext(opsContext())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestOpsActions_InvalidBody(t *testing.T) {
+	h := &OpsHandler{}
+	jobPath := "/admin/jobs/00000000-0000-0000-0000-000000000001"
+
+	tests := []struct {
+		name    string
+		method  string
+		pattern string
+		target  string
+		body    string
+		handler http.HandlerFunc
+	}{
+		{"assign without agent", http.MethodPost, "/admin/jobs/{id}/assign", jobPath + "/assign", `{}`, h.Assign},
+		{"redispatch without radius", http.MethodPost, "/admin/jobs/{id}/redispatch", jobPath + "/redispatch", `{}`, h.Redispatch},
+		{"redispatch radius too wide", http.MethodPost, "/admin/jobs/{id}/redispatch", jobPath + "/redispatch", `{"radius_km":1000}`, h.Redispatch},
+		{"update without fields", http.MethodPatch, "/admin/jobs/{id}", jobPath, `{"reason":"x"}`, h.Update},
+		{"update bad priority", http.MethodPatch, "/admin/jobs/{id}", jobPath, `{"priority":"asap"}`, h.Update},
+		{"update past deadline", http.MethodPatch, "/admin/jobs/{id}", jobPath, `{"deadline":"2020-01-01T00:00:00Z"}`, h.Update},
+		{"cancel without reason", http.MethodPost, "/admin/jobs/{id}/cancel", jobPath + "/cancel", `{"reason":"  "}`, h.Cancel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Method(tt.method, tt.pattern, tt.handler)
+
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body)).WithContext(opsContext())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestParseOpsFilters(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req := httptest.NewRequest(http.MethodGet, "/?"+url.Values{
+		"view":        {"stuck"},
+		"stuck_hours": {"6"},
+		"district":    {"Bengaluru Urban"},
+		"priority":    {""},
+		"agent_id":    {"00000000-0000-0000-0000-000000000002"},
+	}.Encode(), nil)
+
+	params, err := parseOpsFilters(req, now)
+	if err != nil {
+		t.Fatalf("parseOpsFilters: %v", err)
+	}
+	if params.View != viewStuck {
+		t.Errorf("view = %q, want stuck", params.View)
+	}
+	if want := now.Add(-6 * time.Hour); !params.StuckBefore.Equal(want) {
+		t.Errorf("stuck_before = %v, want %v", params.StuckBefore, want)
+	}
+	if params.District == nil || *params.District != "Bengaluru Urban" {
+		t.Errorf("district = %v, want Bengaluru Urban", params.District)
+	}
+	if params.Priority != nil || params.StateCode != nil {
+		t.Error("empty filters should be nil")
+	}
+	if !params.AgentID.Valid {
+		t.Error("agent_id should be set")
+	}
+
+	// Defaults
+	params, err = parseOpsFilters(httptest.NewRequest(http.MethodGet, "/", nil), now)
+	if err != nil {
+		t.Fatalf("parseOpsFilters: %v", err)
+	}
+	if params.View != viewUnassigned {
+		t.Errorf("default view = %q, want unassigned", params.View)
+	}
+	if want := now.Add(-defaultStuckHours * time.Hour); !params.StuckBefore.Equal(want) {
+		t.Errorf("default stuck_before = %v, want %v", params.StuckBefore, want)
+	}
+
+	for _, q := range []string{"stuck_hours=0", "stuck_hours=abc", "agent_id=nope"} {
+		if _, err := parseOpsFilters(httptest.NewRequest(http.MethodGet, "/?"+q, nil), now); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
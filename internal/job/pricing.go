@@ -0,0 +1,144 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// demandWindow is how far back district offer history is counted.
+	demandWindow = 7 * 24 * time.Hour
+
+	// minDemandSample is the fewest recent offers that can trigger a surge.
+	minDemandSample = 5
+
+	defaultUrgencyWindow = 48 * time.Hour
+)
+
+// Default base rates in INR when PricingConfig leaves them unset.
+var defaultBaseRates = map[string]float64{
+	"basic_check":        500,
+	"detailed_survey":    900,
+	"premium_inspection": 1500,
+}
+
+// Pricer computes the payout an agent is offered for a job.
+//
+// The base payout is the survey type's rate plus a supplement for parcel area.
+// Bonuses are added for travel beyond the free distance, for a deadline inside
+// the urgency window, for a district where recent offers have gone unaccepted,
+// and for each cascade round after the first, so a job gets more attractive
+// the longer it stays unassigned.
+type Pricer struct {
+	cfg platform.PricingConfig
+}
+
+// NewPricer creates a pricer. Unset base rates and urgency window fall back
+// to defaults.
+func NewPricer(cfg platform.PricingConfig) *Pricer {
+	if len(cfg.BaseRates) == 0 {
+		cfg.BaseRates = defaultBaseRates
+	}
+	if cfg.UrgencyWindow <= 0 {
+		cfg.UrgencyWindow = defaultUrgencyWindow
+	}
+	return &Pricer{cfg: cfg}
+}
+
+// PricingInput is what an offer's payout depends on.
+type PricingInput struct {
+	SurveyType string
+	AreaSqm    float64
+	DistanceKm float64       // candidate's distance from the parcel
+	TimeLeft   time.Duration // until the job's deadline
+	Round      int32
+	Demand     DistrictDemand
+}
+
+// DistrictDemand counts recent offers for jobs in a district and how many
+// went unaccepted.
+type DistrictDemand struct {
+	Sent     int64
+	Declined int64
+	Expired  int64
+}
+
+// unacceptedRate returns the fraction of recent offers declined or left to
+// expire, or 0 when there are too few to tell.
+func (d DistrictDemand) unacceptedRate() float64 {
+	if d.Sent < minDemandSample {
+		return 0
+	}
+	return min(float64(d.Declined+d.Expired)/float64(d.Sent), 1)
+}
+
+// PayoutBreakdown is an offer's payout in whole INR, shown to the agent.
+type PayoutBreakdown struct {
+	Base     float64 `json:"base"`
+	Distance float64 `json:"distance_bonus"`
+	Urgency  float64 `json:"urgency_bonus"`
+	Surge    float64 `json:"surge_bonus"` // local supply/demand
+	Round    float64 `json:"round_bonus"` // cascade rounds without a taker
+	Total    float64 `json:"total"`
+}
+
+// Price computes the payout breakdown for an offer.
+func (p *Pricer) Price(in PricingInput) PayoutBreakdown {
+	hectares := in.AreaSqm / 10000
+	base := p.baseRate(in.SurveyType) + max(hectares-p.cfg.IncludedHectares, 0)*p.cfg.PerHectare
+
+	var urgency float64
+	if in.TimeLeft < p.cfg.UrgencyWindow {
+		closeness := 1 - max(in.TimeLeft, 0).Seconds()/p.cfg.UrgencyWindow.Seconds()
+		urgency = base * p.cfg.UrgencyMaxPct * closeness
+	}
+
+	b := PayoutBreakdown{
+		Base:     math.Round(base),
+		Distance: math.Round(max(in.DistanceKm-p.cfg.FreeKm, 0) * p.cfg.PerKm),
+		Urgency:  math.Round(urgency),
+		Surge:    math.Round(base * p.cfg.SurgeMaxPct * in.Demand.unacceptedRate()),
+		Round:    math.Round(base * p.cfg.RoundBonusPct * float64(max(in.Round-1, 0))),
+	}
+	b.Total = b.Base + b.Distance + b.Urgency + b.Surge + b.Round
+	return b
+}
+
+// BasePayout returns the survey type's base rate. New jobs store it until the
+// dispatcher prices an offer for a particular agent and parcel.
+func (p *Pricer) BasePayout(surveyType string) pgtype.Numeric {
+	return inr(p.baseRate(surveyType))
+}
+
+// baseRate returns the base rate for a survey type, defaulting to basic_check's.
+func (p *Pricer) baseRate(surveyType string) float64 {
+	if rate, ok := p.cfg.BaseRates[surveyType]; ok {
+		return rate
+	}
+	return p.cfg.BaseRates["basic_check"]
+}
+
+// parseBreakdown decodes an offer's stored payout breakdown. Offers sent
+// before pricing existed have none.
+func parseBreakdown(raw []byte) (*PayoutBreakdown, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var b PayoutBreakdown
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, fmt.Errorf("parsing payout breakdown: %w", err)
+	}
+	return &b, nil
+}
+
+// inr converts an amount to a NUMERIC(8,2) value.
+func inr(amount float64) pgtype.Numeric {
+	n := pgtype.Numeric{}
+	n.Scan(fmt.Sprintf("%.2f", amount))
+	return n
+}
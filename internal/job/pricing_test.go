@@ -0,0 +1,111 @@
+package job
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+func testPricer() *Pricer {
+	return NewPricer(platform.PricingConfig{
+		IncludedHectares: 2,
+		PerHectare:       40,
+		FreeKm:           10,
+		PerKm:            8,
+		UrgencyWindow:    48 * time.Hour,
+		UrgencyMaxPct:    0.3,
+		SurgeMaxPct:      0.25,
+		RoundBonusPct:    0.1,
+	})
+}
+
+func TestPrice(t *testing.T) {
+	p := testPricer()
+
+	tests := []struct {
+		name string
+		in   PricingInput
+		want PayoutBreakdown
+	}{
+		{
+			name: "base only",
+			in:   PricingInput{SurveyType: "basic_check", AreaSqm: 10000, DistanceKm: 5, TimeLeft: 72 * time.Hour, Round: 1},
+			want: PayoutBreakdown{Base: 500, Total: 500},
+		},
+		{
+			name: "unknown survey type uses basic rate",
+			in:   PricingInput{SurveyType: "drone_flyover", TimeLeft: 72 * time.Hour, Round: 1},
+			want: PayoutBreakdown{Base: 500, Total: 500},
+		},
+		{
+			name: "large parcel and long trip",
+			in:   PricingInput{SurveyType: "detailed_survey", AreaSqm: 70000, DistanceKm: 35, TimeLeft: 72 * time.Hour, Round: 1},
+			want: PayoutBreakdown{Base: 1100, Distance: 200, Total: 1300},
+		},
+		{
+			name: "halfway into urgency window",
+			in:   PricingInput{SurveyType: "basic_check", TimeLeft: 24 * time.Hour, Round: 1},
+			want: PayoutBreakdown{Base: 500, Urgency: 75, Total: 575},
+		},
+		{
+			name: "overdue gets full urgency",
+			in:   PricingInput{SurveyType: "basic_check", TimeLeft: -time.Hour, Round: 1},
+			want: PayoutBreakdown{Base: 500, Urgency: 150, Total: 650},
+		},
+		{
+			name: "district surge",
+			in: PricingInput{SurveyType: "basic_check", TimeLeft: 72 * time.Hour, Round: 1,
+				Demand: DistrictDemand{Sent: 10, Declined: 6, Expired: 2}},
+			want: PayoutBreakdown{Base: 500, Surge: 100, Total: 600},
+		},
+		{
+			name: "too few offers for surge",
+			in: PricingInput{SurveyType: "basic_check", TimeLeft: 72 * time.Hour, Round: 1,
+				Demand: DistrictDemand{Sent: 3, Declined: 3}},
+			want: PayoutBreakdown{Base: 500, Total: 500},
+		},
+		{
+			name: "third round",
+			in:   PricingInput{SurveyType: "premium_inspection", TimeLeft: 72 * time.Hour, Round: 3},
+			want: PayoutBreakdown{Base: 1500, Round: 300, Total: 1800},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Price(tt.in); got != tt.want {
+				t.Errorf("Price() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceRisesEachRound(t *testing.T) {
+	p := testPricer()
+	in := PricingInput{SurveyType: "basic_check", DistanceKm: 30, TimeLeft: 30 * time.Hour}
+
+	prev := 0.0
+	for round := int32(1); round <= 5; round++ {
+		in.Round = round
+		total := p.Price(in).Total
+		if total <= prev {
+			t.Errorf("round %d total = %.0f, want more than round %d's %.0f", round, total, round-1, prev)
+		}
+		prev = total
+	}
+}
+
+func TestParseBreakdown(t *testing.T) {
+	if b, err := parseBreakdown(nil); b != nil || err != nil {
+		t.Errorf("parseBreakdown(nil) = %v, %v; want nil, nil", b, err)
+	}
+
+	want := PayoutBreakdown{Base: 500, Distance: 40, Total: 540}
+	raw, _ := json.Marshal(want)
+	b, err := parseBreakdown(raw)
+	if err != nil || b == nil || *b != want {
+		t.Errorf("parseBreakdown() = %v, %v; want %+v", b, err, want)
+	}
+}
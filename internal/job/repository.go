@@ -2,18 +2,24 @@ package job
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/billing"
+	"github.com/terrascore/api/internal/ledger"
 	"github.com/terrascore/api/internal/platform"
 )
 
 // Repository handles survey job and offer persistence.
 type Repository struct {
+	platform.TxScope
+
 	q  *sqlc.Queries
 	db *pgxpool.Pool
 }
@@ -21,8 +27,19 @@ type Repository struct {
 // NewRepository creates a job repository.
 func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{
-		q:  sqlc.New(db),
-		db: db,
+		TxScope: platform.NewTxScope(db),
+		q:       sqlc.New(db),
+		db:      db,
+	}
+}
+
+// WithTx returns a repository whose queries run in tx. Transactions it
+// begins nest in tx with a savepoint.
+func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+	return &Repository{
+		TxScope: r.TxScope.Bind(tx),
+		q:       r.q.WithTx(tx),
+		db:      r.db,
 	}
 }
 
@@ -35,6 +52,24 @@ func (r *Repository) CreateJob(ctx context.Context, params sqlc.CreateSurveyJobP
 	return &job, nil
 }
 
+// CreateScheduledJob creates a survey job and, if it belongs to a
+// subscription, counts it against the subscription's visits for the period.
+func (r *Repository) CreateScheduledJob(ctx context.Context, params sqlc.CreateSurveyJobParams) (*sqlc.SurveyJob, error) {
+	job, _, err := r.inTx(ctx, "scheduled job", func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+		job, err := q.CreateSurveyJob(ctx, params)
+		if err != nil {
+			return job, nil, fmt.Errorf("creating survey job: %w", err)
+		}
+		if params.SubscriptionID.Valid {
+			if err := q.IncrementSubscriptionVisits(ctx, uuid.UUID(params.SubscriptionID.Bytes)); err != nil {
+				return job, nil, fmt.Errorf("counting subscription visit: %w", err)
+			}
+		}
+		return job, nil, nil
+	})
+	return job, err
+}
+
 // GetJobByID returns a survey job by ID.
 func (r *Repository) GetJobByID(ctx context.Context, id uuid.UUID) (*sqlc.SurveyJob, error) {
 	job, err := r.q.GetSurveyJobByID(ctx, id)
@@ -47,19 +82,132 @@ func (r *Repository) GetJobByID(ctx context.Context, id uuid.UUID) (*sqlc.Survey
 	return &job, nil
 }
 
-// UpdateJobStatus updates the status of a survey job.
-func (r *Repository) UpdateJobStatus(ctx context.Context, id uuid.UUID, status string) (*sqlc.SurveyJob, error) {
-	job, err := r.q.UpdateJobStatus(ctx, sqlc.UpdateJobStatusParams{
-		ID:     id,
-		Status: &status,
+// Transition moves a job to a new status if the state machine allows the
+// actor to, applying the status's side effects and recording the change in
+// job_status_history. Offers withdrawn by the transition are returned so the
+// caller can notify those agents.
+func (r *Repository) Transition(ctx context.Context, id uuid.UUID, to string, actor Actor, reason string) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.inTx(ctx, "transition", func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+		return transitionTx(ctx, q, id, to, actor, reason, nil)
 	})
+}
+
+// transitionTx performs a transition within the caller's transaction. The job
+// row is locked while the transition is checked. apply performs the status
+// update along with any columns specific to it; when nil the default update
+// for the target status is used.
+func transitionTx(ctx context.Context, q *sqlc.Queries, id uuid.UUID, to string, actor Actor, reason string, apply func() (sqlc.SurveyJob, error)) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	current, err := q.GetSurveyJobForUpdate(ctx, id)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, platform.NewNotFound("job not found")
+			return sqlc.SurveyJob{}, nil, platform.NewNotFound("job not found")
 		}
-		return nil, fmt.Errorf("updating job status: %w", err)
+		return sqlc.SurveyJob{}, nil, fmt.Errorf("locking job: %w", err)
 	}
-	return &job, nil
+
+	from := jobStatus(&current)
+	if err := checkTransition(from, to, actor.Role); err != nil {
+		return sqlc.SurveyJob{}, nil, err
+	}
+
+	if apply == nil {
+		apply = func() (sqlc.SurveyJob, error) {
+			switch to {
+			case StatusInProgress:
+				return q.StartSurvey(ctx, id)
+			case StatusSubmitted:
+				return q.SubmitJobSurvey(ctx, id)
+			case StatusCompleted:
+				return q.CompleteJob(ctx, id)
+			default:
+				return q.UpdateJobStatus(ctx, sqlc.UpdateJobStatusParams{ID: id, Status: &to})
+			}
+		}
+	}
+	job, err := apply()
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return sqlc.SurveyJob{}, nil, platform.NewConflict(fmt.Sprintf("job cannot move from %s to %s", from, to))
+		}
+		return sqlc.SurveyJob{}, nil, fmt.Errorf("updating job status: %w", err)
+	}
+
+	if to == StatusCompleted && job.AssignedAgentID.Valid {
+		earning := ledger.JobEarning(uuid.UUID(job.AssignedAgentID.Bytes), id, numericToFloat64(job.TotalPayout))
+		if _, err := ledger.Post(ctx, q, earning); err != nil {
+			return sqlc.SurveyJob{}, nil, fmt.Errorf("crediting agent wallet: %w", err)
+		}
+	}
+
+	var withdrawn []sqlc.JobOffer
+	if withdrawsOffers(to) {
+		withdrawn, err = q.WithdrawOpenOffers(ctx, id)
+		if err != nil {
+			return sqlc.SurveyJob{}, nil, fmt.Errorf("withdrawing open offers: %w", err)
+		}
+	}
+
+	params := sqlc.CreateJobStatusHistoryParams{
+		JobID:      id,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorRole:  actor.Role,
+	}
+	if actor.ID != "" {
+		params.ActorID = &actor.ID
+	}
+	if reason != "" {
+		params.Reason = &reason
+	}
+	if err := q.CreateJobStatusHistory(ctx, params); err != nil {
+		return sqlc.SurveyJob{}, nil, fmt.Errorf("recording status history: %w", err)
+	}
+
+	return job, withdrawn, nil
+}
+
+// inTx runs fn in a transaction and commits if it succeeds.
+func (r *Repository) inTx(ctx context.Context, op string, fn func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error)) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("beginning %s transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	job, offers, err := fn(r.q.WithTx(tx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("committing %s: %w", op, err)
+	}
+	return &job, offers, nil
+}
+
+// ListStatusHistory returns a job's status transitions, oldest first.
+func (r *Repository) ListStatusHistory(ctx context.Context, jobID uuid.UUID) ([]sqlc.JobStatusHistory, error) {
+	history, err := r.q.ListJobStatusHistory(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("listing status history: %w", err)
+	}
+	return history, nil
+}
+
+// FinalizeQA moves a submitted job to completed or failed_qa in tx once QA
+// has scored it. Flagged surveys stay submitted for human review.
+func (r *Repository) FinalizeQA(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, qaStatus string) error {
+	var to string
+	switch qaStatus {
+	case "passed":
+		to = StatusCompleted
+	case "failed":
+		to = StatusFailedQA
+	default:
+		return nil
+	}
+	_, _, err := r.WithTx(tx).Transition(ctx, jobID, to, systemActor("qa"), "qa "+qaStatus)
+	return err
 }
 
 // AssignAgent assigns an agent to a survey job.
@@ -80,6 +228,25 @@ func (r *Repository) ListPendingJobs(ctx context.Context, limit int32) ([]sqlc.S
 	return jobs, nil
 }
 
+// ListUnplannedJobs returns pending jobs that have no cascade round yet,
+// soonest deadline first.
+func (r *Repository) ListUnplannedJobs(ctx context.Context, limit int32) ([]sqlc.SurveyJob, error) {
+	jobs, err := r.q.ListUnplannedJobs(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing unplanned jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// IsJobUnplanned reports whether a job is still waiting for its first round.
+func (r *Repository) IsJobUnplanned(ctx context.Context, id uuid.UUID) (bool, error) {
+	ok, err := r.q.IsJobUnplanned(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("checking job plan: %w", err)
+	}
+	return ok, nil
+}
+
 // ListJobsByAgent returns paginated jobs for an agent.
 func (r *Repository) ListJobsByAgent(ctx context.Context, agentID uuid.UUID, limit, offset int32) ([]sqlc.SurveyJob, error) {
 	jobs, err := r.q.ListJobsByAgent(ctx, sqlc.ListJobsByAgentParams{
@@ -102,17 +269,20 @@ func (r *Repository) CreateOffer(ctx context.Context, params sqlc.CreateJobOffer
 	return &offer, nil
 }
 
-// UpdateOfferStatus updates an offer's status and optional decline reason.
-func (r *Repository) UpdateOfferStatus(ctx context.Context, id uuid.UUID, status string, reason *string) error {
-	err := r.q.UpdateJobOfferStatus(ctx, sqlc.UpdateJobOfferStatusParams{
+// DeclineOffer records an agent declining a sent offer. An offer that has
+// since been accepted, expired or withdrawn is a conflict.
+func (r *Repository) DeclineOffer(ctx context.Context, id uuid.UUID, reason *string) (*sqlc.JobOffer, error) {
+	offer, err := r.q.DeclineJobOffer(ctx, sqlc.DeclineJobOfferParams{
 		ID:            id,
-		Status:        &status,
 		DeclineReason: reason,
 	})
 	if err != nil {
-		return fmt.Errorf("updating offer status: %w", err)
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewConflict("offer is no longer open")
+		}
+		return nil, fmt.Errorf("declining offer: %w", err)
 	}
-	return nil
+	return &offer, nil
 }
 
 // GetOfferByJobAndAgent returns a pending offer for a specific job+agent pair.
@@ -179,13 +349,18 @@ func (r *Repository) DistanceToParcelCentroid(ctx context.Context, parcelID uuid
 	return distM, nil
 }
 
-// RecordAgentArrival records the agent's arrival at the parcel.
-func (r *Repository) RecordAgentArrival(ctx context.Context, params sqlc.RecordAgentArrivalParams) error {
-	err := r.q.RecordAgentArrival(ctx, params)
+// RecordAgentArrival records the agent's arrival at the parcel and moves the
+// job to agent_on_site.
+func (r *Repository) RecordAgentArrival(ctx context.Context, params sqlc.RecordAgentArrivalParams, actor Actor) (*sqlc.SurveyJob, error) {
+	job, _, err := r.inTx(ctx, "arrival", func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+		return transitionTx(ctx, q, params.ID, StatusOnSite, actor, "arrived at parcel", func() (sqlc.SurveyJob, error) {
+			return q.RecordAgentArrival(ctx, params)
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("recording agent arrival: %w", err)
+		return nil, err
 	}
-	return nil
+	return job, nil
 }
 
 // ExpireOffers bulk-expires all offers past their deadline.
@@ -205,3 +380,405 @@ func (r *Repository) CountActiveJobsByAgent(ctx context.Context, agentID uuid.UU
 	}
 	return count, nil
 }
+
+// CreateQueuedOffer inserts an offer that is ranked for a cascade round but not yet sent.
+func (r *Repository) CreateQueuedOffer(ctx context.Context, params sqlc.CreateQueuedJobOfferParams) (*sqlc.JobOffer, error) {
+	offer, err := r.q.CreateQueuedJobOffer(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("creating queued job offer: %w", err)
+	}
+	return &offer, nil
+}
+
+// SendOffer promotes a queued offer to sent with the given expiry.
+func (r *Repository) SendOffer(ctx context.Context, id uuid.UUID, expiresAt time.Time, payout PayoutBreakdown) (*sqlc.JobOffer, error) {
+	breakdown, err := json.Marshal(payout)
+	if err != nil {
+		return nil, fmt.Errorf("encoding payout breakdown: %w", err)
+	}
+	offer, err := r.q.SendJobOffer(ctx, sqlc.SendJobOfferParams{
+		ID:              id,
+		ExpiresAt:       expiresAt,
+		PayoutBreakdown: breakdown,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewConflict("offer is no longer queued")
+		}
+		return nil, fmt.Errorf("sending job offer: %w", err)
+	}
+	return &offer, nil
+}
+
+// GetOfferByID returns an offer by ID regardless of status.
+func (r *Repository) GetOfferByID(ctx context.Context, id uuid.UUID) (*sqlc.JobOffer, error) {
+	offer, err := r.q.GetJobOfferByID(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("offer not found")
+		}
+		return nil, fmt.Errorf("getting offer by ID: %w", err)
+	}
+	return &offer, nil
+}
+
+// WithdrawOpenOffers withdraws all queued and sent offers for a job and returns them.
+func (r *Repository) WithdrawOpenOffers(ctx context.Context, jobID uuid.UUID) ([]sqlc.JobOffer, error) {
+	offers, err := r.q.WithdrawOpenOffers(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("withdrawing open offers: %w", err)
+	}
+	return offers, nil
+}
+
+// ClaimOffer accepts an offer and assigns its job to the agent in one
+// transaction. Both updates are conditional, so when several agents accept
+// broadcast offers for the same job only the first succeeds; the rest get a
+// conflict. The job takes the payout the agent was offered. The job's other
+// open offers are withdrawn and returned so the caller can notify those agents.
+func (r *Repository) ClaimOffer(ctx context.Context, offerID uuid.UUID) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.inTx(ctx, "claim", func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+		offer, err := q.AcceptJobOffer(ctx, offerID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return sqlc.SurveyJob{}, nil, platform.NewConflict("offer is no longer open")
+			}
+			return sqlc.SurveyJob{}, nil, fmt.Errorf("accepting offer: %w", err)
+		}
+
+		params := sqlc.ClaimJobParams{
+			ID:              offer.JobID,
+			AssignedAgentID: pgtype.UUID{Bytes: offer.AgentID, Valid: true},
+		}
+		payout, err := parseBreakdown(offer.PayoutBreakdown)
+		if err != nil {
+			return sqlc.SurveyJob{}, nil, err
+		}
+		if payout != nil {
+			params.BasePayout = inr(payout.Base)
+			params.DistanceBonus = inr(payout.Distance)
+			params.UrgencyBonus = inr(payout.Urgency)
+			params.SurgeBonus = inr(payout.Surge + payout.Round)
+			params.TotalPayout = inr(payout.Total)
+		}
+
+		job, withdrawn, err := transitionTx(ctx, q, offer.JobID, StatusAssigned, agentActor(offer.AgentID), "offer accepted", func() (sqlc.SurveyJob, error) {
+			return q.ClaimJob(ctx, params)
+		})
+		if err != nil {
+			if appErr, ok := platform.AsAppError(err); ok && appErr.Code == platform.CodeConflict {
+				return sqlc.SurveyJob{}, nil, platform.NewConflict("job already assigned")
+			}
+			return sqlc.SurveyJob{}, nil, err
+		}
+		return job, withdrawn, nil
+	})
+}
+
+// ParcelDemand returns a parcel's area and the offers sent since the given
+// time for jobs in its district, for pricing.
+func (r *Repository) ParcelDemand(ctx context.Context, parcelID uuid.UUID, since time.Time) (float64, DistrictDemand, error) {
+	parcel, err := r.q.GetParcelByID(ctx, parcelID)
+	if err != nil {
+		return 0, DistrictDemand{}, fmt.Errorf("getting parcel: %w", err)
+	}
+	var areaSqm float64
+	if parcel.AreaSqm != nil {
+		areaSqm = float64(*parcel.AreaSqm)
+	}
+
+	stats, err := r.q.GetDistrictOfferStats(ctx, sqlc.GetDistrictOfferStatsParams{
+		StateCode: parcel.StateCode,
+		District:  parcel.District,
+		SentAt:    pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return areaSqm, DistrictDemand{}, fmt.Errorf("getting district offer stats: %w", err)
+	}
+	return areaSqm, DistrictDemand{Sent: stats.Sent, Declined: stats.Declined, Expired: stats.Expired}, nil
+}
+
+// UpdateJobCascade records a job's current cascade round and offers sent so far.
+func (r *Repository) UpdateJobCascade(ctx context.Context, id uuid.UUID, round, totalOffersSent int32) error {
+	err := r.q.UpdateJobCascade(ctx, sqlc.UpdateJobCascadeParams{
+		ID:              id,
+		CascadeRound:    &round,
+		TotalOffersSent: &totalOffersSent,
+	})
+	if err != nil {
+		return fmt.Errorf("updating job cascade: %w", err)
+	}
+	return nil
+}
+
+// TryLockJob takes a session-level advisory lock on a job so only one worker
+// advances its cascade at a time. The returned unlock func must be called
+// when locked is true.
+func (r *Repository) TryLockJob(ctx context.Context, id uuid.UUID) (unlock func(), locked bool, err error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("acquiring connection: %w", err)
+	}
+
+	key := "job:" + id.String()
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("locking job: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", key)
+		conn.Release()
+	}
+	return unlock, true, nil
+}
+
+// GetAcceptanceRates returns each agent's share of recently answered offers
+// that they accepted. Agents with no recent history are omitted.
+func (r *Repository) GetAcceptanceRates(ctx context.Context, agentIDs []uuid.UUID) (map[uuid.UUID]float64, error) {
+	rows, err := r.q.GetAgentAcceptanceStats(ctx, agentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("getting acceptance stats: %w", err)
+	}
+	rates := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		if row.Responded > 0 {
+			rates[row.AgentID] = float64(row.Accepted) / float64(row.Responded)
+		}
+	}
+	return rates, nil
+}
+
+// CountOpenOffers returns how many offers each agent currently has outstanding.
+func (r *Repository) CountOpenOffers(ctx context.Context, agentIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	rows, err := r.q.CountOpenOffersByAgents(ctx, agentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("counting open offers: %w", err)
+	}
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.AgentID] = int(row.OpenOffers)
+	}
+	return counts, nil
+}
+
+// ListOpsJobs returns jobs needing ops attention for one view (unassigned,
+// overdue or stuck), with the total match count on each row.
+func (r *Repository) ListOpsJobs(ctx context.Context, params sqlc.ListOpsJobsParams) ([]sqlc.ListOpsJobsRow, error) {
+	jobs, err := r.q.ListOpsJobs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing ops jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// AssignJobManually assigns a job to an agent chosen by ops, withdrawing any
+// open offers. The action is audited in the same transaction.
+func (r *Repository) AssignJobManually(ctx context.Context, jobID, agentID uuid.UUID, actor Actor, audit sqlc.CreateJobAdminActionParams) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.adminAction(ctx, audit, StatusAssigned, actor, func(q *sqlc.Queries) (sqlc.SurveyJob, error) {
+		return q.AssignJobManually(ctx, sqlc.AssignJobManuallyParams{
+			ID:              jobID,
+			AssignedAgentID: pgtype.UUID{Bytes: agentID, Valid: true},
+		})
+	})
+}
+
+// RedispatchJob puts a job back into dispatch with a wider radius and a fresh
+// round budget, withdrawing any open offers. The action is audited in the same
+// transaction.
+func (r *Repository) RedispatchJob(ctx context.Context, params sqlc.RedispatchJobParams, actor Actor, audit sqlc.CreateJobAdminActionParams) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.adminAction(ctx, audit, StatusPendingAssignment, actor, func(q *sqlc.Queries) (sqlc.SurveyJob, error) {
+		return q.RedispatchJob(ctx, params)
+	})
+}
+
+// RescheduleJob changes a job's deadline and/or priority. The action is
+// audited in the same transaction.
+func (r *Repository) RescheduleJob(ctx context.Context, params sqlc.UpdateJobScheduleParams, audit sqlc.CreateJobAdminActionParams) (*sqlc.SurveyJob, error) {
+	job, _, err := r.adminAction(ctx, audit, "", Actor{}, func(q *sqlc.Queries) (sqlc.SurveyJob, error) {
+		return q.UpdateJobSchedule(ctx, params)
+	})
+	return job, err
+}
+
+// CancelJob cancels a job and withdraws any open offers. The action is
+// audited in the same transaction.
+func (r *Repository) CancelJob(ctx context.Context, jobID uuid.UUID, actor Actor, audit sqlc.CreateJobAdminActionParams) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.adminAction(ctx, audit, StatusCancelled, actor, func(q *sqlc.Queries) (sqlc.SurveyJob, error) {
+		return q.CancelJob(ctx, jobID)
+	})
+}
+
+// adminAction runs an ops update and records it in job_admin_actions in one
+// transaction. When to is set the update is a status transition checked by
+// the state machine, which also withdraws open offers where needed.
+func (r *Repository) adminAction(ctx context.Context, audit sqlc.CreateJobAdminActionParams, to string, actor Actor, update func(q *sqlc.Queries) (sqlc.SurveyJob, error)) (*sqlc.SurveyJob, []sqlc.JobOffer, error) {
+	return r.inTx(ctx, audit.Action, func(q *sqlc.Queries) (sqlc.SurveyJob, []sqlc.JobOffer, error) {
+		var (
+			job       sqlc.SurveyJob
+			withdrawn []sqlc.JobOffer
+			err       error
+		)
+		if to != "" {
+			job, withdrawn, err = transitionTx(ctx, q, audit.JobID, to, actor, derefString(audit.Reason), func() (sqlc.SurveyJob, error) {
+				return update(q)
+			})
+		} else {
+			job, err = update(q)
+			if err == pgx.ErrNoRows {
+				err = platform.NewConflict(fmt.Sprintf("cannot %s job in its current status", audit.Action))
+			}
+		}
+		if err != nil {
+			return sqlc.SurveyJob{}, nil, err
+		}
+
+		if audit.Details == nil {
+			audit.Details = []byte("{}")
+		}
+		if _, err := q.CreateJobAdminAction(ctx, audit); err != nil {
+			return sqlc.SurveyJob{}, nil, fmt.Errorf("recording %s action: %w", audit.Action, err)
+		}
+		return job, withdrawn, nil
+	})
+}
+
+// derefString returns the string s points to, or "" if s is nil.
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ListAdminActions returns the ops actions taken on a job, newest first.
+func (r *Repository) ListAdminActions(ctx context.Context, jobID uuid.UUID) ([]sqlc.JobAdminAction, error) {
+	actions, err := r.q.ListJobAdminActions(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("listing admin actions: %w", err)
+	}
+	return actions, nil
+}
+
+// OnDemandVisit is the outcome of a landowner's visit request.
+type OnDemandVisit struct {
+	Job      *sqlc.SurveyJob
+	Charge   *sqlc.Transaction // nil when the subscription's quota covered it
+	Replayed bool              // an earlier request with the same idempotency key
+}
+
+// RequestVisit creates a high-priority on-demand job for a parcel owned by
+// userID. The visit uses one of the subscription's on-demand visits if any
+// remain, otherwise a pending charge is recorded. A request repeating an
+// earlier idempotency key returns the earlier job. The parcel row is locked
+// so concurrent requests for the same parcel are serialized.
+func (r *Repository) RequestVisit(ctx context.Context, pricer *Pricer, parcelID, userID uuid.UUID, idempotencyKey string, deadline time.Time) (*OnDemandVisit, error) {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning visit request transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	parcel, err := q.GetParcelForUpdate(ctx, parcelID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("parcel not found")
+		}
+		return nil, fmt.Errorf("locking parcel: %w", err)
+	}
+	if parcel.UserID != userID {
+		return nil, platform.NewForbidden("you do not own this parcel")
+	}
+
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+		job, err := q.GetJobByIdempotencyKey(ctx, sqlc.GetJobByIdempotencyKeyParams{ParcelID: parcelID, IdempotencyKey: key})
+		if err == nil {
+			visit := &OnDemandVisit{Job: &job, Replayed: true}
+			charge, err := q.GetTransactionByJob(ctx, pgtype.UUID{Bytes: job.ID, Valid: true})
+			if err == nil {
+				visit.Charge = &charge
+			} else if err != pgx.ErrNoRows {
+				return nil, fmt.Errorf("getting visit charge: %w", err)
+			}
+			return visit, nil
+		}
+		if err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("getting job by idempotency key: %w", err)
+		}
+	}
+
+	if parcel.Status == nil || *parcel.Status != "active" {
+		return nil, platform.NewConflict("parcel is not active")
+	}
+	open, err := q.HasOpenJobForParcel(ctx, parcelID)
+	if err != nil {
+		return nil, fmt.Errorf("checking open jobs: %w", err)
+	}
+	if open {
+		return nil, platform.NewConflict("parcel already has an open survey job")
+	}
+
+	surveyType, price := baselineSurveyType, billing.DefaultOnDemandPrice
+	var subscriptionID pgtype.UUID
+	covered := false
+
+	sub, err := q.GetActiveSubscriptionForUpdate(ctx, parcelID)
+	switch {
+	case err == nil:
+		subscriptionID = pgtype.UUID{Bytes: sub.ID, Valid: true}
+		if plan, ok := billing.GetPlan(sub.Plan); ok {
+			surveyType, price = plan.SurveyType, plan.OnDemandPrice
+		}
+		if _, err := q.ConsumeOnDemandVisit(ctx, sub.ID); err == nil {
+			covered = true
+		} else if err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("consuming on-demand visit: %w", err)
+		}
+	case err != pgx.ErrNoRows:
+		return nil, fmt.Errorf("getting active subscription: %w", err)
+	}
+
+	priority := "high"
+
+	job, err := q.CreateOnDemandJob(ctx, sqlc.CreateOnDemandJobParams{
+		ParcelID:       parcelID,
+		SubscriptionID: subscriptionID,
+		UserID:         userID,
+		SurveyType:     surveyType,
+		Priority:       &priority,
+		Deadline:       deadline,
+		BasePayout:     pricer.BasePayout(surveyType),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating on-demand job: %w", err)
+	}
+	visit := &OnDemandVisit{Job: &job}
+
+	if !covered {
+		amount := pgtype.Numeric{}
+		amount.Scan(price)
+		charge, err := q.CreateVisitCharge(ctx, sqlc.CreateVisitChargeParams{
+			UserID:         userID,
+			SubscriptionID: subscriptionID,
+			JobID:          pgtype.UUID{Bytes: job.ID, Valid: true},
+			Amount:         amount,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating visit charge: %w", err)
+		}
+		visit.Charge = &charge
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing visit request: %w", err)
+	}
+	return visit, nil
+}
@@ -5,27 +5,39 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/billing"
 	"github.com/terrascore/api/internal/land"
 	"github.com/terrascore/api/internal/platform"
 )
 
+const (
+	schedulerBatchSize = 100
+
+	// baselineSurveyType and baselineDeadline apply to a parcel's first
+	// survey, which is also the only one unsubscribed parcels get.
+	baselineSurveyType = "basic_check"
+	baselineDeadline   = 72 * time.Hour
+)
+
 // Scheduler creates survey jobs from parcels needing surveys on a periodic ticker.
 type Scheduler struct {
 	jobRepo  *Repository
 	landRepo *land.Repository
-	eventBus *platform.EventBus
+	pricer   *Pricer
 	logger   *slog.Logger
 	interval time.Duration
 }
 
 // NewScheduler creates a job scheduler that runs every hour.
-func NewScheduler(jobRepo *Repository, landRepo *land.Repository, eventBus *platform.EventBus, logger *slog.Logger) *Scheduler {
+func NewScheduler(jobRepo *Repository, landRepo *land.Repository, pricer *Pricer, logger *slog.Logger) *Scheduler {
 	return &Scheduler{
 		jobRepo:  jobRepo,
 		landRepo: landRepo,
-		eventBus: eventBus,
+		pricer:   pricer,
 		logger:   logger,
 		interval: 1 * time.Hour,
 	}
@@ -55,64 +67,134 @@ func (s *Scheduler) Start(ctx context.Context) {
 func (s *Scheduler) tick(ctx context.Context) {
 	s.logger.Debug("scheduler tick: looking for parcels needing survey")
 
-	parcels, err := s.landRepo.FindParcelsNeedingSurvey(ctx, 100)
-	if err != nil {
-		s.logger.Error("scheduler: failed to find parcels", "error", err)
-		return
+	now := time.Now()
+	found, created := 0, 0
+
+	// Page through every parcel without an open job; most are not due yet.
+	after := uuid.Nil
+	for {
+		parcels, err := s.landRepo.FindParcelsNeedingSurvey(ctx, after, schedulerBatchSize)
+		if err != nil {
+			s.logger.Error("scheduler: failed to find parcels", "error", err)
+			break
+		}
+
+		for _, p := range parcels {
+			v, ok := planVisit(p, now)
+			if !ok {
+				continue
+			}
+			found++
+
+			if _, err := s.createJobForParcel(ctx, p, v); err != nil {
+				s.logger.Error("scheduler: failed to create job",
+					"parcel_id", p.ID,
+					"error", err,
+				)
+				continue
+			}
+			created++
+		}
+
+		if len(parcels) < schedulerBatchSize {
+			break
+		}
+		after = parcels[len(parcels)-1].ID
 	}
 
-	if len(parcels) == 0 {
+	if found == 0 {
 		s.logger.Debug("scheduler: no parcels need surveys")
 		return
 	}
 
-	created := 0
-	for _, p := range parcels {
-		job, err := s.createJobForParcel(ctx, p)
-		if err != nil {
-			s.logger.Error("scheduler: failed to create job",
-				"parcel_id", p.ID,
-				"error", err,
-			)
-			continue
+	s.logger.Info("scheduler tick complete",
+		"parcels_due", found,
+		"jobs_created", created,
+	)
+}
+
+// scheduledVisit is the job the scheduler should create for a parcel.
+type scheduledVisit struct {
+	SurveyType     string
+	Priority       string
+	Deadline       time.Time
+	SubscriptionID pgtype.UUID
+}
+
+// planVisit decides whether a parcel is due a survey and what kind.
+//
+// A subscribed parcel gets a high-priority baseline survey first, then one
+// every plan interval, created once the next visit is within the plan's
+// grace period and due by the end of it, up to the plan's visits per billing
+// period. A parcel without a subscription gets a single baseline survey.
+func planVisit(p sqlc.FindParcelsNeedingSurveyRow, now time.Time) (scheduledVisit, bool) {
+	var plan billing.Plan
+	ok := p.SubscriptionID.Valid && p.Plan != nil
+	if ok {
+		plan, ok = billing.GetPlan(*p.Plan)
+	}
+	if !ok {
+		if p.JobCount > 0 {
+			return scheduledVisit{}, false
 		}
+		return scheduledVisit{
+			SurveyType: baselineSurveyType,
+			Priority:   "normal",
+			Deadline:   now.Add(baselineDeadline),
+		}, true
+	}
 
-		s.eventBus.Publish(platform.Event{
-			Type:    "job.created",
-			Payload: job,
-		})
-		created++
+	if p.VisitsUsedThisPeriod != nil && int(*p.VisitsUsedThisPeriod) >= plan.VisitsPerPeriod {
+		return scheduledVisit{}, false
 	}
 
-	s.logger.Info("scheduler tick complete",
-		"parcels_found", len(parcels),
-		"jobs_created", created,
-	)
+	v := scheduledVisit{
+		SurveyType:     plan.SurveyType,
+		SubscriptionID: p.SubscriptionID,
+	}
+
+	if !p.LastSurveyedAt.Valid {
+		v.Priority = "high"
+		v.Deadline = now.Add(baselineDeadline)
+		return v, true
+	}
+
+	due := p.LastSurveyedAt.Time.Add(plan.VisitInterval)
+	if due.After(now.Add(plan.GracePeriod)) {
+		return scheduledVisit{}, false
+	}
+	if due.Before(now) {
+		due = now
+	}
+	v.Priority = "normal"
+	v.Deadline = due.Add(plan.GracePeriod)
+	return v, true
 }
 
-// createJobForParcel creates a basic_check survey job with a 72-hour deadline.
-// Phase 1 simplification: all parcels get basic_check, no subscription check.
-func (s *Scheduler) createJobForParcel(ctx context.Context, p sqlc.Parcel) (*sqlc.SurveyJob, error) {
-	surveyType := "basic_check"
-	priority := "normal"
+// createJobForParcel creates the planned survey job for a parcel.
+func (s *Scheduler) createJobForParcel(ctx context.Context, p sqlc.FindParcelsNeedingSurveyRow, v scheduledVisit) (*sqlc.SurveyJob, error) {
 	trigger := "scheduled"
-	deadline := time.Now().Add(72 * time.Hour)
-
-	// Base payout: 500 INR (stored as numeric)
-	basePayout := pgtype.Numeric{}
-	basePayout.Scan("500.00")
 
 	params := sqlc.CreateSurveyJobParams{
-		ParcelID:   p.ID,
-		UserID:     p.UserID,
-		SurveyType: surveyType,
-		Priority:   &priority,
-		Deadline:   deadline,
-		Trigger:    &trigger,
-		BasePayout: basePayout,
+		ParcelID:       p.ID,
+		SubscriptionID: v.SubscriptionID,
+		UserID:         p.UserID,
+		SurveyType:     v.SurveyType,
+		Priority:       &v.Priority,
+		Deadline:       v.Deadline,
+		Trigger:        &trigger,
+		BasePayout:     s.pricer.BasePayout(v.SurveyType),
 	}
 
-	job, err := s.jobRepo.CreateJob(ctx, params)
+	// Record job.created with the job so the dispatcher hears about every job
+	var job *sqlc.SurveyJob
+	err := s.jobRepo.InTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if job, err = s.jobRepo.WithTx(tx).CreateScheduledJob(ctx, params); err != nil {
+			return err
+		}
+		return platform.PublishTx(ctx, tx, platform.NewEvent(jobCreated(job)))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +202,9 @@ func (s *Scheduler) createJobForParcel(ctx context.Context, p sqlc.Parcel) (*sql
 	s.logger.Info("created survey job",
 		"job_id", job.ID,
 		"parcel_id", p.ID,
-		"survey_type", surveyType,
-		"deadline", deadline,
+		"survey_type", v.SurveyType,
+		"priority", v.Priority,
+		"deadline", v.Deadline,
 	)
 
 	return job, nil
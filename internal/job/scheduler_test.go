@@ -0,0 +1,85 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/billing"
+)
+
+func TestPlanVisit(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	sub := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+
+	parcel := func(plan string, used int32, lastSurveyed time.Duration, jobs int64) sqlc.FindParcelsNeedingSurveyRow {
+		p := sqlc.FindParcelsNeedingSurveyRow{ID: uuid.New(), JobCount: jobs}
+		if plan != "" {
+			p.SubscriptionID = sub
+			p.Plan = &plan
+			p.VisitsUsedThisPeriod = &used
+		}
+		if lastSurveyed > 0 {
+			p.LastSurveyedAt = pgtype.Timestamptz{Time: now.Add(-lastSurveyed), Valid: true}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		parcel     sqlc.FindParcelsNeedingSurveyRow
+		wantOK     bool
+		wantType   string
+		wantPrio   string
+		wantDeadln time.Time
+	}{
+		{"unsubscribed first visit", parcel("", 0, 0, 0), true, "basic_check", "normal", now.Add(72 * time.Hour)},
+		{"unsubscribed already surveyed", parcel("", 0, 10*day, 1), false, "", "", time.Time{}},
+		{"unknown plan treated as unsubscribed", parcel("gold", 0, 0, 1), false, "", "", time.Time{}},
+		{"subscribed baseline", parcel("pro", 0, 0, 0), true, "detailed_survey", "high", now.Add(72 * time.Hour)},
+		{"basic not yet due", parcel("basic", 0, 60*day, 1), false, "", "", time.Time{}},
+		{"basic within grace", parcel("basic", 0, 85*day, 1), true, "basic_check", "normal", now.Add(12 * day)},
+		{"pro overdue", parcel("pro", 0, 40*day, 1), true, "detailed_survey", "normal", now.Add(3 * day)},
+		{"pro period used up", parcel("pro", 1, 40*day, 1), false, "", "", time.Time{}},
+		{"premium second visit", parcel("premium", 1, 15*day, 2), true, "premium_inspection", "normal", now.Add(2 * day)},
+		{"premium period used up", parcel("premium", 2, 15*day, 2), false, "", "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := planVisit(tt.parcel, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if v.SurveyType != tt.wantType {
+				t.Errorf("survey type = %q, want %q", v.SurveyType, tt.wantType)
+			}
+			if v.Priority != tt.wantPrio {
+				t.Errorf("priority = %q, want %q", v.Priority, tt.wantPrio)
+			}
+			if !v.Deadline.Equal(tt.wantDeadln) {
+				t.Errorf("deadline = %s, want %s", v.Deadline, tt.wantDeadln)
+			}
+			if v.SubscriptionID != tt.parcel.SubscriptionID {
+				t.Errorf("subscription ID = %v, want %v", v.SubscriptionID, tt.parcel.SubscriptionID)
+			}
+		})
+	}
+}
+
+func TestPlanAgentTiers(t *testing.T) {
+	// The matcher enforces tiers via survey type, so each plan's survey type
+	// must require exactly the plan's tier.
+	for _, plan := range billing.Plans() {
+		tiers := tierMinimum[plan.SurveyType]
+		if len(tiers) == 0 || tiers[0] != plan.AgentTier {
+			t.Errorf("plan %s: survey type %s requires tiers %v, want minimum %s", plan.Name, plan.SurveyType, tiers, plan.AgentTier)
+		}
+	}
+}
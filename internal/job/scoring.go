@@ -0,0 +1,198 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Scoring factor names, used as weight keys and recorded with each offer.
+const (
+	factorDistance   = "distance"
+	factorRating     = "rating"
+	factorCompletion = "completion"
+	factorFreshness  = "freshness"
+	factorQAPass     = "qa_pass"
+	factorAcceptance = "acceptance"
+)
+
+// Strategy names.
+const (
+	strategyDefault     = "default"
+	strategyWeighted    = "weighted"
+	strategyPerformance = "performance"
+)
+
+// ScoreFactors are the raw agent signals available to a scoring strategy.
+type ScoreFactors struct {
+	DistanceKm     float64
+	MaxDistanceKm  float64 // search radius the agent was found within
+	VehicleType    string  // drives the travel-time estimate
+	AvgRating      float64 // 0-5
+	CompletionRate float64 // 0-1
+	QAPassRate     float64 // 0-1
+	AcceptanceRate float64 // 0-1, share of recent offers accepted
+	HoursSinceJob  float64
+}
+
+// normalized maps each factor onto 0-1, higher is better. Distance is scored
+// by estimated travel time against the time a reference vehicle needs to cross
+// the search radius, so slow vehicles rank as if they were further away.
+func (f ScoreFactors) normalized() map[string]float64 {
+	dist := 0.0
+	if f.MaxDistanceKm > 0 {
+		maxMinutes := EstimateTravelMinutes(f.MaxDistanceKm, "")
+		dist = math.Max(1.0-EstimateTravelMinutes(f.DistanceKm, f.VehicleType)/maxMinutes, 0)
+	}
+	return map[string]float64{
+		factorDistance:   dist,
+		factorRating:     f.AvgRating / 5.0,
+		factorCompletion: f.CompletionRate,
+		factorFreshness:  math.Min(f.HoursSinceJob/48.0, 1.0),
+		factorQAPass:     f.QAPassRate,
+		factorAcceptance: f.AcceptanceRate,
+	}
+}
+
+// ScoringStrategy ranks candidate agents for a job. Higher scores rank first.
+type ScoringStrategy interface {
+	// Name identifies the strategy in job_offers.scoring_strategy.
+	Name() string
+	// Weights returns the factor weights, recorded with each offer.
+	Weights() map[string]float64
+	// Score computes the composite score for an agent.
+	Score(f ScoreFactors) float64
+}
+
+// WeightedStrategy scores agents as a weighted sum of normalized factors.
+// Factors without a weight are ignored.
+type WeightedStrategy struct {
+	name    string
+	weights map[string]float64
+}
+
+// NewWeightedStrategy creates a weighted strategy with the given weights.
+func NewWeightedStrategy(name string, weights map[string]float64) *WeightedStrategy {
+	return &WeightedStrategy{name: name, weights: weights}
+}
+
+// DefaultStrategy returns the original distance/rating/completion/freshness formula.
+func DefaultStrategy() *WeightedStrategy {
+	return NewWeightedStrategy(strategyDefault, map[string]float64{
+		factorDistance:   weightDistance,
+		factorRating:     weightRating,
+		factorCompletion: weightCompletion,
+		factorFreshness:  weightFreshness,
+	})
+}
+
+// PerformanceStrategy returns a strategy that also rewards QA pass rate and
+// acceptance history, trading some weight away from distance and rating.
+func PerformanceStrategy() *WeightedStrategy {
+	return NewWeightedStrategy(strategyPerformance, map[string]float64{
+		factorDistance:   0.30,
+		factorRating:     0.20,
+		factorCompletion: 0.15,
+		factorFreshness:  0.05,
+		factorQAPass:     0.15,
+		factorAcceptance: 0.15,
+	})
+}
+
+func (s *WeightedStrategy) Name() string                { return s.name }
+func (s *WeightedStrategy) Weights() map[string]float64 { return s.weights }
+
+func (s *WeightedStrategy) Score(f ScoreFactors) float64 {
+	values := f.normalized()
+	score := 0.0
+	for factor, w := range s.weights {
+		score += w * values[factor]
+	}
+	return score
+}
+
+// usesFactor reports whether a strategy gives weight to a factor.
+func usesFactor(s ScoringStrategy, factor string) bool {
+	return s.Weights()[factor] != 0
+}
+
+// Strategies resolves the scoring strategy for a parcel's region. Regional
+// overrides are keyed by "state_code" or "state_code/district"; the most
+// specific match wins.
+type Strategies struct {
+	base    ScoringStrategy
+	regions map[string]ScoringStrategy
+}
+
+// NewStrategies builds the strategy set from matcher config.
+func NewStrategies(cfg platform.MatcherConfig) (*Strategies, error) {
+	s := &Strategies{regions: map[string]ScoringStrategy{}}
+
+	switch cfg.Strategy {
+	case "", strategyDefault:
+		s.base = DefaultStrategy()
+	case strategyPerformance:
+		s.base = PerformanceStrategy()
+	case strategyWeighted:
+		if err := validateWeights(cfg.Weights); err != nil {
+			return nil, fmt.Errorf("matcher weights: %w", err)
+		}
+		s.base = NewWeightedStrategy(strategyWeighted, cfg.Weights)
+	default:
+		return nil, fmt.Errorf("unknown scoring strategy %q", cfg.Strategy)
+	}
+
+	for region, weights := range cfg.RegionWeights {
+		if err := validateWeights(weights); err != nil {
+			return nil, fmt.Errorf("matcher weights for %s: %w", region, err)
+		}
+		s.regions[strings.ToLower(region)] = NewWeightedStrategy(strategyWeighted+":"+region, weights)
+	}
+
+	return s, nil
+}
+
+// For returns the strategy for a state and district.
+func (s *Strategies) For(stateCode, district string) ScoringStrategy {
+	if st, ok := s.regions[strings.ToLower(stateCode+"/"+district)]; ok {
+		return st
+	}
+	if st, ok := s.regions[strings.ToLower(stateCode)]; ok {
+		return st
+	}
+	return s.base
+}
+
+// validateWeights checks that weights name known factors, are non-negative
+// and sum to 1, which keeps composite scores within 0-1.
+func validateWeights(weights map[string]float64) error {
+	if len(weights) == 0 {
+		return fmt.Errorf("no weights configured")
+	}
+	for factor := range weights {
+		switch factor {
+		case factorDistance, factorRating, factorCompletion, factorFreshness, factorQAPass, factorAcceptance:
+		default:
+			return fmt.Errorf("unknown factor %q", factor)
+		}
+	}
+	return platform.CheckWeights(weights)
+}
+
+// matchScore converts a composite score to job_offers.match_score. Scores
+// are clamped to 0-1 so they always fit its NUMERIC(5,4).
+func matchScore(score float64) pgtype.Numeric {
+	n := pgtype.Numeric{}
+	n.Scan(fmt.Sprintf("%.4f", math.Min(math.Max(score, 0), 1)))
+	return n
+}
+
+// weightsJSON encodes a strategy's weights for storage on job_offers.
+func weightsJSON(s ScoringStrategy) []byte {
+	b, _ := json.Marshal(s.Weights())
+	return b
+}
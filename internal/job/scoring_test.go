@@ -0,0 +1,120 @@
+package job
+
+import (
+	"math"
+	"testing"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+func TestDefaultStrategyMatchesFormula(t *testing.T) {
+	s := DefaultStrategy()
+
+	// Perfect agent: 0km distance, 5.0 rating, 100% completion, 48h since last job
+	perfect := s.Score(ScoreFactors{DistanceKm: 0, MaxDistanceKm: 25, AvgRating: 5, CompletionRate: 1, HoursSinceJob: 48})
+	if math.Abs(perfect-1.0) > 0.001 {
+		t.Errorf("perfect score = %f, want 1.0", perfect)
+	}
+
+	// Agent at max distance: 25km away, 2.5 rating, 50% completion, 0h since last job
+	poor := s.Score(ScoreFactors{DistanceKm: 25, MaxDistanceKm: 25, AvgRating: 2.5, CompletionRate: 0.5, HoursSinceJob: 0})
+	if math.Abs(poor-0.25) > 0.001 {
+		t.Errorf("poor score = %f, want 0.25", poor)
+	}
+
+	// QA and acceptance don't affect the default formula
+	withHistory := s.Score(ScoreFactors{DistanceKm: 25, MaxDistanceKm: 25, AvgRating: 2.5, CompletionRate: 0.5, QAPassRate: 1, AcceptanceRate: 1})
+	if math.Abs(withHistory-poor) > 0.001 {
+		t.Errorf("default score changed with qa/acceptance: %f vs %f", withHistory, poor)
+	}
+}
+
+func TestPerformanceStrategy(t *testing.T) {
+	s := PerformanceStrategy()
+
+	total := 0.0
+	for _, w := range s.Weights() {
+		total += w
+	}
+	if math.Abs(total-1.0) > 0.001 {
+		t.Errorf("performance weights sum = %f, want 1.0", total)
+	}
+
+	base := ScoreFactors{DistanceKm: 5, MaxDistanceKm: 25, AvgRating: 4, CompletionRate: 0.9, HoursSinceJob: 24}
+	good, bad := base, base
+	good.QAPassRate, good.AcceptanceRate = 1.0, 0.9
+	bad.QAPassRate, bad.AcceptanceRate = 0.5, 0.2
+	if s.Score(good) <= s.Score(bad) {
+		t.Errorf("agent with better QA/acceptance should score higher: %f <= %f", s.Score(good), s.Score(bad))
+	}
+}
+
+func TestStrategiesFor(t *testing.T) {
+	s, err := NewStrategies(platform.MatcherConfig{
+		Strategy: "weighted",
+		Weights:  map[string]float64{"distance": 0.6, "rating": 0.4},
+		RegionWeights: map[string]map[string]float64{
+			"KA":                 {"distance": 1.0},
+			"KA/Bengaluru Urban": {"rating": 1.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewStrategies: %v", err)
+	}
+
+	tests := []struct {
+		state, district string
+		expected        string
+	}{
+		{"MH", "Pune", "weighted"},
+		{"KA", "Mysuru", "weighted:KA"},
+		{"ka", "bengaluru urban", "weighted:KA/Bengaluru Urban"},
+	}
+	for _, tt := range tests {
+		if got := s.For(tt.state, tt.district).Name(); got != tt.expected {
+			t.Errorf("For(%q, %q) = %q, want %q", tt.state, tt.district, got, tt.expected)
+		}
+	}
+}
+
+func TestNewStrategiesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  platform.MatcherConfig
+	}{
+		{"unknown strategy", platform.MatcherConfig{Strategy: "random"}},
+		{"weighted without weights", platform.MatcherConfig{Strategy: "weighted"}},
+		{"unknown factor", platform.MatcherConfig{Strategy: "weighted", Weights: map[string]float64{"height": 1}}},
+		{"negative weight", platform.MatcherConfig{Strategy: "weighted", Weights: map[string]float64{"distance": -1}}},
+		{"bad region", platform.MatcherConfig{RegionWeights: map[string]map[string]float64{"KA": {"speed": 1}}}},
+		{"region weights over 1", platform.MatcherConfig{RegionWeights: map[string]map[string]float64{"KA": {"distance": 1, "rating": 1}}}},
+		{"weights under 1", platform.MatcherConfig{Strategy: "weighted", Weights: map[string]float64{"distance": 0.5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStrategies(tt.cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0.87654, "0.8765"},
+		{12.5, "1.0000"},
+		{-0.2, "0.0000"},
+	}
+	for _, tt := range tests {
+		v, err := matchScore(tt.score).Value()
+		if err != nil {
+			t.Fatalf("matchScore(%v): %v", tt.score, err)
+		}
+		if v != tt.want {
+			t.Errorf("matchScore(%v) = %v, want %s", tt.score, v, tt.want)
+		}
+	}
+}
@@ -24,15 +24,26 @@ type JobResponse struct {
 
 // OfferResponse is the API representation of a job offer.
 type OfferResponse struct {
-	ID           uuid.UUID  `json:"id"`
-	JobID        uuid.UUID  `json:"job_id"`
-	AgentID      uuid.UUID  `json:"agent_id"`
-	CascadeRound int32      `json:"cascade_round"`
-	OfferRank    int32      `json:"offer_rank"`
-	DistanceKm   *float32   `json:"distance_km,omitempty"`
-	Status       *string    `json:"status"`
-	ExpiresAt    time.Time  `json:"expires_at"`
-	SentAt       *time.Time `json:"sent_at,omitempty"`
+	ID           uuid.UUID        `json:"id"`
+	JobID        uuid.UUID        `json:"job_id"`
+	AgentID      uuid.UUID        `json:"agent_id"`
+	CascadeRound int32            `json:"cascade_round"`
+	OfferRank    int32            `json:"offer_rank"`
+	DistanceKm   *float32         `json:"distance_km,omitempty"`
+	Status       *string          `json:"status"`
+	ExpiresAt    time.Time        `json:"expires_at"`
+	SentAt       *time.Time       `json:"sent_at,omitempty"`
+	Payout       *PayoutBreakdown `json:"payout,omitempty"`
+}
+
+// StatusHistoryResponse is the API representation of a job status transition.
+type StatusHistoryResponse struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	ActorID    *string   `json:"actor_id,omitempty"`
+	Reason     *string   `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // DeclineRequest is the payload for declining a job offer.
@@ -0,0 +1,621 @@
+// Package sim replays a snapshot of agents, parcels and jobs through the
+// job package's Matcher and cascade policy on a virtual clock, with agents
+// responding to offers according to a model calibrated from their history.
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/job"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Snapshot is the world a dispatch simulation runs against: agents, parcels,
+// the jobs to dispatch and per-agent offer history for the response model.
+type Snapshot struct {
+	Agents     []Agent      `json:"agents"`
+	Parcels    []Parcel     `json:"parcels"`
+	Jobs       []Job        `json:"jobs"`
+	OfferStats []OfferStats `json:"offer_stats"`
+}
+
+// Agent is an agent's matching-relevant state at the start of a simulation.
+type Agent struct {
+	ID                 uuid.UUID  `json:"id"`
+	Lng                float64    `json:"lng"`
+	Lat                float64    `json:"lat"`
+	Tier               string     `json:"tier"`
+	VehicleType        string     `json:"vehicle_type"`
+	AvgRating          float64    `json:"avg_rating"`
+	CompletionRate     float64    `json:"completion_rate"`
+	QAPassRate         float64    `json:"qa_pass_rate"`
+	AvailableDays      []string   `json:"available_days"`
+	AvailableStart     string     `json:"available_start"` // "HH:MM"
+	AvailableEnd       string     `json:"available_end"`   // "HH:MM"
+	PreferredRadiusKm  *int32     `json:"preferred_radius_km"`
+	ActiveJobs         int        `json:"active_jobs"`
+	LastJobCompletedAt *time.Time `json:"last_job_completed_at"`
+}
+
+// Parcel is a parcel's location and region.
+type Parcel struct {
+	ID        uuid.UUID `json:"id"`
+	Lng       float64   `json:"lng"`
+	Lat       float64   `json:"lat"`
+	StateCode string    `json:"state_code"`
+	District  string    `json:"district"`
+}
+
+// Job is a job to dispatch, arriving at CreatedAt.
+type Job struct {
+	ID         uuid.UUID `json:"id"`
+	ParcelID   uuid.UUID `json:"parcel_id"`
+	SurveyType string    `json:"survey_type"`
+	Priority   string    `json:"priority"`
+	CreatedAt  time.Time `json:"created_at"`
+	Deadline   time.Time `json:"deadline"`
+}
+
+// OfferStats summarizes an agent's historical responses to offers.
+type OfferStats struct {
+	AgentID            uuid.UUID `json:"agent_id"`
+	Accepted           int       `json:"accepted"`
+	Declined           int       `json:"declined"`
+	Expired            int       `json:"expired"`
+	AvgResponseMinutes float64   `json:"avg_response_minutes"`
+}
+
+// ResponseProfile is the probability of each response and how long the
+// agent takes to give it. Expiry takes the remaining probability.
+type ResponseProfile struct {
+	Accept              float64
+	Decline             float64
+	MeanResponseMinutes float64
+}
+
+// ResponseModel draws agent responses to offers.
+type ResponseModel struct {
+	Global ResponseProfile
+	Agents map[uuid.UUID]ResponseProfile
+}
+
+// responsePriorWeight is how many offers of history it takes for an agent's
+// own behaviour to outweigh the fleet-wide average.
+const responsePriorWeight = 10
+
+// CalibrateResponseModel builds a response model from offer history. Each
+// agent's rates are shrunk toward the global rates so agents with little
+// history behave like the fleet average.
+func CalibrateResponseModel(stats []OfferStats) ResponseModel {
+	var accepted, declined, total int
+	var minutesSum, minutesN float64
+	for _, s := range stats {
+		n := s.Accepted + s.Declined + s.Expired
+		accepted += s.Accepted
+		declined += s.Declined
+		total += n
+		if s.Accepted+s.Declined > 0 && s.AvgResponseMinutes > 0 {
+			minutesSum += s.AvgResponseMinutes * float64(s.Accepted+s.Declined)
+			minutesN += float64(s.Accepted + s.Declined)
+		}
+	}
+
+	// Fallback when there is no history at all
+	global := ResponseProfile{Accept: 0.5, Decline: 0.3, MeanResponseMinutes: 10}
+	if total > 0 {
+		global.Accept = float64(accepted) / float64(total)
+		global.Decline = float64(declined) / float64(total)
+	}
+	if minutesN > 0 {
+		global.MeanResponseMinutes = minutesSum / minutesN
+	}
+
+	model := ResponseModel{Global: global, Agents: map[uuid.UUID]ResponseProfile{}}
+	for _, s := range stats {
+		n := float64(s.Accepted + s.Declined + s.Expired)
+		p := ResponseProfile{
+			Accept:              (float64(s.Accepted) + responsePriorWeight*global.Accept) / (n + responsePriorWeight),
+			Decline:             (float64(s.Declined) + responsePriorWeight*global.Decline) / (n + responsePriorWeight),
+			MeanResponseMinutes: global.MeanResponseMinutes,
+		}
+		if s.AvgResponseMinutes > 0 {
+			p.MeanResponseMinutes = s.AvgResponseMinutes
+		}
+		model.Agents[s.AgentID] = p
+	}
+	return model
+}
+
+// profile returns an agent's response profile.
+func (m ResponseModel) profile(agentID uuid.UUID) ResponseProfile {
+	if p, ok := m.Agents[agentID]; ok {
+		return p
+	}
+	return m.Global
+}
+
+// Config holds the policy under test.
+type Config struct {
+	Dispatch       platform.DispatchConfig
+	Matcher        platform.MatcherConfig
+	SurveyDuration time.Duration // time on site before an agent is free again
+	Seed           int64
+}
+
+// Report summarizes a simulation run.
+type Report struct {
+	Jobs           int     `json:"jobs"`
+	Assigned       int     `json:"assigned"`
+	Unassigned     int     `json:"unassigned"`
+	UnassignedRate float64 `json:"unassigned_rate"`
+
+	TimeToAssignMean time.Duration `json:"time_to_assign_mean"`
+	TimeToAssignP50  time.Duration `json:"time_to_assign_p50"`
+	TimeToAssignP90  time.Duration `json:"time_to_assign_p90"`
+
+	AvgDistanceKm float64 `json:"avg_distance_km"`
+	AvgRounds     float64 `json:"avg_rounds"`
+
+	OffersSent      int     `json:"offers_sent"`
+	AgentsOffered   int     `json:"agents_offered"`
+	AgentsIdle      int     `json:"agents_idle"`     // agents never offered a job
+	OfferGini       float64 `json:"offer_gini"`      // 0 = offers spread evenly, 1 = one agent got them all
+	TopAgentShare   float64 `json:"top_agent_share"` // share of offers sent to the most-offered agent
+	AssignmentsGini float64 `json:"assignments_gini"`
+}
+
+// Simulation replays a snapshot through the real Matcher and cascade logic
+// against a virtual clock, with agents responding according to a ResponseModel.
+type Simulation struct {
+	cfg     Config
+	model   ResponseModel
+	policy  job.CascadePolicy
+	matcher *job.Matcher
+	rng     *rand.Rand
+	now     time.Time
+	events  eventQueue
+	seq     int
+
+	agents  map[uuid.UUID]*agentState
+	parcels map[uuid.UUID]Parcel
+	jobs    []*jobState
+	offers  map[uuid.UUID]*offerRef
+}
+
+type agentState struct {
+	Agent
+	offersReceived int
+	assignments    int
+}
+
+type jobState struct {
+	Job
+	status     string
+	round      int32
+	offers     []sqlc.JobOffer
+	assignedAt time.Time
+	distanceKm float64
+}
+
+type offerRef struct {
+	job   *jobState
+	index int // position in job.offers
+}
+
+// New prepares a simulation of the snapshot under cfg.
+func New(snap Snapshot, cfg Config, logger *slog.Logger) (*Simulation, error) {
+	if cfg.SurveyDuration <= 0 {
+		cfg.SurveyDuration = time.Hour
+	}
+
+	strategies, err := job.NewStrategies(cfg.Matcher)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Simulation{
+		cfg:     cfg,
+		model:   CalibrateResponseModel(snap.OfferStats),
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		agents:  make(map[uuid.UUID]*agentState, len(snap.Agents)),
+		parcels: make(map[uuid.UUID]Parcel, len(snap.Parcels)),
+		offers:  map[uuid.UUID]*offerRef{},
+	}
+	for _, a := range snap.Agents {
+		if a.Tier == "" {
+			a.Tier = "basic"
+		}
+		s.agents[a.ID] = &agentState{Agent: a}
+	}
+	for _, p := range snap.Parcels {
+		s.parcels[p.ID] = p
+	}
+	for _, j := range snap.Jobs {
+		if _, ok := s.parcels[j.ParcelID]; !ok {
+			return nil, fmt.Errorf("job %s references unknown parcel %s", j.ID, j.ParcelID)
+		}
+		s.jobs = append(s.jobs, &jobState{Job: j, status: job.StatusPendingAssignment})
+	}
+
+	s.policy = job.NewCascadePolicy(cfg.Dispatch)
+	s.matcher = job.NewMatcher(agentFinder{s}, agentLoad{s}, strategies, cfg.Matcher.ExpansionRadiiKm, s.policy.OfferTimeout(), logger)
+	s.matcher.SetClock(func() time.Time { return s.now })
+	return s, nil
+}
+
+// Run simulates until every job is assigned or unassigned.
+func (s *Simulation) Run(ctx context.Context) (*Report, error) {
+	for _, j := range s.jobs {
+		s.schedule(j.CreatedAt, func() { s.advance(ctx, j) })
+	}
+
+	for s.events.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ev := heap.Pop(&s.events).(*event)
+		s.now = ev.at
+		ev.fn()
+	}
+
+	return s.report(), nil
+}
+
+// advance mirrors Dispatcher.advance for an in-memory job.
+func (s *Simulation) advance(ctx context.Context, j *jobState) {
+	if j.status != job.StatusPendingAssignment && j.status != job.StatusOffered {
+		return
+	}
+
+	for {
+		step := s.policy.Next(j.SurveyType, j.Priority, j.round, j.offers)
+		j.round = step.Round
+
+		switch step.Action {
+		case job.StepWait:
+			return
+		case job.StepSend:
+			s.sendOffers(j, step.Offers)
+			j.status = job.StatusOffered
+			return
+		case job.StepExhausted:
+			j.status = job.StatusUnassigned
+			return
+		case job.StepNextRound:
+			j.round++
+			s.planRound(ctx, j)
+		}
+	}
+}
+
+// planRound queues the matcher's candidates for the job's current round.
+func (s *Simulation) planRound(ctx context.Context, j *jobState) {
+	p := s.parcels[j.ParcelID]
+	candidates, _ := s.matcher.FindCandidatesAtLocation(ctx, p.Lng, p.Lat, j.SurveyType,
+		job.Region{StateCode: p.StateCode, District: p.District}, offeredAgentIDs(j.offers))
+
+	for rank, c := range candidates {
+		distKm := float32(c.DistanceKm)
+		queued := job.OfferQueued
+		o := sqlc.JobOffer{
+			ID:           uuid.New(),
+			JobID:        j.ID,
+			AgentID:      c.AgentID,
+			CascadeRound: j.round,
+			OfferRank:    int32(rank + 1),
+			DistanceKm:   &distKm,
+			Status:       &queued,
+		}
+		s.offers[o.ID] = &offerRef{job: j, index: len(j.offers)}
+		j.offers = append(j.offers, o)
+	}
+}
+
+// sendOffers marks offers sent and draws each agent's response.
+func (s *Simulation) sendOffers(j *jobState, queued []sqlc.JobOffer) {
+	timeout := s.policy.OfferTimeout()
+
+	for _, q := range queued {
+		so := s.offers[q.ID]
+		o := &j.offers[so.index]
+		sent := job.OfferSent
+		o.Status = &sent
+		o.SentAt = pgtype.Timestamptz{Time: s.now, Valid: true}
+		o.ExpiresAt = s.now.Add(timeout)
+		s.agents[o.AgentID].offersReceived++
+
+		p := s.model.profile(o.AgentID)
+		delay := time.Duration(s.rng.ExpFloat64() * p.MeanResponseMinutes * float64(time.Minute))
+		roll := s.rng.Float64()
+
+		offerID := o.ID
+		switch {
+		case roll < p.Accept && delay < timeout:
+			s.schedule(s.now.Add(delay), func() { s.respond(offerID, job.OfferAccepted) })
+		case roll < p.Accept+p.Decline && delay < timeout:
+			s.schedule(s.now.Add(delay), func() { s.respond(offerID, job.OfferDeclined) })
+		default:
+			s.schedule(o.ExpiresAt, func() { s.respond(offerID, job.OfferExpired) })
+		}
+	}
+}
+
+// respond applies an agent's response, mirroring AcceptOffer/DeclineOffer
+// and the dispatcher's expiry sweep.
+func (s *Simulation) respond(offerID uuid.UUID, status string) {
+	so := s.offers[offerID]
+	j := so.job
+	o := &j.offers[so.index]
+	if *o.Status != job.OfferSent {
+		return // withdrawn when another agent claimed the job
+	}
+	o.Status = &status
+
+	if status == job.OfferAccepted {
+		// Claim: the first accept wins and every other open offer is withdrawn
+		j.status = job.StatusAssigned
+		j.assignedAt = s.now
+		if o.DistanceKm != nil {
+			j.distanceKm = float64(*o.DistanceKm)
+		}
+		for i := range j.offers {
+			if st := *j.offers[i].Status; st == job.OfferQueued || st == job.OfferSent {
+				withdrawn := job.OfferWithdrawn
+				j.offers[i].Status = &withdrawn
+			}
+		}
+
+		a := s.agents[o.AgentID]
+		a.assignments++
+		a.ActiveJobs++
+		p := s.parcels[j.ParcelID]
+		travel := time.Duration(job.EstimateTravelMinutes(j.distanceKm, a.VehicleType) * float64(time.Minute))
+		s.schedule(s.now.Add(travel+s.cfg.SurveyDuration), func() {
+			a.ActiveJobs--
+			a.Lng, a.Lat = p.Lng, p.Lat
+			done := s.now
+			a.LastJobCompletedAt = &done
+		})
+		return
+	}
+
+	s.advance(context.Background(), j)
+}
+
+// report computes the run's metrics.
+func (s *Simulation) report() *Report {
+	r := &Report{Jobs: len(s.jobs)}
+
+	var waits []time.Duration
+	var distSum, roundSum float64
+	for _, j := range s.jobs {
+		roundSum += float64(j.round)
+		for _, o := range j.offers {
+			if o.SentAt.Valid {
+				r.OffersSent++
+			}
+		}
+		if j.status == job.StatusAssigned {
+			r.Assigned++
+			waits = append(waits, j.assignedAt.Sub(j.CreatedAt))
+			distSum += j.distanceKm
+		} else {
+			r.Unassigned++
+		}
+	}
+
+	if r.Jobs > 0 {
+		r.UnassignedRate = float64(r.Unassigned) / float64(r.Jobs)
+		r.AvgRounds = roundSum / float64(r.Jobs)
+	}
+	if len(waits) > 0 {
+		sort.Slice(waits, func(a, b int) bool { return waits[a] < waits[b] })
+		var total time.Duration
+		for _, w := range waits {
+			total += w
+		}
+		r.TimeToAssignMean = total / time.Duration(len(waits))
+		r.TimeToAssignP50 = percentile(waits, 0.5)
+		r.TimeToAssignP90 = percentile(waits, 0.9)
+		r.AvgDistanceKm = distSum / float64(len(waits))
+	}
+
+	offers := make([]float64, 0, len(s.agents))
+	assignments := make([]float64, 0, len(s.agents))
+	top := 0
+	for _, a := range s.agents {
+		offers = append(offers, float64(a.offersReceived))
+		assignments = append(assignments, float64(a.assignments))
+		if a.offersReceived > 0 {
+			r.AgentsOffered++
+		} else {
+			r.AgentsIdle++
+		}
+		top = max(top, a.offersReceived)
+	}
+	r.OfferGini = gini(offers)
+	r.AssignmentsGini = gini(assignments)
+	if r.OffersSent > 0 {
+		r.TopAgentShare = float64(top) / float64(r.OffersSent)
+	}
+	return r
+}
+
+// schedule queues fn to run at virtual time at.
+func (s *Simulation) schedule(at time.Time, fn func()) {
+	s.seq++
+	heap.Push(&s.events, &event{at: at, seq: s.seq, fn: fn})
+}
+
+// percentile returns the q-th percentile of sorted durations.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
+
+// gini returns the Gini coefficient of non-negative values.
+func gini(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var cum, total float64
+	for i, v := range sorted {
+		cum += float64(i+1) * v
+		total += v
+	}
+	if total == 0 {
+		return 0
+	}
+	return (2*cum)/(float64(n)*total) - float64(n+1)/float64(n)
+}
+
+// event is a callback scheduled on the virtual clock.
+type event struct {
+	at  time.Time
+	seq int // tie-breaker keeps same-time events in scheduling order
+	fn  func()
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
+
+// agentFinder serves FindMatchableAgents from simulation state.
+type agentFinder struct{ s *Simulation }
+
+func (f agentFinder) FindMatchableAgents(_ context.Context, arg sqlc.FindMatchableAgentsParams) ([]sqlc.FindMatchableAgentsRow, error) {
+	lng, _ := arg.StMakepoint.(float64)
+	lat, _ := arg.StMakepoint_2.(float64)
+	radiusM, _ := arg.StDwithin.(float64)
+
+	var rows []sqlc.FindMatchableAgentsRow
+	for _, a := range f.s.agents {
+		if containsID(arg.Column5, a.ID) {
+			continue
+		}
+		distM := haversineMeters(lng, lat, a.Lng, a.Lat)
+		if distM > radiusM {
+			continue
+		}
+		rows = append(rows, a.row(distM))
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].DistanceKm == rows[j].DistanceKm {
+			return rows[i].ID.String() < rows[j].ID.String()
+		}
+		return rows[i].DistanceKm < rows[j].DistanceKm
+	})
+	if len(rows) > int(arg.Limit) {
+		rows = rows[:arg.Limit]
+	}
+	return rows, nil
+}
+
+// row converts agent state to the shape FindMatchableAgents returns.
+func (a *agentState) row(distM float64) sqlc.FindMatchableAgentsRow {
+	tier, vehicle := a.Tier, a.VehicleType
+	row := sqlc.FindMatchableAgentsRow{
+		ID:                a.ID,
+		Tier:              &tier,
+		VehicleType:       &vehicle,
+		PreferredRadiusKm: a.PreferredRadiusKm,
+		AvailableDays:     a.AvailableDays,
+		AvailableStart:    parseClock(a.AvailableStart),
+		AvailableEnd:      parseClock(a.AvailableEnd),
+		DistanceKm:        int32(distM / 1000),
+	}
+	row.AvgRating.Scan(fmt.Sprintf("%.2f", a.AvgRating))
+	row.CompletionRate.Scan(fmt.Sprintf("%.4f", a.CompletionRate))
+	row.QaPassRate.Scan(fmt.Sprintf("%.4f", a.QAPassRate))
+	if a.LastJobCompletedAt != nil {
+		row.LastJobCompletedAt = pgtype.Timestamptz{Time: *a.LastJobCompletedAt, Valid: true}
+	}
+	return row
+}
+
+// agentLoad serves AgentLoad from simulation state.
+type agentLoad struct{ s *Simulation }
+
+func (l agentLoad) CountActiveJobsByAgent(_ context.Context, agentID uuid.UUID) (int64, error) {
+	if a, ok := l.s.agents[agentID]; ok {
+		return int64(a.ActiveJobs), nil
+	}
+	return 0, nil
+}
+
+func (l agentLoad) GetAcceptanceRates(_ context.Context, agentIDs []uuid.UUID) (map[uuid.UUID]float64, error) {
+	rates := make(map[uuid.UUID]float64, len(agentIDs))
+	for _, id := range agentIDs {
+		if p, ok := l.s.model.Agents[id]; ok {
+			rates[id] = p.Accept
+		}
+	}
+	return rates, nil
+}
+
+// parseClock converts "HH:MM" to a pgtype.Time; empty or invalid is unset.
+func parseClock(s string) pgtype.Time {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return pgtype.Time{}
+	}
+	return pgtype.Time{Microseconds: int64(t.Hour()*3600+t.Minute()*60) * 1_000_000, Valid: true}
+}
+
+// offeredAgentIDs returns every agent already offered (or queued for) a job.
+func offeredAgentIDs(offers []sqlc.JobOffer) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(offers))
+	for _, o := range offers {
+		ids = append(ids, o.AgentID)
+	}
+	return ids
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// haversineMeters returns the great-circle distance between two points.
+func haversineMeters(lng1, lat1, lng2, lat2 float64) float64 {
+	const earthRadiusM = 6371000
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusM * math.Asin(math.Sqrt(h))
+}
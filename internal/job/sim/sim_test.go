@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func TestCalibrateResponseModel(t *testing.T) {
+	veteran, newbie := uuid.New(), uuid.New()
+	model := CalibrateResponseModel([]OfferStats{
+		{AgentID: veteran, Accepted: 90, Declined: 10, Expired: 0, AvgResponseMinutes: 4},
+		{AgentID: newbie, Accepted: 0, Declined: 1, Expired: 0},
+	})
+
+	if math.Abs(model.Global.Accept-90.0/101) > 1e-9 {
+		t.Errorf("global accept = %f, want %f", model.Global.Accept, 90.0/101)
+	}
+
+	// Lots of history: close to the agent's own rate
+	if p := model.profile(veteran); math.Abs(p.Accept-0.9) > 0.02 || p.MeanResponseMinutes != 4 {
+		t.Errorf("veteran profile = %+v", p)
+	}
+
+	// One decline shouldn't make an agent a certain decliner
+	if p := model.profile(newbie); p.Accept < 0.7 {
+		t.Errorf("newbie accept = %f, want shrunk toward global", p.Accept)
+	}
+
+	// Unknown agents use the global profile
+	if p := model.profile(uuid.New()); p != model.Global {
+		t.Errorf("unknown agent profile = %+v, want global", p)
+	}
+}
+
+func TestGini(t *testing.T) {
+	if g := gini([]float64{3, 3, 3}); math.Abs(g) > 1e-9 {
+		t.Errorf("even gini = %f, want 0", g)
+	}
+	if g := gini([]float64{0, 0, 0, 12}); math.Abs(g-0.75) > 1e-9 {
+		t.Errorf("concentrated gini = %f, want 0.75", g)
+	}
+	if g := gini(nil); g != 0 {
+		t.Errorf("empty gini = %f, want 0", g)
+	}
+}
+
+func TestSimulationRun(t *testing.T) {
+	start := time.Date(2026, 1, 7, 10, 0, 0, 0, time.FixedZone("IST", 5*60*60+30*60)) // Wednesday
+	parcel := Parcel{ID: uuid.New(), Lng: 77.59, Lat: 12.97, StateCode: "KA", District: "Bengaluru Urban"}
+	near, far := uuid.New(), uuid.New()
+
+	snap := Snapshot{
+		Agents: []Agent{
+			{ID: near, Lng: 77.60, Lat: 12.97, VehicleType: "bike", AvgRating: 4.5, CompletionRate: 1, QAPassRate: 1},
+			{ID: far, Lng: 77.75, Lat: 12.97, VehicleType: "bike", AvgRating: 4.5, CompletionRate: 1, QAPassRate: 1},
+		},
+		Parcels: []Parcel{parcel},
+		Jobs: []Job{
+			{ID: uuid.New(), ParcelID: parcel.ID, SurveyType: "basic_check", Priority: "normal", CreatedAt: start, Deadline: start.Add(72 * time.Hour)},
+		},
+		// The nearest agent always declines; the other always accepts
+		OfferStats: []OfferStats{
+			{AgentID: near, Declined: 10000, AvgResponseMinutes: 2},
+			{AgentID: far, Accepted: 10000, AvgResponseMinutes: 2},
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sim, err := New(snap, Config{Dispatch: platform.DispatchConfig{Mode: "sequential", OfferTimeout: 30 * time.Minute}}, logger)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	report, err := sim.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if report.Assigned != 1 || report.Unassigned != 0 {
+		t.Fatalf("assigned/unassigned = %d/%d, want 1/0", report.Assigned, report.Unassigned)
+	}
+	if report.OffersSent != 2 {
+		t.Errorf("offers sent = %d, want 2 (decline then accept)", report.OffersSent)
+	}
+	if report.TimeToAssignMean <= 0 || report.TimeToAssignMean > time.Hour {
+		t.Errorf("time to assign = %s, want within two offer windows", report.TimeToAssignMean)
+	}
+	if report.AvgDistanceKm < 10 {
+		t.Errorf("avg distance = %.1f km, want the far agent's distance", report.AvgDistanceKm)
+	}
+}
@@ -0,0 +1,151 @@
+package job
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Job status values.
+const (
+	StatusPendingAssignment = "pending_assignment" // system finding an agent
+	StatusOffered           = "offered"            // awaiting agent response
+	StatusAssigned          = "assigned"           // agent accepted
+	StatusEnRoute           = "agent_en_route"     // agent travelling
+	StatusOnSite            = "agent_on_site"      // geofence confirmed
+	StatusInProgress        = "in_progress"        // survey started
+	StatusSubmitted         = "survey_submitted"   // pending QA
+	StatusCompleted         = "completed"          // passed QA
+	StatusFailedQA          = "failed_qa"          // rejected, needs re-survey
+	StatusCancelled         = "cancelled"          // cancelled by ops
+	StatusUnassigned        = "unassigned"         // cascade exhausted
+)
+
+// Actor roles that may trigger transitions.
+const (
+	RoleSystem = "system"
+	RoleAgent  = "agent"
+	RoleOps    = "ops"
+	RoleAdmin  = "admin"
+)
+
+// Actor identifies who triggered a transition, recorded in job_status_history.
+type Actor struct {
+	Role string
+	ID   string // agent ID, Keycloak subject or system component
+}
+
+// systemActor returns the actor for a background component such as the dispatcher.
+func systemActor(component string) Actor {
+	return Actor{Role: RoleSystem, ID: component}
+}
+
+// agentActor returns the actor for an agent acting on their own job.
+func agentActor(agentID uuid.UUID) Actor {
+	return Actor{Role: RoleAgent, ID: agentID.String()}
+}
+
+// opsActor returns the actor for an ops console user.
+func opsActor(u *auth.UserContext) Actor {
+	role := RoleOps
+	if slices.Contains(u.Roles, RoleAdmin) {
+		role = RoleAdmin
+	}
+	return Actor{Role: role, ID: u.KeycloakID}
+}
+
+var (
+	bySystem     = []string{RoleSystem}
+	byAgent      = []string{RoleAgent}
+	byOps        = []string{RoleOps, RoleAdmin}
+	byAgentOrOps = []string{RoleAgent, RoleOps, RoleAdmin}
+)
+
+// jobTransitions lists the legal transitions out of each status and the roles
+// allowed to make them. Completed and cancelled jobs are final.
+var jobTransitions = map[string]map[string][]string{
+	StatusPendingAssignment: {
+		StatusOffered:           bySystem,
+		StatusAssigned:          byAgentOrOps,
+		StatusUnassigned:        bySystem,
+		StatusPendingAssignment: byOps, // re-dispatch
+		StatusCancelled:         byOps,
+	},
+	StatusOffered: {
+		StatusAssigned:          byAgentOrOps,
+		StatusUnassigned:        bySystem,
+		StatusPendingAssignment: byOps,
+		StatusCancelled:         byOps,
+	},
+	StatusUnassigned: {
+		StatusAssigned:          byOps,
+		StatusPendingAssignment: byOps,
+		StatusCancelled:         byOps,
+	},
+	StatusAssigned: {
+		StatusEnRoute:           byAgent,
+		StatusOnSite:            byAgent,
+		StatusAssigned:          byOps, // reassign to another agent
+		StatusPendingAssignment: byOps,
+		StatusCancelled:         byOps,
+	},
+	StatusEnRoute: {
+		StatusOnSite:            byAgent,
+		StatusAssigned:          byOps,
+		StatusPendingAssignment: byOps,
+		StatusCancelled:         byOps,
+	},
+	StatusOnSite: {
+		StatusInProgress: byAgent,
+		StatusSubmitted:  byAgent,
+		StatusCancelled:  byOps,
+	},
+	StatusInProgress: {
+		StatusSubmitted: byAgent,
+		StatusCancelled: byOps,
+	},
+	StatusSubmitted: {
+		StatusCompleted: bySystem,
+		StatusFailedQA:  bySystem,
+	},
+	StatusFailedQA: {
+		StatusAssigned:          byOps,
+		StatusPendingAssignment: byOps, // re-survey
+		StatusCancelled:         byOps,
+	},
+}
+
+// checkTransition returns a conflict error unless role may move a job from
+// one status to another.
+func checkTransition(from, to, role string) error {
+	roles, ok := jobTransitions[from][to]
+	if !ok {
+		return platform.NewConflict(fmt.Sprintf("job cannot move from %s to %s", from, to))
+	}
+	if !slices.Contains(roles, role) {
+		return platform.NewConflict(fmt.Sprintf("%s cannot move job from %s to %s", role, from, to))
+	}
+	return nil
+}
+
+// withdrawsOffers reports whether entering a status ends the current cascade,
+// so the job's queued and sent offers must be withdrawn.
+func withdrawsOffers(to string) bool {
+	switch to {
+	case StatusAssigned, StatusUnassigned, StatusPendingAssignment, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// jobStatus returns a job's status, defaulting to pending_assignment.
+func jobStatus(job *sqlc.SurveyJob) string {
+	if job.Status == nil {
+		return StatusPendingAssignment
+	}
+	return *job.Status
+}
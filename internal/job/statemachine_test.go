@@ -0,0 +1,101 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		role  string
+		legal bool
+	}{
+		{"dispatcher sends offers", StatusPendingAssignment, StatusOffered, RoleSystem, true},
+		{"agent accepts offer", StatusOffered, StatusAssigned, RoleAgent, true},
+		{"ops assigns unassigned job", StatusUnassigned, StatusAssigned, RoleOps, true},
+		{"admin reassigns", StatusAssigned, StatusAssigned, RoleAdmin, true},
+		{"agent cannot claim assigned job", StatusAssigned, StatusAssigned, RoleAgent, false},
+		{"agent heads out", StatusAssigned, StatusEnRoute, RoleAgent, true},
+		{"agent arrives without en route", StatusAssigned, StatusOnSite, RoleAgent, true},
+		{"agent arrives", StatusEnRoute, StatusOnSite, RoleAgent, true},
+		{"agent starts survey", StatusOnSite, StatusInProgress, RoleAgent, true},
+		{"agent submits from on site", StatusOnSite, StatusSubmitted, RoleAgent, true},
+		{"agent submits", StatusInProgress, StatusSubmitted, RoleAgent, true},
+		{"agent cannot submit before arriving", StatusAssigned, StatusSubmitted, RoleAgent, false},
+		{"qa passes", StatusSubmitted, StatusCompleted, RoleSystem, true},
+		{"qa fails", StatusSubmitted, StatusFailedQA, RoleSystem, true},
+		{"agent cannot complete", StatusSubmitted, StatusCompleted, RoleAgent, false},
+		{"ops re-surveys failed job", StatusFailedQA, StatusPendingAssignment, RoleOps, true},
+		{"ops cancels in progress", StatusInProgress, StatusCancelled, RoleOps, true},
+		{"agent cannot cancel", StatusAssigned, StatusCancelled, RoleAgent, false},
+		{"cannot cancel submitted", StatusSubmitted, StatusCancelled, RoleOps, false},
+		{"cascade exhausted", StatusOffered, StatusUnassigned, RoleSystem, true},
+		{"completed is final", StatusCompleted, StatusPendingAssignment, RoleAdmin, false},
+		{"cancelled is final", StatusCancelled, StatusAssigned, RoleOps, false},
+		{"unknown status", "survey_in_progress", StatusSubmitted, RoleAgent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTransition(tt.from, tt.to, tt.role)
+			if tt.legal && err != nil {
+				t.Errorf("checkTransition(%s, %s, %s) = %v, want nil", tt.from, tt.to, tt.role, err)
+			}
+			if !tt.legal {
+				appErr, ok := platform.AsAppError(err)
+				if !ok || appErr.Code != platform.CodeConflict {
+					t.Errorf("checkTransition(%s, %s, %s) = %v, want conflict", tt.from, tt.to, tt.role, err)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitionTableStatuses(t *testing.T) {
+	known := map[string]bool{
+		StatusPendingAssignment: true, StatusOffered: true, StatusAssigned: true,
+		StatusEnRoute: true, StatusOnSite: true, StatusInProgress: true,
+		StatusSubmitted: true, StatusCompleted: true, StatusFailedQA: true,
+		StatusCancelled: true, StatusUnassigned: true,
+	}
+	for from, targets := range jobTransitions {
+		if !known[from] {
+			t.Errorf("unknown source status %q", from)
+		}
+		for to, roles := range targets {
+			if !known[to] {
+				t.Errorf("unknown target status %q", to)
+			}
+			if len(roles) == 0 {
+				t.Errorf("%s → %s has no roles", from, to)
+			}
+		}
+	}
+}
+
+func TestWithdrawsOffers(t *testing.T) {
+	for _, s := range []string{StatusAssigned, StatusUnassigned, StatusPendingAssignment, StatusCancelled} {
+		if !withdrawsOffers(s) {
+			t.Errorf("withdrawsOffers(%s) = false, want true", s)
+		}
+	}
+	for _, s := range []string{StatusOffered, StatusOnSite, StatusSubmitted, StatusCompleted} {
+		if withdrawsOffers(s) {
+			t.Errorf("withdrawsOffers(%s) = true, want false", s)
+		}
+	}
+}
+
+func TestOpsActor(t *testing.T) {
+	if a := opsActor(&auth.UserContext{KeycloakID: "kc", Roles: []string{"ops"}}); a.Role != RoleOps || a.ID != "kc" {
+		t.Errorf("ops user actor = %+v", a)
+	}
+	if a := opsActor(&auth.UserContext{KeycloakID: "kc", Roles: []string{"ops", "admin"}}); a.Role != RoleAdmin {
+		t.Errorf("admin user actor = %+v, want admin role", a)
+	}
+}
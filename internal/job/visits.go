@@ -0,0 +1,121 @@
+package job
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// onDemandDeadline is how long after a request an on-demand visit is due.
+	onDemandDeadline = 48 * time.Hour
+
+	// maxIdempotencyKeyLen matches survey_jobs.idempotency_key.
+	maxIdempotencyKeyLen = 100
+)
+
+// VisitHandler handles landowner requests for on-demand visits.
+type VisitHandler struct {
+	jobRepo  *Repository
+	pricer   *Pricer
+	authRepo *auth.Repository
+	logger   *slog.Logger
+}
+
+// NewVisitHandler creates a visit request handler.
+func NewVisitHandler(jobRepo *Repository, pricer *Pricer, authRepo *auth.Repository, logger *slog.Logger) *VisitHandler {
+	return &VisitHandler{
+		jobRepo:  jobRepo,
+		pricer:   pricer,
+		authRepo: authRepo,
+		logger:   logger,
+	}
+}
+
+// VisitResponse is the API representation of an on-demand visit request.
+type VisitResponse struct {
+	Job           JobResponse     `json:"job"`
+	CoveredByPlan bool            `json:"covered_by_plan"`
+	Charge        *ChargeResponse `json:"charge,omitempty"`
+}
+
+// ChargeResponse is the API representation of a pending charge.
+type ChargeResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Amount float64   `json:"amount"`
+	Status *string   `json:"status"`
+}
+
+// RequestVisit handles POST /v1/parcels/{parcelId}/visits. Clients may send
+// an Idempotency-Key header; repeating a key returns the original job.
+func (h *VisitHandler) RequestVisit(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	parcelID, err := uuid.Parse(chi.URLParam(r, "parcelId"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid parcel ID"))
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLen {
+		platform.HandleError(w, platform.NewBadRequest("idempotency key too long"))
+		return
+	}
+
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	// Record job.created with the job so the dispatcher hears about every visit
+	var visit *OnDemandVisit
+	err = h.jobRepo.InTx(r.Context(), func(tx pgx.Tx) error {
+		var err error
+		visit, err = h.jobRepo.WithTx(tx).RequestVisit(r.Context(), h.pricer, parcelID, user.ID, key, time.Now().Add(onDemandDeadline))
+		if err != nil || visit.Replayed {
+			return err
+		}
+		return platform.PublishTx(r.Context(), tx, platform.NewEvent(jobCreated(visit.Job)))
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	resp := VisitResponse{
+		Job:           jobResponse(visit.Job),
+		CoveredByPlan: visit.Charge == nil,
+	}
+	if visit.Charge != nil {
+		resp.Charge = &ChargeResponse{
+			ID:     visit.Charge.ID,
+			Amount: numericToFloat64(visit.Charge.Amount),
+			Status: visit.Charge.Status,
+		}
+	}
+
+	if visit.Replayed {
+		platform.JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	h.logger.Info("on-demand visit requested",
+		"job_id", visit.Job.ID,
+		"parcel_id", parcelID,
+		"covered_by_plan", resp.CoveredByPlan,
+	)
+
+	platform.JSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,57 @@
+package job
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func landownerContext() context.Context {
+	return auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-landowner-id",
+		Roles:      []string{"landowner"},
+	})
+}
+
+func visitRouter() chi.Router {
+	h := &VisitHandler{}
+	r := chi.NewRouter()
+	r.Post("/parcels/{parcelId}/visits", h.RequestVisit)
+	return r
+}
+
+func TestRequestVisit_NoAuth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/parcels/00000000-0000-0000-0000-000000000001/visits", nil)
+	w := httptest.NewRecorder()
+	visitRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestRequestVisit_InvalidID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/parcels/not-a-uuid/visits", nil).WithContext(landownerContext())
+	w := httptest.NewRecorder()
+	visitRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestRequestVisit_IdempotencyKeyTooLong(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/parcels/00000000-0000-0000-0000-000000000001/visits", nil).WithContext(landownerContext())
+	req.Header.Set("Idempotency-Key", strings.Repeat("k", maxIdempotencyKeyLen+1))
+	w := httptest.NewRecorder()
+	visitRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 // Repository handles parcel persistence.
 type Repository struct {
+	platform.TxScope
+
 	q  *sqlc.Queries
 	db *pgxpool.Pool
 }
@@ -20,8 +22,19 @@ type Repository struct {
 // NewRepository creates a land repository.
 func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{
-		q:  sqlc.New(db),
-		db: db,
+		TxScope: platform.NewTxScope(db),
+		q:       sqlc.New(db),
+		db:      db,
+	}
+}
+
+// WithTx returns a repository whose queries run in tx. Transactions it
+// begins nest in tx with a savepoint.
+func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+	return &Repository{
+		TxScope: r.TxScope.Bind(tx),
+		q:       r.q.WithTx(tx),
+		db:      r.db,
 	}
 }
 
@@ -92,9 +105,13 @@ func (r *Repository) UpdateParcelBoundary(ctx context.Context, id uuid.UUID, geo
 	return nil
 }
 
-// FindParcelsNeedingSurvey returns active parcels with no in-flight survey jobs.
-func (r *Repository) FindParcelsNeedingSurvey(ctx context.Context, limit int32) ([]sqlc.Parcel, error) {
-	parcels, err := r.q.FindParcelsNeedingSurvey(ctx, limit)
+// FindParcelsNeedingSurvey returns active parcels with no in-flight survey jobs
+// and an ID after the given one, with their subscription and survey history.
+func (r *Repository) FindParcelsNeedingSurvey(ctx context.Context, after uuid.UUID, limit int32) ([]sqlc.FindParcelsNeedingSurveyRow, error) {
+	parcels, err := r.q.FindParcelsNeedingSurvey(ctx, sqlc.FindParcelsNeedingSurveyParams{
+		After: after,
+		Lim:   limit,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("finding parcels needing survey: %w", err)
 	}
@@ -5,8 +5,10 @@ import (
 	"log/slog"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/terrascore/api/db/sqlc"
 	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/events"
 	"github.com/terrascore/api/internal/platform"
 )
 
@@ -14,16 +16,14 @@ import (
 type Service struct {
 	repo     *Repository
 	authRepo *auth.Repository
-	eventBus *platform.EventBus
 	logger   *slog.Logger
 }
 
 // NewService creates a land service.
-func NewService(repo *Repository, authRepo *auth.Repository, eventBus *platform.EventBus, logger *slog.Logger) *Service {
+func NewService(repo *Repository, authRepo *auth.Repository, logger *slog.Logger) *Service {
 	return &Service{
 		repo:     repo,
 		authRepo: authRepo,
-		eventBus: eventBus,
 		logger:   logger,
 	}
 }
@@ -122,17 +122,28 @@ func (s *Service) CreateParcel(ctx context.Context, userCtx *auth.UserContext, r
 		params.TitleDeedS3Key = &req.TitleDeedS3Key
 	}
 
-	parcel, err := s.repo.CreateParcel(ctx, params)
+	// Record the event with the parcel so neither is lost without the other
+	var parcel *sqlc.Parcel
+	err = s.repo.InTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if parcel, err = s.repo.WithTx(tx).CreateParcel(ctx, params); err != nil {
+			return err
+		}
+		return platform.PublishTx(ctx, tx, platform.NewEvent(events.ParcelRegistered{
+			ParcelID:          parcel.ID,
+			UserID:            parcel.UserID,
+			District:          parcel.District,
+			State:             parcel.State,
+			StateCode:         parcel.StateCode,
+			AreaSqm:           parcel.AreaSqm,
+			RegisteredAreaSqm: parcel.RegisteredAreaSqm,
+			LandType:          parcel.LandType,
+		}))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Publish event
-	s.eventBus.Publish(platform.Event{
-		Type:    "parcel.registered",
-		Payload: parcel,
-	})
-
 	s.logger.Info("parcel created", "parcel_id", parcel.ID, "user_id", user.ID)
 
 	return &ParcelResponse{
@@ -0,0 +1,187 @@
+package ledger
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/agent"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// defaultStatementDays is the statement range when no dates are given.
+	defaultStatementDays = 30
+
+	// maxAdjustment caps a single manual adjustment, in INR.
+	maxAdjustment = 100000
+)
+
+// ist is the timezone statement dates are given in.
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+// Handler handles wallet ledger HTTP endpoints.
+type Handler struct {
+	repo      *Repository
+	agentRepo *agent.Repository
+	logger    *slog.Logger
+}
+
+// NewHandler creates a ledger handler.
+func NewHandler(repo *Repository, agentRepo *agent.Repository, logger *slog.Logger) *Handler {
+	return &Handler{
+		repo:      repo,
+		agentRepo: agentRepo,
+		logger:    logger,
+	}
+}
+
+// Routes returns the ops ledger router.
+func (h *Handler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole("admin", "ops"))
+		r.Get("/reconciliation", h.Reconciliation)
+		r.Post("/adjustments", h.Adjust)
+	})
+
+	return r
+}
+
+// Statement handles GET /v1/agents/me/statement?from=YYYY-MM-DD&to=YYYY-MM-DD.
+// Both dates are inclusive; the default is the last 30 days.
+func (h *Handler) Statement(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	from, to, err := statementRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), time.Now())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	ag, err := h.agentRepo.GetAgentByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	statement, total, err := h.repo.Statement(r.Context(), ag.ID, from, to.AddDate(0, 0, 1), int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	statement.From = from.Format(time.DateOnly)
+	statement.To = to.Format(time.DateOnly)
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, statement, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// Reconciliation handles GET /v1/admin/ledger/reconciliation.
+func (h *Handler) Reconciliation(w http.ResponseWriter, r *http.Request) {
+	report, err := h.repo.Reconcile(r.Context())
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	if !report.OK {
+		h.logger.Warn("ledger reconciliation found drift",
+			"wallets", len(report.WalletDrift),
+			"unbalanced_transactions", len(report.UnbalancedTransactions),
+			"in_transit_drift", report.PayoutsInTransit.Drift,
+		)
+	}
+
+	platform.JSON(w, http.StatusOK, report)
+}
+
+// Adjust handles POST /v1/admin/ledger/adjustments.
+func (h *Handler) Adjust(w http.ResponseWriter, r *http.Request) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	var req AdjustmentRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	resp, err := h.repo.Adjust(r.Context(), req.AgentID, req.Amount, strings.TrimSpace(req.Reason), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("wallet adjusted",
+		"agent_id", req.AgentID,
+		"amount", req.Amount,
+		"actor", userCtx.KeycloakID,
+	)
+	platform.JSON(w, http.StatusCreated, resp)
+}
+
+// validate checks an adjustment request.
+func (req AdjustmentRequest) validate() error {
+	if req.AgentID == uuid.Nil {
+		return platform.NewValidation("agent_id is required")
+	}
+	if paise(req.Amount) == 0 {
+		return platform.NewValidation("amount must be non-zero")
+	}
+	if req.Amount > maxAdjustment || req.Amount < -maxAdjustment {
+		return platform.NewValidation("amount exceeds the adjustment limit")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return platform.NewValidation("reason is required")
+	}
+	return nil
+}
+
+// statementRange parses a statement's inclusive date range in IST. Either end
+// may be omitted.
+func statementRange(fromStr, toStr string, now time.Time) (from, to time.Time, err error) {
+	now = now.In(ist)
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ist)
+	if toStr != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, toStr, ist); err != nil {
+			return from, to, platform.NewBadRequest("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	from = to.AddDate(0, 0, -(defaultStatementDays - 1))
+	if fromStr != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, fromStr, ist); err != nil {
+			return from, to, platform.NewBadRequest("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if from.After(to) {
+		return from, to, platform.NewBadRequest("from must not be after to")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func opsContext() context.Context {
+	return auth.SetUser(context.Background(), &auth.UserContext{
+		KeycloakID: "test-ops-id",
+		Roles:      []string{"ops"},
+	})
+}
+
+func ledgerRouter() chi.Router {
+	h := &Handler{}
+	r := chi.NewRouter()
+	r.Get("/agents/me/statement", h.Statement)
+	r.Post("/admin/ledger/adjustments", h.Adjust)
+	return r
+}
+
+func TestStatement_NoAuth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/agents/me/statement", nil)
+	w := httptest.NewRecorder()
+	ledgerRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestAdjust_NoAuth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/admin/ledger/adjustments", nil)
+	w := httptest.NewRecorder()
+	ledgerRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestAdjust_Validation(t *testing.T) {
+	agentID := uuid.New()
+
+	tests := []struct {
+		name string
+		body AdjustmentRequest
+	}{
+		{"missing agent", AdjustmentRequest{Amount: 100, Reason: "bonus"}},
+		{"zero amount", AdjustmentRequest{AgentID: agentID, Amount: 0.001, Reason: "bonus"}},
+		{"over limit", AdjustmentRequest{AgentID: agentID, Amount: -maxAdjustment - 1, Reason: "clawback"}},
+		{"blank reason", AdjustmentRequest{AgentID: agentID, Amount: 100, Reason: "  "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, "/admin/ledger/adjustments", bytes.NewReader(body)).WithContext(opsContext())
+			w := httptest.NewRecorder()
+			ledgerRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("expected 422, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatementRange(t *testing.T) {
+	now := time.Date(2026, 10, 16, 1, 0, 0, 0, ist)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{name: "defaults to last 30 days", wantFrom: "2026-09-17", wantTo: "2026-10-16"},
+		{name: "from only", from: "2026-10-01", wantFrom: "2026-10-01", wantTo: "2026-10-16"},
+		{name: "both", from: "2026-04-01", to: "2026-06-30", wantFrom: "2026-04-01", wantTo: "2026-06-30"},
+		{name: "to only", to: "2026-06-30", wantFrom: "2026-06-01", wantTo: "2026-06-30"},
+		{name: "single day", from: "2026-10-01", to: "2026-10-01", wantFrom: "2026-10-01", wantTo: "2026-10-01"},
+		{name: "reversed", from: "2026-10-02", to: "2026-10-01", wantErr: true},
+		{name: "bad date", from: "01/10/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := statementRange(tt.from, tt.to, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statementRange: %v", err)
+			}
+			if got := from.Format(time.DateOnly); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format(time.DateOnly); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
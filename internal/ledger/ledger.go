@@ -0,0 +1,291 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+)
+
+// Codes of the platform's own ledger accounts. Each agent also has a wallet
+// account, a liability: what the platform owes them.
+const (
+	AccountSurveyExpense     = "survey_expense"     // agent earnings for completed jobs
+	AccountCommissionRevenue = "commission_revenue" // platform share taken at settlement
+	AccountPenaltyRevenue    = "penalty_revenue"    // QA fraud penalties
+	AccountTDSPayable        = "tds_payable"        // tax withheld from payouts
+	AccountPayoutsInTransit  = "payouts_in_transit" // settled but not yet confirmed paid
+	AccountBank              = "bank"               // money paid out
+	AccountAdjustments       = "adjustments"        // manual corrections by ops
+)
+
+// Transaction kinds. A kind and reference ID identify a transaction, so each
+// event is recorded once.
+const (
+	KindJobEarning     = "job_earning"     // reference: job
+	KindPayout         = "payout"          // reference: payout
+	KindPayoutReversal = "payout_reversal" // reference: payout
+	KindPayoutPaid     = "payout_paid"     // reference: payout
+	KindFraudPenalty   = "fraud_penalty"   // reference: job
+	KindAdjustment     = "adjustment"      // reference: the adjustment itself
+	KindOpeningBalance = "opening_balance" // reference: agent
+)
+
+// Entry directions.
+const (
+	Debit  = "debit"
+	Credit = "credit"
+)
+
+// createdBySystem records transactions posted by the platform itself.
+const createdBySystem = "system"
+
+// Line is one entry of a transaction, against a system account or, when
+// Account is empty, the agent's wallet.
+type Line struct {
+	Account   string
+	AgentID   uuid.UUID
+	Direction string
+	Amount    float64 // INR, positive
+}
+
+// Transaction is a set of entries recording one event. Its debits and
+// credits must balance.
+type Transaction struct {
+	Kind        string
+	ReferenceID uuid.UUID
+	Description string
+	CreatedBy   string
+	Lines       []Line
+}
+
+// Reversal returns a transaction undoing t, recorded under another kind.
+func (t Transaction) Reversal(kind, description string) Transaction {
+	r := Transaction{
+		Kind:        kind,
+		ReferenceID: t.ReferenceID,
+		Description: description,
+		CreatedBy:   t.CreatedBy,
+		Lines:       make([]Line, len(t.Lines)),
+	}
+	for i, l := range t.Lines {
+		l.Direction = opposite(l.Direction)
+		r.Lines[i] = l
+	}
+	return r
+}
+
+// validate checks every line and that debits equal credits to the paisa.
+func (t Transaction) validate() error {
+	var debits, credits int64
+	for _, l := range t.Lines {
+		if l.Amount < 0 {
+			return fmt.Errorf("ledger %s: negative amount %.2f", t.Kind, l.Amount)
+		}
+		switch l.Direction {
+		case Debit:
+			debits += paise(l.Amount)
+		case Credit:
+			credits += paise(l.Amount)
+		default:
+			return fmt.Errorf("ledger %s: invalid direction %q", t.Kind, l.Direction)
+		}
+	}
+	if debits != credits {
+		return fmt.Errorf("ledger %s: debits %d != credits %d paise", t.Kind, debits, credits)
+	}
+	return nil
+}
+
+// Post records a transaction and updates the wallet balance of each agent it
+// touches. It must run inside the caller's database transaction: the
+// balance check on ledger_entries is deferred to commit. Zero-amount lines
+// are skipped. It returns false without error if the transaction had already
+// been recorded or moves no money.
+func Post(ctx context.Context, q *sqlc.Queries, t Transaction) (bool, error) {
+	if err := t.validate(); err != nil {
+		return false, err
+	}
+
+	lines := make([]Line, 0, len(t.Lines))
+	for _, l := range t.Lines {
+		if paise(l.Amount) != 0 {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return false, nil
+	}
+
+	createdBy := t.CreatedBy
+	if createdBy == "" {
+		createdBy = createdBySystem
+	}
+	txn, err := q.CreateLedgerTransaction(ctx, sqlc.CreateLedgerTransactionParams{
+		Kind:        t.Kind,
+		ReferenceID: t.ReferenceID,
+		Description: t.Description,
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil // already recorded
+		}
+		return false, fmt.Errorf("creating ledger transaction: %w", err)
+	}
+
+	for _, l := range lines {
+		accountID, err := accountID(ctx, q, l)
+		if err != nil {
+			return false, err
+		}
+		if err := q.CreateLedgerEntry(ctx, sqlc.CreateLedgerEntryParams{
+			TransactionID: txn.ID,
+			AccountID:     accountID,
+			Direction:     l.Direction,
+			Amount:        inr(l.Amount),
+		}); err != nil {
+			return false, fmt.Errorf("creating ledger entry: %w", err)
+		}
+
+		if l.Account == "" {
+			delta := l.Amount
+			if l.Direction == Debit {
+				delta = -delta
+			}
+			if err := q.AddToWalletBalance(ctx, sqlc.AddToWalletBalanceParams{
+				ID:    l.AgentID,
+				Delta: inr(delta),
+			}); err != nil {
+				return false, fmt.Errorf("updating wallet balance: %w", err)
+			}
+		}
+	}
+	return true, nil
+}
+
+// accountID resolves a line's account, opening the agent's wallet account on
+// first use.
+func accountID(ctx context.Context, q *sqlc.Queries, l Line) (uuid.UUID, error) {
+	if l.Account == "" {
+		account, err := q.EnsureAgentLedgerAccount(ctx, pgtype.UUID{Bytes: l.AgentID, Valid: true})
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("opening wallet account: %w", err)
+		}
+		return account.ID, nil
+	}
+	account, err := q.GetLedgerAccountByCode(ctx, l.Account)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("getting ledger account %s: %w", l.Account, err)
+	}
+	return account.ID, nil
+}
+
+// JobEarning credits an agent's wallet with a completed job's payout.
+func JobEarning(agentID, jobID uuid.UUID, amount float64) Transaction {
+	return Transaction{
+		Kind:        KindJobEarning,
+		ReferenceID: jobID,
+		Description: "Survey job earnings",
+		Lines: []Line{
+			{Account: AccountSurveyExpense, Direction: Debit, Amount: amount},
+			{AgentID: agentID, Direction: Credit, Amount: amount},
+		},
+	}
+}
+
+// Payout debits an agent's wallet with a settled payout's gross amount,
+// split into commission, TDS and the net amount to be transferred.
+func Payout(agentID, payoutID uuid.UUID, gross, commission, tds, net float64) Transaction {
+	return Transaction{
+		Kind:        KindPayout,
+		ReferenceID: payoutID,
+		Description: "Weekly payout",
+		Lines: []Line{
+			{AgentID: agentID, Direction: Debit, Amount: gross},
+			{Account: AccountCommissionRevenue, Direction: Credit, Amount: commission},
+			{Account: AccountTDSPayable, Direction: Credit, Amount: tds},
+			{Account: AccountPayoutsInTransit, Direction: Credit, Amount: net},
+		},
+	}
+}
+
+// PayoutPaid records that a payout's net amount reached the agent.
+func PayoutPaid(payoutID uuid.UUID, net float64) Transaction {
+	return Transaction{
+		Kind:        KindPayoutPaid,
+		ReferenceID: payoutID,
+		Description: "Payout transferred",
+		Lines: []Line{
+			{Account: AccountPayoutsInTransit, Direction: Debit, Amount: net},
+			{Account: AccountBank, Direction: Credit, Amount: net},
+		},
+	}
+}
+
+// FraudPenalty debits an agent's wallet for a survey QA rejected as fraudulent.
+func FraudPenalty(agentID, jobID uuid.UUID, amount float64) Transaction {
+	return Transaction{
+		Kind:        KindFraudPenalty,
+		ReferenceID: jobID,
+		Description: "Penalty: survey rejected for location fraud",
+		Lines: []Line{
+			{AgentID: agentID, Direction: Debit, Amount: amount},
+			{Account: AccountPenaltyRevenue, Direction: Credit, Amount: amount},
+		},
+	}
+}
+
+// Adjustment credits (positive amount) or debits (negative) an agent's wallet.
+func Adjustment(agentID uuid.UUID, amount float64, reason, createdBy string) Transaction {
+	wallet, counter := Credit, Debit
+	if amount < 0 {
+		wallet, counter = Debit, Credit
+		amount = -amount
+	}
+	return Transaction{
+		Kind:        KindAdjustment,
+		ReferenceID: uuid.New(),
+		Description: reason,
+		CreatedBy:   createdBy,
+		Lines: []Line{
+			{AgentID: agentID, Direction: wallet, Amount: amount},
+			{Account: AccountAdjustments, Direction: counter, Amount: amount},
+		},
+	}
+}
+
+func opposite(direction string) string {
+	if direction == Debit {
+		return Credit
+	}
+	return Debit
+}
+
+// paise converts an INR amount to whole paise.
+func paise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// inr converts an amount to a NUMERIC(12,2) value.
+func inr(amount float64) pgtype.Numeric {
+	n := pgtype.Numeric{}
+	n.Scan(fmt.Sprintf("%.2f", amount))
+	return n
+}
+
+// numericToFloat64 converts pgtype.Numeric to float64 (defaults to 0).
+func numericToFloat64(n pgtype.Numeric) float64 {
+	if !n.Valid || n.Int == nil {
+		return 0
+	}
+	f, _ := n.Float64Value()
+	if !f.Valid {
+		return 0
+	}
+	return math.Round(f.Float64*100) / 100
+}
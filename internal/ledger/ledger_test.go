@@ -0,0 +1,136 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionsBalance(t *testing.T) {
+	agentID, ref := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		txn  Transaction
+	}{
+		{"job earning", JobEarning(agentID, ref, 812)},
+		{"payout", Payout(agentID, ref, 10000, 1500, 85, 8415)},
+		{"payout without TDS", Payout(agentID, ref, 333.33, 50, 0, 283.33)},
+		{"payout paid", PayoutPaid(ref, 8415)},
+		{"fraud penalty", FraudPenalty(agentID, ref, 500)},
+		{"credit adjustment", Adjustment(agentID, 250, "missed bonus", "ops-1")},
+		{"debit adjustment", Adjustment(agentID, -99.99, "duplicate credit", "ops-1")},
+		{"payout reversal", Payout(agentID, ref, 10000, 1500, 85, 8415).Reversal(KindPayoutReversal, "failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.txn.validate(); err != nil {
+				t.Errorf("validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	agentID := uuid.New()
+
+	tests := []struct {
+		name  string
+		lines []Line
+	}{
+		{
+			name: "unbalanced",
+			lines: []Line{
+				{AgentID: agentID, Direction: Credit, Amount: 100},
+				{Account: AccountSurveyExpense, Direction: Debit, Amount: 99.99},
+			},
+		},
+		{
+			name: "negative amount",
+			lines: []Line{
+				{AgentID: agentID, Direction: Credit, Amount: -100},
+				{Account: AccountSurveyExpense, Direction: Debit, Amount: -100},
+			},
+		},
+		{
+			name: "invalid direction",
+			lines: []Line{
+				{AgentID: agentID, Direction: "sideways", Amount: 100},
+				{Account: AccountSurveyExpense, Direction: Debit, Amount: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txn := Transaction{Kind: KindAdjustment, ReferenceID: uuid.New(), Lines: tt.lines}
+			if err := txn.validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+// walletDelta is a transaction's net effect on an agent's wallet.
+func walletDelta(txn Transaction, agentID uuid.UUID) float64 {
+	var delta float64
+	for _, l := range txn.Lines {
+		if l.Account != "" || l.AgentID != agentID {
+			continue
+		}
+		if l.Direction == Credit {
+			delta += l.Amount
+		} else {
+			delta -= l.Amount
+		}
+	}
+	return delta
+}
+
+func TestWalletEffects(t *testing.T) {
+	agentID, ref := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		txn  Transaction
+		want float64
+	}{
+		{"job earning credits", JobEarning(agentID, ref, 812), 812},
+		{"payout debits gross", Payout(agentID, ref, 10000, 1500, 85, 8415), -10000},
+		{"reversal credits gross back", Payout(agentID, ref, 10000, 1500, 85, 8415).Reversal(KindPayoutReversal, ""), 10000},
+		{"payout paid leaves wallet alone", PayoutPaid(ref, 8415), 0},
+		{"fraud penalty debits", FraudPenalty(agentID, ref, 500), -500},
+		{"positive adjustment credits", Adjustment(agentID, 250, "bonus", "ops"), 250},
+		{"negative adjustment debits", Adjustment(agentID, -250, "clawback", "ops"), -250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walletDelta(tt.txn, agentID); got != tt.want {
+				t.Errorf("wallet delta = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReversal(t *testing.T) {
+	agentID, ref := uuid.New(), uuid.New()
+	original := Payout(agentID, ref, 10000, 1500, 85, 8415)
+
+	r := original.Reversal(KindPayoutReversal, "Payout failed")
+	if r.Kind != KindPayoutReversal || r.ReferenceID != ref {
+		t.Errorf("reversal = %s/%s, want %s/%s", r.Kind, r.ReferenceID, KindPayoutReversal, ref)
+	}
+	for i, l := range r.Lines {
+		if l.Direction == original.Lines[i].Direction {
+			t.Errorf("line %d direction not flipped: %s", i, l.Direction)
+		}
+		if l.Amount != original.Lines[i].Amount {
+			t.Errorf("line %d amount = %v, want %v", i, l.Amount, original.Lines[i].Amount)
+		}
+	}
+	if original.Lines[0].Direction != Debit {
+		t.Error("Reversal modified the original transaction")
+	}
+}
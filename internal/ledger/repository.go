@@ -0,0 +1,241 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Repository handles ledger persistence.
+type Repository struct {
+	q  *sqlc.Queries
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a ledger repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		q:  sqlc.New(db),
+		db: db,
+	}
+}
+
+// Post records a transaction in its own database transaction. See Post.
+func (r *Repository) Post(ctx context.Context, t Transaction) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("beginning ledger transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	posted, err := Post(ctx, r.q.WithTx(tx), t)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("committing ledger transaction: %w", err)
+	}
+	return posted, nil
+}
+
+// PenalizeFraud debits, in tx, the wallet of the agent who surveyed a job QA
+// rejected as fraudulent. A job is penalized at most once.
+func (r *Repository) PenalizeFraud(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, amount float64) error {
+	q := r.q.WithTx(tx)
+	job, err := q.GetSurveyJobByID(ctx, jobID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return platform.NewNotFound("job not found")
+		}
+		return fmt.Errorf("getting job: %w", err)
+	}
+	if !job.AssignedAgentID.Valid {
+		return nil
+	}
+	_, err = Post(ctx, q, FraudPenalty(uuid.UUID(job.AssignedAgentID.Bytes), jobID, amount))
+	return err
+}
+
+// Adjust posts a manual adjustment to an agent's wallet and returns the
+// agent's new wallet balance.
+func (r *Repository) Adjust(ctx context.Context, agentID uuid.UUID, amount float64, reason, createdBy string) (*AdjustmentResponse, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning adjustment transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	if _, err := q.GetAgentByID(ctx, agentID); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, platform.NewNotFound("agent not found")
+		}
+		return nil, fmt.Errorf("getting agent: %w", err)
+	}
+
+	t := Adjustment(agentID, amount, reason, createdBy)
+	if _, err := Post(ctx, q, t); err != nil {
+		return nil, err
+	}
+
+	ag, err := q.GetAgentByID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting agent: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing adjustment: %w", err)
+	}
+
+	return &AdjustmentResponse{
+		ReferenceID:   t.ReferenceID,
+		AgentID:       agentID,
+		Amount:        amount,
+		WalletBalance: numericToFloat64(ag.WalletBalance),
+	}, nil
+}
+
+// Statement returns a page of an agent's wallet entries posted in [from, to)
+// along with the balances at either end, and the total number of entries in
+// the range.
+func (r *Repository) Statement(ctx context.Context, agentID uuid.UUID, from, to time.Time, limit, offset int32) (*Statement, int64, error) {
+	statement := &Statement{Entries: []StatementEntry{}}
+
+	account, err := r.q.GetAgentLedgerAccount(ctx, pgtype.UUID{Bytes: agentID, Valid: true})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return statement, 0, nil // no wallet activity yet
+		}
+		return nil, 0, fmt.Errorf("getting wallet account: %w", err)
+	}
+
+	fromTime := pgtype.Timestamptz{Time: from, Valid: true}
+	toTime := pgtype.Timestamptz{Time: to, Valid: true}
+
+	opening, err := r.q.GetLedgerAccountBalance(ctx, sqlc.GetLedgerAccountBalanceParams{
+		AccountID: account.ID,
+		Before:    fromTime,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting opening balance: %w", err)
+	}
+	closing, err := r.q.GetLedgerAccountBalance(ctx, sqlc.GetLedgerAccountBalanceParams{
+		AccountID: account.ID,
+		Before:    toTime,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting closing balance: %w", err)
+	}
+	statement.OpeningBalance = numericToFloat64(opening)
+	statement.ClosingBalance = numericToFloat64(closing)
+
+	total, err := r.q.CountLedgerEntries(ctx, sqlc.CountLedgerEntriesParams{
+		AccountID: account.ID,
+		FromTime:  fromTime,
+		ToTime:    toTime,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting statement entries: %w", err)
+	}
+
+	rows, err := r.q.ListLedgerStatement(ctx, sqlc.ListLedgerStatementParams{
+		AccountID: account.ID,
+		FromTime:  fromTime,
+		ToTime:    toTime,
+		Lim:       limit,
+		Off:       offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing statement entries: %w", err)
+	}
+	for _, row := range rows {
+		statement.Entries = append(statement.Entries, StatementEntry{
+			ID:            row.ID,
+			TransactionID: row.TransactionID,
+			Kind:          row.Kind,
+			ReferenceID:   row.ReferenceID,
+			Description:   row.Description,
+			Direction:     row.Direction,
+			Amount:        numericToFloat64(row.Amount),
+			BalanceAfter:  statement.OpeningBalance + numericToFloat64(row.BalanceChange),
+			CreatedAt:     row.CreatedAt.Time,
+		})
+	}
+	return statement, total, nil
+}
+
+// Reconcile checks wallet balances, transaction balance and payouts in
+// transit against the ledger.
+func (r *Repository) Reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{
+		GeneratedAt:            time.Now(),
+		WalletDrift:            []WalletDrift{},
+		UnbalancedTransactions: []UnbalancedTransaction{},
+	}
+
+	drifted, err := r.q.ListWalletDrift(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing wallet drift: %w", err)
+	}
+	for _, d := range drifted {
+		wallet, ledger := numericToFloat64(d.WalletBalance), numericToFloat64(d.LedgerBalance)
+		report.WalletDrift = append(report.WalletDrift, WalletDrift{
+			AgentID:       d.AgentID,
+			AgentName:     d.FullName,
+			WalletBalance: wallet,
+			LedgerBalance: ledger,
+			Drift:         round(wallet - ledger),
+		})
+	}
+
+	unbalanced, err := r.q.ListUnbalancedLedgerTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing unbalanced transactions: %w", err)
+	}
+	for _, u := range unbalanced {
+		report.UnbalancedTransactions = append(report.UnbalancedTransactions, UnbalancedTransaction{
+			ID:          u.ID,
+			Kind:        u.Kind,
+			ReferenceID: u.ReferenceID,
+			Imbalance:   numericToFloat64(u.Imbalance),
+		})
+	}
+
+	accounts, err := r.q.ListSystemAccountBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing account balances: %w", err)
+	}
+	for _, a := range accounts {
+		balance := numericToFloat64(a.DebitBalance)
+		if a.Type != "asset" && a.Type != "expense" {
+			balance = -balance
+		}
+		report.Accounts = append(report.Accounts, AccountBalance{Code: a.Code, Type: a.Type, Balance: balance})
+		if a.Code == AccountPayoutsInTransit {
+			report.PayoutsInTransit.LedgerBalance = balance
+		}
+	}
+
+	open, err := r.q.GetInTransitPayoutTotal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting open payout total: %w", err)
+	}
+	report.PayoutsInTransit.OpenPayouts = numericToFloat64(open)
+	report.PayoutsInTransit.Drift = round(report.PayoutsInTransit.LedgerBalance - report.PayoutsInTransit.OpenPayouts)
+
+	report.OK = len(report.WalletDrift) == 0 &&
+		len(report.UnbalancedTransactions) == 0 &&
+		report.PayoutsInTransit.Drift == 0
+	return report, nil
+}
+
+// round rounds an amount to the nearest paisa.
+func round(amount float64) float64 {
+	return float64(paise(amount)) / 100
+}
@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdjustmentRequest is the body of POST /v1/admin/ledger/adjustments.
+type AdjustmentRequest struct {
+	AgentID uuid.UUID `json:"agent_id"`
+	Amount  float64   `json:"amount"` // INR; negative debits the wallet
+	Reason  string    `json:"reason"`
+}
+
+// AdjustmentResponse reports an adjustment and the agent's resulting balance.
+type AdjustmentResponse struct {
+	ReferenceID   uuid.UUID `json:"reference_id"`
+	AgentID       uuid.UUID `json:"agent_id"`
+	Amount        float64   `json:"amount"`
+	WalletBalance float64   `json:"wallet_balance"`
+}
+
+// Statement is an agent's wallet activity between two dates.
+type Statement struct {
+	From           string           `json:"from"` // YYYY-MM-DD, inclusive
+	To             string           `json:"to"`   // YYYY-MM-DD, inclusive
+	OpeningBalance float64          `json:"opening_balance"`
+	ClosingBalance float64          `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"` // newest first
+}
+
+// StatementEntry is one movement of an agent's wallet.
+type StatementEntry struct {
+	ID            int64     `json:"id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Kind          string    `json:"kind"`
+	ReferenceID   uuid.UUID `json:"reference_id"`
+	Description   string    `json:"description"`
+	Direction     string    `json:"direction"` // credit adds to the wallet
+	Amount        float64   `json:"amount"`
+	BalanceAfter  float64   `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReconciliationReport checks the ledger against itself and against the
+// balances derived from it. OK is false if any check found drift.
+type ReconciliationReport struct {
+	GeneratedAt            time.Time               `json:"generated_at"`
+	OK                     bool                    `json:"ok"`
+	WalletDrift            []WalletDrift           `json:"wallet_drift"`
+	UnbalancedTransactions []UnbalancedTransaction `json:"unbalanced_transactions"`
+	PayoutsInTransit       InTransitCheck          `json:"payouts_in_transit"`
+	Accounts               []AccountBalance        `json:"accounts"`
+}
+
+// WalletDrift is an agent whose wallet_balance differs from their ledger balance.
+type WalletDrift struct {
+	AgentID       uuid.UUID `json:"agent_id"`
+	AgentName     string    `json:"agent_name"`
+	WalletBalance float64   `json:"wallet_balance"`
+	LedgerBalance float64   `json:"ledger_balance"`
+	Drift         float64   `json:"drift"` // wallet_balance - ledger balance
+}
+
+// UnbalancedTransaction is a transaction whose debits and credits differ.
+type UnbalancedTransaction struct {
+	ID          uuid.UUID `json:"id"`
+	Kind        string    `json:"kind"`
+	ReferenceID uuid.UUID `json:"reference_id"`
+	Imbalance   float64   `json:"imbalance"` // debits - credits
+}
+
+// InTransitCheck compares the payouts-in-transit account with the net amount
+// of payouts not yet paid or failed.
+type InTransitCheck struct {
+	LedgerBalance float64 `json:"ledger_balance"`
+	OpenPayouts   float64 `json:"open_payouts"`
+	Drift         float64 `json:"drift"`
+}
+
+// AccountBalance is a system account's balance on its normal side: debits
+// less credits for assets and expenses, credits less debits otherwise.
+type AccountBalance struct {
+	Code    string  `json:"code"`
+	Type    string  `json:"type"`
+	Balance float64 `json:"balance"`
+}
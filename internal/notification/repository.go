@@ -34,6 +34,18 @@ func (r *Repository) CreateAlert(ctx context.Context, userID uuid.UUID, alertTyp
 	return &alert, nil
 }
 
+// opsRoles are the roles that handle operational alerts.
+var opsRoles = []string{"admin", "ops"}
+
+// ListOpsUsers returns the active users who receive operational alerts.
+func (r *Repository) ListOpsUsers(ctx context.Context) ([]sqlc.User, error) {
+	users, err := r.q.ListActiveUsersByRoles(ctx, opsRoles)
+	if err != nil {
+		return nil, fmt.Errorf("listing ops users: %w", err)
+	}
+	return users, nil
+}
+
 // ListAlerts returns paginated alerts for a user.
 func (r *Repository) ListAlerts(ctx context.Context, userID uuid.UUID, limit, offset int32) ([]sqlc.Alert, error) {
 	alerts, err := r.q.ListAlertsByUser(ctx, sqlc.ListAlertsByUserParams{
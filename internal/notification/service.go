@@ -7,11 +7,19 @@ import (
 	"log/slog"
 
 	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
 )
 
+// alertStore is the part of Repository the service needs.
+type alertStore interface {
+	CreateAlert(ctx context.Context, userID uuid.UUID, alertType, title string, body *string, data []byte) (*sqlc.Alert, error)
+	ListOpsUsers(ctx context.Context) ([]sqlc.User, error)
+}
+
 // Service handles notification dispatch across channels.
 type Service struct {
-	repo    *Repository
+	repo    alertStore
 	pusher  Pusher
 	emailer Emailer
 	sms     SMSSender
@@ -51,6 +59,14 @@ func (s *Service) Notify(ctx context.Context, eventType string, userID uuid.UUID
 			}
 		}
 
+	case "job.unassigned":
+		// Email + in-app, so ops notice without watching the queue
+		if to := data["email"]; to != "" {
+			if err := s.emailer.Send(ctx, to, title, body); err != nil {
+				s.logger.Error("failed to send email", "error", err)
+			}
+		}
+
 	case "survey.submitted", "qa.completed", "job.assigned":
 		// In-app only (already created above)
 
@@ -61,6 +77,36 @@ func (s *Service) Notify(ctx context.Context, eventType string, userID uuid.UUID
 	return nil
 }
 
+// HandleJobUnassigned is the EventBus handler for "job.unassigned". It alerts
+// every ops user that the dispatcher gave up on a job.
+func (s *Service) HandleJobUnassigned(ctx context.Context, e events.JobUnassigned) error {
+	users, err := s.repo.ListOpsUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		s.logger.Warn("no ops users to notify of unassigned job", "job_id", e.JobID)
+		return nil
+	}
+
+	title := "Job needs manual assignment"
+	body := fmt.Sprintf("Job %s could not be assigned: %s", e.JobID, e.Reason)
+	for _, u := range users {
+		data := map[string]string{
+			"job_id":    e.JobID.String(),
+			"parcel_id": e.ParcelID.String(),
+			"reason":    e.Reason,
+		}
+		if u.Email != nil {
+			data["email"] = *u.Email
+		}
+		if err := s.Notify(ctx, e.EventName(), u.ID, title, body, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // HandleTask is the TaskHandler for "notification.send".
 func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
 	var p NotificationPayload
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
+	"github.com/terrascore/api/internal/platform"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	ops    []sqlc.User
+	alerts []sqlc.Alert
+}
+
+func (f *fakeStore) CreateAlert(ctx context.Context, userID uuid.UUID, alertType, title string, body *string, data []byte) (*sqlc.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a := sqlc.Alert{ID: uuid.New(), UserID: userID, Type: alertType, Title: title, Body: body, Data: data}
+	f.alerts = append(f.alerts, a)
+	return &a, nil
+}
+
+func (f *fakeStore) ListOpsUsers(ctx context.Context) ([]sqlc.User, error) {
+	return f.ops, nil
+}
+
+func (f *fakeStore) alertCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.alerts)
+}
+
+type fakeEmailer struct {
+	mu sync.Mutex
+	to []string
+}
+
+func (f *fakeEmailer) Send(ctx context.Context, to, subject, htmlBody string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.to = append(f.to, to)
+	return nil
+}
+
+func TestHandleJobUnassigned_AlertsOps(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	email := "ops@example.com"
+	store := &fakeStore{ops: []sqlc.User{
+		{ID: uuid.New(), Role: "ops", Email: &email},
+		{ID: uuid.New(), Role: "admin"},
+	}}
+	emailer := &fakeEmailer{}
+	svc := &Service{repo: store, emailer: emailer, logger: logger}
+
+	eb := platform.NewEventBus(logger, 10)
+	platform.Subscribe(eb, svc.HandleJobUnassigned)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go eb.Start(ctx)
+
+	jobID := uuid.New()
+	eb.Publish(platform.NewEvent(events.JobUnassigned{JobID: jobID, ParcelID: uuid.New(), Reason: "no agents available"}))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for store.alertCount() < len(store.ops) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.alerts) != 2 {
+		t.Fatalf("alerts = %d, want one per ops user (2)", len(store.alerts))
+	}
+	for i, a := range store.alerts {
+		if a.UserID != store.ops[i].ID || a.Type != "job.unassigned" {
+			t.Errorf("alert %d = %s for %s, want job.unassigned for %s", i, a.Type, a.UserID, store.ops[i].ID)
+		}
+	}
+
+	emailer.mu.Lock()
+	defer emailer.mu.Unlock()
+	if len(emailer.to) != 1 || emailer.to[0] != email {
+		t.Errorf("emails sent to %v, want only %s", emailer.to, email)
+	}
+}
@@ -1,8 +1,12 @@
 package platform
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,6 +20,16 @@ type Config struct {
 	OTP          OTPConfig
 	AWS          AWSConfig
 	Notification NotificationConfig
+	Dispatch     DispatchConfig
+	Matcher      MatcherConfig
+	Pricing      PricingConfig
+	Payout       PayoutConfig
+	Payment      PaymentConfig
+	Invoice      InvoiceConfig
+	TaskQueue    TaskQueueConfig
+	EventBus     EventBusConfig
+	Webhook      WebhookConfig
+	Risk         RiskConfig
 }
 
 type ServerConfig struct {
@@ -75,6 +89,108 @@ type NotificationConfig struct {
 	Provider string // "mock" (default) or "fcm", "sendgrid", "msg91"
 }
 
+type DispatchConfig struct {
+	Mode                 string   // "sequential" (default) or "broadcast"
+	BroadcastSize        int      // offers sent at once in broadcast mode
+	BroadcastSurveyTypes []string // survey types always dispatched in broadcast mode
+	BroadcastPriorities  []string // job priorities always dispatched in broadcast mode
+	OfferTimeout         time.Duration // how long an agent has to answer an offer
+	MaxRounds            int           // cascade rounds before a job is left unassigned
+
+	BatchEnabled  bool          // plan first offers with the periodic batch assigner
+	BatchInterval time.Duration // how often the batch assigner runs
+}
+
+type MatcherConfig struct {
+	Strategy         string                        // "default", "weighted" or "performance"
+	Weights          map[string]float64            // factor weights for the "weighted" strategy
+	RegionWeights    map[string]map[string]float64 // overrides keyed by "state_code" or "state_code/district"
+	ExpansionRadiiKm []float64                     // search radii tried in order
+}
+
+type PricingConfig struct {
+	BaseRates        map[string]float64 // INR per survey type
+	IncludedHectares float64            // parcel area covered by the base rate
+	PerHectare       float64            // INR per hectare above IncludedHectares
+	FreeKm           float64            // travel covered by the base rate
+	PerKm            float64            // INR per km beyond FreeKm
+	UrgencyWindow    time.Duration      // deadlines closer than this earn an urgency bonus
+	UrgencyMaxPct    float64            // urgency bonus at the deadline, as a fraction of base
+	SurgeMaxPct      float64            // bonus when every recent offer in the district went unaccepted
+	RoundBonusPct    float64            // bonus added per cascade round after the first
+}
+
+type PayoutConfig struct {
+	Provider       string  // "mock" (default) or "razorpayx"
+	CommissionRate float64 // platform share of an agent's gross earnings
+	TDSRate        float64 // deducted from earnings after commission
+	TDSSingleLimit float64 // INR; a payout above this attracts TDS
+	TDSAnnualLimit float64 // INR; TDS applies once financial-year earnings exceed this
+	FraudPenalty   float64 // INR debited from an agent's wallet when QA rejects a survey as fraudulent
+
+	RazorpayXBaseURL       string
+	RazorpayXKeyID         string
+	RazorpayXKeySecret     string
+	RazorpayXAccountNumber string // RazorpayX current account payouts are made from
+	RazorpayXWebhookSecret string
+}
+
+type PaymentConfig struct {
+	Provider              string // "mock" (default) or "razorpay"
+	RazorpayBaseURL       string
+	RazorpayKeyID         string
+	RazorpayKeySecret     string
+	RazorpayWebhookSecret string
+}
+
+type InvoiceConfig struct {
+	Prefix  string  // invoice numbers are Prefix/YYYY-YY/NNNNNN
+	GSTRate float64 // included in every charge
+	SACCode string  // services accounting code printed on invoices
+
+	CompanyName      string
+	CompanyGSTIN     string
+	CompanyAddress   string
+	CompanyStateCode string // e.g. "KA"; decides CGST/SGST vs IGST
+}
+
+type TaskQueueConfig struct {
+	Workers      int           // tasks processed concurrently
+	PollInterval time.Duration // how long an idle worker waits before polling again
+	Defaults     TaskSettings
+	Types        map[string]TaskSettings // overrides keyed by task type
+}
+
+// TaskSettings are how tasks of one type are run and retried.
+type TaskSettings struct {
+	MaxAttempts int           // attempts before a task is dead
+	Timeout     time.Duration // per attempt
+	BaseBackoff time.Duration // delay before the first retry; doubles per retry
+	MaxBackoff  time.Duration
+}
+
+type EventBusConfig struct {
+	Backend    string // "memory" (default), "redis" or "postgres"
+	BufferSize int    // in-memory backend only
+
+	// Durable backends
+	VisibilityTimeout time.Duration // an unacknowledged event is redelivered after this
+	MaxDeliveries     int           // deliveries of an event to one subscriber before giving up
+	StreamMaxLen      int64         // Redis: approximate events kept per stream
+	GroupStartID      string        // Redis: where a new subscriber's group starts; "0" or "$"
+	PollInterval      time.Duration // Postgres: how often the outbox is checked
+	Retention         time.Duration // Postgres: how long delivered events are kept
+}
+
+type WebhookConfig struct {
+	Timeout       time.Duration // per delivery attempt
+	AllowInsecure bool          // allow plain HTTP and private addresses, for local development
+}
+
+type RiskConfig struct {
+	RulesFile string // JSON rule set; the built-in rules if empty
+}
+
 // LoadConfig reads configuration from environment variables.
 func LoadConfig() (*Config, error) {
 	v := viper.New()
@@ -121,6 +237,141 @@ func LoadConfig() (*Config, error) {
 	// Notification defaults
 	v.SetDefault("NOTIFICATION_PROVIDER", "mock")
 
+	// Dispatch defaults
+	v.SetDefault("DISPATCH_MODE", "sequential")
+	v.SetDefault("DISPATCH_BROADCAST_SIZE", 5)
+	v.SetDefault("DISPATCH_BROADCAST_SURVEY_TYPES", "")
+	v.SetDefault("DISPATCH_BROADCAST_PRIORITIES", "urgent")
+	v.SetDefault("DISPATCH_OFFER_TIMEOUT", "30m")
+	v.SetDefault("DISPATCH_MAX_ROUNDS", 3)
+	v.SetDefault("DISPATCH_BATCH_ENABLED", false)
+	v.SetDefault("DISPATCH_BATCH_INTERVAL", "2m")
+
+	// Matcher defaults
+	v.SetDefault("MATCHER_STRATEGY", "default")
+	v.SetDefault("MATCHER_WEIGHTS", "")
+	v.SetDefault("MATCHER_REGION_WEIGHTS", "")
+	v.SetDefault("MATCHER_EXPANSION_RADII_KM", "25,50,100")
+
+	// Pricing defaults
+	v.SetDefault("PRICING_BASE_RATES", `{"basic_check":500,"detailed_survey":900,"premium_inspection":1500}`)
+	v.SetDefault("PRICING_INCLUDED_HECTARES", 2)
+	v.SetDefault("PRICING_PER_HECTARE", 40)
+	v.SetDefault("PRICING_FREE_KM", 10)
+	v.SetDefault("PRICING_PER_KM", 8)
+	v.SetDefault("PRICING_URGENCY_WINDOW", "48h")
+	v.SetDefault("PRICING_URGENCY_MAX_PCT", 0.3)
+	v.SetDefault("PRICING_SURGE_MAX_PCT", 0.25)
+	v.SetDefault("PRICING_ROUND_BONUS_PCT", 0.1)
+
+	// Payout defaults
+	v.SetDefault("PAYOUT_PROVIDER", "mock")
+	v.SetDefault("PAYOUT_COMMISSION_RATE", 0.15)
+	v.SetDefault("PAYOUT_TDS_RATE", 0.01)
+	v.SetDefault("PAYOUT_TDS_SINGLE_LIMIT", 30000)
+	v.SetDefault("PAYOUT_TDS_ANNUAL_LIMIT", 100000)
+	v.SetDefault("PAYOUT_FRAUD_PENALTY", 500)
+	v.SetDefault("RAZORPAYX_BASE_URL", "https://api.razorpay.com")
+	v.SetDefault("RAZORPAYX_KEY_ID", "")
+	v.SetDefault("RAZORPAYX_KEY_SECRET", "")
+	v.SetDefault("RAZORPAYX_ACCOUNT_NUMBER", "")
+	v.SetDefault("RAZORPAYX_WEBHOOK_SECRET", "")
+
+	// Payment defaults
+	v.SetDefault("PAYMENT_PROVIDER", "mock")
+	v.SetDefault("RAZORPAY_BASE_URL", "https://api.razorpay.com")
+	v.SetDefault("RAZORPAY_KEY_ID", "")
+	v.SetDefault("RAZORPAY_KEY_SECRET", "")
+	v.SetDefault("RAZORPAY_WEBHOOK_SECRET", "")
+
+	// Invoice defaults
+	v.SetDefault("INVOICE_PREFIX", "TS")
+	v.SetDefault("INVOICE_GST_RATE", 0.18)
+	v.SetDefault("INVOICE_SAC_CODE", "998343")
+	v.SetDefault("INVOICE_COMPANY_NAME", "TerraScore Technologies Pvt Ltd")
+	v.SetDefault("INVOICE_COMPANY_GSTIN", "")
+	v.SetDefault("INVOICE_COMPANY_ADDRESS", "")
+	v.SetDefault("INVOICE_COMPANY_STATE_CODE", "KA")
+
+	// Task queue defaults
+	v.SetDefault("TASK_WORKERS", 4)
+	v.SetDefault("TASK_POLL_INTERVAL", "5s")
+	v.SetDefault("TASK_MAX_ATTEMPTS", 3)
+	v.SetDefault("TASK_TIMEOUT", "5m")
+	v.SetDefault("TASK_BACKOFF_BASE", "30s")
+	v.SetDefault("TASK_BACKOFF_MAX", "1h")
+	v.SetDefault("TASK_TYPE_SETTINGS", "")
+
+	// Event bus defaults
+	v.SetDefault("EVENT_BUS_BACKEND", "memory")
+	v.SetDefault("EVENT_BUS_BUFFER_SIZE", 1000)
+	v.SetDefault("EVENT_BUS_VISIBILITY_TIMEOUT", "1m")
+	v.SetDefault("EVENT_BUS_MAX_DELIVERIES", 10)
+	v.SetDefault("EVENT_BUS_STREAM_MAX_LEN", 100000)
+	v.SetDefault("EVENT_BUS_GROUP_START_ID", "0")
+	v.SetDefault("EVENT_BUS_POLL_INTERVAL", "1s")
+	v.SetDefault("EVENT_BUS_RETENTION", "168h")
+
+	// Webhooks
+	v.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	v.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	v.SetDefault("WEBHOOK_BACKOFF_BASE", "1m")
+	v.SetDefault("WEBHOOK_BACKOFF_MAX", "6h")
+	v.SetDefault("WEBHOOK_ALLOW_INSECURE", false)
+
+	// Risk scoring
+	v.SetDefault("RISK_RULES_FILE", "")
+
+	matcherWeights := map[string]float64{}
+	if raw := v.GetString("MATCHER_WEIGHTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &matcherWeights); err != nil {
+			return nil, fmt.Errorf("parsing MATCHER_WEIGHTS: %w", err)
+		}
+	}
+	if len(matcherWeights) > 0 {
+		if err := CheckWeights(matcherWeights); err != nil {
+			return nil, fmt.Errorf("invalid MATCHER_WEIGHTS: %w", err)
+		}
+	}
+	regionWeights := map[string]map[string]float64{}
+	if raw := v.GetString("MATCHER_REGION_WEIGHTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &regionWeights); err != nil {
+			return nil, fmt.Errorf("parsing MATCHER_REGION_WEIGHTS: %w", err)
+		}
+	}
+	for region, weights := range regionWeights {
+		if err := CheckWeights(weights); err != nil {
+			return nil, fmt.Errorf("invalid MATCHER_REGION_WEIGHTS for %s: %w", region, err)
+		}
+	}
+	baseRates := map[string]float64{}
+	if raw := v.GetString("PRICING_BASE_RATES"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &baseRates); err != nil {
+			return nil, fmt.Errorf("parsing PRICING_BASE_RATES: %w", err)
+		}
+	}
+	taskTypes, err := parseTaskTypeSettings(v.GetString("TASK_TYPE_SETTINGS"))
+	if err != nil {
+		return nil, err
+	}
+	// Webhook deliveries retry longer than other tasks, since receivers can
+	// be down for hours; TASK_TYPE_SETTINGS takes precedence
+	if _, ok := taskTypes["webhook.deliver"]; !ok {
+		taskTypes["webhook.deliver"] = TaskSettings{
+			MaxAttempts: v.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+			BaseBackoff: v.GetDuration("WEBHOOK_BACKOFF_BASE"),
+			MaxBackoff:  v.GetDuration("WEBHOOK_BACKOFF_MAX"),
+		}
+	}
+	var radiiKm []float64
+	for _, part := range splitList(v.GetString("MATCHER_EXPANSION_RADII_KM")) {
+		km, err := strconv.ParseFloat(part, 64)
+		if err != nil || km <= 0 {
+			return nil, fmt.Errorf("invalid MATCHER_EXPANSION_RADII_KM entry %q", part)
+		}
+		radiiKm = append(radiiKm, km)
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
 			Host: v.GetString("SERVER_HOST"),
@@ -163,7 +414,160 @@ func LoadConfig() (*Config, error) {
 		Notification: NotificationConfig{
 			Provider: v.GetString("NOTIFICATION_PROVIDER"),
 		},
+		Dispatch: DispatchConfig{
+			Mode:                 v.GetString("DISPATCH_MODE"),
+			BroadcastSize:        v.GetInt("DISPATCH_BROADCAST_SIZE"),
+			BroadcastSurveyTypes: splitList(v.GetString("DISPATCH_BROADCAST_SURVEY_TYPES")),
+			BroadcastPriorities:  splitList(v.GetString("DISPATCH_BROADCAST_PRIORITIES")),
+			OfferTimeout:         v.GetDuration("DISPATCH_OFFER_TIMEOUT"),
+			MaxRounds:            v.GetInt("DISPATCH_MAX_ROUNDS"),
+			BatchEnabled:         v.GetBool("DISPATCH_BATCH_ENABLED"),
+			BatchInterval:        v.GetDuration("DISPATCH_BATCH_INTERVAL"),
+		},
+		Matcher: MatcherConfig{
+			Strategy:         v.GetString("MATCHER_STRATEGY"),
+			Weights:          matcherWeights,
+			RegionWeights:    regionWeights,
+			ExpansionRadiiKm: radiiKm,
+		},
+		Pricing: PricingConfig{
+			BaseRates:        baseRates,
+			IncludedHectares: v.GetFloat64("PRICING_INCLUDED_HECTARES"),
+			PerHectare:       v.GetFloat64("PRICING_PER_HECTARE"),
+			FreeKm:           v.GetFloat64("PRICING_FREE_KM"),
+			PerKm:            v.GetFloat64("PRICING_PER_KM"),
+			UrgencyWindow:    v.GetDuration("PRICING_URGENCY_WINDOW"),
+			UrgencyMaxPct:    v.GetFloat64("PRICING_URGENCY_MAX_PCT"),
+			SurgeMaxPct:      v.GetFloat64("PRICING_SURGE_MAX_PCT"),
+			RoundBonusPct:    v.GetFloat64("PRICING_ROUND_BONUS_PCT"),
+		},
+		Payout: PayoutConfig{
+			Provider:               v.GetString("PAYOUT_PROVIDER"),
+			CommissionRate:         v.GetFloat64("PAYOUT_COMMISSION_RATE"),
+			TDSRate:                v.GetFloat64("PAYOUT_TDS_RATE"),
+			TDSSingleLimit:         v.GetFloat64("PAYOUT_TDS_SINGLE_LIMIT"),
+			TDSAnnualLimit:         v.GetFloat64("PAYOUT_TDS_ANNUAL_LIMIT"),
+			FraudPenalty:           v.GetFloat64("PAYOUT_FRAUD_PENALTY"),
+			RazorpayXBaseURL:       v.GetString("RAZORPAYX_BASE_URL"),
+			RazorpayXKeyID:         v.GetString("RAZORPAYX_KEY_ID"),
+			RazorpayXKeySecret:     v.GetString("RAZORPAYX_KEY_SECRET"),
+			RazorpayXAccountNumber: v.GetString("RAZORPAYX_ACCOUNT_NUMBER"),
+			RazorpayXWebhookSecret: v.GetString("RAZORPAYX_WEBHOOK_SECRET"),
+		},
+		Payment: PaymentConfig{
+			Provider:              v.GetString("PAYMENT_PROVIDER"),
+			RazorpayBaseURL:       v.GetString("RAZORPAY_BASE_URL"),
+			RazorpayKeyID:         v.GetString("RAZORPAY_KEY_ID"),
+			RazorpayKeySecret:     v.GetString("RAZORPAY_KEY_SECRET"),
+			RazorpayWebhookSecret: v.GetString("RAZORPAY_WEBHOOK_SECRET"),
+		},
+		Invoice: InvoiceConfig{
+			Prefix:           v.GetString("INVOICE_PREFIX"),
+			GSTRate:          v.GetFloat64("INVOICE_GST_RATE"),
+			SACCode:          v.GetString("INVOICE_SAC_CODE"),
+			CompanyName:      v.GetString("INVOICE_COMPANY_NAME"),
+			CompanyGSTIN:     v.GetString("INVOICE_COMPANY_GSTIN"),
+			CompanyAddress:   v.GetString("INVOICE_COMPANY_ADDRESS"),
+			CompanyStateCode: v.GetString("INVOICE_COMPANY_STATE_CODE"),
+		},
+		TaskQueue: TaskQueueConfig{
+			Workers:      v.GetInt("TASK_WORKERS"),
+			PollInterval: v.GetDuration("TASK_POLL_INTERVAL"),
+			Defaults: TaskSettings{
+				MaxAttempts: v.GetInt("TASK_MAX_ATTEMPTS"),
+				Timeout:     v.GetDuration("TASK_TIMEOUT"),
+				BaseBackoff: v.GetDuration("TASK_BACKOFF_BASE"),
+				MaxBackoff:  v.GetDuration("TASK_BACKOFF_MAX"),
+			},
+			Types: taskTypes,
+		},
+		EventBus: EventBusConfig{
+			Backend:           v.GetString("EVENT_BUS_BACKEND"),
+			BufferSize:        v.GetInt("EVENT_BUS_BUFFER_SIZE"),
+			VisibilityTimeout: v.GetDuration("EVENT_BUS_VISIBILITY_TIMEOUT"),
+			MaxDeliveries:     v.GetInt("EVENT_BUS_MAX_DELIVERIES"),
+			StreamMaxLen:      v.GetInt64("EVENT_BUS_STREAM_MAX_LEN"),
+			GroupStartID:      v.GetString("EVENT_BUS_GROUP_START_ID"),
+			PollInterval:      v.GetDuration("EVENT_BUS_POLL_INTERVAL"),
+			Retention:         v.GetDuration("EVENT_BUS_RETENTION"),
+		},
+		Webhook: WebhookConfig{
+			Timeout:       v.GetDuration("WEBHOOK_TIMEOUT"),
+			AllowInsecure: v.GetBool("WEBHOOK_ALLOW_INSECURE"),
+		},
+		Risk: RiskConfig{
+			RulesFile: v.GetString("RISK_RULES_FILE"),
+		},
 	}
 
 	return cfg, nil
 }
+
+// parseTaskTypeSettings parses TASK_TYPE_SETTINGS, a JSON object of settings
+// by task type with durations as strings, e.g.
+// {"report.generate":{"max_attempts":5,"timeout":"15m"}}.
+func parseTaskTypeSettings(raw string) (map[string]TaskSettings, error) {
+	out := map[string]TaskSettings{}
+	if raw == "" {
+		return out, nil
+	}
+
+	var entries map[string]struct {
+		MaxAttempts int    `json:"max_attempts"`
+		Timeout     string `json:"timeout"`
+		BaseBackoff string `json:"backoff_base"`
+		MaxBackoff  string `json:"backoff_max"`
+	}
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("parsing TASK_TYPE_SETTINGS: %w", err)
+	}
+
+	for taskType, e := range entries {
+		s := TaskSettings{MaxAttempts: e.MaxAttempts}
+		for _, d := range []struct {
+			raw string
+			dst *time.Duration
+		}{{e.Timeout, &s.Timeout}, {e.BaseBackoff, &s.BaseBackoff}, {e.MaxBackoff, &s.MaxBackoff}} {
+			if d.raw == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing TASK_TYPE_SETTINGS for %s: %w", taskType, err)
+			}
+			*d.dst = parsed
+		}
+		out[taskType] = s
+	}
+	return out, nil
+}
+
+// weightSumTolerance is how far matcher weights may sum from 1, so weights
+// like thirds can be written out to a few decimals.
+const weightSumTolerance = 0.01
+
+// CheckWeights checks that scoring weights are non-negative and sum to 1.
+func CheckWeights(weights map[string]float64) error {
+	sum := 0.0
+	for factor, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("negative weight for %q", factor)
+		}
+		sum += w
+	}
+	if math.Abs(sum-1) > weightSumTolerance {
+		return fmt.Errorf("weights sum to %.4g, not 1", sum)
+	}
+	return nil
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_MatcherWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights string
+		regions string
+		err     string
+	}{
+		{"valid", `{"distance":0.6,"rating":0.4}`, `{"KA":{"distance":0.333,"rating":0.333,"completion":0.334}}`, ""},
+		{"over 1", "", `{"KA":{"distance":1,"rating":1}}`, "MATCHER_REGION_WEIGHTS for KA"},
+		{"under 1", `{"distance":0.5}`, "", "MATCHER_WEIGHTS"},
+		{"negative", `{"distance":1.5,"rating":-0.5}`, "", "negative weight"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MATCHER_WEIGHTS", tt.weights)
+			t.Setenv("MATCHER_REGION_WEIGHTS", tt.regions)
+
+			_, err := LoadConfig()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -33,3 +34,60 @@ func NewDBPool(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// TxBeginner starts transactions. A *pgxpool.Pool begins a new transaction;
+// a pgx.Tx begins a nested one backed by a savepoint.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// InTx runs fn in a transaction on db, committing if fn returns nil and
+// rolling back otherwise.
+func InTx(ctx context.Context, db TxBeginner, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// TxScope is embedded by repositories that can be bound to a caller's
+// transaction. Their own transactions begin in the bound transaction, nested
+// with a savepoint, or on the pool if there is none.
+type TxScope struct {
+	db *pgxpool.Pool
+	tx pgx.Tx
+}
+
+// NewTxScope creates a scope that begins transactions on db.
+func NewTxScope(db *pgxpool.Pool) TxScope {
+	return TxScope{db: db}
+}
+
+// Bind returns a copy of the scope bound to tx.
+func (s TxScope) Bind(tx pgx.Tx) TxScope {
+	return TxScope{db: s.db, tx: tx}
+}
+
+// Begin starts a transaction in the scope.
+func (s TxScope) Begin(ctx context.Context) (pgx.Tx, error) {
+	if s.tx != nil {
+		return s.tx.Begin(ctx)
+	}
+	return s.db.Begin(ctx)
+}
+
+// InTx runs fn in a transaction in the scope. Bind repositories to it with
+// WithTx and record the events it causes with PublishTx.
+func (s TxScope) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return InTx(ctx, s, fn)
+}
@@ -2,72 +2,257 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
-// Event represents an in-process event.
+// publishTimeout bounds how long Publish waits on a durable backend.
+const publishTimeout = 5 * time.Second
+
+// Event is a message published on the bus.
 type Event struct {
+	ID      string // assigned by durable backends; empty in memory
 	Type    string
+	Version int // schema version of a typed payload; 0 if untyped
 	Payload any
 }
 
+// Decode unmarshals the event's payload into v. Events from a durable backend
+// carry the payload as JSON; in-memory events carry the published value,
+// which is converted through JSON too so handlers behave the same on every
+// backend.
+func (e Event) Decode(v any) error {
+	raw, ok := e.Payload.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(e.Payload); err != nil {
+			return fmt.Errorf("encoding %s payload: %w", e.Type, err)
+		}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("decoding %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
 // EventHandler processes an event.
 type EventHandler func(ctx context.Context, event Event)
 
-// EventBus is a simple in-process pub/sub bus using Go channels.
+// Subscription is a handler subscribed to one event type. Name identifies the
+// subscriber to durable backends, which track delivery per subscriber.
+type Subscription struct {
+	Name      string
+	EventType string
+	Handler   EventHandler
+
+	// handle replaces Handler for typed subscriptions, whose handlers
+	// report errors.
+	handle func(ctx context.Context, event Event) error
+}
+
+// Deliver calls the handler. An event is acknowledged once its handler
+// returns; an error returned by a typed handler, or a panic, leaves it
+// unacknowledged. The handler can read the event's ID with EventID.
+func (s Subscription) Deliver(ctx context.Context, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panic: %v", r)
+		}
+	}()
+	ctx = context.WithValue(ctx, eventIDKey, event.ID)
+	if s.handle != nil {
+		return s.handle(ctx, event)
+	}
+	s.Handler(ctx, event)
+	return nil
+}
+
+// eventIDKey is the context key for the ID of the event being handled.
+const eventIDKey contextKey = "event_id"
+
+// EventID returns the ID of the event a handler was called with, or "" if
+// the event came from the in-memory backend. A redelivered event keeps its
+// ID, so handlers can use it to deduplicate.
+func EventID(ctx context.Context) string {
+	id, _ := ctx.Value(eventIDKey).(string)
+	return id
+}
+
+// EventBackend carries events from publishers to subscribers.
+type EventBackend interface {
+	// Publish sends an event to every subscriber of its type.
+	Publish(ctx context.Context, event Event) error
+	// Run delivers events to subs until ctx is cancelled.
+	Run(ctx context.Context, subs []Subscription)
+}
+
+// EventBus is a pub/sub bus. The backend decides how far events travel: the
+// in-memory backend delivers within the process, best effort, while the Redis
+// Streams and Postgres outbox backends deliver across replicas, at least once
+// per subscriber, and survive restarts.
 type EventBus struct {
-	mu       sync.RWMutex
-	handlers map[string][]EventHandler
-	ch       chan Event
-	logger   *slog.Logger
+	mu      sync.Mutex
+	subs    []Subscription
+	schemas map[string]eventSchema
+	backend EventBackend
+	logger  *slog.Logger
 }
 
-// NewEventBus creates an event bus with the given buffer size.
+// NewEventBus creates an in-memory event bus with the given buffer size.
 func NewEventBus(logger *slog.Logger, bufferSize int) *EventBus {
+	return NewEventBusWithBackend(newMemoryBackend(bufferSize, logger), logger)
+}
+
+// NewEventBusWithBackend creates an event bus on the given backend.
+func NewEventBusWithBackend(backend EventBackend, logger *slog.Logger) *EventBus {
 	return &EventBus{
-		handlers: make(map[string][]EventHandler),
-		ch:       make(chan Event, bufferSize),
-		logger:   logger,
+		schemas: make(map[string]eventSchema),
+		backend: backend,
+		logger:  logger,
 	}
 }
 
-// Subscribe registers a handler for an event type.
+// Subscribe registers a handler for an event type. Subscribe before Start.
+// Handlers of typed events should use the generic Subscribe instead.
+//
+// Durable backends know a subscriber by its handler's name, e.g.
+// "job.Dispatcher.HandleJobCreated", so renaming a handler starts a new
+// subscription that only sees events published afterwards. Use SubscribeAs
+// to keep the old name.
 func (eb *EventBus) Subscribe(eventType string, handler EventHandler) {
+	eb.SubscribeAs(handlerName(handler), eventType, handler)
+}
+
+// SubscribeAs is Subscribe under an explicit subscriber name.
+func (eb *EventBus) SubscribeAs(name, eventType string, handler EventHandler) {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
-	eb.handlers[eventType] = append(eb.handlers[eventType], handler)
+
+	eb.add(Subscription{Name: name, EventType: eventType, Handler: handler})
 }
 
-// Publish sends an event to the bus.
+// add appends a subscription, suffixing its name if the event type already
+// has a subscriber of that name. The caller holds eb.mu.
+func (eb *EventBus) add(s Subscription) {
+	name := s.Name
+	for i := 2; eb.subscribed(s.EventType, s.Name); i++ {
+		s.Name = fmt.Sprintf("%s#%d", name, i)
+	}
+	eb.subs = append(eb.subs, s)
+}
+
+func (eb *EventBus) subscribed(eventType, name string) bool {
+	for _, s := range eb.subs {
+		if s.EventType == eventType && s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends an event to the bus. Failures are logged; they are not
+// returned to the publisher. Events that must not be lost when the process
+// crashes after a database write should go through the outbox with
+// PublishTx instead.
 func (eb *EventBus) Publish(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := eb.Send(ctx, event); err != nil {
+		eb.logger.Error("publishing event", "type", event.Type, "error", err)
+	}
+}
+
+// Send sends an event to the bus and returns the backend's error, so the
+// caller can retry. A typed payload must match the schema its event type was
+// registered with.
+func (eb *EventBus) Send(ctx context.Context, event Event) error {
+	if err := checkEvent(event); err != nil {
+		return err
+	}
+	if p, ok := event.Payload.(EventPayload); ok {
+		eb.mu.Lock()
+		err := eb.register(p)
+		eb.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return eb.backend.Publish(ctx, event)
+}
+
+// Start begins delivering events. Call in a goroutine.
+func (eb *EventBus) Start(ctx context.Context) {
+	eb.mu.Lock()
+	subs := append([]Subscription(nil), eb.subs...)
+	eb.mu.Unlock()
+
+	eb.backend.Run(ctx, subs)
+}
+
+// handlerName derives a stable subscriber name from a handler function, e.g.
+// "job.Dispatcher.HandleJobCreated" for a method value.
+func handlerName(handler any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return "handler"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+// errEventBusFull is returned by the in-memory backend when its buffer is full.
+var errEventBusFull = errors.New("event bus full, dropping event")
+
+// memoryBackend delivers events within the process through a buffered
+// channel. Events are dropped when the buffer is full and lost on restart.
+type memoryBackend struct {
+	ch     chan Event
+	logger *slog.Logger
+}
+
+func newMemoryBackend(bufferSize int, logger *slog.Logger) *memoryBackend {
+	return &memoryBackend{
+		ch:     make(chan Event, bufferSize),
+		logger: logger,
+	}
+}
+
+func (b *memoryBackend) Publish(ctx context.Context, event Event) error {
 	select {
-	case eb.ch <- event:
+	case b.ch <- event:
+		return nil
 	default:
-		eb.logger.Warn("event bus full, dropping event", "type", event.Type)
+		return errEventBusFull
 	}
 }
 
-// Start begins processing events. Call in a goroutine.
-func (eb *EventBus) Start(ctx context.Context) {
+func (b *memoryBackend) Run(ctx context.Context, subs []Subscription) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event := <-eb.ch:
-			eb.mu.RLock()
-			handlers := eb.handlers[event.Type]
-			eb.mu.RUnlock()
-
-			for _, h := range handlers {
-				go func(handler EventHandler) {
-					defer func() {
-						if r := recover(); r != nil {
-							eb.logger.Error("event handler panic", "type", event.Type, "error", r)
-						}
-					}()
-					handler(ctx, event)
-				}(h)
+		case event := <-b.ch:
+			for _, s := range subs {
+				if s.EventType != event.Type {
+					continue
+				}
+				go func(s Subscription) {
+					if err := s.Deliver(ctx, event); err != nil {
+						b.logger.Error("event handler failed", "type", event.Type, "subscriber", s.Name, "error", err)
+					}
+				}(s)
 			}
 		}
 	}
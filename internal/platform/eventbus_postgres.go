@@ -0,0 +1,278 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+)
+
+const (
+	outboxBatchSize       = 100
+	outboxCleanupInterval = 1 * time.Hour
+)
+
+// outboxRetry is how soon a delivery whose handler failed is retried.
+var outboxRetry = TaskSettings{BaseBackoff: 5 * time.Second, MaxBackoff: 10 * time.Minute}
+
+// PostgresOutboxBackend carries events through an outbox table. Publish
+// writes the event; a relay on any replica fans it out to one delivery per
+// subscriber of its type, and workers claim deliveries under a lease and
+// acknowledge them once handled. A delivery whose handler fails is retried
+// with backoff, and one whose lease expires, e.g. because its replica
+// crashed, is redelivered. Replicas record their subscribers in a registry
+// and the relay fans out to every registered subscriber, so replicas on
+// different releases still deliver to each other's subscribers.
+type PostgresOutboxBackend struct {
+	db     *pgxpool.Pool
+	q      *sqlc.Queries
+	cfg    EventBusConfig
+	logger *slog.Logger
+}
+
+// NewPostgresOutboxBackend creates a Postgres outbox event backend.
+func NewPostgresOutboxBackend(db *pgxpool.Pool, cfg EventBusConfig, logger *slog.Logger) *PostgresOutboxBackend {
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = time.Minute
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	return &PostgresOutboxBackend{
+		db:     db,
+		q:      sqlc.New(db),
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Publish writes an event to the outbox.
+func (b *PostgresOutboxBackend) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("marshalling event payload: %w", err)
+	}
+
+	if _, err := b.q.InsertOutboxEvent(ctx, sqlc.InsertOutboxEventParams{EventType: event.Type, Version: int32(event.Version), Payload: data}); err != nil {
+		return fmt.Errorf("writing event to outbox: %w", err)
+	}
+	return nil
+}
+
+// Run relays and delivers events until ctx is cancelled.
+func (b *PostgresOutboxBackend) Run(ctx context.Context, subs []Subscription) {
+	b.logger.Info("event bus started", "backend", "postgres", "subscribers", len(subs))
+
+	byName := make(map[string]Subscription, len(subs))
+	names := make([]string, 0, len(subs))
+	for _, s := range subs {
+		byName[s.Name] = s
+		names = append(names, s.Name)
+	}
+
+	ticker := time.NewTicker(b.cfg.PollInterval)
+	defer ticker.Stop()
+	var lastRegister, lastCleanup time.Time
+
+	for {
+		// Register before relaying, and keep the registration fresh so
+		// cleanup only forgets subscribers no replica runs any more
+		if time.Since(lastRegister) >= outboxCleanupInterval && b.register(ctx, subs) {
+			lastRegister = time.Now()
+		}
+		b.relay(ctx)
+		b.deliver(ctx, names, byName)
+		if time.Since(lastCleanup) >= outboxCleanupInterval {
+			b.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// register records this process's subscribers in the registry and reports
+// whether it succeeded.
+func (b *PostgresOutboxBackend) register(ctx context.Context, subs []Subscription) bool {
+	if len(subs) == 0 {
+		return true
+	}
+	arg := sqlc.RegisterEventSubscribersParams{
+		EventTypes:  make([]string, len(subs)),
+		Subscribers: make([]string, len(subs)),
+	}
+	for i, s := range subs {
+		arg.EventTypes[i] = s.EventType
+		arg.Subscribers[i] = s.Name
+	}
+	if err := b.q.RegisterEventSubscribers(ctx, arg); err != nil {
+		b.logger.Error("event bus: registering subscribers", "error", err)
+		return false
+	}
+	return true
+}
+
+// relay fans undispatched events out to a delivery per registered
+// subscriber.
+func (b *PostgresOutboxBackend) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := b.relayBatch(ctx)
+		if err != nil {
+			b.logger.Error("event bus: relaying outbox", "error", err)
+			return
+		}
+		if n < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (b *PostgresOutboxBackend) relayBatch(ctx context.Context) (int, error) {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := b.q.WithTx(tx)
+
+	events, err := q.ClaimUndispatchedEvents(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claiming outbox events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	if err := q.CreateEventDeliveries(ctx, ids); err != nil {
+		return 0, fmt.Errorf("creating event deliveries: %w", err)
+	}
+	if err := q.MarkEventsDispatched(ctx, ids); err != nil {
+		return 0, fmt.Errorf("marking events dispatched: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing relay: %w", err)
+	}
+	return len(events), nil
+}
+
+// deliver claims due deliveries for this process's subscribers and hands
+// them to their handlers, batch by batch until none are due.
+func (b *PostgresOutboxBackend) deliver(ctx context.Context, names []string, byName map[string]Subscription) {
+	if len(names) == 0 {
+		return
+	}
+
+	for ctx.Err() == nil {
+		rows, err := b.q.ClaimEventDeliveries(ctx, sqlc.ClaimEventDeliveriesParams{
+			LeaseExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(b.cfg.VisibilityTimeout), Valid: true},
+			Subscribers:    names,
+			Lim:            outboxBatchSize,
+		})
+		if err != nil {
+			b.logger.Error("event bus: claiming deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, row := range rows {
+			wg.Add(1)
+			go func(row sqlc.ClaimEventDeliveriesRow) {
+				defer wg.Done()
+				b.handle(ctx, byName[row.Subscriber], row)
+			}(row)
+		}
+		wg.Wait()
+
+		if len(rows) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// handle delivers one event to one subscriber and records the outcome.
+func (b *PostgresOutboxBackend) handle(ctx context.Context, s Subscription, row sqlc.ClaimEventDeliveriesRow) {
+	if b.cfg.MaxDeliveries > 0 && int(row.Attempts) > b.cfg.MaxDeliveries {
+		b.kill(ctx, row, "lease expired on the final delivery")
+		return
+	}
+
+	event := Event{ID: strconv.FormatInt(row.EventID, 10), Type: row.EventType, Version: int(row.Version), Payload: row.Payload}
+	hctx, cancel := context.WithTimeout(ctx, b.cfg.VisibilityTimeout)
+	defer cancel()
+
+	err := s.Deliver(hctx, event)
+	if err == nil {
+		if err := b.q.AckEventDelivery(ctx, sqlc.AckEventDeliveryParams{ID: row.ID, Attempts: row.Attempts}); err != nil {
+			b.logger.Error("event bus: acknowledging delivery", "id", row.ID, "error", err)
+		}
+		return
+	}
+
+	b.logger.Error("event handler failed", "type", row.EventType, "subscriber", s.Name, "event_id", row.EventID, "attempt", row.Attempts, "error", err)
+	if b.cfg.MaxDeliveries > 0 && int(row.Attempts) >= b.cfg.MaxDeliveries {
+		b.kill(ctx, row, err.Error())
+		return
+	}
+
+	msg := err.Error()
+	err = b.q.RetryEventDelivery(ctx, sqlc.RetryEventDeliveryParams{
+		ID:          row.ID,
+		Attempts:    row.Attempts,
+		AvailableAt: time.Now().Add(backoff(outboxRetry, int(row.Attempts), rand.Float64())),
+		LastError:   &msg,
+	})
+	if err != nil {
+		b.logger.Error("event bus: scheduling redelivery", "id", row.ID, "error", err)
+	}
+}
+
+// kill gives up on a delivery.
+func (b *PostgresOutboxBackend) kill(ctx context.Context, row sqlc.ClaimEventDeliveriesRow, reason string) {
+	b.logger.Error("event bus: giving up on delivery",
+		"type", row.EventType, "subscriber", row.Subscriber, "event_id", row.EventID, "attempts", row.Attempts, "reason", reason)
+	err := b.q.KillEventDelivery(ctx, sqlc.KillEventDeliveryParams{ID: row.ID, Attempts: row.Attempts, LastError: &reason})
+	if err != nil {
+		b.logger.Error("event bus: marking delivery dead", "id", row.ID, "error", err)
+	}
+}
+
+// cleanup forgets subscribers no replica has registered within the retention
+// period, then deletes events every subscriber has finished with once they
+// are older than it.
+func (b *PostgresOutboxBackend) cleanup(ctx context.Context) {
+	cutoff := time.Now().Add(-b.cfg.Retention)
+	if n, err := b.q.DeleteStaleEventSubscribers(ctx, cutoff); err != nil {
+		b.logger.Error("event bus: deleting stale subscribers", "error", err)
+	} else if n > 0 {
+		b.logger.Info("event bus: dropped deliveries to stale subscribers", "count", n)
+	}
+
+	n, err := b.q.DeleteDeliveredEvents(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		b.logger.Error("event bus: deleting delivered events", "error", err)
+		return
+	}
+	if n > 0 {
+		b.logger.Info("event bus: deleted delivered events", "count", n)
+	}
+}
@@ -0,0 +1,236 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamReadCount = 10
+	streamReadBlock = 5 * time.Second
+	streamRetryWait = 2 * time.Second
+)
+
+// streamKey is the Redis stream holding events of a type.
+func streamKey(eventType string) string {
+	return "events:" + eventType
+}
+
+// RedisStreamsBackend carries events on Redis Streams, one stream per event
+// type. Each subscriber is a consumer group shared by every replica, so each
+// event is handled once per subscriber across the cluster. Events are
+// acknowledged once handled; events left pending longer than the visibility
+// timeout, e.g. by a replica that crashed, are claimed and redelivered.
+type RedisStreamsBackend struct {
+	rdb      *redis.Client
+	cfg      EventBusConfig
+	consumer string
+	logger   *slog.Logger
+}
+
+// NewRedisStreamsBackend creates a Redis Streams event backend.
+func NewRedisStreamsBackend(rdb *redis.Client, cfg EventBusConfig, logger *slog.Logger) *RedisStreamsBackend {
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = time.Minute
+	}
+	if cfg.GroupStartID == "" {
+		cfg.GroupStartID = "0"
+	}
+	host, _ := os.Hostname()
+	return &RedisStreamsBackend{
+		rdb:      rdb,
+		cfg:      cfg,
+		consumer: host + "-" + uuid.NewString()[:8],
+		logger:   logger,
+	}
+}
+
+// Publish appends an event to its type's stream.
+func (b *RedisStreamsBackend) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("marshalling event payload: %w", err)
+	}
+
+	err = b.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(event.Type),
+		MaxLen: b.cfg.StreamMaxLen,
+		Approx: true,
+		Values: map[string]any{"version": event.Version, "payload": data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("adding event to stream: %w", err)
+	}
+	return nil
+}
+
+// Run consumes each subscriber's group until ctx is cancelled.
+func (b *RedisStreamsBackend) Run(ctx context.Context, subs []Subscription) {
+	b.logger.Info("event bus started", "backend", "redis", "consumer", b.consumer, "subscribers", len(subs))
+
+	var wg sync.WaitGroup
+	for _, s := range subs {
+		wg.Add(1)
+		go func(s Subscription) {
+			defer wg.Done()
+			b.consume(ctx, s)
+		}(s)
+	}
+	wg.Wait()
+}
+
+// consume reads new events for a subscriber and periodically reclaims events
+// other consumers left unacknowledged.
+func (b *RedisStreamsBackend) consume(ctx context.Context, s Subscription) {
+	key := streamKey(s.EventType)
+	var lastReclaim time.Time
+	grouped := false
+
+	for ctx.Err() == nil {
+		if !grouped {
+			if err := b.ensureGroup(ctx, key, s.Name); err != nil {
+				b.logger.Error("event bus: creating consumer group", "stream", key, "group", s.Name, "error", err)
+				sleep(ctx, streamRetryWait)
+				continue
+			}
+			grouped = true
+		}
+
+		if time.Since(lastReclaim) >= b.cfg.VisibilityTimeout/2 {
+			b.reclaim(ctx, s)
+			lastReclaim = time.Now()
+		}
+
+		streams, err := b.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.Name,
+			Consumer: b.consumer,
+			Streams:  []string{key, ">"},
+			Count:    streamReadCount,
+			Block:    streamReadBlock,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			b.logger.Error("event bus: reading stream", "stream", key, "group", s.Name, "error", err)
+			// The stream or group may have been deleted
+			grouped = !strings.HasPrefix(err.Error(), "NOGROUP")
+			sleep(ctx, streamRetryWait)
+			continue
+		}
+		for _, stream := range streams {
+			b.handle(ctx, s, stream.Messages)
+		}
+	}
+}
+
+// ensureGroup creates a subscriber's consumer group unless it already exists.
+// The group starts at the configured ID; the default, "0", replays events
+// still in the stream, so events published before a new subscriber's first
+// start are not skipped.
+func (b *RedisStreamsBackend) ensureGroup(ctx context.Context, key, group string) error {
+	err := b.rdb.XGroupCreateMkStream(ctx, key, group, b.cfg.GroupStartID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// reclaim takes over events that have been pending longer than the visibility
+// timeout and redelivers them. An event delivered MaxDeliveries times is
+// acknowledged and dropped so it cannot block the group forever.
+func (b *RedisStreamsBackend) reclaim(ctx context.Context, s Subscription) {
+	key := streamKey(s.EventType)
+	pending, err := b.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: key,
+		Group:  s.Name,
+		Idle:   b.cfg.VisibilityTimeout,
+		Start:  "-",
+		End:    "+",
+		Count:  streamReadCount,
+	}).Result()
+	if err != nil {
+		b.logger.Error("event bus: listing pending events", "stream", key, "group", s.Name, "error", err)
+		return
+	}
+
+	var ids []string
+	for _, p := range pending {
+		if b.cfg.MaxDeliveries > 0 && p.RetryCount >= int64(b.cfg.MaxDeliveries) {
+			b.logger.Error("event bus: dropping event after max deliveries",
+				"stream", key, "group", s.Name, "id", p.ID, "deliveries", p.RetryCount)
+			b.rdb.XAck(ctx, key, s.Name, p.ID)
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	msgs, err := b.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   key,
+		Group:    s.Name,
+		Consumer: b.consumer,
+		MinIdle:  b.cfg.VisibilityTimeout,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		b.logger.Error("event bus: claiming pending events", "stream", key, "group", s.Name, "error", err)
+		return
+	}
+	if len(msgs) > 0 {
+		b.logger.Warn("event bus: redelivering unacknowledged events", "stream", key, "group", s.Name, "count", len(msgs))
+	}
+	b.handle(ctx, s, msgs)
+}
+
+// handle delivers a batch of events concurrently, acknowledging each one its
+// handler finished.
+func (b *RedisStreamsBackend) handle(ctx context.Context, s Subscription, msgs []redis.XMessage) {
+	key := streamKey(s.EventType)
+
+	var wg sync.WaitGroup
+	for _, msg := range msgs {
+		wg.Add(1)
+		go func(msg redis.XMessage) {
+			defer wg.Done()
+
+			payload, _ := msg.Values["payload"].(string)
+			version, _ := msg.Values["version"].(string)
+			v, _ := strconv.Atoi(version) // absent before typed events
+			event := Event{ID: msg.ID, Type: s.EventType, Version: v, Payload: json.RawMessage(payload)}
+
+			hctx, cancel := context.WithTimeout(ctx, b.cfg.VisibilityTimeout)
+			defer cancel()
+			if err := s.Deliver(hctx, event); err != nil {
+				// Left pending; reclaimed after the visibility timeout
+				b.logger.Error("event handler failed", "type", s.EventType, "subscriber", s.Name, "id", msg.ID, "error", err)
+				return
+			}
+			if err := b.rdb.XAck(ctx, key, s.Name, msg.ID).Err(); err != nil {
+				b.logger.Error("event bus: acknowledging event", "stream", key, "group", s.Name, "id", msg.ID, "error", err)
+			}
+		}(msg)
+	}
+	wg.Wait()
+}
+
+// sleep waits for d or until ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
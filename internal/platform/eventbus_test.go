@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	JobID string `json:"job_id"`
+	Round int    `json:"round"`
+}
+
+type testSubscriber struct{}
+
+func (testSubscriber) HandleJobCreated(ctx context.Context, event Event) {}
+
+func TestEventDecode(t *testing.T) {
+	want := testPayload{JobID: "job-1", Round: 2}
+
+	tests := []struct {
+		name    string
+		payload any
+	}{
+		{"in-memory value", want},
+		{"in-memory pointer", &want},
+		{"durable JSON", json.RawMessage(`{"job_id":"job-1","round":2}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testPayload
+			if err := (Event{Type: "job.created", Payload: tt.payload}).Decode(&got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	var got testPayload
+	if err := (Event{Type: "job.created", Payload: json.RawMessage(`[1]`)}).Decode(&got); err == nil {
+		t.Error("expected an error decoding a mismatched payload")
+	}
+}
+
+func TestSubscribe_Names(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 1)
+	s := testSubscriber{}
+
+	eb.Subscribe("job.created", s.HandleJobCreated)
+	eb.Subscribe("job.created", s.HandleJobCreated)
+	eb.Subscribe("job.redispatched", s.HandleJobCreated)
+	eb.SubscribeAs("dispatcher", "job.created", s.HandleJobCreated)
+
+	want := []string{
+		"platform.testSubscriber.HandleJobCreated",
+		"platform.testSubscriber.HandleJobCreated#2",
+		"platform.testSubscriber.HandleJobCreated",
+		"dispatcher",
+	}
+	for i, sub := range eb.subs {
+		if sub.Name != want[i] {
+			t.Errorf("subscription %d named %q, want %q", i, sub.Name, want[i])
+		}
+	}
+}
+
+func TestSubscriptionDeliver_Panic(t *testing.T) {
+	s := Subscription{Handler: func(ctx context.Context, event Event) { panic("boom") }}
+	if err := s.Deliver(context.Background(), Event{}); err == nil {
+		t.Error("expected a panicking handler to leave the event unacknowledged")
+	}
+}
+
+func TestSubscriptionDeliver_EventID(t *testing.T) {
+	var got string
+	s := Subscription{Handler: func(ctx context.Context, event Event) { got = EventID(ctx) }}
+	if err := s.Deliver(context.Background(), Event{ID: "1700000000000-0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "1700000000000-0" {
+		t.Errorf("expected the handler to see event ID 1700000000000-0, got %q", got)
+	}
+	if id := EventID(context.Background()); id != "" {
+		t.Errorf("expected no event ID outside a handler, got %q", id)
+	}
+}
+
+func TestMemoryEventBus(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 10)
+
+	got := make(chan Event, 2)
+	eb.Subscribe("job.created", func(ctx context.Context, event Event) { got <- event })
+	eb.Subscribe("job.assigned", func(ctx context.Context, event Event) { t.Error("delivered to the wrong subscriber") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go eb.Start(ctx)
+
+	eb.Publish(Event{Type: "job.created", Payload: testPayload{JobID: "job-1"}})
+
+	select {
+	case e := <-got:
+		var p testPayload
+		if err := e.Decode(&p); err != nil || p.JobID != "job-1" {
+			t.Errorf("got payload %+v, err %v", p, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+}
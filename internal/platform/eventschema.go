@@ -0,0 +1,101 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// EventPayload is a typed event definition. Its name is the event type it is
+// published as, and its version is bumped whenever its JSON encoding changes
+// in a way existing subscribers cannot read. Implement both methods on the
+// value type; the definitions live in package events.
+type EventPayload interface {
+	EventName() string
+	EventVersion() int
+}
+
+// eventSchema is the Go type and version registered for an event type.
+type eventSchema struct {
+	version int
+	typ     reflect.Type
+}
+
+func (s eventSchema) String() string {
+	return fmt.Sprintf("%s v%d", s.typ, s.version)
+}
+
+func schemaOf(p EventPayload) eventSchema {
+	return eventSchema{version: p.EventVersion(), typ: reflect.TypeOf(p)}
+}
+
+// NewEvent wraps a typed payload in an Event of its type and version.
+func NewEvent(p EventPayload) Event {
+	return Event{Type: p.EventName(), Version: p.EventVersion(), Payload: p}
+}
+
+// Subscribe registers a typed handler for T's event type. The event is
+// decoded into T before the handler is called; an event of another version,
+// e.g. from a replica running a different release, is returned as an error
+// and left unacknowledged for a replica that can read it. A handler error
+// also leaves the event unacknowledged, so durable backends redeliver it.
+//
+// Subscribe panics if the bus already knows T's event type by a different Go
+// type or version, so conflicting definitions are caught at startup. Like
+// EventBus.Subscribe, it names the subscriber after the handler.
+func Subscribe[T EventPayload](eb *EventBus, handler func(ctx context.Context, payload T) error) {
+	SubscribeAs(eb, handlerName(handler), handler)
+}
+
+// SubscribeAs is Subscribe under an explicit subscriber name.
+func SubscribeAs[T EventPayload](eb *EventBus, subscriber string, handler func(ctx context.Context, payload T) error) {
+	var zero T
+	name, version := zero.EventName(), zero.EventVersion()
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if err := eb.register(zero); err != nil {
+		panic(err)
+	}
+	eb.add(Subscription{
+		Name:      subscriber,
+		EventType: name,
+		handle: func(ctx context.Context, event Event) error {
+			if event.Version != version {
+				return fmt.Errorf("%s event is v%d, handler reads v%d", name, event.Version, version)
+			}
+			var payload T
+			if err := event.Decode(&payload); err != nil {
+				return err
+			}
+			return handler(ctx, payload)
+		},
+	})
+}
+
+// register records p's schema for its event type, or checks it against the
+// schema already recorded. The caller holds eb.mu.
+func (eb *EventBus) register(p EventPayload) error {
+	want := schemaOf(p)
+	name := p.EventName()
+	if got, ok := eb.schemas[name]; ok && got != want {
+		return fmt.Errorf("event %s: %s conflicts with registered %s", name, want, got)
+	}
+	eb.schemas[name] = want
+	return nil
+}
+
+// checkEvent verifies that an event carrying a typed payload is labelled
+// with the payload's type and version.
+func checkEvent(event Event) error {
+	p, ok := event.Payload.(EventPayload)
+	if !ok {
+		return nil
+	}
+	if event.Type != p.EventName() || event.Version != p.EventVersion() {
+		return fmt.Errorf("event %s v%d carries a %s v%d payload; build it with NewEvent",
+			event.Type, event.Version, p.EventName(), p.EventVersion())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type jobCreatedV1 struct {
+	JobID string `json:"job_id"`
+}
+
+func (jobCreatedV1) EventName() string { return "job.created" }
+func (jobCreatedV1) EventVersion() int { return 1 }
+
+type jobCreatedV2 struct {
+	JobID string `json:"job_id"`
+	Round int    `json:"round"`
+}
+
+func (jobCreatedV2) EventName() string { return "job.created" }
+func (jobCreatedV2) EventVersion() int { return 2 }
+
+type typedSubscriber struct {
+	got chan jobCreatedV1
+}
+
+func (s typedSubscriber) HandleJobCreated(ctx context.Context, e jobCreatedV1) error {
+	s.got <- e
+	return nil
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 10)
+	s := typedSubscriber{got: make(chan jobCreatedV1, 1)}
+	Subscribe(eb, s.HandleJobCreated)
+
+	if got := eb.subs[0].Name; got != "platform.typedSubscriber.HandleJobCreated" {
+		t.Errorf("subscription named %q", got)
+	}
+	named := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 1)
+	SubscribeAs(named, "typed", s.HandleJobCreated)
+	if got := named.subs[0].Name; got != "typed" {
+		t.Errorf("explicit subscription named %q, want typed", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go eb.Start(ctx)
+
+	eb.Publish(NewEvent(jobCreatedV1{JobID: "job-1"}))
+
+	select {
+	case e := <-s.got:
+		if e.JobID != "job-1" {
+			t.Errorf("got %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+}
+
+func TestSubscribeTyped_Version(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 1)
+	handled := false
+	Subscribe(eb, func(ctx context.Context, e jobCreatedV1) error {
+		handled = true
+		return nil
+	})
+	sub := eb.subs[0]
+
+	v2 := Event{Type: "job.created", Version: 2, Payload: json.RawMessage(`{"job_id":"job-1","round":2}`)}
+	if err := sub.Deliver(context.Background(), v2); err == nil || handled {
+		t.Error("expected a v2 event to be rejected by a v1 handler")
+	}
+
+	v1 := Event{Type: "job.created", Version: 1, Payload: json.RawMessage(`{"job_id":"job-1"}`)}
+	if err := sub.Deliver(context.Background(), v1); err != nil || !handled {
+		t.Errorf("v1 event not handled: %v", err)
+	}
+}
+
+func TestSubscribeTyped_HandlerError(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 1)
+	boom := errors.New("boom")
+	Subscribe(eb, func(ctx context.Context, e jobCreatedV1) error { return boom })
+
+	if err := eb.subs[0].Deliver(context.Background(), NewEvent(jobCreatedV1{})); !errors.Is(err, boom) {
+		t.Errorf("got %v, want the handler's error", err)
+	}
+}
+
+func TestEventSchemaConflicts(t *testing.T) {
+	eb := NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)), 10)
+	Subscribe(eb, func(ctx context.Context, e jobCreatedV1) error { return nil })
+
+	t.Run("subscribe", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected subscribing to a conflicting version to panic")
+			}
+		}()
+		Subscribe(eb, func(ctx context.Context, e jobCreatedV2) error { return nil })
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		if err := eb.Send(context.Background(), NewEvent(jobCreatedV2{})); err == nil {
+			t.Error("expected publishing a conflicting version to fail")
+		}
+	})
+
+	t.Run("mislabelled", func(t *testing.T) {
+		event := Event{Type: "job.assigned", Payload: jobCreatedV1{}}
+		if err := eb.Send(context.Background(), event); err == nil {
+			t.Error("expected an event labelled with another type to fail")
+		}
+	})
+}
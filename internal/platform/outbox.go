@@ -0,0 +1,167 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+)
+
+const (
+	relayInterval        = 1 * time.Second
+	relayCleanupInterval = 1 * time.Hour
+	relayRetention       = 7 * 24 * time.Hour
+)
+
+// PublishTx records an event in tx's event_outbox, so the event is published
+// if and only if the write it describes commits. The Postgres event backend
+// delivers it from there like any other event; on other backends the
+// OutboxRelay publishes it once tx commits.
+func PublishTx(ctx context.Context, tx pgx.Tx, event Event) error {
+	if err := checkEvent(event); err != nil {
+		return err
+	}
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("marshalling %s payload: %w", event.Type, err)
+	}
+
+	_, err = sqlc.New(tx).InsertOutboxEvent(ctx, sqlc.InsertOutboxEventParams{
+		EventType: event.Type,
+		Version:   int32(event.Version),
+		Payload:   data,
+	})
+	if err != nil {
+		return fmt.Errorf("writing %s to outbox: %w", event.Type, err)
+	}
+	return nil
+}
+
+// OutboxRelay publishes events that PublishTx recorded in event_outbox on an
+// event bus whose backend does not read the outbox itself, oldest first, and
+// marks them dispatched. Each batch is locked while it is sent, so replicas
+// can run relays side by side. An event that cannot be sent stays in the
+// outbox and is retried on the next pass. Delivery is at least once: a crash
+// between sending a batch and marking it dispatched sends it again.
+type OutboxRelay struct {
+	db       *pgxpool.Pool
+	q        *sqlc.Queries
+	eventBus *EventBus
+	logger   *slog.Logger
+}
+
+// NewOutboxRelay creates an outbox relay.
+func NewOutboxRelay(db *pgxpool.Pool, eventBus *EventBus, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		db:       db,
+		q:        sqlc.New(db),
+		eventBus: eventBus,
+		logger:   logger,
+	}
+}
+
+// Start relays events until ctx is cancelled. Call in a goroutine. It returns
+// at once if the event bus delivers from the outbox itself.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	if _, ok := r.eventBus.backend.(*PostgresOutboxBackend); ok {
+		return
+	}
+	r.logger.Info("outbox relay started")
+
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		r.relay(ctx)
+		if time.Since(lastCleanup) >= relayCleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay sends undispatched events batch by batch until none are left.
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.relayBatch(ctx)
+		if err != nil {
+			r.logger.Error("outbox relay: relaying batch", "error", err)
+			return
+		}
+		if n < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch locks a batch of undispatched events, sends them in order and
+// marks the ones sent. It stops at the first event that cannot be sent, so
+// later events are not published ahead of it.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := r.q.WithTx(tx)
+
+	events, err := q.LockUndispatchedEvents(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("locking outbox events: %w", err)
+	}
+
+	var sent []int64
+	for _, e := range events {
+		if err := r.send(ctx, Event{Type: e.EventType, Version: int(e.Version), Payload: e.Payload}); err != nil {
+			r.logger.Error("outbox relay: sending event", "id", e.ID, "type", e.EventType, "error", err)
+			break
+		}
+		sent = append(sent, e.ID)
+	}
+	if len(sent) == 0 {
+		return 0, nil
+	}
+
+	if err := q.MarkEventsDispatched(ctx, sent); err != nil {
+		return 0, fmt.Errorf("marking events dispatched: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing relay: %w", err)
+	}
+	return len(sent), nil
+}
+
+// send publishes one event on the bus.
+func (r *OutboxRelay) send(ctx context.Context, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	return r.eventBus.Send(ctx, event)
+}
+
+// cleanup deletes events dispatched longer ago than the retention period.
+func (r *OutboxRelay) cleanup(ctx context.Context) {
+	cutoff := time.Now().Add(-relayRetention)
+	n, err := r.q.DeleteDeliveredEvents(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		r.logger.Error("outbox relay: deleting dispatched events", "error", err)
+		return
+	}
+	if n > 0 {
+		r.logger.Info("outbox relay: deleted dispatched events", "count", n)
+	}
+}
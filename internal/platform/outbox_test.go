@@ -0,0 +1,52 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestOutboxRelaySend(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	eb := NewEventBus(logger, 1)
+	r := NewOutboxRelay(nil, eb, logger)
+	ctx := context.Background()
+
+	event := Event{Type: "job.created", Version: 1, Payload: json.RawMessage(`{"job_id":"job-1","round":1}`)}
+	if err := r.send(ctx, event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := <-eb.backend.(*memoryBackend).ch
+	var payload testPayload
+	if err := got.Decode(&payload); err != nil || got.Type != "job.created" || got.Version != 1 || payload.JobID != "job-1" {
+		t.Errorf("got event %+v, payload %+v, err %v", got, payload, err)
+	}
+
+	// A full bus is reported so the event stays in the outbox
+	eb.Publish(Event{Type: "job.created"})
+	if err := r.send(ctx, event); !errors.Is(err, errEventBusFull) {
+		t.Errorf("got %v, want errEventBusFull", err)
+	}
+}
+
+func TestOutboxRelayPostgresBackend(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	eb := NewEventBusWithBackend(NewPostgresOutboxBackend(nil, EventBusConfig{}, logger), logger)
+
+	// The Postgres backend delivers from the outbox itself
+	done := make(chan struct{})
+	go func() {
+		NewOutboxRelay(nil, eb, logger).Start(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay should not run on the Postgres backend")
+	}
+}
@@ -0,0 +1,99 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/terrascore/api/db/sqlc"
+)
+
+const (
+	listenRetryMin = 1 * time.Second
+	listenRetryMax = 1 * time.Minute
+)
+
+// TaskChannel is the NOTIFY channel workers listen on for a task type.
+func TaskChannel(taskType string) string {
+	return "task_queue:" + taskType
+}
+
+// notify tells listening workers that a task of taskType is due. It is best
+// effort: workers that miss it pick the task up on their next poll. Sent
+// within a transaction, it goes out when the transaction commits.
+func (tq *TaskQueue) notify(ctx context.Context, q *sqlc.Queries, taskType string) {
+	err := q.NotifyTask(ctx, sqlc.NotifyTaskParams{Channel: TaskChannel(taskType), Payload: taskType})
+	if err != nil {
+		tq.logger.Warn("notifying task workers", "type", taskType, "error", err)
+	}
+}
+
+// wakeWorkers wakes up to n idle workers without blocking.
+func (tq *TaskQueue) wakeWorkers(n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case tq.wake <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+// listen holds a dedicated connection that LISTENs on the channel of every
+// registered task type and wakes a worker for each notification. When the
+// connection drops it reconnects with backoff and wakes every worker to sweep
+// for tasks enqueued while it was down.
+func (tq *TaskQueue) listen(ctx context.Context) {
+	channels := make([]string, 0, len(tq.handlers))
+	for taskType := range tq.handlers {
+		channels = append(channels, TaskChannel(taskType))
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	retry := listenRetryMin
+	for ctx.Err() == nil {
+		err := tq.listenOnce(ctx, channels, func() { retry = listenRetryMin })
+		if ctx.Err() != nil {
+			return
+		}
+		tq.logger.Warn("task listener disconnected, falling back to polling", "error", err, "retry_in", retry)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(retry*2, listenRetryMax)
+	}
+}
+
+// listenOnce connects, LISTENs and relays notifications until the connection
+// fails. connected is called once listening has started.
+func (tq *TaskQueue) listenOnce(ctx context.Context, channels []string, connected func()) error {
+	conn, err := pgx.ConnectConfig(ctx, tq.db.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	for _, ch := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{ch}.Sanitize()); err != nil {
+			return fmt.Errorf("listening on %s: %w", ch, err)
+		}
+	}
+	connected()
+	tq.logger.Info("task listener connected", "channels", len(channels))
+
+	// Anything enqueued before LISTEN took effect was not announced.
+	tq.wakeWorkers(tq.cfg.Workers)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("waiting for notification: %w", err)
+		}
+		tq.wakeWorkers(1)
+	}
+}
@@ -3,31 +3,77 @@ package platform
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
 )
 
-// TaskHandler processes a claimed task. Return nil on success, error to mark as failed.
+// TaskHandler processes a claimed task. Return nil on success, error to retry
+// it later.
 type TaskHandler func(ctx context.Context, taskType string, payload json.RawMessage) error
 
+// Task statuses.
+const (
+	TaskPending    = "pending"
+	TaskProcessing = "processing"
+	TaskCompleted  = "completed"
+	TaskDead       = "dead" // failed max_attempts times; not retried
+	TaskCancelled  = "cancelled"
+)
+
+const (
+	// leaseGrace is how long past its timeout a claimed task may run before
+	// the reaper assumes its worker is gone.
+	leaseGrace   = 1 * time.Minute
+	reapInterval = 1 * time.Minute
+)
+
 // TaskQueue polls PostgreSQL for durable background tasks.
+//
+// Tasks are claimed highest priority first, then oldest scheduled_at first,
+// and never before their scheduled_at. A task whose handler fails is retried
+// with exponential backoff and jitter until it has been attempted
+// max_attempts times, after which it is dead and left for an operator.
+//
+// A pool of workers claims tasks concurrently. Each claim holds a lease of the
+// task's timeout plus a grace period; a reaper requeues tasks whose lease ran
+// out, e.g. because the process running them died.
+//
+// Enqueue NOTIFYs a channel per task type, which a dedicated listener
+// connection relays to idle workers so due tasks start at once. Workers still
+// poll in case a notification is lost.
 type TaskQueue struct {
 	db       *pgxpool.Pool
+	q        *sqlc.Queries
 	logger   *slog.Logger
 	handlers map[string]TaskHandler
-	pollFreq time.Duration
+	cfg      TaskQueueConfig
+	wake     chan struct{} // signalled when a task may be due
 }
 
 // NewTaskQueue creates a task queue worker.
-func NewTaskQueue(db *pgxpool.Pool, logger *slog.Logger) *TaskQueue {
+func NewTaskQueue(db *pgxpool.Pool, cfg TaskQueueConfig, logger *slog.Logger) *TaskQueue {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
 	return &TaskQueue{
 		db:       db,
+		q:        sqlc.New(db),
 		logger:   logger,
 		handlers: make(map[string]TaskHandler),
-		pollFreq: 5 * time.Second,
+		cfg:      cfg,
+		wake:     make(chan struct{}, cfg.Workers),
 	}
 }
 
@@ -36,28 +82,158 @@ func (tq *TaskQueue) Register(taskType string, handler TaskHandler) {
 	tq.handlers[taskType] = handler
 }
 
+// settings returns the retry settings for a task type: its entry in
+// TaskQueueConfig.Types, with unset fields taken from the defaults.
+func (tq *TaskQueue) settings(taskType string) TaskSettings {
+	s := tq.cfg.Types[taskType]
+	d := tq.cfg.Defaults
+	if s.MaxAttempts <= 0 {
+		s.MaxAttempts = d.MaxAttempts
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = d.Timeout
+	}
+	if s.BaseBackoff <= 0 {
+		s.BaseBackoff = d.BaseBackoff
+	}
+	if s.MaxBackoff <= 0 {
+		s.MaxBackoff = d.MaxBackoff
+	}
+	return s
+}
+
+// backoff returns the delay before retrying a task that has failed attempt
+// times: BaseBackoff doubled for each earlier failure, capped at MaxBackoff.
+// jitter, in [0, 1), spreads the upper half of the delay so that tasks that
+// failed together are not all retried together.
+func backoff(s TaskSettings, attempt int, jitter float64) time.Duration {
+	d := s.BaseBackoff
+	for i := 1; i < attempt && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	if s.MaxBackoff > 0 && d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(jitter*float64(d-half))
+}
+
+// EnqueueOption sets how a task is scheduled.
+type EnqueueOption func(*sqlc.EnqueueTaskParams)
+
+// WithPriority claims the task ahead of tasks with a lower priority. The
+// default is 0.
+func WithPriority(priority int) EnqueueOption {
+	return func(p *sqlc.EnqueueTaskParams) {
+		v := int32(priority)
+		p.Priority = &v
+	}
+}
+
+// WithRunAt holds the task until t.
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(p *sqlc.EnqueueTaskParams) {
+		p.ScheduledAt = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+}
+
+// WithDelay holds the task for d.
+func WithDelay(d time.Duration) EnqueueOption {
+	return WithRunAt(time.Now().Add(d))
+}
+
 // Enqueue inserts a task into the queue.
-func (tq *TaskQueue) Enqueue(ctx context.Context, taskType string, payload any) error {
+func (tq *TaskQueue) Enqueue(ctx context.Context, taskType string, payload any, opts ...EnqueueOption) error {
+	return tq.enqueue(ctx, tq.q, taskType, payload, opts...)
+}
+
+// EnqueueTx inserts a task into the queue within tx, so the task exists if
+// and only if the write that caused it commits. Workers are notified when tx
+// commits.
+func (tq *TaskQueue) EnqueueTx(ctx context.Context, tx pgx.Tx, taskType string, payload any, opts ...EnqueueOption) error {
+	return tq.enqueue(ctx, tq.q.WithTx(tx), taskType, payload, opts...)
+}
+
+func (tq *TaskQueue) enqueue(ctx context.Context, q *sqlc.Queries, taskType string, payload any, opts ...EnqueueOption) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshalling task payload: %w", err)
 	}
 
-	_, err = tq.db.Exec(ctx,
-		`INSERT INTO task_queue (task_type, payload, status, created_at)
-		 VALUES ($1, $2, 'pending', NOW())`,
-		taskType, data,
-	)
-	if err != nil {
+	priority := int32(0)
+	maxAttempts := int32(tq.settings(taskType).MaxAttempts)
+	params := sqlc.EnqueueTaskParams{
+		TaskType:    taskType,
+		Payload:     data,
+		Priority:    &priority,
+		MaxAttempts: &maxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	if _, err := q.EnqueueTask(ctx, params); err != nil {
 		return fmt.Errorf("enqueueing task: %w", err)
 	}
 
+	if !params.ScheduledAt.Valid || !params.ScheduledAt.Time.After(time.Now()) {
+		tq.notify(ctx, q, taskType)
+	}
 	return nil
 }
 
-// Start begins polling for tasks. Call in a goroutine.
+// Start runs the worker pool and the lease reaper until ctx is cancelled,
+// then waits for in-flight tasks to finish. Call in a goroutine; it returns
+// once the queue has stopped.
 func (tq *TaskQueue) Start(ctx context.Context) {
-	ticker := time.NewTicker(tq.pollFreq)
+	tq.logger.Info("task queue started", "workers", tq.cfg.Workers, "poll_interval", tq.cfg.PollInterval)
+
+	var wg sync.WaitGroup
+	for i := 0; i < tq.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tq.work(ctx)
+		}()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		tq.reap(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		tq.listen(ctx)
+	}()
+
+	wg.Wait()
+	tq.logger.Info("task queue stopped")
+}
+
+// work claims and runs tasks back to back. When none are due it waits to be
+// woken by a notification, or for a poll interval.
+func (tq *TaskQueue) work(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if tq.runNext(ctx) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tq.wake:
+		case <-time.After(tq.cfg.PollInterval):
+		}
+	}
+}
+
+// reap periodically requeues tasks whose lease has expired.
+func (tq *TaskQueue) reap(ctx context.Context) {
+	tq.reapExpired(ctx)
+
+	ticker := time.NewTicker(reapInterval)
 	defer ticker.Stop()
 
 	for {
@@ -65,53 +241,121 @@ func (tq *TaskQueue) Start(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			tq.poll(ctx)
+			tq.reapExpired(ctx)
 		}
 	}
 }
 
-func (tq *TaskQueue) poll(ctx context.Context) {
-	// Claim one pending task atomically
-	row := tq.db.QueryRow(ctx,
-		`UPDATE task_queue
-		 SET status = 'processing', started_at = NOW()
-		 WHERE id = (
-		   SELECT id FROM task_queue
-		   WHERE status = 'pending'
-		   ORDER BY created_at ASC
-		   LIMIT 1
-		   FOR UPDATE SKIP LOCKED
-		 )
-		 RETURNING id, task_type, payload`,
-	)
+func (tq *TaskQueue) reapExpired(ctx context.Context) {
+	reaped, err := tq.q.ReapExpiredTasks(ctx)
+	if err != nil {
+		tq.logger.Error("reaping expired tasks", "error", err)
+		return
+	}
+	for _, t := range reaped {
+		tq.logger.Warn("task lease expired", "type", t.TaskType, "id", t.ID, "attempts", t.Attempts, "status", t.Status)
+	}
+}
+
+// lease returns when a claim on a task with the given timeout expires.
+func lease(timeout time.Duration) time.Time {
+	return time.Now().Add(timeout + leaseGrace)
+}
 
-	var id int64
-	var taskType string
-	var payload json.RawMessage
-	if err := row.Scan(&id, &taskType, &payload); err != nil {
-		return // no tasks available
+// runNext claims and processes one due task, reporting whether there was one.
+// Once claimed, the task runs to completion even if ctx is cancelled, bounded
+// by its timeout.
+func (tq *TaskQueue) runNext(ctx context.Context) bool {
+	task, err := tq.q.ClaimTask(ctx, pgtype.Timestamptz{Time: lease(tq.cfg.Defaults.Timeout), Valid: true})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) && ctx.Err() == nil {
+			tq.logger.Error("claiming task", "error", err)
+		}
+		return false
 	}
+	ctx = context.WithoutCancel(ctx)
 
-	handler, ok := tq.handlers[taskType]
+	attempt := 1
+	if task.Attempts != nil {
+		attempt = int(*task.Attempts)
+	}
+	s := tq.settings(task.TaskType)
+
+	if s.Timeout != tq.cfg.Defaults.Timeout {
+		err := tq.q.ExtendTaskLease(ctx, sqlc.ExtendTaskLeaseParams{
+			ID:             task.ID,
+			Attempts:       task.Attempts,
+			LeaseExpiresAt: pgtype.Timestamptz{Time: lease(s.Timeout), Valid: true},
+		})
+		if err != nil {
+			tq.logger.Error("setting task lease", "type", task.TaskType, "id", task.ID, "error", err)
+		}
+	}
+
+	handler, ok := tq.handlers[task.TaskType]
 	if !ok {
-		tq.logger.Warn("no handler for task type", "type", taskType, "id", id)
-		return
+		tq.fail(ctx, task, attempt, s, fmt.Errorf("no handler for task type %q", task.TaskType))
+		return true
+	}
+
+	tq.logger.Info("processing task", "type", task.TaskType, "id", task.ID, "attempt", attempt)
+
+	if err := tq.run(ctx, handler, task, s.Timeout); err != nil {
+		tq.fail(ctx, task, attempt, s, err)
+		return true
+	}
+
+	n, err := tq.q.CompleteTask(ctx, sqlc.CompleteTaskParams{ID: task.ID, Attempts: task.Attempts})
+	if err != nil {
+		tq.logger.Error("marking task completed", "type", task.TaskType, "id", task.ID, "error", err)
+		return true
+	}
+	if n == 0 {
+		tq.logger.Warn("task completed after its lease expired", "type", task.TaskType, "id", task.ID)
+		return true
 	}
+	tq.logger.Info("task completed", "type", task.TaskType, "id", task.ID)
+	return true
+}
+
+// run calls a task's handler with its timeout, turning a panic into an error.
+func (tq *TaskQueue) run(ctx context.Context, handler TaskHandler, task sqlc.TaskQueue, timeout time.Duration) (err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, task.TaskType, task.Payload)
+}
 
-	tq.logger.Info("processing task", "type", taskType, "id", id)
+// fail records a failed attempt, scheduling a retry or marking the task dead.
+func (tq *TaskQueue) fail(ctx context.Context, task sqlc.TaskQueue, attempt int, s TaskSettings, taskErr error) {
+	retryAt := time.Now().Add(backoff(s, attempt, rand.Float64()))
+	msg := taskErr.Error()
 
-	if err := handler(ctx, taskType, payload); err != nil {
-		tq.logger.Error("task failed", "type", taskType, "id", id, "error", err)
-		tq.db.Exec(ctx,
-			`UPDATE task_queue SET status = 'failed', error_message = $2, completed_at = NOW() WHERE id = $1`,
-			id, err.Error(),
-		)
+	status, err := tq.q.FailTask(ctx, sqlc.FailTaskParams{
+		ID:        task.ID,
+		Attempts:  task.Attempts,
+		LastError: &msg,
+		RetryAt:   pgtype.Timestamptz{Time: retryAt, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		tq.logger.Warn("task failed after its lease expired", "type", task.TaskType, "id", task.ID, "error", msg)
+		return
+	}
+	if err != nil {
+		tq.logger.Error("recording task failure", "type", task.TaskType, "id", task.ID, "task_error", msg, "error", err)
 		return
 	}
 
-	tq.db.Exec(ctx,
-		`UPDATE task_queue SET status = 'completed', completed_at = NOW() WHERE id = $1`,
-		id,
-	)
-	tq.logger.Info("task completed", "type", taskType, "id", id)
+	if status != nil && *status == TaskDead {
+		tq.logger.Error("task dead", "type", task.TaskType, "id", task.ID, "attempts", attempt, "error", msg)
+		return
+	}
+	tq.logger.Warn("task failed, will retry", "type", task.TaskType, "id", task.ID, "attempt", attempt, "retry_at", retryAt, "error", msg)
 }
@@ -0,0 +1,82 @@
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	s := TaskSettings{BaseBackoff: 30 * time.Second, MaxBackoff: 10 * time.Minute}
+
+	tests := []struct {
+		name    string
+		attempt int
+		jitter  float64
+		want    time.Duration
+	}{
+		{"first retry, no jitter", 1, 0, 15 * time.Second},
+		{"first retry, full jitter", 1, 0.999999999, 30 * time.Second},
+		{"doubles per attempt", 3, 0, 60 * time.Second},
+		{"jitter spreads the upper half", 3, 0.5, 90 * time.Second},
+		{"capped at max", 10, 0, 5 * time.Minute},
+		{"capped at max with jitter", 50, 0.5, 450 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backoff(s, tt.attempt, tt.jitter).Round(time.Second)
+			if got != tt.want {
+				t.Errorf("backoff(%d, %v) = %v, want %v", tt.attempt, tt.jitter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskQueueSettings(t *testing.T) {
+	tq := &TaskQueue{cfg: TaskQueueConfig{
+		Defaults: TaskSettings{MaxAttempts: 3, Timeout: 5 * time.Minute, BaseBackoff: 30 * time.Second, MaxBackoff: time.Hour},
+		Types: map[string]TaskSettings{
+			"report.generate": {MaxAttempts: 5, Timeout: 15 * time.Minute},
+		},
+	}}
+
+	got := tq.settings("report.generate")
+	want := TaskSettings{MaxAttempts: 5, Timeout: 15 * time.Minute, BaseBackoff: 30 * time.Second, MaxBackoff: time.Hour}
+	if got != want {
+		t.Errorf("settings(report.generate) = %+v, want %+v", got, want)
+	}
+
+	if got := tq.settings("notification.send"); got != tq.cfg.Defaults {
+		t.Errorf("settings(notification.send) = %+v, want defaults %+v", got, tq.cfg.Defaults)
+	}
+}
+
+func TestParseTaskTypeSettings(t *testing.T) {
+	got, err := parseTaskTypeSettings(`{"report.generate":{"max_attempts":5,"timeout":"15m","backoff_base":"1m","backoff_max":"2h"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TaskSettings{MaxAttempts: 5, Timeout: 15 * time.Minute, BaseBackoff: time.Minute, MaxBackoff: 2 * time.Hour}
+	if got["report.generate"] != want {
+		t.Errorf("got %+v, want %+v", got["report.generate"], want)
+	}
+
+	if _, err := parseTaskTypeSettings(`{"report.generate":{"timeout":"soon"}}`); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
+
+func TestWakeWorkers(t *testing.T) {
+	tq := &TaskQueue{wake: make(chan struct{}, 2)}
+
+	tq.wakeWorkers(1)
+	if len(tq.wake) != 1 {
+		t.Fatalf("after waking 1 worker, %d wake-ups pending, want 1", len(tq.wake))
+	}
+
+	// Waking more workers than are idle must not block.
+	tq.wakeWorkers(5)
+	if len(tq.wake) != 2 {
+		t.Errorf("after waking 5 workers, %d wake-ups pending, want 2", len(tq.wake))
+	}
+}
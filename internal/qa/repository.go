@@ -5,13 +5,17 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
 )
 
 // Repository handles QA-related spatial queries using raw SQL.
 type Repository struct {
+	platform.TxScope
+
 	q  *sqlc.Queries
 	db *pgxpool.Pool
 }
@@ -19,8 +23,19 @@ type Repository struct {
 // NewRepository creates a QA repository.
 func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{
-		q:  sqlc.New(db),
-		db: db,
+		TxScope: platform.NewTxScope(db),
+		q:       sqlc.New(db),
+		db:      db,
+	}
+}
+
+// WithTx returns a repository whose queries run in tx. Transactions it
+// begins nest in tx with a savepoint.
+func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+	return &Repository{
+		TxScope: r.TxScope.Bind(tx),
+		q:       r.q.WithTx(tx),
+		db:      r.db,
 	}
 }
 
@@ -88,6 +103,20 @@ func (r *Repository) FindDuplicateHashes(ctx context.Context, jobID, parcelID uu
 	return dupes, total, nil
 }
 
+// LockPendingQA locks a job while its QA result is recorded and reports
+// whether it still awaits one, that is, it is submitted and has not been
+// scored since.
+func (r *Repository) LockPendingQA(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	pending, err := r.q.LockJobPendingQA(ctx, jobID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, platform.NewNotFound("job not found")
+		}
+		return false, fmt.Errorf("locking job for QA: %w", err)
+	}
+	return pending != nil && *pending, nil
+}
+
 // UpdateJobQA updates the QA score, status, and notes on a survey job.
 func (r *Repository) UpdateJobQA(ctx context.Context, jobID uuid.UUID, score float64, status, notes string) error {
 	scoreNum := pgtype.Numeric{}
@@ -10,25 +10,45 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/terrascore/api/internal/events"
 	"github.com/terrascore/api/internal/platform"
 	"github.com/terrascore/api/internal/survey"
 )
 
+// JobFinalizer moves a scored job to its final status in tx. *job.Repository implements it.
+type JobFinalizer interface {
+	FinalizeQA(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, qaStatus string) error
+}
+
+// FraudPenalizer debits an agent's wallet in tx for a fraudulent survey.
+// *ledger.Repository implements it.
+type FraudPenalizer interface {
+	PenalizeFraud(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, amount float64) error
+}
+
 // Service handles QA scoring for survey submissions.
 type Service struct {
-	qaRepo     *Repository
-	surveyRepo *survey.Repository
-	taskQueue  *platform.TaskQueue
-	logger     *slog.Logger
+	qaRepo       *Repository
+	surveyRepo   *survey.Repository
+	jobs         JobFinalizer
+	penalties    FraudPenalizer
+	fraudPenalty float64
+	taskQueue    *platform.TaskQueue
+	logger       *slog.Logger
 }
 
-// NewService creates a QA service.
-func NewService(qaRepo *Repository, surveyRepo *survey.Repository, taskQueue *platform.TaskQueue, logger *slog.Logger) *Service {
+// NewService creates a QA service. Agents whose surveys are rejected for
+// location fraud are penalized fraudPenalty INR.
+func NewService(qaRepo *Repository, surveyRepo *survey.Repository, jobs JobFinalizer, penalties FraudPenalizer, fraudPenalty float64, taskQueue *platform.TaskQueue, logger *slog.Logger) *Service {
 	return &Service{
-		qaRepo:     qaRepo,
-		surveyRepo: surveyRepo,
-		taskQueue:  taskQueue,
-		logger:     logger,
+		qaRepo:       qaRepo,
+		surveyRepo:   surveyRepo,
+		jobs:         jobs,
+		penalties:    penalties,
+		fraudPenalty: fraudPenalty,
+		taskQueue:    taskQueue,
+		logger:       logger,
 	}
 }
 
@@ -37,7 +57,7 @@ func (s *Service) ScoreSurvey(ctx context.Context, jobID, parcelID uuid.UUID) (*
 	checks := make([]CheckScore, 0, 5)
 
 	// 1. Geo check (25%): media within boundary
-	geoScore, geoDetail := s.checkGeo(ctx, jobID)
+	geoScore, geoDetail, geoChecked := s.checkGeo(ctx, jobID)
 	checks = append(checks, CheckScore{Name: "geo_within_boundary", Weight: WeightGeo, Score: geoScore, Detail: geoDetail})
 
 	// 2. Completeness check (25%): media count + response completeness
@@ -65,11 +85,13 @@ func (s *Service) ScoreSurvey(ctx context.Context, jobID, parcelID uuid.UUID) (*
 	// Determine status
 	status := StatusPassed
 	var notes []string
+	var suspectedFraud bool
 
 	// Force reject if geo score below threshold
 	if geoScore < ThresholdGeoReject {
 		status = StatusFailed
 		notes = append(notes, "geo check below threshold — possible location fraud")
+		suspectedFraud = geoChecked
 	} else if overall < ThresholdFlagged {
 		status = StatusFailed
 		notes = append(notes, "overall score below minimum threshold")
@@ -93,13 +115,28 @@ func (s *Service) ScoreSurvey(ctx context.Context, jobID, parcelID uuid.UUID) (*
 	}
 
 	return &ScoreResult{
-		OverallScore: overall,
-		Status:       status,
-		Notes:        noteStr,
-		Checks:       checks,
+		OverallScore:   overall,
+		Status:         status,
+		Notes:          noteStr,
+		Checks:         checks,
+		SuspectedFraud: suspectedFraud,
 	}, nil
 }
 
+// HandleSurveySubmitted is the EventBus handler for "survey.submitted"
+// events. It enqueues the survey for QA scoring.
+func (s *Service) HandleSurveySubmitted(ctx context.Context, e events.SurveySubmitted) error {
+	p := SurveyQAPayload{
+		JobID:    e.JobID.String(),
+		ParcelID: e.ParcelID.String(),
+		UserID:   e.UserID.String(),
+	}
+	if err := s.taskQueue.Enqueue(ctx, "qa.score_survey", p); err != nil {
+		return fmt.Errorf("enqueueing QA task for job %s: %w", p.JobID, err)
+	}
+	return nil
+}
+
 // HandleTask is the TaskHandler for "qa.score_survey".
 func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
 	var p SurveyQAPayload
@@ -115,6 +152,10 @@ func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.
 	if err != nil {
 		return fmt.Errorf("invalid parcel ID: %w", err)
 	}
+	userID, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
 
 	s.logger.Info("scoring survey", "job_id", jobID, "parcel_id", parcelID)
 
@@ -123,36 +164,83 @@ func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.
 		return fmt.Errorf("scoring survey: %w", err)
 	}
 
-	// Update job QA columns
-	if err := s.qaRepo.UpdateJobQA(ctx, jobID, result.OverallScore, result.Status, result.Notes); err != nil {
-		return fmt.Errorf("updating QA result: %w", err)
+	return s.record(ctx, jobID, parcelID, userID, result)
+}
+
+// record finalizes a scored job, penalizes fraud, stores the QA result and
+// publishes qa.completed in one transaction, so a retried task either finds
+// all of it recorded and does nothing, or records all of it.
+func (s *Service) record(ctx context.Context, jobID, parcelID, userID uuid.UUID, result *ScoreResult) error {
+	var recorded bool
+	err := s.qaRepo.InTx(ctx, func(tx pgx.Tx) error {
+		repo := s.qaRepo.WithTx(tx)
+		pending, err := repo.LockPendingQA(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		if !pending {
+			return nil
+		}
+
+		// Passed jobs complete, failed jobs need a re-survey
+		if err := s.jobs.FinalizeQA(ctx, tx, jobID, result.Status); err != nil {
+			return fmt.Errorf("finalizing job after QA: %w", err)
+		}
+		if result.SuspectedFraud && s.fraudPenalty > 0 {
+			if err := s.penalties.PenalizeFraud(ctx, tx, jobID, s.fraudPenalty); err != nil {
+				return fmt.Errorf("penalizing fraudulent survey: %w", err)
+			}
+		}
+		if err := repo.UpdateJobQA(ctx, jobID, result.OverallScore, result.Status, result.Notes); err != nil {
+			return fmt.Errorf("updating QA result: %w", err)
+		}
+		if err := s.taskQueue.EnqueueTx(ctx, tx, "report.generate", map[string]string{
+			"job_id":    jobID.String(),
+			"parcel_id": parcelID.String(),
+			"user_id":   userID.String(),
+		}); err != nil {
+			return fmt.Errorf("enqueueing report generation: %w", err)
+		}
+		if err := platform.PublishTx(ctx, tx, platform.NewEvent(events.QACompleted{
+			JobID:    jobID,
+			ParcelID: parcelID,
+			UserID:   userID,
+			Score:    result.OverallScore,
+			Status:   result.Status,
+		})); err != nil {
+			return err
+		}
+		recorded = true
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	if !recorded {
+		s.logger.Info("QA result already recorded", "job_id", jobID)
+		return nil
+	}
+	if result.SuspectedFraud && s.fraudPenalty > 0 {
+		s.logger.Warn("agent penalized for suspected fraud", "job_id", jobID, "amount", s.fraudPenalty)
+	}
 	s.logger.Info("QA scoring complete",
 		"job_id", jobID,
 		"score", result.OverallScore,
 		"status", result.Status,
 	)
-
-	// Enqueue report generation
-	if err := s.taskQueue.Enqueue(ctx, "report.generate", map[string]string{
-		"job_id":    p.JobID,
-		"parcel_id": p.ParcelID,
-		"user_id":   p.UserID,
-	}); err != nil {
-		s.logger.Error("failed to enqueue report generation", "error", err)
-	}
-
 	return nil
 }
 
-func (s *Service) checkGeo(ctx context.Context, jobID uuid.UUID) (float64, string) {
+// checkGeo scores the share of media captured inside the parcel boundary.
+// checked is false when there was no media to check.
+func (s *Service) checkGeo(ctx context.Context, jobID uuid.UUID) (score float64, detail string, checked bool) {
 	within, total, err := s.qaRepo.CheckMediaWithinBoundary(ctx, jobID)
 	if err != nil || total == 0 {
-		return 0.0, "no media found or error checking geo"
+		return 0.0, "no media found or error checking geo", false
 	}
-	score := float64(within) / float64(total)
-	return score, fmt.Sprintf("%d/%d media within boundary", within, total)
+	score = float64(within) / float64(total)
+	return score, fmt.Sprintf("%d/%d media within boundary", within, total), true
 }
 
 func (s *Service) checkCompleteness(ctx context.Context, jobID uuid.UUID) (float64, string) {
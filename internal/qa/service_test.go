@@ -0,0 +1,161 @@
+package qa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// fakeState is the part of the database a QA result touches.
+type fakeState struct {
+	jobStatus string
+	qaStatus  string
+	penalties int
+	events    []string
+	tasks     []string
+}
+
+// fakeTx runs the sqlc queries recording a QA result against a fakeState.
+// Nested transactions work on a copy that Commit hands back to the parent.
+type fakeTx struct {
+	pgx.Tx
+
+	parent *fakeTx
+	state  fakeState
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	state := tx.state
+	state.events = append([]string(nil), tx.state.events...)
+	state.tasks = append([]string(nil), tx.state.tasks...)
+	return &fakeTx{parent: tx, state: state}, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.parent.state = tx.state
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error { return nil }
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch queryName(sql) {
+	case "UpdateJobQA":
+		tx.state.qaStatus = *args[2].(*string)
+	case "NotifyTask":
+	default:
+		return pgconn.CommandTag{}, errors.New("unexpected exec " + queryName(sql))
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	switch queryName(sql) {
+	case "LockJobPendingQA":
+		pending := tx.state.jobStatus == "survey_submitted" && tx.state.qaStatus == StatusPending
+		return fakeRow{value: &pending}
+	case "InsertOutboxEvent":
+		tx.state.events = append(tx.state.events, args[0].(string))
+	case "EnqueueTask":
+		tx.state.tasks = append(tx.state.tasks, args[0].(string))
+	default:
+		return fakeRow{err: errors.New("unexpected query " + queryName(sql))}
+	}
+	return fakeRow{}
+}
+
+// queryName returns the name of a sqlc query from its "-- name:" header.
+func queryName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) < 3 {
+		return ""
+	}
+	return fields[2]
+}
+
+type fakeRow struct {
+	value *bool
+	err   error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.value != nil {
+		*dest[0].(**bool) = r.value
+	}
+	return nil
+}
+
+type fakeFinalizer struct{}
+
+func (fakeFinalizer) FinalizeQA(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, qaStatus string) error {
+	if qaStatus == StatusFailed {
+		tx.(*fakeTx).state.jobStatus = "failed_qa"
+	}
+	return nil
+}
+
+// failingPenalizer fails its first fails calls, like a crash mid-task.
+type failingPenalizer struct {
+	fails int
+}
+
+func (p *failingPenalizer) PenalizeFraud(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, amount float64) error {
+	if p.fails > 0 {
+		p.fails--
+		return errors.New("connection reset")
+	}
+	tx.(*fakeTx).state.penalties++
+	return nil
+}
+
+func TestRecordRetryAfterPartialFailure(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := &fakeTx{state: fakeState{jobStatus: "survey_submitted", qaStatus: StatusPending}}
+	s := &Service{
+		qaRepo:       NewRepository(nil).WithTx(db),
+		jobs:         fakeFinalizer{},
+		penalties:    &failingPenalizer{fails: 1},
+		fraudPenalty: 500,
+		taskQueue:    platform.NewTaskQueue(nil, platform.TaskQueueConfig{}, logger),
+		logger:       logger,
+	}
+	ctx := context.Background()
+	jobID, parcelID, userID := uuid.New(), uuid.New(), uuid.New()
+	result := &ScoreResult{OverallScore: 0.2, Status: StatusFailed, Notes: "possible location fraud", SuspectedFraud: true}
+
+	// The penalty fails after the job was finalized: nothing is recorded
+	if err := s.record(ctx, jobID, parcelID, userID, result); err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+	if db.state.jobStatus != "survey_submitted" || db.state.qaStatus != StatusPending || len(db.state.events) != 0 {
+		t.Fatalf("partial failure left %+v, want nothing recorded", db.state)
+	}
+
+	// The retry records everything once, and a later retry does nothing
+	for attempt := 2; attempt <= 3; attempt++ {
+		if err := s.record(ctx, jobID, parcelID, userID, result); err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", attempt, err)
+		}
+		got := db.state
+		if got.jobStatus != "failed_qa" || got.qaStatus != StatusFailed || got.penalties != 1 {
+			t.Errorf("attempt %d: got job %s, qa %s, %d penalties; want failed_qa, failed, 1", attempt, got.jobStatus, got.qaStatus, got.penalties)
+		}
+		if len(got.events) != 1 || got.events[0] != "qa.completed" {
+			t.Errorf("attempt %d: events = %v, want one qa.completed", attempt, got.events)
+		}
+		if len(got.tasks) != 1 || got.tasks[0] != "report.generate" {
+			t.Errorf("attempt %d: tasks = %v, want one report.generate", attempt, got.tasks)
+		}
+	}
+}
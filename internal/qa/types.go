@@ -19,6 +19,7 @@ const (
 
 // QA status values.
 const (
+	StatusPending = "pending"
 	StatusPassed  = "passed"
 	StatusFlagged = "flagged"
 	StatusFailed  = "failed"
@@ -38,6 +39,9 @@ type ScoreResult struct {
 	Status       string       `json:"status"`
 	Notes        string       `json:"notes"`
 	Checks       []CheckScore `json:"checks"`
+
+	// SuspectedFraud is set when media was captured away from the parcel.
+	SuspectedFraud bool `json:"suspected_fraud"`
 }
 
 // SurveyQAPayload is the task queue payload for scoring a survey.
@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/terrascore/api/db/sqlc"
 	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/events"
 	"github.com/terrascore/api/internal/job"
 	"github.com/terrascore/api/internal/platform"
 	"github.com/terrascore/api/internal/survey"
@@ -31,6 +32,7 @@ type Service struct {
 	authRepo   *auth.Repository
 	s3Client   *platform.S3Client
 	taskQueue  *platform.TaskQueue
+	eventBus   *platform.EventBus
 	logger     *slog.Logger
 }
 
@@ -42,6 +44,7 @@ func NewService(
 	authRepo *auth.Repository,
 	s3Client *platform.S3Client,
 	taskQueue *platform.TaskQueue,
+	eventBus *platform.EventBus,
 	logger *slog.Logger,
 ) *Service {
 	return &Service{
@@ -51,6 +54,7 @@ func NewService(
 		authRepo:   authRepo,
 		s3Client:   s3Client,
 		taskQueue:  taskQueue,
+		eventBus:   eventBus,
 		logger:     logger,
 	}
 }
@@ -180,6 +184,15 @@ func (s *Service) GenerateReport(ctx context.Context, jobID, parcelID uuid.UUID,
 		"s3_key", s3Key,
 	)
 
+	s.eventBus.Publish(platform.NewEvent(events.ReportGenerated{
+		ReportID:   report.ID,
+		JobID:      jobID,
+		ParcelID:   parcelID,
+		UserID:     j.UserID,
+		ReportType: report.ReportType,
+		Format:     report.Format,
+	}))
+
 	// Enqueue notification
 	if err := s.taskQueue.Enqueue(ctx, "notification.send", map[string]string{
 		"event_type": "report.generated",
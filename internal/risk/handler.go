@@ -0,0 +1,136 @@
+package risk
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Handler handles parcel risk score endpoints.
+type Handler struct {
+	repo     *Repository
+	authRepo *auth.Repository
+}
+
+// NewHandler creates a risk handler.
+func NewHandler(repo *Repository, authRepo *auth.Repository) *Handler {
+	return &Handler{repo: repo, authRepo: authRepo}
+}
+
+// Get handles GET /v1/parcels/{parcelId}/risk.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	parcelID, ok := h.ownedParcel(w, r)
+	if !ok {
+		return
+	}
+
+	score, err := h.repo.GetLatestScore(r.Context(), parcelID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, scoreResponse(*score))
+}
+
+// History handles GET /v1/parcels/{parcelId}/risk/history.
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	parcelID, ok := h.ownedParcel(w, r)
+	if !ok {
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	scores, total, err := h.repo.ListScores(r.Context(), parcelID, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]ScoreResponse, len(scores))
+	for i, s := range scores {
+		result[i] = scoreResponse(s)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// ownedParcel resolves the parcel in the URL and checks that the landowner
+// owns it.
+func (h *Handler) ownedParcel(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return uuid.Nil, false
+	}
+	parcelID, err := uuid.Parse(chi.URLParam(r, "parcelId"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid parcel ID"))
+		return uuid.Nil, false
+	}
+
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return uuid.Nil, false
+	}
+	parcel, err := h.repo.GetParcel(r.Context(), parcelID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return uuid.Nil, false
+	}
+	if parcel.UserID != user.ID {
+		platform.HandleError(w, platform.NewForbidden("you do not own this parcel"))
+		return uuid.Nil, false
+	}
+	return parcelID, true
+}
+
+// scoreResponse converts a risk score row to its API representation.
+func scoreResponse(s sqlc.RiskScore) ScoreResponse {
+	resp := ScoreResponse{
+		ID:           s.ID,
+		ParcelID:     s.ParcelID,
+		OverallScore: numericToFloat64(s.OverallScore),
+		RiskLevel:    s.RiskLevel,
+		Categories: CategoryScores{
+			Encroachment:  numericToFloat64(s.EncroachmentScore),
+			Boundary:      numericToFloat64(s.BoundaryScore),
+			Environmental: numericToFloat64(s.EnvironmentalScore),
+			Neighborhood:  numericToFloat64(s.NeighborhoodScore),
+		},
+		Factors: []Factor{},
+	}
+	if s.JobID.Valid {
+		jobID := uuid.UUID(s.JobID.Bytes)
+		resp.JobID = &jobID
+	}
+	if s.ComputedAt.Valid {
+		resp.ComputedAt = &s.ComputedAt.Time
+	}
+
+	var exp Explanation
+	if len(s.ContributingFactors) > 0 && json.Unmarshal(s.ContributingFactors, &exp) == nil {
+		resp.RulesVersion = exp.RulesVersion
+		resp.Inputs = &exp.Inputs
+		if exp.Factors != nil {
+			resp.Factors = exp.Factors
+		}
+	}
+	return resp
+}
@@ -0,0 +1,51 @@
+package risk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func riskRouter() chi.Router {
+	h := &Handler{}
+	r := chi.NewRouter()
+	r.Get("/parcels/{parcelId}/risk", h.Get)
+	r.Get("/parcels/{parcelId}/risk/history", h.History)
+	return r
+}
+
+// testParcelID is any valid parcel ID; the handlers reject the request
+// before looking it up.
+const testParcelID = "6f1c2b1e-3d4a-4b5c-8d9e-0a1b2c3d4e5f"
+
+func TestRiskEndpoints_NoAuth(t *testing.T) {
+	for _, path := range []string{"/parcels/" + testParcelID + "/risk", "/parcels/" + testParcelID + "/risk/history"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			riskRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestRiskEndpoints_InvalidParcelID(t *testing.T) {
+	for _, path := range []string{"/parcels/not-a-uuid/risk", "/parcels/not-a-uuid/risk/history"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			ctx := auth.SetUser(req.Context(), &auth.UserContext{KeycloakID: "kc-1", Roles: []string{"landowner"}})
+			w := httptest.NewRecorder()
+			riskRouter().ServeHTTP(w, req.WithContext(ctx))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Repository handles risk score persistence and the spatial queries scoring
+// needs.
+type Repository struct {
+	platform.TxScope
+
+	q  *sqlc.Queries
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a risk repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		TxScope: platform.NewTxScope(db),
+		q:       sqlc.New(db),
+		db:      db,
+	}
+}
+
+// WithTx returns a repository whose queries run in tx. Transactions it
+// begins nest in tx with a savepoint.
+func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+	return &Repository{
+		TxScope: r.TxScope.Bind(tx),
+		q:       r.q.WithTx(tx),
+		db:      r.db,
+	}
+}
+
+// GetParcel returns a parcel by ID.
+func (r *Repository) GetParcel(ctx context.Context, id uuid.UUID) (*sqlc.Parcel, error) {
+	parcel, err := r.q.GetParcelByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("parcel not found")
+		}
+		return nil, fmt.Errorf("getting parcel: %w", err)
+	}
+	return &parcel, nil
+}
+
+// BoundaryDeviation returns the Hausdorff distance in meters between a job's
+// boundary walk and its parcel's boundary, or nil if no walk was recorded.
+func (r *Repository) BoundaryDeviation(ctx context.Context, jobID uuid.UUID) (*float64, error) {
+	var meters *float64
+	err := r.db.QueryRow(ctx,
+		`SELECT ST_HausdorffDistance(
+			p.boundary::geometry,
+			sr.gps_trail::geometry
+		) * 111320 -- approximate degrees to meters at equator
+		FROM survey_responses sr
+		JOIN survey_jobs sj ON sr.job_id = sj.id
+		JOIN parcels p ON sj.parcel_id = p.id
+		WHERE sr.job_id = $1
+		ORDER BY sr.submitted_at DESC
+		LIMIT 1`,
+		jobID,
+	).Scan(&meters)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("measuring boundary deviation: %w", err)
+	}
+	return meters, nil
+}
+
+// CountNearbyIssues counts the scored parcels within radius meters of a
+// parcel, and how many of them had encroachment findings when last scored.
+func (r *Repository) CountNearbyIssues(ctx context.Context, parcelID uuid.UUID, radius float64) (scored, withIssues int, err error) {
+	row, err := r.q.CountNearbyRiskIssues(ctx, sqlc.CountNearbyRiskIssuesParams{
+		Radius:   radius,
+		ParcelID: parcelID,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("counting nearby risk issues: %w", err)
+	}
+	return int(row.Scored), int(row.WithIssues), nil
+}
+
+// CreateScore records a risk score. It returns nil if the job has already
+// been scored.
+func (r *Repository) CreateScore(ctx context.Context, params sqlc.CreateRiskScoreParams) (*sqlc.RiskScore, error) {
+	score, err := r.q.CreateRiskScore(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("creating risk score: %w", err)
+	}
+	return &score, nil
+}
+
+// GetLatestScore returns a parcel's most recent risk score.
+func (r *Repository) GetLatestScore(ctx context.Context, parcelID uuid.UUID) (*sqlc.RiskScore, error) {
+	score, err := r.q.GetLatestRiskScore(ctx, parcelID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("parcel has not been scored yet")
+		}
+		return nil, fmt.Errorf("getting latest risk score: %w", err)
+	}
+	return &score, nil
+}
+
+// ListScores returns a parcel's risk scores, newest first, and their total.
+func (r *Repository) ListScores(ctx context.Context, parcelID uuid.UUID, limit, offset int32) ([]sqlc.RiskScore, int64, error) {
+	scores, err := r.q.ListRiskScores(ctx, sqlc.ListRiskScoresParams{
+		ParcelID: parcelID,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing risk scores: %w", err)
+	}
+
+	total, err := r.q.CountRiskScores(ctx, parcelID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting risk scores: %w", err)
+	}
+	return scores, total, nil
+}
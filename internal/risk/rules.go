@@ -0,0 +1,330 @@
+// Package risk scores a parcel's land risk from its latest QA-passed survey.
+//
+// Scoring is rule based. A rule set, kept as JSON, lists rules that each add
+// points to one risk category when a checklist answer or a measurement
+// matches. Category scores are capped at 100 and weighted into an overall
+// score and risk level, and every rule that fired is stored with the score so
+// the result can be explained.
+package risk
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Risk categories, one per score column of risk_scores.
+const (
+	CategoryEncroachment  = "encroachment"
+	CategoryBoundary      = "boundary"
+	CategoryEnvironmental = "environmental"
+	CategoryNeighborhood  = "neighborhood"
+)
+
+var categories = []string{CategoryEncroachment, CategoryBoundary, CategoryEnvironmental, CategoryNeighborhood}
+
+// Measurements a rule can test with "signal" and "above".
+const (
+	SignalBoundaryDeviation  = "boundary_deviation_m" // Hausdorff distance between the boundary walk and the parcel boundary
+	SignalAreaDiscrepancy    = "area_discrepancy_pct" // mapped area vs registered area, in percent
+	SignalNearbyIssueDensity = "nearby_issue_density" // share of nearby scored parcels with encroachment findings
+)
+
+var signals = []string{SignalBoundaryDeviation, SignalAreaDiscrepancy, SignalNearbyIssueDensity}
+
+// RuleSet is a versioned set of scoring rules.
+type RuleSet struct {
+	Version int                `json:"version"`
+	Weights map[string]float64 `json:"weights"` // relative weight of each category in the overall score
+	Levels  []Level            `json:"levels"`  // ascending by Min
+
+	// Nearby parcels are those whose centroid is within NeighborhoodRadiusM.
+	// The issue density is only measured when at least
+	// NeighborhoodMinParcels of them have been scored.
+	NeighborhoodRadiusM    float64 `json:"neighborhood_radius_m"`
+	NeighborhoodMinParcels int     `json:"neighborhood_min_parcels"`
+
+	Rules []Rule `json:"rules"`
+}
+
+// Level names the risk level of overall scores from Min up to the next
+// level's Min.
+type Level struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+}
+
+// Rule adds Points to a category when it matches. A checklist rule matches
+// when any of Questions was answered with one of Answers; a measurement rule
+// matches when Signal is above Above. A rule fires at most once.
+type Rule struct {
+	ID          string   `json:"id"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Points      float64  `json:"points"`
+	Questions   []string `json:"questions,omitempty"`
+	Answers     []string `json:"answers,omitempty"`
+	Signal      string   `json:"signal,omitempty"`
+	Above       float64  `json:"above,omitempty"`
+}
+
+// LoadRules reads a rule set from a JSON file, or returns the built-in rule
+// set if path is empty.
+func LoadRules(path string) (*RuleSet, error) {
+	if path == "" {
+		return ParseRules(defaultRules)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading risk rules: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// ParseRules parses and validates a JSON rule set.
+func ParseRules(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("parsing risk rules: %w", err)
+	}
+	if err := rs.validate(); err != nil {
+		return nil, fmt.Errorf("invalid risk rules: %w", err)
+	}
+	return &rs, nil
+}
+
+func (rs *RuleSet) validate() error {
+	var total float64
+	for c, w := range rs.Weights {
+		if !slices.Contains(categories, c) {
+			return fmt.Errorf("unknown category %q in weights", c)
+		}
+		if w < 0 {
+			return fmt.Errorf("negative weight for %s", c)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("no category has a weight")
+	}
+
+	if len(rs.Levels) == 0 || rs.Levels[0].Min != 0 {
+		return fmt.Errorf("levels must start at 0")
+	}
+	for i, l := range rs.Levels {
+		if l.Name == "" || len(l.Name) > 10 {
+			return fmt.Errorf("level names must be 1 to 10 characters")
+		}
+		if i > 0 && l.Min <= rs.Levels[i-1].Min {
+			return fmt.Errorf("levels must be in ascending order")
+		}
+	}
+
+	if rs.NeighborhoodRadiusM <= 0 || rs.NeighborhoodMinParcels < 1 {
+		return fmt.Errorf("neighborhood radius and minimum parcels must be positive")
+	}
+
+	seen := make(map[string]bool, len(rs.Rules))
+	for _, r := range rs.Rules {
+		switch {
+		case r.ID == "":
+			return fmt.Errorf("rule without an id")
+		case seen[r.ID]:
+			return fmt.Errorf("duplicate rule %s", r.ID)
+		case !slices.Contains(categories, r.Category):
+			return fmt.Errorf("rule %s: unknown category %q", r.ID, r.Category)
+		case r.Points <= 0:
+			return fmt.Errorf("rule %s: points must be positive", r.ID)
+		case (r.Signal == "") == (len(r.Questions) == 0):
+			return fmt.Errorf("rule %s: needs either questions or a signal", r.ID)
+		case r.Signal != "" && !slices.Contains(signals, r.Signal):
+			return fmt.Errorf("rule %s: unknown signal %q", r.ID, r.Signal)
+		case len(r.Questions) > 0 && len(r.Answers) == 0:
+			return fmt.Errorf("rule %s: questions need answers", r.ID)
+		}
+		for _, a := range r.Answers {
+			if a != strings.ToLower(a) {
+				return fmt.Errorf("rule %s: answers must be lower case", r.ID)
+			}
+		}
+		seen[r.ID] = true
+	}
+	return nil
+}
+
+// Inputs are what a parcel is scored on. Measurements are nil when they
+// could not be taken.
+type Inputs struct {
+	Answers            map[string]string `json:"answers"` // checklist answers by question ID, lower case
+	BoundaryDeviationM *float64          `json:"boundary_deviation_m,omitempty"`
+	AreaDiscrepancyPct *float64          `json:"area_discrepancy_pct,omitempty"`
+	NearbyIssueDensity *float64          `json:"nearby_issue_density,omitempty"`
+}
+
+func (in Inputs) signal(name string) *float64 {
+	switch name {
+	case SignalBoundaryDeviation:
+		return in.BoundaryDeviationM
+	case SignalAreaDiscrepancy:
+		return in.AreaDiscrepancyPct
+	case SignalNearbyIssueDensity:
+		return in.NearbyIssueDensity
+	}
+	return nil
+}
+
+// Factor is a rule that fired and what it added to the overall score.
+type Factor struct {
+	Rule         string  `json:"rule"`
+	Category     string  `json:"category"`
+	Description  string  `json:"description"`
+	Observed     string  `json:"observed"` // the answer or measurement that matched
+	Points       float64 `json:"points"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Assessment is the result of scoring a parcel.
+type Assessment struct {
+	RulesVersion int                `json:"rules_version"`
+	Overall      float64            `json:"overall"`
+	Level        string             `json:"level"`
+	Categories   map[string]float64 `json:"categories"`
+	Factors      []Factor           `json:"factors"`
+}
+
+// Evaluate scores inputs against the rule set.
+func (rs *RuleSet) Evaluate(in Inputs) Assessment {
+	sums := make(map[string]float64, len(categories))
+	factors := make([]Factor, 0)
+	for _, r := range rs.Rules {
+		observed, ok := r.match(in)
+		if !ok {
+			continue
+		}
+		sums[r.Category] += r.Points
+		factors = append(factors, Factor{
+			Rule:        r.ID,
+			Category:    r.Category,
+			Description: r.Description,
+			Observed:    observed,
+			Points:      r.Points,
+		})
+	}
+
+	var totalWeight float64
+	for _, w := range rs.Weights {
+		totalWeight += w
+	}
+
+	a := Assessment{
+		RulesVersion: rs.Version,
+		Categories:   make(map[string]float64, len(categories)),
+		Factors:      factors,
+	}
+	var overall float64
+	for _, c := range categories {
+		score := math.Min(sums[c], 100)
+		a.Categories[c] = round2(score)
+		overall += score * rs.Weights[c] / totalWeight
+	}
+	// A capped category's points are scaled down so contributions add up to
+	// the overall score
+	for i := range a.Factors {
+		f := &a.Factors[i]
+		scale := 1.0
+		if sums[f.Category] > 100 {
+			scale = 100 / sums[f.Category]
+		}
+		f.Contribution = round2(f.Points * scale * rs.Weights[f.Category] / totalWeight)
+	}
+
+	a.Overall = round2(overall)
+	a.Level = rs.level(a.Overall)
+	return a
+}
+
+// match reports whether a rule fires on inputs, and what it matched.
+func (r Rule) match(in Inputs) (string, bool) {
+	if r.Signal != "" {
+		v := in.signal(r.Signal)
+		if v == nil || *v <= r.Above {
+			return "", false
+		}
+		return fmt.Sprintf("%s=%.2f", r.Signal, *v), true
+	}
+	for _, q := range r.Questions {
+		if a, ok := in.Answers[q]; ok && slices.Contains(r.Answers, a) {
+			return q + "=" + a, true
+		}
+	}
+	return "", false
+}
+
+func (rs *RuleSet) level(score float64) string {
+	name := rs.Levels[0].Name
+	for _, l := range rs.Levels {
+		if score >= l.Min {
+			name = l.Name
+		}
+	}
+	return name
+}
+
+// parseAnswers flattens checklist answers from a survey response. Answers are
+// either top-level strings or booleans keyed by question, as the agent app
+// sends them, or grouped by step as {"step": {"answers": {...}}}.
+func parseAnswers(responses json.RawMessage) map[string]string {
+	answers := map[string]string{}
+	var steps map[string]json.RawMessage
+	if err := json.Unmarshal(responses, &steps); err != nil {
+		return answers
+	}
+	for key, raw := range steps {
+		if a, ok := answer(raw); ok {
+			answers[key] = a
+			continue
+		}
+		var step struct {
+			Answers map[string]json.RawMessage `json:"answers"`
+		}
+		if err := json.Unmarshal(raw, &step); err != nil {
+			continue
+		}
+		for q, raw := range step.Answers {
+			if a, ok := answer(raw); ok {
+				answers[q] = a
+			}
+		}
+	}
+	return answers
+}
+
+// answer normalizes a single checklist answer.
+func answer(raw json.RawMessage) (string, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.ToLower(strings.TrimSpace(s)), true
+	}
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		if b {
+			return "yes", true
+		}
+		return "no", true
+	}
+	return "", false
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
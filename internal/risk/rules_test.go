@@ -0,0 +1,196 @@
+package risk
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func defaultRuleSet(t *testing.T) *RuleSet {
+	t.Helper()
+	rs, err := LoadRules("")
+	if err != nil {
+		t.Fatalf("loading built-in rules: %v", err)
+	}
+	return rs
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func TestEvaluate_Clean(t *testing.T) {
+	rs := defaultRuleSet(t)
+	a := rs.Evaluate(Inputs{
+		Answers:            map[string]string{"enc_1": "no", "enc_2": "yes", "water_logging": "na"},
+		BoundaryDeviationM: ptr(12),
+		AreaDiscrepancyPct: ptr(2.5),
+		NearbyIssueDensity: ptr(0.1),
+	})
+
+	if a.Overall != 0 || a.Level != "low" || len(a.Factors) != 0 {
+		t.Errorf("expected a clean low score, got %+v", a)
+	}
+	if a.RulesVersion != rs.Version {
+		t.Errorf("expected rules version %d, got %d", rs.Version, a.RulesVersion)
+	}
+}
+
+func TestEvaluate_Factors(t *testing.T) {
+	rs := defaultRuleSet(t)
+	a := rs.Evaluate(Inputs{
+		Answers:            map[string]string{"enc_1": "yes", "enc_2": "no", "dumping": "yes"},
+		BoundaryDeviationM: ptr(35),
+		AreaDiscrepancyPct: ptr(12),
+	})
+
+	fired := map[string]Factor{}
+	for _, f := range a.Factors {
+		fired[f.Rule] = f
+	}
+	for _, id := range []string{"new_structures", "markers_not_intact", "dumping", "boundary_walk_deviation", "area_discrepancy", "area_discrepancy_minor"} {
+		if _, ok := fired[id]; !ok {
+			t.Errorf("expected rule %s to fire", id)
+		}
+	}
+	if _, ok := fired["boundary_walk_mismatch"]; ok {
+		t.Error("boundary_walk_mismatch fired below its threshold")
+	}
+	if f := fired["new_structures"]; f.Observed != "enc_1=yes" {
+		t.Errorf("unexpected observation %q", f.Observed)
+	}
+
+	// encroachment 25, boundary 10+15+20+10=55, environmental 10
+	if a.Categories[CategoryEncroachment] != 25 || a.Categories[CategoryBoundary] != 55 || a.Categories[CategoryEnvironmental] != 10 {
+		t.Errorf("unexpected categories: %v", a.Categories)
+	}
+	want := round2((25*35 + 55*20 + 10*10) / 75.0)
+	if a.Overall != want {
+		t.Errorf("expected overall %.2f, got %.2f", want, a.Overall)
+	}
+	if a.Level != "medium" {
+		t.Errorf("expected medium, got %s", a.Level)
+	}
+	assertContributionsSum(t, a)
+}
+
+func TestEvaluate_CategoryCap(t *testing.T) {
+	rs, err := ParseRules([]byte(`{
+		"version": 2,
+		"weights": {"encroachment": 3, "boundary": 1},
+		"levels": [{"name": "low", "min": 0}, {"name": "high", "min": 50}],
+		"neighborhood_radius_m": 1000,
+		"neighborhood_min_parcels": 1,
+		"rules": [
+			{"id": "a", "category": "encroachment", "points": 80, "questions": ["a"], "answers": ["yes"]},
+			{"id": "b", "category": "encroachment", "points": 80, "questions": ["b"], "answers": ["yes"]},
+			{"id": "c", "category": "boundary", "points": 40, "signal": "boundary_deviation_m", "above": 20}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parsing rules: %v", err)
+	}
+
+	a := rs.Evaluate(Inputs{
+		Answers:            map[string]string{"a": "yes", "b": "yes"},
+		BoundaryDeviationM: ptr(25),
+	})
+	if a.Categories[CategoryEncroachment] != 100 || a.Categories[CategoryBoundary] != 40 {
+		t.Errorf("unexpected categories: %v", a.Categories)
+	}
+	if a.Overall != 85 || a.Level != "high" {
+		t.Errorf("expected 85 high, got %.2f %s", a.Overall, a.Level)
+	}
+	// Each encroachment rule's 80 points are scaled to 50 of the capped 100
+	if a.Factors[0].Contribution != 37.5 {
+		t.Errorf("expected contribution 37.5, got %.2f", a.Factors[0].Contribution)
+	}
+	assertContributionsSum(t, a)
+}
+
+func TestRuleSetLevel(t *testing.T) {
+	rs := defaultRuleSet(t)
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "low"},
+		{24.99, "low"},
+		{25, "medium"},
+		{50, "high"},
+		{74.5, "high"},
+		{75, "critical"},
+		{100, "critical"},
+	}
+	for _, tt := range tests {
+		if got := rs.level(tt.score); got != tt.want {
+			t.Errorf("level(%.2f) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestParseAnswers(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses string
+		want      map[string]string
+	}{
+		{"flat", `{"enc_1":"Yes","boundary_photos":"uploaded","markers_intact":false}`,
+			map[string]string{"enc_1": "yes", "boundary_photos": "uploaded", "markers_intact": "no"}},
+		{"by step", `{"encroachment_check":{"answers":{"enc_1":"yes","enc_2":"no"}},"boundary_walk":{"gps_trace":{}}}`,
+			map[string]string{"enc_1": "yes", "enc_2": "no"}},
+		{"not an object", `["yes"]`, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAnswers(json.RawMessage(tt.responses))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("answer %s: expected %q, got %q", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	base := `"version":1,"weights":{"encroachment":1},"levels":[{"name":"low","min":0}],"neighborhood_radius_m":1000,"neighborhood_min_parcels":1`
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{"unknown category", `{` + base + `,"rules":[{"id":"a","category":"legal","points":1,"signal":"area_discrepancy_pct"}]}`, "unknown category"},
+		{"unknown signal", `{` + base + `,"rules":[{"id":"a","category":"boundary","points":1,"signal":"slope"}]}`, "unknown signal"},
+		{"questions and signal", `{` + base + `,"rules":[{"id":"a","category":"boundary","points":1,"signal":"area_discrepancy_pct","questions":["q"],"answers":["yes"]}]}`, "either questions or a signal"},
+		{"no answers", `{` + base + `,"rules":[{"id":"a","category":"boundary","points":1,"questions":["q"]}]}`, "need answers"},
+		{"duplicate", `{` + base + `,"rules":[{"id":"a","category":"boundary","points":1,"questions":["q"],"answers":["yes"]},{"id":"a","category":"boundary","points":1,"questions":["r"],"answers":["yes"]}]}`, "duplicate rule"},
+		{"levels", `{"version":1,"weights":{"boundary":1},"levels":[{"name":"low","min":10}],"neighborhood_radius_m":1000,"neighborhood_min_parcels":1,"rules":[]}`, "levels must start at 0"},
+		{"unknown field", `{` + base + `,"rules":[],"extra":true}`, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.rules))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// assertContributionsSum checks that factor contributions explain the
+// overall score, within rounding.
+func assertContributionsSum(t *testing.T, a Assessment) {
+	t.Helper()
+	var sum float64
+	for _, f := range a.Factors {
+		sum += f.Contribution
+	}
+	if math.Abs(sum-a.Overall) > 0.05 {
+		t.Errorf("contributions sum to %.2f, overall is %.2f", sum, a.Overall)
+	}
+}
@@ -0,0 +1,206 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
+	"github.com/terrascore/api/internal/platform"
+	"github.com/terrascore/api/internal/qa"
+	"github.com/terrascore/api/internal/survey"
+)
+
+// Service scores parcels after their surveys pass QA.
+type Service struct {
+	repo       *Repository
+	surveyRepo *survey.Repository
+	rules      *RuleSet
+	taskQueue  *platform.TaskQueue
+	logger     *slog.Logger
+}
+
+// NewService creates a risk service that scores with rules.
+func NewService(repo *Repository, surveyRepo *survey.Repository, rules *RuleSet, taskQueue *platform.TaskQueue, logger *slog.Logger) *Service {
+	return &Service{
+		repo:       repo,
+		surveyRepo: surveyRepo,
+		rules:      rules,
+		taskQueue:  taskQueue,
+		logger:     logger,
+	}
+}
+
+// HandleQACompleted is the EventBus handler for "qa.completed" events. It
+// enqueues the parcel for scoring if the survey passed.
+func (s *Service) HandleQACompleted(ctx context.Context, e events.QACompleted) error {
+	if e.Status != qa.StatusPassed {
+		return nil
+	}
+	p := ScorePayload{
+		JobID:    e.JobID.String(),
+		ParcelID: e.ParcelID.String(),
+		UserID:   e.UserID.String(),
+	}
+	if err := s.taskQueue.Enqueue(ctx, TaskType, p); err != nil {
+		return fmt.Errorf("enqueueing risk scoring for job %s: %w", p.JobID, err)
+	}
+	return nil
+}
+
+// HandleTask is the TaskHandler for "risk.score_parcel".
+func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
+	var p ScorePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("unmarshalling risk payload: %w", err)
+	}
+	jobID, err := uuid.Parse(p.JobID)
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+	parcelID, err := uuid.Parse(p.ParcelID)
+	if err != nil {
+		return fmt.Errorf("invalid parcel ID: %w", err)
+	}
+
+	_, err = s.ScoreParcel(ctx, parcelID, jobID)
+	return err
+}
+
+// ScoreParcel scores a parcel from a job's survey and records the score. A
+// change of risk level is published as "risk.changed". Scoring a job again
+// records nothing and returns nil.
+func (s *Service) ScoreParcel(ctx context.Context, parcelID, jobID uuid.UUID) (*sqlc.RiskScore, error) {
+	parcel, err := s.repo.GetParcel(ctx, parcelID)
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.inputs(ctx, parcel, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := s.rules.Evaluate(in)
+	factors, err := json.Marshal(Explanation{RulesVersion: a.RulesVersion, Inputs: in, Factors: a.Factors})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling risk factors: %w", err)
+	}
+
+	// Record the change with the score so neither is lost without the other
+	var score *sqlc.RiskScore
+	err = s.repo.InTx(ctx, func(tx pgx.Tx) error {
+		repo := s.repo.WithTx(tx)
+		prev, err := repo.GetLatestScore(ctx, parcelID)
+		if err != nil {
+			var appErr *platform.AppError
+			if !errors.As(err, &appErr) || appErr.Code != platform.CodeNotFound {
+				return err
+			}
+		}
+
+		score, err = repo.CreateScore(ctx, sqlc.CreateRiskScoreParams{
+			ParcelID:            parcelID,
+			JobID:               pgtype.UUID{Bytes: jobID, Valid: true},
+			OverallScore:        numeric(a.Overall),
+			RiskLevel:           a.Level,
+			EncroachmentScore:   numeric(a.Categories[CategoryEncroachment]),
+			BoundaryScore:       numeric(a.Categories[CategoryBoundary]),
+			EnvironmentalScore:  numeric(a.Categories[CategoryEnvironmental]),
+			NeighborhoodScore:   numeric(a.Categories[CategoryNeighborhood]),
+			ContributingFactors: factors,
+		})
+		if err != nil || score == nil {
+			return err
+		}
+		if prev != nil && prev.RiskLevel == score.RiskLevel {
+			return nil
+		}
+
+		e := events.RiskChanged{
+			ParcelID:    parcelID,
+			UserID:      parcel.UserID,
+			JobID:       jobID,
+			RiskScoreID: score.ID,
+			Level:       score.RiskLevel,
+			Score:       a.Overall,
+		}
+		if prev != nil {
+			previous := numericToFloat64(prev.OverallScore)
+			e.PreviousLevel = prev.RiskLevel
+			e.PreviousScore = &previous
+		}
+		return platform.PublishTx(ctx, tx, platform.NewEvent(e))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if score == nil {
+		s.logger.Info("job already risk scored", "job_id", jobID, "parcel_id", parcelID)
+		return nil, nil
+	}
+
+	s.logger.Info("parcel risk scored",
+		"parcel_id", parcelID,
+		"job_id", jobID,
+		"score", a.Overall,
+		"level", a.Level,
+		"factors", len(a.Factors),
+	)
+	return score, nil
+}
+
+// inputs gathers what a parcel is scored on from a job's survey.
+func (s *Service) inputs(ctx context.Context, parcel *sqlc.Parcel, jobID uuid.UUID) (Inputs, error) {
+	resp, err := s.surveyRepo.GetSurveyResponseByJob(ctx, jobID)
+	if err != nil {
+		return Inputs{}, err
+	}
+	in := Inputs{Answers: parseAnswers(resp.Responses)}
+
+	if in.BoundaryDeviationM, err = s.repo.BoundaryDeviation(ctx, jobID); err != nil {
+		return Inputs{}, err
+	}
+
+	if parcel.AreaSqm != nil && parcel.RegisteredAreaSqm != nil && *parcel.RegisteredAreaSqm > 0 {
+		pct := math.Abs(float64(*parcel.AreaSqm)-float64(*parcel.RegisteredAreaSqm)) / float64(*parcel.RegisteredAreaSqm) * 100
+		pct = round2(pct)
+		in.AreaDiscrepancyPct = &pct
+	}
+
+	scored, withIssues, err := s.repo.CountNearbyIssues(ctx, parcel.ID, s.rules.NeighborhoodRadiusM)
+	if err != nil {
+		return Inputs{}, err
+	}
+	if scored >= s.rules.NeighborhoodMinParcels {
+		density := round2(float64(withIssues) / float64(scored))
+		in.NearbyIssueDensity = &density
+	}
+
+	return in, nil
+}
+
+// numeric converts a score to a NUMERIC(5,2) value.
+func numeric(v float64) pgtype.Numeric {
+	n := pgtype.Numeric{}
+	n.Scan(fmt.Sprintf("%.2f", v))
+	return n
+}
+
+// numericToFloat64 converts pgtype.Numeric to float64 (defaults to 0).
+func numericToFloat64(n pgtype.Numeric) float64 {
+	if !n.Valid || n.Int == nil {
+		return 0
+	}
+	f, _ := n.Float64Value()
+	if !f.Valid {
+		return 0
+	}
+	return round2(f.Float64)
+}
@@ -0,0 +1,48 @@
+package risk
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskType is the task queue type that scores a parcel after a survey passes
+// QA.
+const TaskType = "risk.score_parcel"
+
+// ScorePayload is the task queue payload for scoring a parcel.
+type ScorePayload struct {
+	JobID    string `json:"job_id"`
+	ParcelID string `json:"parcel_id"`
+	UserID   string `json:"user_id"`
+}
+
+// Explanation is stored in risk_scores.contributing_factors: the inputs a
+// score was computed from and the rules that fired.
+type Explanation struct {
+	RulesVersion int      `json:"rules_version"`
+	Inputs       Inputs   `json:"inputs"`
+	Factors      []Factor `json:"factors"`
+}
+
+// CategoryScores are a score's category scores, each 0 to 100.
+type CategoryScores struct {
+	Encroachment  float64 `json:"encroachment"`
+	Boundary      float64 `json:"boundary"`
+	Environmental float64 `json:"environmental"`
+	Neighborhood  float64 `json:"neighborhood"`
+}
+
+// ScoreResponse is a parcel's risk score with its explanation.
+type ScoreResponse struct {
+	ID           uuid.UUID      `json:"id"`
+	ParcelID     uuid.UUID      `json:"parcel_id"`
+	JobID        *uuid.UUID     `json:"job_id,omitempty"`
+	OverallScore float64        `json:"overall_score"`
+	RiskLevel    string         `json:"risk_level"`
+	Categories   CategoryScores `json:"categories"`
+	RulesVersion int            `json:"rules_version"`
+	Factors      []Factor       `json:"factors"`
+	Inputs       *Inputs        `json:"inputs,omitempty"`
+	ComputedAt   *time.Time     `json:"computed_at,omitempty"`
+}
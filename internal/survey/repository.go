@@ -25,6 +25,14 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	}
 }
 
+// WithTx returns a repository whose queries run in tx.
+func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+	return &Repository{
+		q:  r.q.WithTx(tx),
+		db: r.db,
+	}
+}
+
 // CreateSurveyResponse inserts a new survey response.
 func (r *Repository) CreateSurveyResponse(ctx context.Context, params sqlc.CreateSurveyResponseParams) (*sqlc.SurveyResponse, error) {
 	resp, err := r.q.CreateSurveyResponse(ctx, params)
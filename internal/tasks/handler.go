@@ -0,0 +1,271 @@
+package tasks
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+const (
+	// defaultStatsWindow is the throughput window when none is given.
+	defaultStatsWindow = 24 * time.Hour
+	maxStatsWindow     = 30 * 24 * time.Hour
+)
+
+// Handler handles the ops task queue endpoints.
+type Handler struct {
+	repo   *Repository
+	logger *slog.Logger
+}
+
+// NewHandler creates a task admin handler.
+func NewHandler(repo *Repository, logger *slog.Logger) *Handler {
+	return &Handler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Routes returns the ops task queue router.
+func (h *Handler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole("admin", "ops"))
+		r.Get("/", h.List)
+		r.Get("/stats", h.Stats)
+		r.Post("/purge", h.Purge)
+		r.Get("/{id}", h.Get)
+		r.Post("/{id}/retry", h.Retry)
+		r.Post("/{id}/cancel", h.Cancel)
+	})
+
+	return r
+}
+
+// List handles GET /v1/admin/tasks?status=dead&type=report.generate.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	var status, taskType *string
+	if s := r.URL.Query().Get("status"); s != "" {
+		switch s {
+		case platform.TaskPending, platform.TaskProcessing, platform.TaskCompleted, platform.TaskDead, platform.TaskCancelled:
+			status = &s
+		default:
+			platform.HandleError(w, platform.NewBadRequest("status must be one of pending, processing, completed, dead, cancelled"))
+			return
+		}
+	}
+	if t := r.URL.Query().Get("type"); t != "" {
+		taskType = &t
+	}
+
+	pg := platform.ParsePagination(r)
+	tasks, total, err := h.repo.List(r.Context(), status, taskType, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]TaskResponse, len(tasks))
+	for i, t := range tasks {
+		result[i] = taskResponse(t, false)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// Get handles GET /v1/admin/tasks/{id}, including the payload.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, taskResponse(*task, true))
+}
+
+// Retry handles POST /v1/admin/tasks/{id}/retry for a dead task.
+func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.repo.Retry(r.Context(), id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("task retried", "id", id, "type", task.TaskType, "actor", actor(r))
+	platform.JSON(w, http.StatusAccepted, taskResponse(*task, false))
+}
+
+// Cancel handles POST /v1/admin/tasks/{id}/cancel for a pending task.
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.repo.Cancel(r.Context(), id)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("task cancelled", "id", id, "type", task.TaskType, "actor", actor(r))
+	platform.JSON(w, http.StatusOK, taskResponse(*task, false))
+}
+
+// Purge handles POST /v1/admin/tasks/purge, deleting completed tasks older
+// than the given number of days.
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	var req PurgeRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.OlderThanDays < 1 {
+		platform.HandleError(w, platform.NewValidation("older_than_days must be at least 1"))
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -req.OlderThanDays)
+	deleted, err := h.repo.PurgeCompleted(r.Context(), cutoff)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("completed tasks purged", "older_than_days", req.OlderThanDays, "deleted", deleted, "actor", actor(r))
+	platform.JSON(w, http.StatusOK, PurgeResponse{Deleted: deleted})
+}
+
+// Stats handles GET /v1/admin/tasks/stats?window=24h.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	window := defaultStatsWindow
+	if s := r.URL.Query().Get("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 || d > maxStatsWindow {
+			platform.HandleError(w, platform.NewBadRequest("window must be a duration such as 1h or 24h, up to 720h"))
+			return
+		}
+		window = d
+	}
+
+	rows, err := h.repo.Stats(r.Context(), time.Now().Add(-window))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, summarize(rows, window))
+}
+
+// summarize totals per-type stats and derives throughput and failure rates.
+// Every attempt of a completed task but the last failed, as did every attempt
+// of a dead one.
+func summarize(rows []sqlc.GetTaskStatsRow, window time.Duration) StatsResponse {
+	resp := StatsResponse{Window: window.String(), Types: make([]TypeStats, len(rows))}
+	for i, row := range rows {
+		t := TypeStats{
+			TaskType:          row.TaskType,
+			Pending:           row.Pending,
+			Due:               row.Due,
+			Processing:        row.Processing,
+			Dead:              row.Dead,
+			OldestDueSeconds:  row.OldestDueSeconds,
+			Completed:         row.CompletedSince,
+			DeadInWindow:      row.DeadSince,
+			ThroughputPerHour: float64(row.CompletedSince) / window.Hours(),
+		}
+		if row.AttemptsSince > 0 {
+			failed := row.AttemptsSince - row.CompletedSince
+			t.FailureRate = float64(failed) / float64(row.AttemptsSince)
+		}
+		resp.Types[i] = t
+
+		resp.Pending += row.Pending
+		resp.Due += row.Due
+		resp.Processing += row.Processing
+		resp.Dead += row.Dead
+		resp.OldestDueSeconds = max(resp.OldestDueSeconds, row.OldestDueSeconds)
+	}
+	return resp
+}
+
+// taskID parses the task ID in the URL, writing an error response if it is
+// invalid.
+func taskID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		platform.HandleError(w, platform.NewBadRequest("invalid task ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// actor is the Keycloak ID of the ops user making a request, for audit logs.
+func actor(r *http.Request) string {
+	if userCtx := auth.GetUser(r.Context()); userCtx != nil {
+		return userCtx.KeycloakID
+	}
+	return ""
+}
+
+func taskResponse(t sqlc.TaskQueue, withPayload bool) TaskResponse {
+	resp := TaskResponse{
+		ID:        t.ID,
+		TaskType:  t.TaskType,
+		LastError: t.LastError,
+	}
+	if t.Status != nil {
+		resp.Status = *t.Status
+	}
+	if t.Priority != nil {
+		resp.Priority = *t.Priority
+	}
+	if t.Attempts != nil {
+		resp.Attempts = *t.Attempts
+	}
+	if t.MaxAttempts != nil {
+		resp.MaxAttempts = *t.MaxAttempts
+	}
+	if withPayload {
+		resp.Payload = t.Payload
+	}
+	if t.ScheduledAt.Valid {
+		resp.ScheduledAt = &t.ScheduledAt.Time
+	}
+	if t.StartedAt.Valid {
+		resp.StartedAt = &t.StartedAt.Time
+	}
+	if t.CompletedAt.Valid {
+		resp.CompletedAt = &t.CompletedAt.Time
+	}
+	if t.CreatedAt.Valid {
+		resp.CreatedAt = &t.CreatedAt.Time
+	}
+	return resp
+}
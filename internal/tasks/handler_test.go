@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/db/sqlc"
+)
+
+func tasksRouter() chi.Router {
+	h := &Handler{}
+	r := chi.NewRouter()
+	r.Get("/admin/tasks", h.List)
+	r.Get("/admin/tasks/stats", h.Stats)
+	r.Post("/admin/tasks/purge", h.Purge)
+	r.Get("/admin/tasks/{id}", h.Get)
+	r.Post("/admin/tasks/{id}/retry", h.Retry)
+	r.Post("/admin/tasks/{id}/cancel", h.Cancel)
+	return r
+}
+
+func TestHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"unknown status", http.MethodGet, "/admin/tasks?status=failed", nil, http.StatusBadRequest},
+		{"bad window", http.MethodGet, "/admin/tasks/stats?window=soon", nil, http.StatusBadRequest},
+		{"window too long", http.MethodGet, "/admin/tasks/stats?window=1000h", nil, http.StatusBadRequest},
+		{"bad task ID", http.MethodGet, "/admin/tasks/abc", nil, http.StatusBadRequest},
+		{"retry bad task ID", http.MethodPost, "/admin/tasks/0/retry", nil, http.StatusBadRequest},
+		{"cancel bad task ID", http.MethodPost, "/admin/tasks/-1/cancel", nil, http.StatusBadRequest},
+		{"purge zero days", http.MethodPost, "/admin/tasks/purge", PurgeRequest{OlderThanDays: 0}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tt.body != nil {
+				json.NewEncoder(&body).Encode(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, &body)
+			w := httptest.NewRecorder()
+			tasksRouter().ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	rows := []sqlc.GetTaskStatsRow{
+		{TaskType: "qa.score_survey", Pending: 4, Due: 3, Processing: 1, OldestDueSeconds: 12, CompletedSince: 48, AttemptsSince: 50},
+		{TaskType: "report.generate", Pending: 1, Due: 1, Dead: 2, OldestDueSeconds: 90, CompletedSince: 6, DeadSince: 1, AttemptsSince: 10},
+		{TaskType: "notification.send", Dead: 1},
+	}
+
+	got := summarize(rows, 2*time.Hour)
+
+	if got.Window != "2h0m0s" {
+		t.Errorf("window = %q", got.Window)
+	}
+	if got.Pending != 5 || got.Due != 4 || got.Processing != 1 || got.Dead != 3 {
+		t.Errorf("totals = pending %d, due %d, processing %d, dead %d; want 5, 4, 1, 3", got.Pending, got.Due, got.Processing, got.Dead)
+	}
+	if got.OldestDueSeconds != 90 {
+		t.Errorf("oldest due = %v, want 90", got.OldestDueSeconds)
+	}
+
+	tests := []struct {
+		i           int
+		throughput  float64
+		failureRate float64
+	}{
+		{0, 24, 0.04}, // 2 retries in 50 attempts
+		{1, 3, 0.4},   // 4 failed attempts in 10, including the dead task's
+		{2, 0, 0},     // nothing finished in the window
+	}
+	for _, tt := range tests {
+		ts := got.Types[tt.i]
+		if ts.ThroughputPerHour != tt.throughput {
+			t.Errorf("%s throughput = %v, want %v", ts.TaskType, ts.ThroughputPerHour, tt.throughput)
+		}
+		if math.Abs(ts.FailureRate-tt.failureRate) > 1e-9 {
+			t.Errorf("%s failure rate = %v, want %v", ts.TaskType, ts.FailureRate, tt.failureRate)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Repository handles task queue administration.
+type Repository struct {
+	q  *sqlc.Queries
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a task admin repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		q:  sqlc.New(db),
+		db: db,
+	}
+}
+
+// List returns tasks, newest first, optionally filtered by status and type.
+func (r *Repository) List(ctx context.Context, status, taskType *string, limit, offset int32) ([]sqlc.TaskQueue, int64, error) {
+	tasks, err := r.q.ListTasks(ctx, sqlc.ListTasksParams{
+		Status:   status,
+		TaskType: taskType,
+		Lim:      limit,
+		Off:      offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing tasks: %w", err)
+	}
+
+	total, err := r.q.CountTasks(ctx, sqlc.CountTasksParams{Status: status, TaskType: taskType})
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting tasks: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// Get returns a task.
+func (r *Repository) Get(ctx context.Context, id int64) (*sqlc.TaskQueue, error) {
+	task, err := r.q.GetTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("task not found")
+		}
+		return nil, fmt.Errorf("getting task: %w", err)
+	}
+	return &task, nil
+}
+
+// Retry requeues a dead task with a fresh set of attempts and wakes the
+// workers for its type.
+func (r *Repository) Retry(ctx context.Context, id int64) (*sqlc.TaskQueue, error) {
+	task, err := r.q.RetryDeadTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.stateError(ctx, id, "only dead tasks can be retried")
+		}
+		return nil, fmt.Errorf("retrying task: %w", err)
+	}
+
+	// Best effort; workers also poll
+	r.q.NotifyTask(ctx, sqlc.NotifyTaskParams{Channel: platform.TaskChannel(task.TaskType), Payload: task.TaskType})
+	return &task, nil
+}
+
+// Cancel stops a pending task from running.
+func (r *Repository) Cancel(ctx context.Context, id int64) (*sqlc.TaskQueue, error) {
+	task, err := r.q.CancelTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.stateError(ctx, id, "only pending tasks can be cancelled")
+		}
+		return nil, fmt.Errorf("cancelling task: %w", err)
+	}
+	return &task, nil
+}
+
+// stateError explains why a task could not change state: it does not exist,
+// or it is in the wrong state.
+func (r *Repository) stateError(ctx context.Context, id int64, msg string) error {
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+	return platform.NewConflict(msg)
+}
+
+// PurgeCompleted deletes tasks that completed before cutoff.
+func (r *Repository) PurgeCompleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	n, err := r.q.PurgeCompletedTasks(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("purging completed tasks: %w", err)
+	}
+	return n, nil
+}
+
+// Stats returns per-type queue stats, with throughput counted since since.
+func (r *Repository) Stats(ctx context.Context, since time.Time) ([]sqlc.GetTaskStatsRow, error) {
+	rows, err := r.q.GetTaskStats(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("getting task stats: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TaskResponse is a task in the queue. Payload is only included when a
+// single task is fetched.
+type TaskResponse struct {
+	ID          int64           `json:"id"`
+	TaskType    string          `json:"task_type"`
+	Status      string          `json:"status"`
+	Priority    int32           `json:"priority"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	ScheduledAt *time.Time      `json:"scheduled_at,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   *time.Time      `json:"created_at,omitempty"`
+}
+
+// PurgeRequest is the body of POST /v1/admin/tasks/purge.
+type PurgeRequest struct {
+	OlderThanDays int `json:"older_than_days"`
+}
+
+// PurgeResponse reports how many completed tasks were deleted.
+type PurgeResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// StatsResponse summarizes the queue. Throughput and failure rates cover the
+// window ending now.
+type StatsResponse struct {
+	Window           string      `json:"window"`
+	Pending          int64       `json:"pending"`
+	Due              int64       `json:"due"` // pending tasks whose scheduled time has passed
+	Processing       int64       `json:"processing"`
+	Dead             int64       `json:"dead"`
+	OldestDueSeconds float64     `json:"oldest_due_seconds"` // how long the oldest due task has waited
+	Types            []TypeStats `json:"types"`
+}
+
+// TypeStats summarizes the queue for one task type.
+type TypeStats struct {
+	TaskType          string  `json:"task_type"`
+	Pending           int64   `json:"pending"`
+	Due               int64   `json:"due"`
+	Processing        int64   `json:"processing"`
+	Dead              int64   `json:"dead"`
+	OldestDueSeconds  float64 `json:"oldest_due_seconds"`
+	Completed         int64   `json:"completed"`      // in the window
+	DeadInWindow      int64   `json:"dead_in_window"` // died in the window
+	ThroughputPerHour float64 `json:"throughput_per_hour"`
+	FailureRate       float64 `json:"failure_rate"` // share of attempts in the window that failed
+}
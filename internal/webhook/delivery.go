@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Headers sent with every delivery. Receivers verify a delivery by
+// recomputing the signature over the timestamp and the raw body, and should
+// reject timestamps more than a few minutes old.
+const (
+	HeaderEvent     = "X-TerraScore-Event"
+	HeaderDelivery  = "X-TerraScore-Delivery"
+	HeaderTimestamp = "X-TerraScore-Timestamp"
+	HeaderSignature = "X-TerraScore-Signature"
+)
+
+// maxResponseBody is how much of an endpoint's response is kept in the
+// delivery log.
+const maxResponseBody = 2048
+
+var errPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+
+// Sign returns the signature of a delivery body sent at timestamp (Unix
+// seconds): "sha256=" followed by the hex HMAC-SHA256, keyed with the
+// subscription's secret, of "<timestamp>.<body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSecret generates a subscription's signing secret.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// validateURL checks a subscription's endpoint. Only HTTPS is accepted unless
+// allowInsecure is set.
+func validateURL(raw string, allowInsecure bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return platform.NewValidation("url must be an absolute URL")
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && allowInsecure:
+	default:
+		return platform.NewValidation("url must use https")
+	}
+	if u.User != nil {
+		return platform.NewValidation("url must not contain credentials")
+	}
+	return nil
+}
+
+// Result is the outcome of one delivery attempt.
+type Result struct {
+	StatusCode int // 0 if no response was received
+	Body       string
+	Duration   time.Duration
+	Err        error // nil if the endpoint answered 2xx
+}
+
+// Sender POSTs signed deliveries. Unless allowInsecure is set it refuses to
+// connect to loopback, private and link-local addresses, checked after DNS
+// resolution, so subscribers cannot point webhooks at internal services.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose attempts time out after timeout.
+func NewSender(timeout time.Duration, allowInsecure bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowInsecure {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect is reported as the delivery's response
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send POSTs an envelope to endpoint, signed with secret.
+func (s *Sender) Send(ctx context.Context, endpoint, secret string, env Envelope) Result {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return Result{Err: fmt.Errorf("marshalling webhook body: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{Err: fmt.Errorf("building webhook request: %w", err)}
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TerraScore-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, env.Type)
+	req.Header.Set(HeaderDelivery, env.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(start), Err: fmt.Errorf("posting webhook: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Kept as TEXT, which takes neither invalid UTF-8 nor NULs
+	kept := strings.ReplaceAll(strings.ToValidUTF8(string(respBody), ""), "\x00", "")
+	result := Result{StatusCode: resp.StatusCode, Body: kept, Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.Err = fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+	return result
+}
+
+// publicIP reports whether ip is routable on the public internet.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
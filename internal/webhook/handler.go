@@ -0,0 +1,355 @@
+package webhook
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/auth"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Handler handles webhook subscription endpoints.
+type Handler struct {
+	repo     *Repository
+	service  *Service
+	authRepo *auth.Repository
+	logger   *slog.Logger
+}
+
+// NewHandler creates a webhook handler.
+func NewHandler(repo *Repository, service *Service, authRepo *auth.Repository, logger *slog.Logger) *Handler {
+	return &Handler{
+		repo:     repo,
+		service:  service,
+		authRepo: authRepo,
+		logger:   logger,
+	}
+}
+
+// Routes returns the webhook router.
+func (h *Handler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(auth.RequireRole("landowner"))
+	r.Get("/event-types", h.ListEventTypes)
+	r.Post("/", h.Create)
+	r.Get("/", h.List)
+	r.Get("/{id}", h.Get)
+	r.Patch("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+	r.Get("/{id}/deliveries", h.ListDeliveries)
+	r.Post("/{id}/test", h.SendTest)
+	return r
+}
+
+// ListEventTypes handles GET /v1/webhooks/event-types.
+func (h *Handler) ListEventTypes(w http.ResponseWriter, r *http.Request) {
+	platform.JSON(w, http.StatusOK, EventTypes)
+}
+
+// Create handles POST /v1/webhooks.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if err := validateURL(req.URL, h.allowInsecure()); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	params := sqlc.CreateWebhookSubscriptionParams{
+		UserID:     user.ID,
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	}
+	if req.Description != "" {
+		params.Description = &req.Description
+	}
+
+	sub, err := h.repo.CreateSubscription(r.Context(), params)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	h.logger.Info("webhook subscription created", "subscription_id", sub.ID, "user_id", user.ID, "event_types", sub.EventTypes)
+	resp := subscriptionResponse(*sub)
+	resp.Secret = sub.Secret
+	platform.JSON(w, http.StatusCreated, resp)
+}
+
+// List handles GET /v1/webhooks.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	subs, err := h.repo.ListSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]SubscriptionResponse, len(subs))
+	for i, s := range subs {
+		result[i] = subscriptionResponse(s)
+	}
+	platform.JSON(w, http.StatusOK, result)
+}
+
+// Get handles GET /v1/webhooks/{id}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+	platform.JSON(w, http.StatusOK, subscriptionResponse(*sub))
+}
+
+// Update handles PATCH /v1/webhooks/{id}.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid webhook ID"))
+		return
+	}
+
+	var req UpdateSubscriptionRequest
+	if err := platform.Decode(r, &req); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+	if req.URL == nil && req.EventTypes == nil && req.Description == nil && req.Active == nil {
+		platform.HandleError(w, platform.NewValidation("nothing to update"))
+		return
+	}
+	if req.URL != nil {
+		if err := validateURL(*req.URL, h.allowInsecure()); err != nil {
+			platform.HandleError(w, err)
+			return
+		}
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			platform.HandleError(w, err)
+			return
+		}
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.repo.UpdateSubscription(r.Context(), sqlc.UpdateWebhookSubscriptionParams{
+		Url:         req.URL,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		Active:      req.Active,
+		ID:          id,
+		UserID:      user.ID,
+	})
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, subscriptionResponse(*sub))
+}
+
+// Delete handles DELETE /v1/webhooks/{id}.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid webhook ID"))
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteSubscription(r.Context(), id, user.ID); err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, map[string]string{"message": "webhook deleted"})
+}
+
+// ListDeliveries handles GET /v1/webhooks/{id}/deliveries.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	pg := platform.ParsePagination(r)
+	deliveries, total, err := h.repo.ListDeliveries(r.Context(), sub.ID, int32(pg.PerPage), int32(pg.Offset))
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	result := make([]DeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = deliveryResponse(d)
+	}
+
+	totalPages := int(total) / pg.PerPage
+	if int(total)%pg.PerPage != 0 {
+		totalPages++
+	}
+
+	platform.JSONList(w, http.StatusOK, result, platform.Meta{
+		Page:       pg.Page,
+		PerPage:    pg.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	})
+}
+
+// SendTest handles POST /v1/webhooks/{id}/test. It sends a test event
+// straight away and returns the delivery, whether or not it succeeded.
+func (h *Handler) SendTest(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.service.SendTest(r.Context(), sub)
+	if err != nil {
+		platform.HandleError(w, err)
+		return
+	}
+
+	platform.JSON(w, http.StatusOK, deliveryResponse(*d))
+}
+
+// currentUser resolves the authenticated user's record.
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*sqlc.User, bool) {
+	userCtx := auth.GetUser(r.Context())
+	if userCtx == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return nil, false
+	}
+	user, err := h.authRepo.GetUserByKeycloakID(r.Context(), userCtx.KeycloakID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// subscription resolves the user's subscription in the URL.
+func (h *Handler) subscription(w http.ResponseWriter, r *http.Request) (*sqlc.WebhookSubscription, bool) {
+	if auth.GetUser(r.Context()) == nil {
+		platform.JSONError(w, http.StatusUnauthorized, platform.CodeUnauthorized, "not authenticated")
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		platform.HandleError(w, platform.NewBadRequest("invalid webhook ID"))
+		return nil, false
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	sub, err := h.repo.GetUserSubscription(r.Context(), id, user.ID)
+	if err != nil {
+		platform.HandleError(w, err)
+		return nil, false
+	}
+	return sub, true
+}
+
+func (h *Handler) allowInsecure() bool {
+	return h.service != nil && h.service.allowInsecure
+}
+
+// validateEventTypes checks a subscription's event types against EventTypes.
+func validateEventTypes(types []string) error {
+	if len(types) == 0 {
+		return platform.NewValidation("event_types must not be empty")
+	}
+	for _, t := range types {
+		if !slices.Contains(EventTypes, t) {
+			return platform.NewValidation("unknown event type " + t + "; must be one of " + strings.Join(EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// subscriptionResponse converts a subscription row to its API
+// representation, without the secret.
+func subscriptionResponse(s sqlc.WebhookSubscription) SubscriptionResponse {
+	return SubscriptionResponse{
+		ID:          s.ID,
+		URL:         s.Url,
+		EventTypes:  s.EventTypes,
+		Description: s.Description,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// deliveryResponse converts a delivery row to its API representation.
+func deliveryResponse(d sqlc.WebhookDelivery) DeliveryResponse {
+	resp := DeliveryResponse{
+		ID:             d.ID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		LastError:      d.LastError,
+		DurationMs:     d.DurationMs,
+		Payload:        d.Payload,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.LastAttemptAt.Valid {
+		resp.LastAttemptAt = &d.LastAttemptAt.Time
+	}
+	if d.DeliveredAt.Valid {
+		resp.DeliveredAt = &d.DeliveredAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/terrascore/api/internal/auth"
+)
+
+func webhookRouter() chi.Router {
+	h := &Handler{logger: slog.Default()}
+	r := chi.NewRouter()
+	r.Post("/webhooks", h.Create)
+	r.Get("/webhooks", h.List)
+	r.Get("/webhooks/{id}", h.Get)
+	r.Patch("/webhooks/{id}", h.Update)
+	r.Delete("/webhooks/{id}", h.Delete)
+	r.Get("/webhooks/{id}/deliveries", h.ListDeliveries)
+	r.Post("/webhooks/{id}/test", h.SendTest)
+	return r
+}
+
+// testWebhookID is any valid subscription ID; the handlers reject the
+// request before looking it up.
+const testWebhookID = "6f1c2b1e-3d4a-4b5c-8d9e-0a1b2c3d4e5f"
+
+func TestWebhookEndpoints_NoAuth(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/webhooks"},
+		{http.MethodGet, "/webhooks"},
+		{http.MethodGet, "/webhooks/" + testWebhookID},
+		{http.MethodPatch, "/webhooks/" + testWebhookID},
+		{http.MethodDelete, "/webhooks/" + testWebhookID},
+		{http.MethodGet, "/webhooks/" + testWebhookID + "/deliveries"},
+		{http.MethodPost, "/webhooks/" + testWebhookID + "/test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			w := httptest.NewRecorder()
+			webhookRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestWebhookEndpoints_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"plain http", http.MethodPost, "/webhooks", `{"url":"http://example.com/hook","event_types":["qa.completed"]}`, http.StatusUnprocessableEntity},
+		{"relative url", http.MethodPost, "/webhooks", `{"url":"/hook","event_types":["qa.completed"]}`, http.StatusUnprocessableEntity},
+		{"no event types", http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","event_types":[]}`, http.StatusUnprocessableEntity},
+		{"unknown event type", http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","event_types":["job.cancelled"]}`, http.StatusUnprocessableEntity},
+		{"unknown field", http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["qa.completed"]}`, http.StatusBadRequest},
+		{"empty update", http.MethodPatch, "/webhooks/" + testWebhookID, `{}`, http.StatusUnprocessableEntity},
+		{"update url", http.MethodPatch, "/webhooks/" + testWebhookID, `{"url":"ftp://example.com"}`, http.StatusUnprocessableEntity},
+		{"update invalid id", http.MethodPatch, "/webhooks/not-a-uuid", `{"active":false}`, http.StatusBadRequest},
+		{"test invalid id", http.MethodPost, "/webhooks/not-a-uuid/test", ``, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			ctx := auth.SetUser(req.Context(), &auth.UserContext{KeycloakID: "kc-1", Roles: []string{"landowner"}})
+			w := httptest.NewRecorder()
+			webhookRouter().ServeHTTP(w, req.WithContext(ctx))
+
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// Repository handles webhook subscription and delivery persistence.
+type Repository struct {
+	q  *sqlc.Queries
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a webhook repository.
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		q:  sqlc.New(db),
+		db: db,
+	}
+}
+
+// CreateSubscription inserts a subscription.
+func (r *Repository) CreateSubscription(ctx context.Context, params sqlc.CreateWebhookSubscriptionParams) (*sqlc.WebhookSubscription, error) {
+	sub, err := r.q.CreateWebhookSubscription(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// GetSubscription returns a subscription by ID.
+func (r *Repository) GetSubscription(ctx context.Context, id uuid.UUID) (*sqlc.WebhookSubscription, error) {
+	sub, err := r.q.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("webhook subscription not found")
+		}
+		return nil, fmt.Errorf("getting webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// GetUserSubscription returns a subscription owned by userID.
+func (r *Repository) GetUserSubscription(ctx context.Context, id, userID uuid.UUID) (*sqlc.WebhookSubscription, error) {
+	sub, err := r.q.GetUserWebhookSubscription(ctx, sqlc.GetUserWebhookSubscriptionParams{ID: id, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("webhook subscription not found")
+		}
+		return nil, fmt.Errorf("getting webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions returns a user's subscriptions, newest first.
+func (r *Repository) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]sqlc.WebhookSubscription, error) {
+	subs, err := r.q.ListWebhookSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// UpdateSubscription changes the given fields of a subscription owned by
+// params.UserID.
+func (r *Repository) UpdateSubscription(ctx context.Context, params sqlc.UpdateWebhookSubscriptionParams) (*sqlc.WebhookSubscription, error) {
+	sub, err := r.q.UpdateWebhookSubscription(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("webhook subscription not found")
+		}
+		return nil, fmt.Errorf("updating webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription deletes a subscription owned by userID and its
+// delivery log.
+func (r *Repository) DeleteSubscription(ctx context.Context, id, userID uuid.UUID) error {
+	n, err := r.q.DeleteWebhookSubscription(ctx, sqlc.DeleteWebhookSubscriptionParams{ID: id, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("deleting webhook subscription: %w", err)
+	}
+	if n == 0 {
+		return platform.NewNotFound("webhook subscription not found")
+	}
+	return nil
+}
+
+// ListSubscriptionsForEvent returns a user's active subscriptions to an
+// event type.
+func (r *Repository) ListSubscriptionsForEvent(ctx context.Context, userID uuid.UUID, eventType string) ([]sqlc.WebhookSubscription, error) {
+	subs, err := r.q.ListWebhookSubscriptionsForEvent(ctx, sqlc.ListWebhookSubscriptionsForEventParams{
+		UserID:    userID,
+		EventType: eventType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions for %s: %w", eventType, err)
+	}
+	return subs, nil
+}
+
+// CreateDeliveries logs a delivery of an event to each subscription and
+// enqueues the tasks that send them, in one transaction. Subscriptions that
+// already have a delivery of the source event are skipped; an empty
+// sourceEventID is never deduplicated.
+func (r *Repository) CreateDeliveries(ctx context.Context, tasks *platform.TaskQueue, subs []sqlc.WebhookSubscription, sourceEventID, eventType string, version int, payload json.RawMessage) error {
+	var source *string
+	if sourceEventID != "" {
+		source = &sourceEventID
+	}
+	return platform.InTx(ctx, r.db, func(tx pgx.Tx) error {
+		q := r.q.WithTx(tx)
+		for _, sub := range subs {
+			d, err := q.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+				SubscriptionID: sub.ID,
+				EventType:      eventType,
+				EventVersion:   int32(version),
+				Payload:        payload,
+				SourceEventID:  source,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue // already delivered from an earlier attempt at this event
+			}
+			if err != nil {
+				return fmt.Errorf("creating webhook delivery: %w", err)
+			}
+			if err := tasks.EnqueueTx(ctx, tx, TaskType, DeliverPayload{DeliveryID: d.ID.String()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateDelivery logs a delivery that the caller sends itself.
+func (r *Repository) CreateDelivery(ctx context.Context, params sqlc.CreateWebhookDeliveryParams) (*sqlc.WebhookDelivery, error) {
+	d, err := r.q.CreateWebhookDelivery(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// GetDelivery returns a delivery by ID.
+func (r *Repository) GetDelivery(ctx context.Context, id uuid.UUID) (*sqlc.WebhookDelivery, error) {
+	d, err := r.q.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, platform.NewNotFound("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("getting webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// RecordAttempt records the outcome of a delivery attempt.
+func (r *Repository) RecordAttempt(ctx context.Context, params sqlc.RecordWebhookAttemptParams) (*sqlc.WebhookDelivery, error) {
+	d, err := r.q.RecordWebhookAttempt(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("recording webhook attempt: %w", err)
+	}
+	return &d, nil
+}
+
+// ListDeliveries returns a subscription's delivery log, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int32) ([]sqlc.WebhookDelivery, int64, error) {
+	deliveries, err := r.q.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing webhook deliveries: %w", err)
+	}
+
+	total, err := r.q.CountWebhookDeliveries(ctx, subscriptionID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
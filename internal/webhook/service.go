@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrascore/api/db/sqlc"
+	"github.com/terrascore/api/internal/events"
+	"github.com/terrascore/api/internal/platform"
+)
+
+// EventTypes are the events users can subscribe webhooks to.
+var EventTypes = []string{
+	events.ParcelRegistered{}.EventName(),
+	events.JobCreated{}.EventName(),
+	events.JobAssigned{}.EventName(),
+	events.SurveySubmitted{}.EventName(),
+	events.QACompleted{}.EventName(),
+	events.ReportGenerated{}.EventName(),
+	events.RiskChanged{}.EventName(),
+}
+
+// Service fans events out to webhook subscriptions and delivers them.
+// Deliveries go through the task queue, which retries failed attempts with
+// backoff; a receiver may see a delivery more than once and should
+// deduplicate by its ID. An event the bus redelivers is not delivered again
+// to a subscription that already has it.
+type Service struct {
+	repo          *Repository
+	tasks         *platform.TaskQueue
+	sender        *Sender
+	allowInsecure bool
+	logger        *slog.Logger
+}
+
+// NewService creates a webhook service.
+func NewService(repo *Repository, tasks *platform.TaskQueue, cfg platform.WebhookConfig, logger *slog.Logger) *Service {
+	return &Service{
+		repo:          repo,
+		tasks:         tasks,
+		sender:        NewSender(cfg.Timeout, cfg.AllowInsecure),
+		allowInsecure: cfg.AllowInsecure,
+		logger:        logger,
+	}
+}
+
+// Subscribe registers the service's handlers for every event in EventTypes.
+func (s *Service) Subscribe(eb *platform.EventBus) {
+	platform.Subscribe(eb, s.HandleParcelRegistered)
+	platform.Subscribe(eb, s.HandleJobCreated)
+	platform.Subscribe(eb, s.HandleJobAssigned)
+	platform.Subscribe(eb, s.HandleSurveySubmitted)
+	platform.Subscribe(eb, s.HandleQACompleted)
+	platform.Subscribe(eb, s.HandleReportGenerated)
+	platform.Subscribe(eb, s.HandleRiskChanged)
+}
+
+// HandleParcelRegistered is the EventBus handler for "parcel.registered".
+func (s *Service) HandleParcelRegistered(ctx context.Context, e events.ParcelRegistered) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleJobCreated is the EventBus handler for "job.created".
+func (s *Service) HandleJobCreated(ctx context.Context, e events.JobCreated) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleJobAssigned is the EventBus handler for "job.assigned".
+func (s *Service) HandleJobAssigned(ctx context.Context, e events.JobAssigned) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleSurveySubmitted is the EventBus handler for "survey.submitted".
+func (s *Service) HandleSurveySubmitted(ctx context.Context, e events.SurveySubmitted) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleQACompleted is the EventBus handler for "qa.completed".
+func (s *Service) HandleQACompleted(ctx context.Context, e events.QACompleted) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleReportGenerated is the EventBus handler for "report.generated".
+func (s *Service) HandleReportGenerated(ctx context.Context, e events.ReportGenerated) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// HandleRiskChanged is the EventBus handler for "risk.changed".
+func (s *Service) HandleRiskChanged(ctx context.Context, e events.RiskChanged) error {
+	return s.fanOut(ctx, e.UserID, e)
+}
+
+// fanOut logs and enqueues a delivery of an event to each of the user's
+// subscriptions to it.
+func (s *Service) fanOut(ctx context.Context, userID uuid.UUID, e platform.EventPayload) error {
+	subs, err := s.repo.ListSubscriptionsForEvent(ctx, userID, e.EventName())
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshalling %s payload: %w", e.EventName(), err)
+	}
+	if err := s.repo.CreateDeliveries(ctx, s.tasks, subs, platform.EventID(ctx), e.EventName(), e.EventVersion(), payload); err != nil {
+		return err
+	}
+
+	s.logger.Info("webhook deliveries enqueued", "event", e.EventName(), "user_id", userID, "subscriptions", len(subs))
+	return nil
+}
+
+// HandleTask is the TaskHandler for "webhook.deliver". A failed attempt is
+// returned as an error so the task queue retries it.
+func (s *Service) HandleTask(ctx context.Context, taskType string, payload json.RawMessage) error {
+	var p DeliverPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("unmarshalling webhook payload: %w", err)
+	}
+	id, err := uuid.Parse(p.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("invalid delivery ID: %w", err)
+	}
+
+	d, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		var appErr *platform.AppError
+		if errors.As(err, &appErr) && appErr.Code == platform.CodeNotFound {
+			return nil // its subscription was deleted
+		}
+		return err
+	}
+	if d.Status == DeliverySucceeded {
+		return nil
+	}
+
+	sub, err := s.repo.GetSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if !sub.Active {
+		s.logger.Info("webhook subscription disabled, skipping delivery", "delivery_id", d.ID, "subscription_id", sub.ID)
+		return nil
+	}
+
+	d, err = s.attempt(ctx, sub, d)
+	if err != nil {
+		return err
+	}
+	if d.Status != DeliverySucceeded {
+		return fmt.Errorf("delivering webhook %s: %s", d.ID, deref(d.LastError))
+	}
+	return nil
+}
+
+// SendTest sends a test event to a subscription straight away, without
+// retries, and returns the logged delivery.
+func (s *Service) SendTest(ctx context.Context, sub *sqlc.WebhookSubscription) (*sqlc.WebhookDelivery, error) {
+	payload, err := json.Marshal(map[string]string{
+		"subscription_id": sub.ID.String(),
+		"message":         "This is a test event from TerraScore.",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling test payload: %w", err)
+	}
+
+	d, err := s.repo.CreateDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		SubscriptionID: sub.ID,
+		EventType:      TestEventType,
+		EventVersion:   1,
+		Payload:        payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.attempt(ctx, sub, d)
+}
+
+// attempt sends a delivery once and records the outcome.
+func (s *Service) attempt(ctx context.Context, sub *sqlc.WebhookSubscription, d *sqlc.WebhookDelivery) (*sqlc.WebhookDelivery, error) {
+	result := s.sender.Send(ctx, sub.Url, sub.Secret, Envelope{
+		ID:        d.ID,
+		EventID:   deref(d.SourceEventID),
+		Type:      d.EventType,
+		Version:   int(d.EventVersion),
+		CreatedAt: d.CreatedAt,
+		Data:      d.Payload,
+	})
+
+	duration := int32(result.Duration / time.Millisecond)
+	params := sqlc.RecordWebhookAttemptParams{
+		ID:         d.ID,
+		Status:     DeliverySucceeded,
+		DurationMs: &duration,
+	}
+	if result.StatusCode != 0 {
+		code := int32(result.StatusCode)
+		params.ResponseStatus = &code
+		params.ResponseBody = &result.Body
+	}
+	if result.Err != nil {
+		msg := result.Err.Error()
+		params.Status = DeliveryFailed
+		params.LastError = &msg
+		s.logger.Warn("webhook delivery failed",
+			"delivery_id", d.ID, "subscription_id", sub.ID, "event", d.EventType, "attempt", d.Attempts+1, "error", result.Err)
+	}
+
+	return s.repo.RecordAttempt(ctx, params)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
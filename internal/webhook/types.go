@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery status values.
+const (
+	DeliveryPending   = "pending"   // not attempted yet
+	DeliverySucceeded = "succeeded" // the endpoint answered 2xx
+	DeliveryFailed    = "failed"    // the latest attempt failed; retried until attempts run out
+)
+
+// TaskType is the task queue type that delivers a webhook.
+const TaskType = "webhook.deliver"
+
+// TestEventType is the event type of deliveries sent by the test endpoint.
+const TestEventType = "webhook.test"
+
+// CreateSubscriptionRequest is the body of POST /v1/webhooks.
+type CreateSubscriptionRequest struct {
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description,omitempty"`
+}
+
+// UpdateSubscriptionRequest is the body of PATCH /v1/webhooks/{id}. Omitted
+// fields are left unchanged.
+type UpdateSubscriptionRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	EventTypes  []string `json:"event_types,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// SubscriptionResponse is a webhook subscription. The signing secret is only
+// returned when the subscription is created.
+type SubscriptionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Description *string   `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DeliveryResponse is an entry in a subscription's delivery log, with the
+// outcome of its latest attempt.
+type DeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	ResponseStatus *int32          `json:"response_status,omitempty"`
+	ResponseBody   *string         `json:"response_body,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	DurationMs     *int32          `json:"duration_ms,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// DeliverPayload is the task queue payload for delivering a webhook.
+type DeliverPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Envelope is the body POSTed to a subscriber.
+type Envelope struct {
+	ID        uuid.UUID       `json:"id"`                 // the delivery ID; the same on every retry
+	EventID   string          `json:"event_id,omitempty"` // the bus event delivered; empty for test events
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -91,6 +91,7 @@ export interface Offer {
 
 // WebSocket offer message
 export interface WSOfferMessage {
+  type?: 'offer' | 'offer_withdrawn';
   offer_id: string;
   job_id: string;
   expires_at: string;